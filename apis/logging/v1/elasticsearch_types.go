@@ -4,6 +4,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -71,9 +72,40 @@ type ElasticsearchSpec struct {
 	// Indicator if the resource is 'Managed' or 'Unmanaged' by the operator.
 	ManagementState ManagementState `json:"managementState"`
 
+	// Pause the reconciliation of the cluster, e.g. during manual maintenance.
+	// The operator leaves all resources of the cluster untouched and only sets
+	// the ReconciliationPaused condition until it is resumed.
+	//
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// The name of the Elasticsearch cluster, overriding the default of the name of
+	// this resource. Changing it on an existing cluster requires a full cluster restart.
+	//
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
 	// The policy towards data redundancy to specify the number of redundant primary shards
 	RedundancyPolicy RedundancyPolicyType `json:"redundancyPolicy"`
 
+	// The number of primary shards per index, overriding the default of one
+	// shard per data node up to 5
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	ShardsPerIndex *int32 `json:"shardsPerIndex,omitempty"`
+
+	// The number of replica shards per index, overriding the number derived
+	// from the redundancy policy. Must be less than the number of data nodes.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	ReplicasPerIndex *int32 `json:"replicasPerIndex,omitempty"`
+
 	// Specification of the different Elasticsearch nodes
 	//
 	// +optional
@@ -89,6 +121,178 @@ type ElasticsearchSpec struct {
 	// +nullable
 	// +optional
 	IndexManagement *IndexManagementSpec `json:"indexManagement"`
+
+	// Specification of the service exposing the Elasticsearch REST API
+	//
+	// +nullable
+	// +optional
+	Service *ElasticsearchServiceSpec `json:"service,omitempty"`
+
+	// Specification of the pod disruption budgets of the cluster nodes
+	//
+	// +nullable
+	// +optional
+	PodDisruptionBudget *ElasticsearchPodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// Specification of the monitoring of the cluster nodes
+	//
+	// +nullable
+	// +optional
+	Monitoring *ElasticsearchMonitoringSpec `json:"monitoring,omitempty"`
+
+	// An existing secret holding the cluster certificates, i.e. the keys admin-ca,
+	// admin-cert, admin-key, elasticsearch.crt, elasticsearch.key, logging-es.crt
+	// and logging-es.key. When set the operator does not generate certificates.
+	//
+	// +nullable
+	// +optional
+	CertificateSecret *corev1.LocalObjectReference `json:"certificateSecret,omitempty"`
+
+	// Retention policies deleting the indices older than a number of days
+	//
+	// +nullable
+	// +optional
+	Retention []ElasticsearchRetentionPolicy `json:"retention,omitempty"`
+
+	// What to do with the persistent volume claims of removed nodes, e.g. after
+	// scaling down. Retain keeps them, Delete removes the claims of the cluster no
+	// node uses anymore and all of them once the cluster is deleted. Defaults to Retain.
+	//
+	// +optional
+	VolumeClaimCleanupPolicy VolumeClaimCleanupPolicy `json:"volumeClaimCleanupPolicy,omitempty"`
+
+	// Recommend resources for the nodes from the usage reported by the metrics
+	// server. The recommendations are recorded in the node status, the pods are
+	// never changed.
+	//
+	// +nullable
+	// +optional
+	ResourceRecommendations *ElasticsearchResourceRecommendationsSpec `json:"resourceRecommendations,omitempty"`
+
+	// The maximum number of nodes without the master role upgraded at the same
+	// time by a rolling update. It is capped by the replicas per index, so that
+	// every shard keeps a copy, and master eligible nodes are always upgraded one
+	// at a time. Defaults to 1.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+
+	// Cluster settings applied once through the cluster settings API after the
+	// cluster turned green, e.g. action.destructive_requires_name. They are
+	// applied again only when they are changed in the spec.
+	//
+	// +nullable
+	// +optional
+	ClusterSettings *ElasticsearchClusterSettingsSpec `json:"clusterSettings,omitempty"`
+
+	// The disk watermarks of the shard allocation, applied as persistent cluster
+	// settings. Take precedence over the same settings of the ConfigMap.
+	//
+	// +nullable
+	// +optional
+	DiskWatermarks *ElasticsearchDiskWatermarksSpec `json:"diskWatermarks,omitempty"`
+}
+
+// ElasticsearchResourceRecommendationsSpec defines the resource recommendations of the nodes
+type ElasticsearchResourceRecommendationsSpec struct {
+	// Read the usage of the elasticsearch containers from the metrics server
+	// and record recommended requests and limits in status.nodes
+	//
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// ElasticsearchRetentionPolicy deletes the indices matching a pattern once they are older than a number of days
+type ElasticsearchRetentionPolicy struct {
+	// The index name pattern, where * matches any characters, e.g. "app-*"
+	//
+	// +kubebuilder:validation:MinLength=1
+	IndexPattern string `json:"indexPattern"`
+
+	// Delete the matching indices created more than this number of days ago
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxAgeDays int32 `json:"maxAgeDays"`
+}
+
+// ElasticsearchMonitoringSpec defines the additional monitoring of the cluster
+type ElasticsearchMonitoringSpec struct {
+	// Run the prometheus elasticsearch exporter as a sidecar of every node,
+	// exposing its metrics on port 9114
+	//
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// ElasticsearchPodDisruptionBudgetSpec defines the voluntary disruptions tolerated
+// by the cluster. Master nodes are always kept at quorum.
+type ElasticsearchPodDisruptionBudgetSpec struct {
+	// The maximum number of unavailable data nodes during voluntary disruptions,
+	// e.g. node drains. Defaults to 1.
+	//
+	// +nullable
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// ElasticsearchClusterSettingsSpec defines the cluster settings applied once the
+// cluster is formed
+type ElasticsearchClusterSettingsSpec struct {
+	// Whether the settings are persistent or transient, i.e. lost on a full
+	// cluster restart. Defaults to Persistent.
+	//
+	// +optional
+	Type ClusterSettingsType `json:"type,omitempty"`
+
+	// The settings keyed by their flat name. The values are parsed as YAML.
+	// Settings managed by the operator are ignored.
+	Settings map[string]string `json:"settings"`
+}
+
+// ElasticsearchDiskWatermarksSpec defines the disk usage thresholds of the shard
+// allocation. The values are either all percentages of the used disk, e.g. 85%,
+// or all amounts of free disk, e.g. 20gb. Unset thresholds keep their default.
+type ElasticsearchDiskWatermarksSpec struct {
+	// No shard is allocated to a node above the low watermark
+	//
+	// +optional
+	Low string `json:"low,omitempty"`
+
+	// Shards are relocated away from a node above the high watermark
+	//
+	// +optional
+	High string `json:"high,omitempty"`
+
+	// The indices with a shard on a node above the flood stage watermark are
+	// made read-only
+	//
+	// +optional
+	FloodStage string `json:"floodStage,omitempty"`
+}
+
+// ElasticsearchServiceSpec defines how the REST API of the cluster is exposed
+type ElasticsearchServiceSpec struct {
+	// The type of the service exposing the REST API. Defaults to ClusterIP.
+	//
+	// +kubebuilder:validation:Enum:=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Whether external traffic is routed to the nodes of the cluster on the
+	// receiving host only, preserving the client source IP, or cluster wide.
+	// Applies to NodePort and LoadBalancer services. Defaults to Cluster.
+	//
+	// +kubebuilder:validation:Enum:=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
+	// Annotations of the service, e.g. the provider specific annotations
+	// provisioning an internal load balancer
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // ElasticsearchStatus defines the observed state of Elasticsearch
@@ -110,6 +314,83 @@ type ElasticsearchStatus struct {
 	Conditions ClusterConditions `json:"conditions,omitempty"`
 	// +optional
 	IndexManagementStatus *IndexManagementStatus `json:"indexManagement,omitempty"`
+	// DynamicSettings lists the user settings applied through the cluster settings API
+	// +optional
+	DynamicSettings []string `json:"dynamicSettings,omitempty"`
+	// ClusterSettings are the cluster settings of the spec last applied
+	// +nullable
+	// +optional
+	ClusterSettings *ElasticsearchClusterSettingsSpec `json:"clusterSettings,omitempty"`
+	// EffectiveConfig is the configuration resolved from the spec and the operator defaults
+	// +nullable
+	// +optional
+	EffectiveConfig *ElasticsearchEffectiveConfig `json:"effectiveConfig,omitempty"`
+	// Upgrade is the progress of the rolling upgrade of the nodes, if any
+	// +nullable
+	// +optional
+	Upgrade *ElasticsearchUpgradeProgress `json:"upgrade,omitempty"`
+}
+
+// ElasticsearchUpgradeProgress is the progress of a rolling upgrade. The nodes
+// without the master role are upgraded first, then the master eligible nodes
+// one at a time.
+type ElasticsearchUpgradeProgress struct {
+	// The group of nodes being upgraded
+	Stage ElasticsearchUpgradeStage `json:"stage"`
+	// The nodes left to upgrade in the order they are upgraded
+	// +optional
+	PendingNodes []string `json:"pendingNodes,omitempty"`
+}
+
+// ElasticsearchUpgradeStage is the group of nodes a rolling upgrade works on
+type ElasticsearchUpgradeStage string
+
+const (
+	// UpgradeStageNonMasterNodes upgrades the data, ingest and client nodes
+	UpgradeStageNonMasterNodes ElasticsearchUpgradeStage = "NonMasterNodes"
+	// UpgradeStageMasterNodes upgrades the master eligible nodes
+	UpgradeStageMasterNodes ElasticsearchUpgradeStage = "MasterNodes"
+)
+
+// ElasticsearchEffectiveConfig is the configuration the operator resolved from
+// the spec and its defaults
+type ElasticsearchEffectiveConfig struct {
+	// The image of the elasticsearch containers
+	Image string `json:"image"`
+	// The number of primary shards of the indices
+	PrimaryShards int32 `json:"primaryShards"`
+	// The number of replica shards of the indices
+	ReplicaShards int32 `json:"replicaShards"`
+	// The resolved configuration of each node group of spec.nodes
+	// +optional
+	Nodes []ElasticsearchNodeEffectiveConfig `json:"nodes,omitempty"`
+}
+
+// ElasticsearchNodeEffectiveConfig is the resolved configuration of a node group
+type ElasticsearchNodeEffectiveConfig struct {
+	// The GenUUID of the node group
+	// +optional
+	GenUUID string `json:"genUUID,omitempty"`
+	// The number of nodes of the group
+	NodeCount int32 `json:"nodeCount"`
+	// Whether the nodes are master eligible
+	Master bool `json:"master"`
+	// Whether the nodes hold data
+	Data bool `json:"data"`
+	// Whether the nodes serve client requests
+	Client bool `json:"client"`
+	// Whether the nodes run ingest pipelines
+	Ingest bool `json:"ingest"`
+	// The resources of the elasticsearch container
+	Resources corev1.ResourceRequirements `json:"resources"`
+	// The resources of the proxy container
+	ProxyResources corev1.ResourceRequirements `json:"proxyResources"`
+	// The JVM heap size. Unset when the heap is derived from the memory limit.
+	// +nullable
+	// +optional
+	HeapSize *resource.Quantity `json:"heapSize,omitempty"`
+	// The image of the elasticsearch containers
+	Image string `json:"image"`
 }
 
 type ClusterHealth struct {
@@ -130,6 +411,10 @@ type ClusterHealth struct {
 	// The number of Active Shards for the Elasticsearch Cluster
 	// +operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors="urn:alm:descriptor:text"
 	ActiveShards int32 `json:"activeShards"`
+	// The percentage of active shards for the Elasticsearch Cluster, e.g. "100.0"
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Active Shards Percent",xDescriptors="urn:alm:descriptor:text"
+	// +optional
+	ActiveShardsPercent string `json:"activeShardsPercent,omitempty"`
 	// The number of Relocating Shards for the Elasticsearch Cluster
 	// +operator-sdk:csv:customresourcedefinitions:type=status,xDescriptors="urn:alm:descriptor:text"
 	RelocatingShards int32 `json:"relocatingShards"`
@@ -164,11 +449,61 @@ type ElasticsearchNode struct {
 	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
 	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// Custom attributes of the node rendered as node.attr.<name>, e.g.
+	// box_type: hot, to drive shard allocation filtering and awareness
+	//
+	// +nullable
+	// +optional
+	NodeAttributes map[string]string `json:"nodeAttributes,omitempty"`
+
+	// Whether pods of the node spread across hosts as a scheduling preference
+	// or as a hard requirement, or may share hosts when Disabled. Takes precedence
+	// over the mode of the common node spec. Defaults to Preferred.
+	//
+	// +optional
+	AntiAffinityMode AntiAffinityMode `json:"antiAffinityMode,omitempty"`
+
+	// Custom affinity rules merged into the default pod anti-affinity. Takes
+	// precedence over the affinity of the common node spec.
+	//
+	// +nullable
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Constraints spreading the pods of the node across topology domains.
+	// Take precedence over the constraints of the common node spec.
+	//
+	// +nullable
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// How changes of the node are rolled out to its pods. Defaults to a
+	// rolling update of all pods, one at a time.
+	//
+	// +nullable
+	// +optional
+	UpdateStrategy *ElasticsearchUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// The workload running the data nodes. A StatefulSet keeps a persistent
+	// volume claim per replica. Entries added from now on are set to StatefulSet,
+	// existing entries keep running one Deployment per replica. Nodes without
+	// the data role always run as StatefulSet.
+	//
+	// +optional
+	Workload ElasticsearchNodeWorkload `json:"workload,omitempty"`
+
 	// The type of backing storage that should be used for the node
 	//
 	// +optional
 	Storage ElasticsearchStorageSpec `json:"storage,omitempty"`
 
+	// Additional data volumes of data nodes, each mounted at
+	// /elasticsearch/data/<name> and added to path.data, to spread the shards
+	// across several volumes
+	//
+	// +optional
+	DataVolumes []ElasticsearchDataVolume `json:"dataVolumes,omitempty"`
+
 	// GenUUID will be populated by the operator if not provided
 	//
 	// +nullable
@@ -176,16 +511,111 @@ type ElasticsearchNode struct {
 
 	// The resource requirements for the Elasticsearch proxy
 	ProxyResources corev1.ResourceRequirements `json:"proxyResources,omitempty"`
+
+	// The JVM heap size of the Elasticsearch nodes. Takes precedence over the
+	// heap size of the common node spec. If unset, the heap is derived from
+	// the memory limit.
+	//
+	// +nullable
+	// +optional
+	HeapSize *resource.Quantity `json:"heapSize,omitempty"`
+
+	// Additional environment variables of the Elasticsearch container.
+	// Take precedence over the environment variables of the common node spec
+	// with the same name. Variables set by the operator cannot be overridden.
+	//
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Additional labels of the Elasticsearch pods. Take precedence over the
+	// labels of the common node spec with the same key. Labels set by the
+	// operator cannot be overridden.
+	//
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Additional annotations of the Elasticsearch pods. Take precedence over
+	// the annotations of the common node spec with the same key.
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// The readiness probe settings for the Elasticsearch container.
+	// Takes precedence over the readiness probe of the common node spec.
+	//
+	// +nullable
+	// +optional
+	ReadinessProbe *ElasticsearchProbeSpec `json:"readinessProbe,omitempty"`
+
+	// The liveness probe settings for the Elasticsearch container.
+	// Takes precedence over the liveness probe of the common node spec.
+	//
+	// +nullable
+	// +optional
+	LivenessProbe *ElasticsearchProbeSpec `json:"livenessProbe,omitempty"`
+
+	// The startup probe settings for the Elasticsearch container. Takes precedence
+	// over the startup probe of the common node spec.
+	//
+	// +nullable
+	// +optional
+	StartupProbe *ElasticsearchProbeSpec `json:"startupProbe,omitempty"`
+
+	// The seconds the readiness script of the image waits for Elasticsearch,
+	// passed as READINESS_PROBE_TIMEOUT. Takes precedence over the timeout of
+	// the common node spec.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	ReadinessProbeTimeoutSeconds *int32 `json:"readinessProbeTimeoutSeconds,omitempty"`
+
+	// The priority class of the Elasticsearch pods, e.g. to give master nodes
+	// a higher priority than data nodes. Takes precedence over the priority
+	// class of the common node spec.
+	//
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// The seconds the restarted pods of the node must have been ready before a
+	// rolling restart or upgrade proceeds with the next node. Takes precedence
+	// over the value of the common node spec.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// The image of the Elasticsearch nodes, e.g. to canary a build on a single
+	// tier. Takes precedence over the image of the common node spec.
+	//
+	// +optional
+	Image string `json:"image,omitempty"`
 }
 
 // ElasticsearchNodeSpec represents configuration of an individual Elasticsearch node
 type ElasticsearchNodeSpec struct {
-	// The image to use for the Elasticsearch nodes
+	// The image to use for the Elasticsearch nodes. Defaults to the image the
+	// operator is deployed with.
 	//
 	// +nullable
 	// +optional
 	Image string `json:"image,omitempty"`
 
+	// The pull policy for the Elasticsearch image. Defaults to IfNotPresent
+	// for images with an explicit tag and Always for untagged or latest images.
+	//
+	// +kubebuilder:validation:Enum:=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// The secrets used to pull the Elasticsearch image from a private registry.
+	// The secrets must exist in the namespace of the cluster.
+	//
+	// +nullable
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
 	// The resource requirements for the Elasticsearch nodes
 	//
 	// +nullable
@@ -199,11 +629,494 @@ type ElasticsearchNodeSpec struct {
 	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
 	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// Whether pods of the same roles spread across hosts as a scheduling
+	// preference or as a hard requirement, or may share hosts when Disabled.
+	// Defaults to Preferred.
+	//
+	// +optional
+	AntiAffinityMode AntiAffinityMode `json:"antiAffinityMode,omitempty"`
+
+	// Custom affinity rules merged into the default pod anti-affinity.
+	// Node and pod affinity are used as given, pod anti-affinity terms are
+	// added to the default anti-affinity of the node roles.
+	//
+	// +nullable
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Constraints spreading the pods of the same roles across topology domains
+	// such as zones. A constraint without label selector matches the pods of
+	// the cluster with the same roles. Defaults to none.
+	//
+	// +nullable
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Additional entries of the /etc/hosts file of the Elasticsearch pods, e.g.
+	// to resolve a snapshot repository host in split-horizon DNS environments
+	//
+	// +optional
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+
+	// The DNS policy of the Elasticsearch pods. Defaults to ClusterFirst. The
+	// policy None requires nameservers in dnsConfig.
+	//
+	// +kubebuilder:validation:Enum:=ClusterFirstWithHostNet;ClusterFirst;Default;None
+	// +optional
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// DNS parameters of the Elasticsearch pods merged into the ones generated
+	// from the DNS policy, e.g. to lower ndots for external snapshot endpoints
+	//
+	// +nullable
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// Additional volumes of the Elasticsearch pods, e.g. a secret with keystore
+	// files or an NFS share for snapshots. The names of the volumes of the
+	// operator, e.g. elasticsearch-storage, elasticsearch-config and certificates,
+	// are reserved.
+	//
+	// +optional
+	ExtraVolumes []corev1.Volume `json:"extraVolumes,omitempty"`
+
+	// Additional volume mounts of the Elasticsearch container. They must refer
+	// to extra volumes and not use the mount paths of the operator.
+	//
+	// +optional
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+
+	// Additional containers of the Elasticsearch pods, e.g. a log shipper. The
+	// volume elasticsearch-shared-logs with the log files of Elasticsearch is
+	// shared with them and can be mounted by name. The container names
+	// elasticsearch, proxy and exporter are reserved.
+	//
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
 	// The resource requirements for the Elasticsearch proxy
 	//
 	// +nullable
 	// +optional
 	ProxyResources corev1.ResourceRequirements `json:"proxyResources,omitempty"`
+
+	// The JVM heap size of the Elasticsearch nodes. Must not exceed the memory
+	// limit. If unset, the heap is derived from the memory limit.
+	//
+	// +nullable
+	// +optional
+	HeapSize *resource.Quantity `json:"heapSize,omitempty"`
+
+	// The ratio of the memory limit to an explicit heap size, reserving room for
+	// off-heap memory and the page cache. The memory limit of nodes with a heap
+	// size and without memory resources defaults to the heap times this ratio.
+	// Defaults to 2.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	HeapMemoryMultiplier *int32 `json:"heapMemoryMultiplier,omitempty"`
+
+	// Additional environment variables of the Elasticsearch container.
+	// Variables set by the operator cannot be overridden.
+	//
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Additional labels of the Elasticsearch pods, e.g. for cost allocation.
+	// Labels set by the operator cannot be overridden.
+	//
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Additional annotations of the Elasticsearch pods, e.g. scrape hints
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// The readiness probe settings for the Elasticsearch container
+	//
+	// +nullable
+	// +optional
+	ReadinessProbe *ElasticsearchProbeSpec `json:"readinessProbe,omitempty"`
+
+	// The liveness probe settings for the Elasticsearch container
+	//
+	// +nullable
+	// +optional
+	LivenessProbe *ElasticsearchProbeSpec `json:"livenessProbe,omitempty"`
+
+	// The startup probe settings for the Elasticsearch container. The liveness and
+	// readiness probes only start once it succeeded, so slowly starting nodes are
+	// not restarted. Disabled unless set, checks the HTTP port by default.
+	//
+	// +nullable
+	// +optional
+	StartupProbe *ElasticsearchProbeSpec `json:"startupProbe,omitempty"`
+
+	// The seconds the readiness script of the image waits for Elasticsearch,
+	// passed as READINESS_PROBE_TIMEOUT. Defaults to 30 seconds. A first boot on
+	// large existing data is granted its window by the startup probe instead.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	ReadinessProbeTimeoutSeconds *int32 `json:"readinessProbeTimeoutSeconds,omitempty"`
+
+	// The priority class of the Elasticsearch pods. Defaults to the
+	// cluster default priority.
+	//
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// The security context of the Elasticsearch pods. Replaces the default,
+	// which runs as the non-root elasticsearch user with the fsGroup below when
+	// the pods have persistent volumes.
+	//
+	// +nullable
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// The group owning the persistent and hostPath volumes of the Elasticsearch
+	// pods, so that the non-root elasticsearch user can write to them. Defaults
+	// to the gid of the elasticsearch user. Ignored with a podSecurityContext.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+
+	// The security context of the Elasticsearch container. Replaces the default,
+	// which drops all capabilities and disallows privilege escalation.
+	//
+	// +nullable
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Run the Elasticsearch container with a read-only root filesystem. The
+	// paths Elasticsearch writes to outside of its volumes are backed by
+	// emptyDir volumes.
+	//
+	// +optional
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+
+	// The time in seconds granted to the Elasticsearch pods to shut down
+	// gracefully. Defaults to 180.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// The port of the Elasticsearch REST API. Defaults to 9200.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	HTTPPort int32 `json:"httpPort,omitempty"`
+
+	// The port of the Elasticsearch transport used for the communication
+	// between the nodes. Defaults to 9300.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	TransportPort int32 `json:"transportPort,omitempty"`
+
+	// The names of the container ports the services of the cluster target.
+	// Legacy names the transport port "cluster" and the REST API port of the
+	// proxy "restapi", Conventional names them "transport" and "http". Changing
+	// it restarts all the nodes. Defaults to Legacy.
+	//
+	// +optional
+	PortNaming ElasticsearchPortNaming `json:"portNaming,omitempty"`
+
+	// The queue size of the write thread pool, rendered as
+	// thread_pool.write.queue_size. Defaults to the Elasticsearch one.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	WriteQueueSize *int32 `json:"writeQueueSize,omitempty"`
+
+	// The queue size of the search thread pool, rendered as
+	// thread_pool.search.queue_size. Defaults to the Elasticsearch one.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	SearchQueueSize *int32 `json:"searchQueueSize,omitempty"`
+
+	// The node attributes shard allocation is aware of, rendered as
+	// cluster.routing.allocation.awareness.attributes. Replicas of a shard are
+	// spread across the values of these attributes.
+	//
+	// +nullable
+	// +optional
+	AllocationAwarenessAttributes []string `json:"allocationAwarenessAttributes,omitempty"`
+
+	// Set the zone node attribute of every node from the
+	// topology.kubernetes.io/zone label of its pod and add it to the allocation
+	// awareness attributes, so that the replicas of a shard spread across zones
+	//
+	// +optional
+	ZoneAwareness bool `json:"zoneAwareness,omitempty"`
+
+	// Run a privileged init container that raises vm.max_map_count on the
+	// host before Elasticsearch starts. Requires the service account of the
+	// cluster to be allowed to run privileged pods.
+	//
+	// +optional
+	SysctlInitContainer bool `json:"sysctlInitContainer,omitempty"`
+
+	// The vm.max_map_count set by the sysctl init container. Defaults to 262144.
+	//
+	// +kubebuilder:validation:Minimum=262144
+	// +nullable
+	// +optional
+	MaxMapCount *int64 `json:"maxMapCount,omitempty"`
+
+	// Where the JVM writes heap dumps on out of memory errors. Defaults to the
+	// data volume.
+	//
+	// +nullable
+	// +optional
+	HeapDump *ElasticsearchHeapDumpSpec `json:"heapDump,omitempty"`
+
+	// Enables the JVM garbage collection logs, written to rotated files in the
+	// Elasticsearch log directory or on the dedicated volume if one is requested.
+	// Disabled by default.
+	//
+	// +optional
+	GCLogging bool `json:"gcLogging,omitempty"`
+
+	// A dedicated volume for the garbage collection logs, so that they do not
+	// consume the capacity of the data volume. An emptyDir is used unless a size
+	// is given, in which case a PVC is created per node.
+	//
+	// +nullable
+	// +optional
+	GCLogStorage *ElasticsearchStorageSpec `json:"gcLogStorage,omitempty"`
+
+	// Secure settings loaded into the Elasticsearch keystore by an init container,
+	// e.g. the credentials of a snapshot repository. Disabled unless set.
+	//
+	// +nullable
+	// +optional
+	Keystore *ElasticsearchKeystoreSpec `json:"keystore,omitempty"`
+
+	// CA certificates added to the JVM truststore by an init container, e.g. of
+	// a private CA signing the certificates of an S3 compatible snapshot
+	// repository or an LDAP server. Disabled unless set.
+	//
+	// +nullable
+	// +optional
+	CABundle *ElasticsearchCABundleSpec `json:"caBundle,omitempty"`
+
+	// Plugins installed by an init container before Elasticsearch starts, each
+	// passed to elasticsearch-plugin install, e.g. the URL of a plugin zip on a
+	// local mirror. Disabled unless set.
+	//
+	// +nullable
+	// +optional
+	Plugins []string `json:"plugins,omitempty"`
+
+	// The settings of the cluster recovery after a full cluster restart
+	//
+	// +nullable
+	// +optional
+	Recovery *ElasticsearchRecoverySpec `json:"recovery,omitempty"`
+
+	// A ConfigMap in the cluster namespace whose keys are elasticsearch settings,
+	// e.g. thread_pool.write.queue_size, added to the generated elasticsearch.yml.
+	// Settings managed by the operator cannot be overridden. A log4j2.properties
+	// key replaces the logging configuration generated by the operator.
+	//
+	// +nullable
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// The TLS settings of the transport layer between the nodes
+	//
+	// +nullable
+	// +optional
+	TransportTLS *ElasticsearchTransportTLSSpec `json:"transportTLS,omitempty"`
+
+	// Add a readiness gate to the Elasticsearch pods which the operator only
+	// opens once the node has no initializing or relocating shards, keeping
+	// recovering nodes out of the service endpoints.
+	//
+	// +optional
+	ShardRecoveryReadinessGate bool `json:"shardRecoveryReadinessGate,omitempty"`
+
+	// Overrides the entrypoint of the Elasticsearch image, e.g. to run a debug
+	// shell. The entrypoint of the image is used if unset.
+	//
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Overrides the arguments of the Elasticsearch image entrypoint. The
+	// arguments of the image are used if unset.
+	//
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// A prefix prepended to the names of the config maps and of the discovery
+	// and metrics services generated for the cluster. The secret holding the
+	// certificates and the REST API service keep the name of the cluster.
+	//
+	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:Pattern=`^[a-z]([-a-z0-9]*)?$`
+	// +optional
+	ResourceNamePrefix string `json:"resourceNamePrefix,omitempty"`
+
+	// A prefix prepended to the Elasticsearch node names, which are the pod
+	// names for statefulsets and the deployment names otherwise. Changing it
+	// renames the nodes and restarts them.
+	//
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	// +optional
+	NodeNamePrefix string `json:"nodeNamePrefix,omitempty"`
+
+	// The seconds the restarted pods of a node must have been ready before a
+	// rolling restart or upgrade proceeds with the next node, so that the node
+	// can settle. Defaults to 30 for data nodes and 0 otherwise.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+}
+
+// ElasticsearchTransportTLSSpec defines the TLS settings of the node to node
+// communication. Nodes always authenticate each other with their certificates.
+type ElasticsearchTransportTLSSpec struct {
+	// Verify that the certificate of a node matches its address. Requires
+	// node certificates with the pod IPs provided in spec.certificateSecret.
+	//
+	// +optional
+	EnforceHostnameVerification bool `json:"enforceHostnameVerification,omitempty"`
+}
+
+// ElasticsearchRecoverySpec defines when the cluster starts recovering its
+// shards after a full cluster restart
+type ElasticsearchRecoverySpec struct {
+	// How long to wait for the expected nodes before the recovery starts,
+	// e.g. 10m. Defaults to 5m.
+	//
+	// +kubebuilder:validation:Pattern:=`^[0-9]+(nanos|micros|ms|s|m|h|d)$`
+	// +optional
+	AfterTime string `json:"afterTime,omitempty"`
+
+	// The number of nodes expected in the cluster, the recovery starts
+	// immediately once they joined. Defaults to the number of data nodes.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +nullable
+	// +optional
+	ExpectedNodes *int32 `json:"expectedNodes,omitempty"`
+}
+
+// ElasticsearchDataVolume is an additional data volume of an Elasticsearch node
+type ElasticsearchDataVolume struct {
+	// The name of the volume, unique within the node
+	//
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// The storage of the volume. An emptyDir is used unless a size is given, in
+	// which case a PVC is created per node.
+	//
+	// +optional
+	Storage ElasticsearchStorageSpec `json:"storage,omitempty"`
+}
+
+// ElasticsearchHeapDumpSpec defines the location of the heap dumps of the
+// Elasticsearch nodes
+type ElasticsearchHeapDumpSpec struct {
+	// The absolute path of the heap dump file. Defaults to heapdump.hprof on
+	// the dedicated volume if one is requested or else on the data volume.
+	//
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// A dedicated volume for heap dumps, so that a dump does not consume the
+	// capacity of the data volume. An emptyDir is used unless a size is given,
+	// in which case a PVC is created per node.
+	//
+	// +nullable
+	// +optional
+	Storage *ElasticsearchStorageSpec `json:"storage,omitempty"`
+}
+
+// ElasticsearchKeystoreSpec defines the secure settings of the Elasticsearch
+// nodes
+type ElasticsearchKeystoreSpec struct {
+	// The secret in the cluster namespace holding the secure settings. Each key,
+	// e.g. s3.client.default.secret_key, is added to the keystore with its value.
+	// A key elasticsearch.keystore is used as the keystore file instead.
+	SecretName string `json:"secretName"`
+}
+
+// ElasticsearchCABundleSpec defines the CA certificates trusted by the
+// Elasticsearch nodes for outbound TLS connections
+type ElasticsearchCABundleSpec struct {
+	// The config map in the cluster namespace holding the PEM encoded CA
+	// certificates. Exactly one of configMapName and secretName is required.
+	//
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// The secret in the cluster namespace holding the PEM encoded CA
+	// certificates. Exactly one of configMapName and secretName is required.
+	//
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// The key of the config map or secret holding the certificates, defaults
+	// to ca-bundle.crt
+	//
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ElasticsearchProbeSpec tunes a probe of the Elasticsearch container.
+// Unset fields fall back to the operator defaults.
+type ElasticsearchProbeSpec struct {
+	// The kind of check performed by the probe. Exec runs the probe script
+	// shipped with the image, TCP opens a socket on the given port and HTTP
+	// requests /_cluster/health on the given port. The HTTP check is
+	// authenticated with the admin certificate of the node.
+	//
+	// +optional
+	Type ElasticsearchProbeType `json:"type,omitempty"`
+
+	// The container port to check. Defaults to 9300 for TCP and 9200 for HTTP.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// Number of seconds after the container has started before the probe is initiated
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// Number of seconds after which the probe times out
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// Minimum consecutive failures for the probe to be considered failed
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
 }
 
 type ElasticsearchStorageSpec struct {
@@ -214,6 +1127,20 @@ type ElasticsearchStorageSpec struct {
 
 	// The max storage capacity for the node to provision.
 	Size *resource.Quantity `json:"size,omitempty"`
+
+	// The medium and size limit of the emptyDir volume used when no size is
+	// provided. Cannot be combined with size.
+	//
+	// +nullable
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+
+	// Annotations of the PVCs created for the storage, e.g. required by the
+	// storage provisioner or by a backup tool. Existing claims of nodes run as
+	// statefulset are not updated.
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // ElasticsearchNodeStatus represents the status of individual Elasticsearch node
@@ -230,8 +1157,42 @@ type ElasticsearchNodeStatus struct {
 	Roles []ElasticsearchNodeRole `json:"roles,omitempty"`
 	// +optional
 	Conditions ClusterConditions `json:"conditions,omitempty"`
+	// The resources recommended for the elasticsearch container from its usage
+	//
+	// +nullable
+	// +optional
+	ResourceRecommendation *ElasticsearchResourceRecommendation `json:"resourceRecommendation,omitempty"`
 }
 
+// ElasticsearchResourceRecommendation is the resources recommended for the
+// elasticsearch container of a node from the highest usage of its pods
+type ElasticsearchResourceRecommendation struct {
+	// Whether the current requests are too low, too high or fit the usage
+	Provisioning ResourceProvisioning `json:"provisioning"`
+
+	// The recommended requests
+	//
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// The recommended limits
+	//
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+}
+
+// ResourceProvisioning compares the requests of a node to its usage
+type ResourceProvisioning string
+
+const (
+	// UnderProvisioned nodes use more than requested
+	UnderProvisioned ResourceProvisioning = "UnderProvisioned"
+	// OverProvisioned nodes use less than half of the requests
+	OverProvisioned ResourceProvisioning = "OverProvisioned"
+	// Provisioned nodes have requests fitting their usage
+	Provisioned ResourceProvisioning = "Provisioned"
+)
+
 type ElasticsearchNodeUpgradeStatus struct {
 	ScheduledForUpgrade      corev1.ConditionStatus    `json:"scheduledUpgrade,omitempty"`
 	ScheduledForRedeploy     corev1.ConditionStatus    `json:"scheduledRedeploy,omitempty"`
@@ -269,15 +1230,114 @@ const (
 	ZeroRedundancy RedundancyPolicyType = "ZeroRedundancy"
 )
 
-// +kubebuilder:validation:Enum:=master;client;data
+// +kubebuilder:validation:Enum:=master;client;data;ingest
 type ElasticsearchNodeRole string
 
 const (
 	ElasticsearchRoleClient ElasticsearchNodeRole = "client"
 	ElasticsearchRoleData   ElasticsearchNodeRole = "data"
 	ElasticsearchRoleMaster ElasticsearchNodeRole = "master"
+	ElasticsearchRoleIngest ElasticsearchNodeRole = "ingest"
+)
+
+// ElasticsearchProbeType is the kind of check a probe performs
+//
+// +kubebuilder:validation:Enum:=Exec;TCP;HTTP
+type ElasticsearchProbeType string
+
+const (
+	ProbeTypeExec ElasticsearchProbeType = "Exec"
+	ProbeTypeTCP  ElasticsearchProbeType = "TCP"
+	ProbeTypeHTTP ElasticsearchProbeType = "HTTP"
+)
+
+// AntiAffinityMode defines how strictly pods of the same roles are kept
+// off the same host
+//
+// +kubebuilder:validation:Enum:=Preferred;Required;Disabled
+type AntiAffinityMode string
+
+const (
+	AntiAffinityPreferred AntiAffinityMode = "Preferred"
+	AntiAffinityRequired  AntiAffinityMode = "Required"
+	AntiAffinityDisabled  AntiAffinityMode = "Disabled"
+)
+
+// ElasticsearchNodeWorkload is the kind of workload running the data nodes
+//
+// +kubebuilder:validation:Enum:=Deployment;StatefulSet
+type ElasticsearchNodeWorkload string
+
+const (
+	DeploymentWorkload  ElasticsearchNodeWorkload = "Deployment"
+	StatefulSetWorkload ElasticsearchNodeWorkload = "StatefulSet"
+)
+
+// ElasticsearchUpdateStrategyType defines how changes are rolled out to the pods of a node
+//
+// +kubebuilder:validation:Enum:=RollingUpdate;OnDelete
+type ElasticsearchUpdateStrategyType string
+
+const (
+	// RollingUpdateStrategy restarts the pods one at a time, waiting for each
+	// to rejoin the cluster, from the highest ordinal down to the partition
+	RollingUpdateStrategy ElasticsearchUpdateStrategyType = "RollingUpdate"
+	// OnDeleteStrategy leaves the rollout to the user, pods are updated once deleted
+	OnDeleteStrategy ElasticsearchUpdateStrategyType = "OnDelete"
+)
+
+// VolumeClaimCleanupPolicy defines what happens to the claims of removed nodes
+//
+// +kubebuilder:validation:Enum:=Retain;Delete
+type VolumeClaimCleanupPolicy string
+
+const (
+	// VolumeClaimCleanupRetain keeps the claims of removed nodes
+	VolumeClaimCleanupRetain VolumeClaimCleanupPolicy = "Retain"
+	// VolumeClaimCleanupDelete deletes the claims of removed nodes
+	VolumeClaimCleanupDelete VolumeClaimCleanupPolicy = "Delete"
+)
+
+// ClusterSettingsType defines how long cluster settings last
+//
+// +kubebuilder:validation:Enum:=Persistent;Transient
+type ClusterSettingsType string
+
+const (
+	// ClusterSettingsPersistent settings survive a full cluster restart
+	ClusterSettingsPersistent ClusterSettingsType = "Persistent"
+	// ClusterSettingsTransient settings are lost on a full cluster restart
+	ClusterSettingsTransient ClusterSettingsType = "Transient"
+)
+
+// ElasticsearchPortNaming defines the names of the container ports of the nodes
+//
+// +kubebuilder:validation:Enum:=Legacy;Conventional
+type ElasticsearchPortNaming string
+
+const (
+	// PortNamingLegacy names the ports "cluster" and "restapi"
+	PortNamingLegacy ElasticsearchPortNaming = "Legacy"
+	// PortNamingConventional names the ports "transport" and "http"
+	PortNamingConventional ElasticsearchPortNaming = "Conventional"
 )
 
+// ElasticsearchUpdateStrategy follows the StatefulSet update strategy
+type ElasticsearchUpdateStrategy struct {
+	// The type of the update strategy. Defaults to RollingUpdate.
+	//
+	// +optional
+	Type ElasticsearchUpdateStrategyType `json:"type,omitempty"`
+
+	// Only pods with an ordinal greater or equal to the partition are updated
+	// by a rolling update. Raise it to stage a canary, lower it to continue.
+	// Defaults to 0.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Partition *int32 `json:"partition,omitempty"`
+}
+
 type ShardAllocationState string
 
 const (
@@ -332,6 +1392,7 @@ const (
 	InvalidData              ClusterConditionType = "InvalidData"
 	InvalidRedundancy        ClusterConditionType = "InvalidRedundancy"
 	InvalidUUID              ClusterConditionType = "InvalidUUID"
+	InvalidSettings          ClusterConditionType = "InvalidSettings"
 	ESContainerWaiting       ClusterConditionType = "ElasticsearchContainerWaiting"
 	ESContainerTerminated    ClusterConditionType = "ElasticsearchContainerTerminated"
 	ProxyContainerWaiting    ClusterConditionType = "ProxyContainerWaiting"
@@ -343,4 +1404,5 @@ const (
 	StorageClassName         ClusterConditionType = "StorageClassNameChangeIgnored"
 	StorageSize              ClusterConditionType = "StorageSizeChangeIgnored"
 	StorageStructure         ClusterConditionType = "StorageStructureChangeIgnored"
+	ReconciliationPaused     ClusterConditionType = "ReconciliationPaused"
 )
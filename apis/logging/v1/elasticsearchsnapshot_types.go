@@ -0,0 +1,165 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ElasticsearchSnapshotSpec defines scheduled snapshots of an Elasticsearch cluster
+//
+// +k8s:openapi-gen=true
+type ElasticsearchSnapshotSpec struct {
+	// The name of the Elasticsearch cluster in the same namespace to take snapshots of
+	//
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Elasticsearch Cluster"
+	ClusterName string `json:"clusterName"`
+
+	// The snapshot repository to register and store the snapshots in
+	//
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Snapshot Repository"
+	Repository SnapshotRepositorySpec `json:"repository"`
+
+	// The schedule in Cron format, e.g. "0 2 * * *" for every night at 2am UTC
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Schedule"
+	Schedule string `json:"schedule"`
+
+	// The indices to include in the snapshots, defaults to all indices
+	//
+	// +nullable
+	// +optional
+	Indices []string `json:"indices,omitempty"`
+
+	// Stop taking snapshots, the repository stays registered
+	//
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// SnapshotRepositoryType is the object storage backing a snapshot repository
+//
+// +kubebuilder:validation:Enum=s3;gcs
+type SnapshotRepositoryType string
+
+const (
+	SnapshotRepositoryS3  SnapshotRepositoryType = "s3"
+	SnapshotRepositoryGCS SnapshotRepositoryType = "gcs"
+)
+
+// SnapshotRepositorySpec defines an object storage snapshot repository.
+// The storage credentials are read from the client settings of the
+// elasticsearch keystore.
+type SnapshotRepositorySpec struct {
+	// The name to register the repository with
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The type of the object storage: s3 or gcs
+	Type SnapshotRepositoryType `json:"type"`
+
+	// The bucket to store the snapshots in
+	//
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// The path within the bucket, defaults to the bucket root
+	//
+	// +optional
+	BasePath string `json:"basePath,omitempty"`
+
+	// The name of the storage client settings to use, defaults to "default"
+	//
+	// +optional
+	Client string `json:"client,omitempty"`
+}
+
+// ElasticsearchSnapshotStatus defines the observed state of ElasticsearchSnapshot
+//
+// +k8s:openapi-gen=true
+type ElasticsearchSnapshotStatus struct {
+	// The time the last snapshot was started
+	//
+	// +nullable
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// The time the next snapshot is due
+	//
+	// +nullable
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// The result of the last snapshot
+	//
+	// +nullable
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Snapshot"
+	LastSnapshot *SnapshotResult `json:"lastSnapshot,omitempty"`
+
+	// +nullable
+	// +optional
+	Conditions ClusterConditions `json:"conditions,omitempty"`
+}
+
+// SnapshotResult is the state of a snapshot as reported by Elasticsearch
+type SnapshotResult struct {
+	// The name of the snapshot in the repository
+	Name string `json:"name"`
+
+	// The state of the snapshot: IN_PROGRESS, SUCCESS, PARTIAL or FAILED
+	//
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// +nullable
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +nullable
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// The reason of a failed snapshot
+	//
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+const (
+	// SnapshotRepositoryFailed is set when the repository cannot be registered
+	SnapshotRepositoryFailed ClusterConditionType = "SnapshotRepositoryFailed"
+	// SnapshotFailed is set when the last snapshot could not be taken
+	SnapshotFailed ClusterConditionType = "SnapshotFailed"
+)
+
+// +kubebuilder:object:root=true
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=elasticsearchsnapshots,categories=logging,scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",JSONPath=".spec.clusterName",type=string
+// +kubebuilder:printcolumn:name="Schedule",JSONPath=".spec.schedule",type=string
+// +kubebuilder:printcolumn:name="Last Snapshot",JSONPath=".status.lastSnapshot.name",type=string
+// +kubebuilder:printcolumn:name="State",JSONPath=".status.lastSnapshot.state",type=string
+// Scheduled snapshots of an Elasticsearch cluster
+// +operator-sdk:csv:customresourcedefinitions:displayName="Elasticsearch Snapshot"
+type ElasticsearchSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElasticsearchSnapshotSpec   `json:"spec,omitempty"`
+	Status ElasticsearchSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchSnapshotList contains a list of ElasticsearchSnapshot
+type ElasticsearchSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchSnapshot{}, &ElasticsearchSnapshotList{})
+}
@@ -8,6 +8,7 @@ package v1
 import (
 	corev1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -89,6 +90,131 @@ func (in *Elasticsearch) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCABundleSpec) DeepCopyInto(out *ElasticsearchCABundleSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCABundleSpec.
+func (in *ElasticsearchCABundleSpec) DeepCopy() *ElasticsearchCABundleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCABundleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterSettingsSpec) DeepCopyInto(out *ElasticsearchClusterSettingsSpec) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterSettingsSpec.
+func (in *ElasticsearchClusterSettingsSpec) DeepCopy() *ElasticsearchClusterSettingsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterSettingsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataVolume) DeepCopyInto(out *ElasticsearchDataVolume) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataVolume.
+func (in *ElasticsearchDataVolume) DeepCopy() *ElasticsearchDataVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDiskWatermarksSpec) DeepCopyInto(out *ElasticsearchDiskWatermarksSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDiskWatermarksSpec.
+func (in *ElasticsearchDiskWatermarksSpec) DeepCopy() *ElasticsearchDiskWatermarksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDiskWatermarksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchEffectiveConfig) DeepCopyInto(out *ElasticsearchEffectiveConfig) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]ElasticsearchNodeEffectiveConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchEffectiveConfig.
+func (in *ElasticsearchEffectiveConfig) DeepCopy() *ElasticsearchEffectiveConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchEffectiveConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchHeapDumpSpec) DeepCopyInto(out *ElasticsearchHeapDumpSpec) {
+	*out = *in
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(ElasticsearchStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchHeapDumpSpec.
+func (in *ElasticsearchHeapDumpSpec) DeepCopy() *ElasticsearchHeapDumpSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchHeapDumpSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchKeystoreSpec) DeepCopyInto(out *ElasticsearchKeystoreSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchKeystoreSpec.
+func (in *ElasticsearchKeystoreSpec) DeepCopy() *ElasticsearchKeystoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchKeystoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElasticsearchList) DeepCopyInto(out *ElasticsearchList) {
 	*out = *in
@@ -103,136 +229,727 @@ func (in *ElasticsearchList) DeepCopyInto(out *ElasticsearchList) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchList.
-func (in *ElasticsearchList) DeepCopy() *ElasticsearchList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchList.
+func (in *ElasticsearchList) DeepCopy() *ElasticsearchList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMonitoringSpec) DeepCopyInto(out *ElasticsearchMonitoringSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMonitoringSpec.
+func (in *ElasticsearchMonitoringSpec) DeepCopy() *ElasticsearchMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchNode) DeepCopyInto(out *ElasticsearchNode) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]ElasticsearchNodeRole, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeAttributes != nil {
+		in, out := &in.NodeAttributes, &out.NodeAttributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(ElasticsearchUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.DataVolumes != nil {
+		in, out := &in.DataVolumes, &out.DataVolumes
+		*out = make([]ElasticsearchDataVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GenUUID != nil {
+		in, out := &in.GenUUID, &out.GenUUID
+		*out = new(string)
+		**out = **in
+	}
+	in.ProxyResources.DeepCopyInto(&out.ProxyResources)
+	if in.HeapSize != nil {
+		in, out := &in.HeapSize, &out.HeapSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbeTimeoutSeconds != nil {
+		in, out := &in.ReadinessProbeTimeoutSeconds, &out.ReadinessProbeTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinReadySeconds != nil {
+		in, out := &in.MinReadySeconds, &out.MinReadySeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNode.
+func (in *ElasticsearchNode) DeepCopy() *ElasticsearchNode {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchNodeEffectiveConfig) DeepCopyInto(out *ElasticsearchNodeEffectiveConfig) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.ProxyResources.DeepCopyInto(&out.ProxyResources)
+	if in.HeapSize != nil {
+		in, out := &in.HeapSize, &out.HeapSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeEffectiveConfig.
+func (in *ElasticsearchNodeEffectiveConfig) DeepCopy() *ElasticsearchNodeEffectiveConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchNodeEffectiveConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchNodeSpec) DeepCopyInto(out *ElasticsearchNodeSpec) {
+	*out = *in
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]corev1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(corev1.PodDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraVolumes != nil {
+		in, out := &in.ExtraVolumes, &out.ExtraVolumes
+		*out = make([]corev1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExtraVolumeMounts != nil {
+		in, out := &in.ExtraVolumeMounts, &out.ExtraVolumeMounts
+		*out = make([]corev1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.ProxyResources.DeepCopyInto(&out.ProxyResources)
+	if in.HeapSize != nil {
+		in, out := &in.HeapSize, &out.HeapSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.HeapMemoryMultiplier != nil {
+		in, out := &in.HeapMemoryMultiplier, &out.HeapMemoryMultiplier
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(ElasticsearchProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbeTimeoutSeconds != nil {
+		in, out := &in.ReadinessProbeTimeoutSeconds, &out.ReadinessProbeTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.WriteQueueSize != nil {
+		in, out := &in.WriteQueueSize, &out.WriteQueueSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SearchQueueSize != nil {
+		in, out := &in.SearchQueueSize, &out.SearchQueueSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllocationAwarenessAttributes != nil {
+		in, out := &in.AllocationAwarenessAttributes, &out.AllocationAwarenessAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxMapCount != nil {
+		in, out := &in.MaxMapCount, &out.MaxMapCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HeapDump != nil {
+		in, out := &in.HeapDump, &out.HeapDump
+		*out = new(ElasticsearchHeapDumpSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCLogStorage != nil {
+		in, out := &in.GCLogStorage, &out.GCLogStorage
+		*out = new(ElasticsearchStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Keystore != nil {
+		in, out := &in.Keystore, &out.Keystore
+		*out = new(ElasticsearchKeystoreSpec)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(ElasticsearchCABundleSpec)
+		**out = **in
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Recovery != nil {
+		in, out := &in.Recovery, &out.Recovery
+		*out = new(ElasticsearchRecoverySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.TransportTLS != nil {
+		in, out := &in.TransportTLS, &out.TransportTLS
+		*out = new(ElasticsearchTransportTLSSpec)
+		**out = **in
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinReadySeconds != nil {
+		in, out := &in.MinReadySeconds, &out.MinReadySeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeSpec.
+func (in *ElasticsearchNodeSpec) DeepCopy() *ElasticsearchNodeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchNodeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchNodeStatus) DeepCopyInto(out *ElasticsearchNodeStatus) {
+	*out = *in
+	out.UpgradeStatus = in.UpgradeStatus
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]ElasticsearchNodeRole, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(ClusterConditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceRecommendation != nil {
+		in, out := &in.ResourceRecommendation, &out.ResourceRecommendation
+		*out = new(ElasticsearchResourceRecommendation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeStatus.
+func (in *ElasticsearchNodeStatus) DeepCopy() *ElasticsearchNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchNodeUpgradeStatus) DeepCopyInto(out *ElasticsearchNodeUpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeUpgradeStatus.
+func (in *ElasticsearchNodeUpgradeStatus) DeepCopy() *ElasticsearchNodeUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchNodeUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchPodDisruptionBudgetSpec) DeepCopyInto(out *ElasticsearchPodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchPodDisruptionBudgetSpec.
+func (in *ElasticsearchPodDisruptionBudgetSpec) DeepCopy() *ElasticsearchPodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchPodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchProbeSpec) DeepCopyInto(out *ElasticsearchProbeSpec) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchProbeSpec.
+func (in *ElasticsearchProbeSpec) DeepCopy() *ElasticsearchProbeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchList)
+	out := new(ElasticsearchProbeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchRecoverySpec) DeepCopyInto(out *ElasticsearchRecoverySpec) {
+	*out = *in
+	if in.ExpectedNodes != nil {
+		in, out := &in.ExpectedNodes, &out.ExpectedNodes
+		*out = new(int32)
+		**out = **in
 	}
-	return nil
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRecoverySpec.
+func (in *ElasticsearchRecoverySpec) DeepCopy() *ElasticsearchRecoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchRecoverySpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchNode) DeepCopyInto(out *ElasticsearchNode) {
+func (in *ElasticsearchResourceRecommendation) DeepCopyInto(out *ElasticsearchResourceRecommendation) {
 	*out = *in
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = make([]ElasticsearchNodeRole, len(*in))
-		copy(*out, *in)
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = val.DeepCopy()
 		}
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]corev1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
 		}
 	}
-	in.Storage.DeepCopyInto(&out.Storage)
-	if in.GenUUID != nil {
-		in, out := &in.GenUUID, &out.GenUUID
-		*out = new(string)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchResourceRecommendation.
+func (in *ElasticsearchResourceRecommendation) DeepCopy() *ElasticsearchResourceRecommendation {
+	if in == nil {
+		return nil
 	}
-	in.ProxyResources.DeepCopyInto(&out.ProxyResources)
+	out := new(ElasticsearchResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNode.
-func (in *ElasticsearchNode) DeepCopy() *ElasticsearchNode {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchResourceRecommendationsSpec) DeepCopyInto(out *ElasticsearchResourceRecommendationsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchResourceRecommendationsSpec.
+func (in *ElasticsearchResourceRecommendationsSpec) DeepCopy() *ElasticsearchResourceRecommendationsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchNode)
+	out := new(ElasticsearchResourceRecommendationsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchNodeSpec) DeepCopyInto(out *ElasticsearchNodeSpec) {
+func (in *ElasticsearchRetentionPolicy) DeepCopyInto(out *ElasticsearchRetentionPolicy) {
 	*out = *in
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRetentionPolicy.
+func (in *ElasticsearchRetentionPolicy) DeepCopy() *ElasticsearchRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchServiceSpec) DeepCopyInto(out *ElasticsearchServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]corev1.Toleration, len(*in))
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchServiceSpec.
+func (in *ElasticsearchServiceSpec) DeepCopy() *ElasticsearchServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchSnapshot) DeepCopyInto(out *ElasticsearchSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSnapshot.
+func (in *ElasticsearchSnapshot) DeepCopy() *ElasticsearchSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchSnapshotList) DeepCopyInto(out *ElasticsearchSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchSnapshot, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.ProxyResources.DeepCopyInto(&out.ProxyResources)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeSpec.
-func (in *ElasticsearchNodeSpec) DeepCopy() *ElasticsearchNodeSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSnapshotList.
+func (in *ElasticsearchSnapshotList) DeepCopy() *ElasticsearchSnapshotList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchNodeSpec)
+	out := new(ElasticsearchSnapshotList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchNodeStatus) DeepCopyInto(out *ElasticsearchNodeStatus) {
+func (in *ElasticsearchSnapshotSpec) DeepCopyInto(out *ElasticsearchSnapshotSpec) {
 	*out = *in
-	out.UpgradeStatus = in.UpgradeStatus
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = make([]ElasticsearchNodeRole, len(*in))
+	out.Repository = in.Repository
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(ClusterConditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeStatus.
-func (in *ElasticsearchNodeStatus) DeepCopy() *ElasticsearchNodeStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSnapshotSpec.
+func (in *ElasticsearchSnapshotSpec) DeepCopy() *ElasticsearchSnapshotSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchNodeStatus)
+	out := new(ElasticsearchSnapshotSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchNodeUpgradeStatus) DeepCopyInto(out *ElasticsearchNodeUpgradeStatus) {
+func (in *ElasticsearchSnapshotStatus) DeepCopyInto(out *ElasticsearchSnapshotStatus) {
 	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSnapshot != nil {
+		in, out := &in.LastSnapshot, &out.LastSnapshot
+		*out = new(SnapshotResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(ClusterConditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchNodeUpgradeStatus.
-func (in *ElasticsearchNodeUpgradeStatus) DeepCopy() *ElasticsearchNodeUpgradeStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSnapshotStatus.
+func (in *ElasticsearchSnapshotStatus) DeepCopy() *ElasticsearchSnapshotStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchNodeUpgradeStatus)
+	out := new(ElasticsearchSnapshotStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -240,6 +957,16 @@ func (in *ElasticsearchNodeUpgradeStatus) DeepCopy() *ElasticsearchNodeUpgradeSt
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElasticsearchSpec) DeepCopyInto(out *ElasticsearchSpec) {
 	*out = *in
+	if in.ShardsPerIndex != nil {
+		in, out := &in.ShardsPerIndex, &out.ShardsPerIndex
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReplicasPerIndex != nil {
+		in, out := &in.ReplicasPerIndex, &out.ReplicasPerIndex
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Nodes != nil {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make([]ElasticsearchNode, len(*in))
@@ -253,6 +980,51 @@ func (in *ElasticsearchSpec) DeepCopyInto(out *ElasticsearchSpec) {
 		*out = new(IndexManagementSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ElasticsearchServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(ElasticsearchPodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(ElasticsearchMonitoringSpec)
+		**out = **in
+	}
+	if in.CertificateSecret != nil {
+		in, out := &in.CertificateSecret, &out.CertificateSecret
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = make([]ElasticsearchRetentionPolicy, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = new(ElasticsearchResourceRecommendationsSpec)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ClusterSettings != nil {
+		in, out := &in.ClusterSettings, &out.ClusterSettings
+		*out = new(ElasticsearchClusterSettingsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DiskWatermarks != nil {
+		in, out := &in.DiskWatermarks, &out.DiskWatermarks
+		*out = new(ElasticsearchDiskWatermarksSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSpec.
@@ -313,6 +1085,26 @@ func (in *ElasticsearchStatus) DeepCopyInto(out *ElasticsearchStatus) {
 		*out = new(IndexManagementStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DynamicSettings != nil {
+		in, out := &in.DynamicSettings, &out.DynamicSettings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSettings != nil {
+		in, out := &in.ClusterSettings, &out.ClusterSettings
+		*out = new(ElasticsearchClusterSettingsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EffectiveConfig != nil {
+		in, out := &in.EffectiveConfig, &out.EffectiveConfig
+		*out = new(ElasticsearchEffectiveConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(ElasticsearchUpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchStatus.
@@ -338,6 +1130,18 @@ func (in *ElasticsearchStorageSpec) DeepCopyInto(out *ElasticsearchStorageSpec)
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchStorageSpec.
@@ -350,6 +1154,61 @@ func (in *ElasticsearchStorageSpec) DeepCopy() *ElasticsearchStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchTransportTLSSpec) DeepCopyInto(out *ElasticsearchTransportTLSSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchTransportTLSSpec.
+func (in *ElasticsearchTransportTLSSpec) DeepCopy() *ElasticsearchTransportTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchTransportTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchUpdateStrategy) DeepCopyInto(out *ElasticsearchUpdateStrategy) {
+	*out = *in
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchUpdateStrategy.
+func (in *ElasticsearchUpdateStrategy) DeepCopy() *ElasticsearchUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchUpgradeProgress) DeepCopyInto(out *ElasticsearchUpgradeProgress) {
+	*out = *in
+	if in.PendingNodes != nil {
+		in, out := &in.PendingNodes, &out.PendingNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchUpgradeProgress.
+func (in *ElasticsearchUpgradeProgress) DeepCopy() *ElasticsearchUpgradeProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchUpgradeProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IndexManagementActionSpec) DeepCopyInto(out *IndexManagementActionSpec) {
 	*out = *in
@@ -849,3 +1708,41 @@ func (in *ProxySpec) DeepCopy() *ProxySpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotRepositorySpec) DeepCopyInto(out *SnapshotRepositorySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRepositorySpec.
+func (in *SnapshotRepositorySpec) DeepCopy() *SnapshotRepositorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotRepositorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotResult) DeepCopyInto(out *SnapshotResult) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotResult.
+func (in *SnapshotResult) DeepCopy() *SnapshotResult {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotResult)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -7,12 +7,12 @@ import (
 	"github.com/openshift/elasticsearch-operator/internal/indexmanagement"
 	"github.com/openshift/elasticsearch-operator/internal/manifests/console"
 	"github.com/openshift/elasticsearch-operator/internal/metrics"
+	"github.com/openshift/elasticsearch-operator/internal/retention"
 
 	"github.com/go-logr/logr"
-	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -23,8 +23,13 @@ import (
 // ElasticsearchReconciler reconciles a Elasticsearch object
 type ElasticsearchReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// HealthPollInterval is how often the health of the clusters is checked
+	// again and reported in their status. Defaults to 30 seconds.
+	HealthPollInterval time.Duration
 }
 
 // Reconcile reads that state of the cluster for a Elasticsearch object and makes changes based on the state read
@@ -54,6 +59,10 @@ func (r *ElasticsearchReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		return ctrl.Result{}, err
 	}
 
+	if cluster.DeletionTimestamp != nil {
+		return ctrl.Result{}, elasticsearch.Finalize(r.Log, cluster, r.Client)
+	}
+
 	metrics.CollectNodeMetrics(&cluster.Spec)
 	metrics.SetRedundancyMetric(cluster.Spec.RedundancyPolicy)
 	metrics.SetManagementStateMetric(cluster.Spec.ManagementState == loggingv1.ManagementStateManaged)
@@ -62,38 +71,57 @@ func (r *ElasticsearchReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		return ctrl.Result{}, nil
 	}
 
-	if cluster.Spec.Spec.Image != "" {
-		if cluster.Status.Conditions == nil {
-			cluster.Status.Conditions = []loggingv1.ClusterCondition{}
-		}
-		exists := false
-		for _, condition := range cluster.Status.Conditions {
-			if condition.Type == loggingv1.CustomImage {
-				exists = true
-				break
-			}
+	if err = elasticsearch.UpdatePausedCondition(cluster, r.Client); err != nil {
+		return r.requeueResult(), err
+	}
+	if cluster.Spec.Paused {
+		r.Log.Info("Reconciliation is paused", elasticsearch.ClusterLogValues(cluster)...)
+		return ctrl.Result{}, nil
+	}
+
+	// in dry-run mode the cluster itself is left untouched too
+	if !elasticsearch.IsDryRun(cluster) {
+		if err = elasticsearch.EnsureFinalizer(ctx, r.Client, cluster); err != nil {
+			return r.requeueResult(), err
 		}
-		if !exists {
-			cluster.Status.Conditions = append(cluster.Status.Conditions, loggingv1.ClusterCondition{
-				Type:               loggingv1.CustomImage,
-				Status:             v1.ConditionTrue,
-				LastTransitionTime: metav1.Now(),
-				Reason:             "CustomImageUnsupported",
-				Message:            "Specifiying a custom image from the custom resource is not supported",
-			})
+	}
+
+	// custom images are used by the nodes, a condition reporting them as ignored is stale
+	conditions := cluster.Status.Conditions[:0]
+	for _, condition := range cluster.Status.Conditions {
+		if condition.Type != loggingv1.CustomImage {
+			conditions = append(conditions, condition)
 		}
+	}
+	cluster.Status.Conditions = conditions
 
+	if err = elasticsearch.Reconcile(r.Log, cluster, r.Client, r.Recorder); err != nil {
+		return r.requeueResult(), err
 	}
 
-	if err = elasticsearch.Reconcile(r.Log, cluster, r.Client); err != nil {
-		return reconcileResult, err
+	// index management and retention write to elasticsearch directly
+	if elasticsearch.IsDryRun(cluster) {
+		return r.requeueResult(), nil
 	}
 
 	if err = indexmanagement.Reconcile(r.Log, cluster, r.Client); err != nil {
-		return reconcileResult, err
+		return r.requeueResult(), err
+	}
+
+	if err = retention.Reconcile(r.Log, cluster, r.Client); err != nil {
+		return r.requeueResult(), err
 	}
 
-	return reconcileResult, nil
+	return r.requeueResult(), nil
+}
+
+// requeueResult returns the result requeueing the cluster to poll its health
+// after the health poll interval
+func (r *ElasticsearchReconciler) requeueResult() ctrl.Result {
+	if r.HealthPollInterval <= 0 {
+		return reconcileResult
+	}
+	return ctrl.Result{RequeueAfter: r.HealthPollInterval}
 }
 
 func (r *ElasticsearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
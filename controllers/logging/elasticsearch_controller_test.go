@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/log"
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcilePausedCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(loggingv1.AddToScheme(scheme))
+
+	cluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+		Spec: loggingv1.ElasticsearchSpec{
+			ManagementState: loggingv1.ManagementStateManaged,
+			Paused:          true,
+			Nodes: []loggingv1.ElasticsearchNode{
+				{Roles: []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleMaster, loggingv1.ElasticsearchRoleData}, NodeCount: 1},
+			},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+
+	r := &ElasticsearchReconciler{Client: k8sClient, Log: log.NewLogger("controller-testing"), Scheme: scheme}
+	key := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Exp. no error reconciling a paused cluster but got %v", err)
+	}
+	if res.RequeueAfter != 0 {
+		t.Errorf("Exp. a paused cluster not to be requeued but got %v", res)
+	}
+
+	lists := []client.ObjectList{
+		&v1.ConfigMapList{},
+		&v1.ServiceList{},
+		&v1.ServiceAccountList{},
+		&v1.SecretList{},
+	}
+	for _, list := range lists {
+		if err := k8sClient.List(context.TODO(), list, client.InNamespace(cluster.Namespace)); err != nil {
+			t.Fatalf("Unable to list %T: %v", list, err)
+		}
+		if items, _ := meta.ExtractList(list); len(items) != 0 {
+			t.Errorf("Exp. no resources to be created while paused but got %T with %d items", list, len(items))
+		}
+	}
+
+	got := &loggingv1.Elasticsearch{}
+	if err := k8sClient.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("Unable to get the cluster: %v", err)
+	}
+	if !hasCondition(got.Status.Conditions, loggingv1.ReconciliationPaused, v1.ConditionTrue) {
+		t.Errorf("Exp. the ReconciliationPaused condition to be set but got %v", got.Status.Conditions)
+	}
+}
+
+func TestRequeueAfterHealthPollInterval(t *testing.T) {
+	tests := []struct {
+		desc     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{desc: "default", want: 30 * time.Second},
+		{desc: "fast polling", interval: 5 * time.Second, want: 5 * time.Second},
+		{desc: "slow polling", interval: 5 * time.Minute, want: 5 * time.Minute},
+	}
+	for _, test := range tests {
+		r := &ElasticsearchReconciler{HealthPollInterval: test.interval}
+		if got := r.requeueResult().RequeueAfter; got != test.want {
+			t.Errorf("%s: Exp. the cluster to be requeued after %v but got %v", test.desc, test.want, got)
+		}
+	}
+}
+
+func hasCondition(conditions []loggingv1.ClusterCondition, conditionType loggingv1.ClusterConditionType, status v1.ConditionStatus) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status == status
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/snapshot"
+)
+
+// ElasticsearchSnapshotReconciler reconciles a ElasticsearchSnapshot object
+type ElasticsearchSnapshotReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *ElasticsearchSnapshotReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	instance := &loggingv1.ElasticsearchSnapshot{}
+
+	err := r.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	requeueAfter, err := snapshot.Reconcile(r.Log, instance, r.Client)
+	if err != nil {
+		return reconcileResult, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (r *ElasticsearchSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("elasticsearchsnapshot-controller").
+		// the reconciler requeues itself for the schedule, skip its own status updates
+		For(&loggingv1.ElasticsearchSnapshot{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
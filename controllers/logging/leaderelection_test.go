@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/log"
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testLeaderElectionID        = "d471c3b1.openshift.io"
+	testLeaderElectionNamespace = "openshift-operators-redhat"
+)
+
+// writeCountingClient counts the writes reaching the wrapped client
+type writeCountingClient struct {
+	client.Client
+	writes int32
+}
+
+func (c *writeCountingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	atomic.AddInt32(&c.writes, 1)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	atomic.AddInt32(&c.writes, 1)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	atomic.AddInt32(&c.writes, 1)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *writeCountingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	atomic.AddInt32(&c.writes, 1)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Status() client.StatusWriter {
+	atomic.AddInt32(&c.writes, 1)
+	return c.Client.Status()
+}
+
+// newHeldLeaseServer serves the lease of the operator as held by another
+// instance and counts the lookups of the lease
+func newHeldLeaseServer(t *testing.T, lookups *int32) *httptest.Server {
+	path := "/apis/coordination.k8s.io/v1/namespaces/" + testLeaderElectionNamespace + "/leases/" + testLeaderElectionID
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("Exp. the non-leader to only look up its lease but got %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(lookups, 1)
+
+		now := metav1.NewMicroTime(time.Now())
+		lease := coordinationv1.Lease{
+			TypeMeta: metav1.TypeMeta{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testLeaderElectionID,
+				Namespace: testLeaderElectionNamespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String("elasticsearch-operator-leader"),
+				LeaseDurationSeconds: pointer.Int32(3600),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(lease)
+	}))
+}
+
+func TestNonLeaderDoesNotReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(loggingv1.AddToScheme(scheme))
+
+	var lookups int32
+	server := newHeldLeaseServer(t, &lookups)
+	defer server.Close()
+
+	informers := &informertest.FakeInformers{Scheme: scheme}
+	k8sClient := &writeCountingClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+	mgr, err := ctrl.NewManager(&rest.Config{Host: server.URL}, ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      "0",
+		LeaderElection:          true,
+		LeaderElectionID:        testLeaderElectionID,
+		LeaderElectionNamespace: testLeaderElectionNamespace,
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+		NewCache: func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return informers, nil
+		},
+		NewClient: func(cache.Cache, *rest.Config, client.Options, ...client.Object) (client.Client, error) {
+			return k8sClient, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create the manager: %v", err)
+	}
+
+	r := &ElasticsearchReconciler{Client: mgr.GetClient(), Log: log.NewLogger("controller-testing"), Scheme: scheme}
+	if err := r.SetupWithManager(mgr); err != nil {
+		t.Fatalf("Unable to set up the controller: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_ = mgr.Start(ctx)
+	}()
+
+	// leave the non-leader the time to retry taking the lease
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt32(&lookups) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-stopped
+
+	if n := atomic.LoadInt32(&lookups); n < 2 {
+		t.Fatalf("Exp. the manager to try taking the lease but it looked it up %d times", n)
+	}
+	select {
+	case <-mgr.Elected():
+		t.Fatal("Exp. the manager not to be elected while another instance holds the lease")
+	default:
+	}
+
+	gvk := loggingv1.GroupVersion.WithKind("Elasticsearch")
+	if _, ok := informers.InformersByGVK[gvk]; ok {
+		t.Error("Exp. the non-leader not to watch the Elasticsearch clusters")
+	}
+	if k8sClient.writes != 0 {
+		t.Errorf("Exp. no write from the non-leader but got %d", k8sClient.writes)
+	}
+}
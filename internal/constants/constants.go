@@ -15,6 +15,7 @@ const (
 	ElasticsearchDefaultImage   = "quay.io/openshift-logging/elasticsearch6:6.8.1"
 	ProxyDefaultImage           = "quay.io/openshift-logging/elasticsearch-proxy:1.0"
 	CuratorDefaultImage         = "quay.io/openshift-logging/curator5:5.8.1"
+	ExporterDefaultImage        = "quay.io/prometheuscommunity/elasticsearch-exporter:v1.5.0"
 	TheoreticalShardMaxSizeInMB = 40960
 
 	// OcpTemplatePrefix is the prefix all operator generated templates
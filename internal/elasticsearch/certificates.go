@@ -168,6 +168,13 @@ func NewCertificateRequest(log logr.Logger, clusterName, namespace string, owner
 	}
 }
 
+// addDNSNames adds subject alternative names to the certificate of the component
+func (cr *CertificateRequest) addDNSNames(componentName string, names ...string) {
+	ext := cr.Extensions[componentName]
+	ext.dns = append(ext.dns, names...)
+	cr.Extensions[componentName] = ext
+}
+
 func (cr *CertificateRequest) GenerateComponentCerts(secretName, cn string) {
 	certMutex.Lock()
 	defer certMutex.Unlock()
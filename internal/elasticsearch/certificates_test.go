@@ -0,0 +1,127 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestGenerateElasticsearchCerts(t *testing.T) {
+	client := fake.NewFakeClient()
+	cr := NewCertificateRequest(log.Log, "elasticsearch", "openshift-logging", metav1.OwnerReference{}, client)
+
+	cr.GenerateElasticsearchCerts("elasticsearch")
+
+	got := &corev1.Secret{}
+	key := types.NamespacedName{Name: "elasticsearch", Namespace: "openshift-logging"}
+	if err := client.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("Exp. the certificates secret to be created but got: %s", err)
+	}
+
+	for _, name := range []string{esComponentKeyName, esComponentCertName, esInternalKeyName, esInternalCertname, esAdminKeyName, esAdminCertName, esAdminCAName} {
+		if len(got.Data[name]) == 0 {
+			t.Errorf("Exp. the certificates secret to contain %q", name)
+		}
+	}
+
+	ca, err := pemDecodeCert(got.Data[esAdminCAName])
+	if err != nil {
+		t.Fatalf("failed to decode the CA: %s", err)
+	}
+
+	esCert, err := pemDecodeCert(got.Data[esComponentCertName])
+	if err != nil {
+		t.Fatalf("failed to decode the elasticsearch certificate: %s", err)
+	}
+	if err := esCert.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("Exp. the elasticsearch certificate to be signed by the CA: %s", err)
+	}
+
+	wantDNS := []string{"localhost", "elasticsearch-cluster", "elasticsearch-cluster.openshift-logging.svc"}
+	for _, name := range wantDNS {
+		if err := esCert.VerifyHostname(name); err != nil {
+			t.Errorf("Exp. the elasticsearch certificate to be valid for %q: %s", name, err)
+		}
+	}
+
+	// A second run keeps the valid certificates
+	cr.GenerateElasticsearchCerts("elasticsearch")
+
+	again := &corev1.Secret{}
+	if err := client.Get(context.TODO(), key, again); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if string(again.Data[esComponentCertName]) != string(got.Data[esComponentCertName]) {
+		t.Error("Exp. a valid elasticsearch certificate not to be regenerated")
+	}
+}
+
+func TestCertWillExpireSoon(t *testing.T) {
+	tests := []struct {
+		desc     string
+		notAfter time.Time
+		want     bool
+	}{
+		{desc: "valid for a year", notAfter: time.Now().AddDate(1, 0, 0), want: false},
+		{desc: "expiring within the hour", notAfter: time.Now().Add(30 * time.Minute), want: true},
+		{desc: "expired", notAfter: time.Now().Add(-time.Minute), want: true},
+	}
+
+	for _, test := range tests {
+		if got := certWillExpireSoon(&x509.Certificate{NotAfter: test.notAfter}); got != test.want {
+			t.Errorf("%s: exp. %t but was %t", test.desc, test.want, got)
+		}
+	}
+}
+
+func TestEnsureCertRegeneratesExpiringCert(t *testing.T) {
+	client := fake.NewFakeClient()
+	cr := NewCertificateRequest(log.Log, "elasticsearch", "openshift-logging", metav1.OwnerReference{}, client)
+
+	if err := cr.ensureCA(&certCA{}); err != nil {
+		t.Fatalf("failed to create the CA: %s", err)
+	}
+
+	// Reload the CA like on later reconciliations
+	ca := &certCA{}
+	if err := cr.ensureCA(ca); err != nil {
+		t.Fatalf("failed to load the CA: %s", err)
+	}
+
+	generated := &certificate{}
+	if err := cr.EnsureCert(esComponentName, generated, ca); err != nil {
+		t.Fatalf("failed to generate the certificate: %s", err)
+	}
+
+	// Reload the certificate like from the secret
+	cert := &certificate{}
+	if err := unmarshalCert(generated.cert, generated.key, cert); err != nil {
+		t.Fatalf("failed to decode the certificate: %s", err)
+	}
+	serial := cert.x509Cert.SerialNumber.Int64()
+
+	if err := cr.EnsureCert(esComponentName, cert, ca); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if got := cert.x509Cert.SerialNumber.Int64(); got != serial {
+		t.Errorf("Exp. a valid certificate to be kept but it was regenerated with serial %d", got)
+	}
+
+	cert.x509Cert.NotAfter = time.Now().Add(10 * time.Minute)
+	if err := cr.EnsureCert(esComponentName, cert, ca); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if got := cert.x509Cert.SerialNumber.Int64(); got == serial {
+		t.Error("Exp. a certificate about to expire to be regenerated")
+	}
+	if certWillExpireSoon(cert.x509Cert) {
+		t.Errorf("Exp. the regenerated certificate to be valid but it expires at %s", cert.x509Cert.NotAfter)
+	}
+}
@@ -11,9 +11,11 @@ import (
 	"github.com/openshift/elasticsearch-operator/internal/utils"
 	"github.com/openshift/elasticsearch-operator/internal/utils/comparators"
 
+	"github.com/ViaQ/logerr/v2/kverrors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/retry"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
@@ -28,6 +30,10 @@ var (
 
 var aliasNeededMap map[string]bool
 
+// allocationCheckedMap holds the clusters whose shard allocation was checked
+// since the operator started
+var allocationCheckedMap map[string]bool
+
 func FlushNodes(clusterName, namespace string) {
 	nodes[nodeMapKey(clusterName, namespace)] = []NodeTypeInterface{}
 }
@@ -62,6 +68,12 @@ func (er *ElasticsearchRequest) CreateOrUpdateElasticsearchCluster() error {
 	// may leave the shard allocation in an undesirable state
 	er.tryEnsureNoTransitiveShardAllocations()
 
+	// re-enable the shard allocation left disabled by an interrupted restart
+	er.ensureShardAllocationRecovered()
+
+	// open the readiness gates of recovered pods before any restart waits for them
+	er.updateShardRecoveryConditions()
+
 	// Update the cluster status immediately to refresh status.nodes
 	// before progressing with any unschedulable nodes.
 	// Ensures that deleted nodes are removed from status.nodes.
@@ -90,10 +102,11 @@ func (er *ElasticsearchRequest) CreateOrUpdateElasticsearchCluster() error {
 	scheduledNodes := er.getScheduledUpgradeNodes()
 
 	// Check if we have a node that was in the progress -- if so, continue updating it
+	// together with the other nodes of its batch
 	if inProgressNode != nil {
 		// Check to see if the inProgressNode was being updated or restarted
 		if _, ok := containsNodeTypeInterface(inProgressNode, scheduledNodes); ok {
-			if err := er.PerformNodeUpdate(inProgressNode); err != nil {
+			if err := er.PerformNodesUpdate(er.getNodesUpgradeInProgress(scheduledNodes)); err != nil {
 				er.ll.Error(err, "unable to update node")
 				return er.UpdateClusterStatus()
 			}
@@ -170,6 +183,9 @@ func (er *ElasticsearchRequest) CreateOrUpdateElasticsearchCluster() error {
 			}
 		}
 
+		// report scaling up until the new nodes joined the cluster
+		er.updateScalingUpCondition()
+
 		// ensure that MinMasters is (n / 2 + 1)
 		er.updateMinMasters()
 
@@ -182,6 +198,12 @@ func (er *ElasticsearchRequest) CreateOrUpdateElasticsearchCluster() error {
 		// we only want to update our replicas if we aren't in the middle up an update
 		er.updateReplicas()
 
+		// apply the dynamic user settings without restarting the nodes
+		er.updateDynamicSettings()
+
+		// apply the cluster settings of the spec once the cluster is green
+		er.applyClusterSettings()
+
 		// add alias to old indices if they exist and don't have one
 		// this should be removed after one release...
 		if er.ClusterReady() {
@@ -222,6 +244,20 @@ func (er *ElasticsearchRequest) getNodeUpgradeInProgress() NodeTypeInterface {
 	return nil
 }
 
+// getNodesUpgradeInProgress returns the scheduled nodes under upgrade, i.e. the
+// batch of an interrupted rolling update
+func (er *ElasticsearchRequest) getNodesUpgradeInProgress(scheduledNodes []NodeTypeInterface) []NodeTypeInterface {
+	batch := []NodeTypeInterface{}
+	for _, node := range scheduledNodes {
+		_, nodeStatus := getNodeStatus(node.name(), &er.cluster.Status)
+		if nodeStatus.UpgradeStatus.UnderUpgrade == v1.ConditionTrue {
+			batch = append(batch, node)
+		}
+	}
+
+	return batch
+}
+
 func (er *ElasticsearchRequest) progressUnschedulableNodes() error {
 	cluster := er.cluster
 	clusterNodes := nodes[nodeMapKey(cluster.GetName(), cluster.GetNamespace())]
@@ -259,12 +295,22 @@ func (er *ElasticsearchRequest) setUUIDs() {
 				continue
 			}
 
-			er.setUUID(index, uuid)
+			er.setUUID(index, uuid, newNodeWorkload(cluster.Spec.Nodes[index]))
 		}
 	}
 }
 
-func (er *ElasticsearchRequest) setUUID(index int, uuid string) {
+// newNodeWorkload returns the workload of a new node, data nodes run as statefulset
+// unless set otherwise
+func newNodeWorkload(node api.ElasticsearchNode) api.ElasticsearchNodeWorkload {
+	if isDataNode(node) && node.Workload == "" {
+		return api.StatefulSetWorkload
+	}
+	return node.Workload
+}
+
+// setUUID sets the uuid of the node and its workload if not empty
+func (er *ElasticsearchRequest) setUUID(index int, uuid string, workload api.ElasticsearchNodeWorkload) {
 	nretries := -1
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		nretries++
@@ -279,6 +325,9 @@ func (er *ElasticsearchRequest) setUUID(index int, uuid string) {
 		}
 
 		er.cluster.Spec.Nodes[index].GenUUID = &uuid
+		if workload != "" {
+			er.cluster.Spec.Nodes[index].Workload = workload
+		}
 
 		if updateErr := er.client.Update(context.TODO(), er.cluster); updateErr != nil {
 			// FIXME: return structured error
@@ -323,15 +372,41 @@ func (er *ElasticsearchRequest) populateNodes() error {
 		}
 	}
 
-	minMasterUpdated := false
-
 	// we want to only keep nodes that were generated and purge/delete any other ones...
-	// make sure cluster is green/yellow before we delete nodes
+	removedNodes := []NodeTypeInterface{}
 	for _, node := range nodes[nodeMapKey(cluster.Name, cluster.Namespace)] {
 		if _, ok := containsNodeTypeInterface(node, currentNodes); !ok {
-			if status, _ := er.esClient.GetClusterHealthStatus(); !utils.Contains(desiredClusterStates, status) {
-				er.ll.Info("Unable to delete/scale down any Elasticsearch nodes because of current cluster health", "currentHealth", status, "desiredHealth", desiredClusterStates)
-				break
+			removedNodes = append(removedNodes, node)
+		}
+	}
+
+	leavingNodes := leavingDataNodes(removedNodes, currentNodes)
+
+	if len(removedNodes) > 0 || len(leavingNodes) > 0 {
+		// relocate the shards of removed data nodes before deleting them
+		drainingNodes := er.drainDataNodes(leavingNodes)
+
+		scalingDown := v1.ConditionFalse
+		if len(drainingNodes) > 0 {
+			scalingDown = v1.ConditionTrue
+		}
+		if err := updateConditionWithRetry(cluster, scalingDown, updateScalingDownCondition, er.client); err != nil {
+			er.ll.Error(err, "unable to update scaling down condition")
+		}
+
+		// make sure cluster is green/yellow before we delete nodes
+		healthy := true
+		if status, _ := er.esClient.GetClusterHealthStatus(); !utils.Contains(desiredClusterStates, status) {
+			er.ll.Info("Unable to delete/scale down any Elasticsearch nodes because of current cluster health", "currentHealth", status, "desiredHealth", desiredClusterStates)
+			healthy = false
+		}
+
+		minMasterUpdated := false
+		for _, node := range removedNodes {
+			if isDraining(node, drainingNodes) || !healthy {
+				// keep tracking the node to delete it on a later reconciliation
+				currentNodes = append(currentNodes, node)
+				continue
 			}
 
 			if !minMasterUpdated {
@@ -344,6 +419,8 @@ func (er *ElasticsearchRequest) populateNodes() error {
 
 			if err := node.delete(); err != nil {
 				er.ll.Error(err, "unable to delete node")
+			} else {
+				er.recordEvent(v1.EventTypeNormal, eventReasonScaledDown, "Removed node %s from the cluster", node.name())
 			}
 
 			// remove from status.Nodes
@@ -351,13 +428,199 @@ func (er *ElasticsearchRequest) populateNodes() error {
 				cluster.Status.Nodes = append(cluster.Status.Nodes[:index], cluster.Status.Nodes[index+1:]...)
 			}
 		}
+
+		// scale down the data statefulsets once their leaving nodes are drained
+		for _, leaving := range leavingNodes {
+			node, ok := leaving.node.(*statefulSetNode)
+			if !ok || isDraining(node, drainingNodes) || !healthy {
+				continue
+			}
+			if _, removed := containsNodeTypeInterface(node, removedNodes); removed {
+				continue
+			}
+
+			if !minMasterUpdated && er.AnyNodeReady() {
+				er.updateMinMasters()
+				minMasterUpdated = true
+			}
+
+			if err := node.setReplicaCount(node.replicas); err != nil {
+				er.ll.Error(err, "unable to scale down data nodes", "node", node.name())
+			} else {
+				er.recordEvent(v1.EventTypeNormal, eventReasonScaledDown, "Scaled node %s down to %d replicas", node.name(), node.replicas)
+			}
+		}
+
+		// stop excluding the deleted nodes, a node with the same name may be added again later
+		if healthy {
+			if err := er.setAllocationExcludedNodes(dataNodeNames(drainingNodes)); err != nil {
+				er.ll.Error(err, "unable to update shard allocation exclusion")
+			}
+		}
 	}
 
+	er.deleteOrphanedVolumeClaims(currentNodes)
+
 	nodes[nodeMapKey(cluster.Name, cluster.Namespace)] = currentNodes
 
 	return nil
 }
 
+// updateScalingUpCondition sets the ScalingUp condition while fewer nodes than
+// requested joined the cluster
+func (er *ElasticsearchRequest) updateScalingUpCondition() {
+	if !er.AnyNodeReady() {
+		return
+	}
+
+	joined, err := er.esClient.GetClusterNodeCount()
+	if err != nil {
+		er.ll.Error(err, "unable to get the number of nodes in the cluster")
+		return
+	}
+
+	requested := getNodeCount(er.cluster)
+	scalingUp := v1.ConditionFalse
+	if joined < requested {
+		scalingUp = v1.ConditionTrue
+	}
+	wasScalingUp := containsClusterCondition(api.ScalingUp, v1.ConditionTrue, &er.cluster.Status)
+
+	if err := updateConditionWithRetry(er.cluster, scalingUp, updateScalingUpCondition, er.client); err != nil {
+		er.ll.Error(err, "unable to update scaling up condition")
+		return
+	}
+
+	switch {
+	case scalingUp == v1.ConditionTrue && !wasScalingUp:
+		er.recordEvent(v1.EventTypeNormal, eventReasonScalingUp, "Waiting for %d of %d nodes to join the cluster", requested-joined, requested)
+	case scalingUp == v1.ConditionFalse && wasScalingUp:
+		er.recordEvent(v1.EventTypeNormal, eventReasonScaledUp, "All %d nodes joined the cluster", requested)
+	}
+}
+
+// leavingNode is a node with the names of its elasticsearch data nodes leaving the cluster
+type leavingNode struct {
+	node  NodeTypeInterface
+	names []string
+}
+
+// leavingDataNodes returns the removed data nodes and the data statefulsets
+// scaling down with the elasticsearch nodes leaving the cluster
+func leavingDataNodes(removedNodes, currentNodes []NodeTypeInterface) []leavingNode {
+	leaving := []leavingNode{}
+	for _, node := range removedNodes {
+		if names := leavingDataNodeNames(node, true); len(names) > 0 {
+			leaving = append(leaving, leavingNode{node: node, names: names})
+		}
+	}
+	for _, node := range currentNodes {
+		if names := leavingDataNodeNames(node, false); len(names) > 0 {
+			leaving = append(leaving, leavingNode{node: node, names: names})
+		}
+	}
+	return leaving
+}
+
+// leavingDataNodeNames returns the names of the elasticsearch data nodes leaving
+// the cluster with the node, all of them if the node is removed
+func leavingDataNodeNames(node NodeTypeInterface, removed bool) []string {
+	switch n := node.(type) {
+	case *deploymentNode:
+		if removed {
+			return []string{n.elasticsearchNodeName()}
+		}
+	case *statefulSetNode:
+		if !n.dataNode {
+			return nil
+		}
+
+		replicas, err := n.currentReplicas()
+		if err != nil {
+			return nil
+		}
+
+		desired := n.replicas
+		if removed {
+			desired = 0
+		}
+		return n.elasticsearchNodeNames(desired, replicas)
+	}
+
+	return nil
+}
+
+// drainDataNodes excludes the leaving data nodes from shard allocation so that
+// their shards relocate to the remaining nodes. It returns the nodes still
+// holding shards, which must not be deleted or scaled down yet.
+func (er *ElasticsearchRequest) drainDataNodes(leavingNodes []leavingNode) []leavingNode {
+	if len(leavingNodes) == 0 {
+		return leavingNodes
+	}
+
+	if err := er.setAllocationExcludedNodes(dataNodeNames(leavingNodes)); err != nil {
+		er.ll.Error(err, "unable to exclude removed data nodes from shard allocation")
+		return leavingNodes
+	}
+
+	drainingNodes := []leavingNode{}
+	for _, leaving := range leavingNodes {
+		for _, name := range leaving.names {
+			shards, err := er.esClient.GetNodeShardCount(name)
+			if err != nil {
+				er.ll.Error(err, "unable to get the shards of removed node", "node", name)
+				drainingNodes = append(drainingNodes, leaving)
+				break
+			}
+
+			if shards > 0 {
+				er.ll.Info("Waiting for shards to relocate before removing node", "node", name, "shards", shards)
+				drainingNodes = append(drainingNodes, leaving)
+				break
+			}
+		}
+	}
+
+	return drainingNodes
+}
+
+func isDraining(node NodeTypeInterface, drainingNodes []leavingNode) bool {
+	for _, draining := range drainingNodes {
+		if draining.node.name() == node.name() {
+			return true
+		}
+	}
+	return false
+}
+
+// setAllocationExcludedNodes excludes exactly the named nodes from shard allocation
+func (er *ElasticsearchRequest) setAllocationExcludedNodes(nodeNames []string) error {
+	excluded, err := er.esClient.GetAllocationExcludedNodes()
+	if err != nil {
+		return err
+	}
+
+	if sets.NewString(excluded...).Equal(sets.NewString(nodeNames...)) {
+		return nil
+	}
+
+	if ok, err := er.esClient.SetAllocationExcludedNodes(nodeNames); !ok {
+		return kverrors.Wrap(err, "failed to set shard allocation exclusion",
+			"nodes", nodeNames,
+		)
+	}
+
+	return nil
+}
+
+func dataNodeNames(nodes []leavingNode) []string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.names...)
+	}
+	return names
+}
+
 func (er *ElasticsearchRequest) getScheduledUpgradeNodes() []NodeTypeInterface {
 	cluster := er.cluster
 	upgradeNodes := []NodeTypeInterface{}
@@ -431,7 +694,11 @@ func (er *ElasticsearchRequest) checkWatermarkAndUnblockIndices() {
 
 func (er *ElasticsearchRequest) isDiskUtilizationBelowFloodWatermark() bool {
 	for _, nodeTypeInterface := range nodes[nodeMapKey(er.cluster.Name, er.cluster.Namespace)] {
-		usage, percent, err := er.esClient.GetNodeDiskUsage(nodeTypeInterface.name())
+		nodeName := nodeTypeInterface.name()
+		if n, ok := nodeTypeInterface.(*deploymentNode); ok {
+			nodeName = n.elasticsearchNodeName()
+		}
+		usage, percent, err := er.esClient.GetNodeDiskUsage(nodeName)
 		if err != nil {
 			er.ll.Info("Unable to get disk usage", "error", err)
 			continue
@@ -6,9 +6,12 @@ import (
 	elasticsearchv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
 	"github.com/openshift/elasticsearch-operator/test/helpers"
+
+	"github.com/google/go-cmp/cmp"
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -56,6 +59,92 @@ func TestDiskUtilizationBelowFloodWatermark(t *testing.T) {
 	}
 }
 
+func TestDrainDataNodes(t *testing.T) {
+	const (
+		esCluster   = "elasticsearch"
+		esNamespace = "openshift-logging"
+		drained     = "elasticsearch-cdm-1-1"
+		draining    = "elasticsearch-cdm-1-2"
+	)
+
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_cluster/settings": {
+			{StatusCode: 200, Body: `{"persistent":{}}`},
+			{StatusCode: 200, Body: `{"acknowledged":true}`},
+		},
+		"_cat/allocation/" + drained + "?format=json&h=shards,node": {
+			{StatusCode: 200, Body: `[{"shards":"0","node":"elasticsearch-cdm-1-1"}]`},
+		},
+		"_cat/allocation/" + draining + "?format=json&h=shards,node": {
+			{StatusCode: 200, Body: `[{"shards":"3","node":"elasticsearch-cdm-1-2"}]`},
+		},
+	})
+	k8sClient := fake.NewFakeClient()
+	er := ElasticsearchRequest{
+		cluster:  &elasticsearchv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Name: esCluster, Namespace: esNamespace}},
+		client:   k8sClient,
+		esClient: helpers.NewFakeElasticsearchClient(esCluster, esNamespace, k8sClient, chatter),
+	}
+
+	removed := []NodeTypeInterface{
+		&deploymentNode{self: appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: drained, Namespace: esNamespace}}},
+		&deploymentNode{self: appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: draining, Namespace: esNamespace}}},
+		&statefulSetNode{self: appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cm-1", Namespace: esNamespace}}},
+	}
+
+	got := er.drainDataNodes(leavingDataNodes(removed, nil))
+	if len(got) != 1 || got[0].node.name() != draining {
+		t.Fatalf("Exp. only %q to be waited for but got %v", draining, dataNodeNames(got))
+	}
+
+	settings := chatter.Requests["_cluster/settings"]
+	if len(settings) != 2 || settings[0].Method != "GET" || settings[1].Method != "PUT" {
+		t.Fatalf("Exp. the current exclusion to be read before being set but got %v", settings)
+	}
+	put := settings[1]
+	wantBody := `{"persistent":{"cluster.routing.allocation.exclude._name":"elasticsearch-cdm-1-1,elasticsearch-cdm-1-2"}}`
+	if put.Body != wantBody {
+		t.Errorf("Exp. the removed data nodes to be excluded with %s but got %s", wantBody, put.Body)
+	}
+
+	for _, name := range []string{drained, draining} {
+		req, found := chatter.GetRequest("_cat/allocation/" + name + "?format=json&h=shards,node")
+		if !found {
+			t.Fatalf("Exp. the shards of %q to be counted", name)
+		}
+		if req.SeqNo < put.SeqNo {
+			t.Errorf("Exp. the shards of %q to be counted after excluding the node", name)
+		}
+	}
+
+	// Stop excluding the deleted node
+	chatter.Requests["_cluster/settings"] = nil
+	chatter.Responses["_cluster/settings"] = helpers.FakeElasticsearchResponses{
+		{StatusCode: 200, Body: `{"persistent":{"cluster":{"routing":{"allocation":{"exclude":{"_name":"elasticsearch-cdm-1-1,elasticsearch-cdm-1-2"}}}}}}`},
+		{StatusCode: 200, Body: `{"acknowledged":true}`},
+	}
+	if err := er.setAllocationExcludedNodes(dataNodeNames(got)); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	settings = chatter.Requests["_cluster/settings"]
+	wantBody = `{"persistent":{"cluster.routing.allocation.exclude._name":"elasticsearch-cdm-1-2"}}`
+	if len(settings) != 2 || settings[1].Body != wantBody {
+		t.Errorf("Exp. only the draining node to stay excluded with %s but got %v", wantBody, settings)
+	}
+
+	// Nothing to do when the exclusion is up to date
+	chatter.Requests["_cluster/settings"] = nil
+	chatter.Responses["_cluster/settings"] = helpers.FakeElasticsearchResponses{
+		{StatusCode: 200, Body: `{"persistent":{"cluster":{"routing":{"allocation":{"exclude":{"_name":"elasticsearch-cdm-1-2"}}}}}}`},
+	}
+	if err := er.setAllocationExcludedNodes([]string{draining}); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if settings = chatter.Requests["_cluster/settings"]; len(settings) != 1 {
+		t.Errorf("Exp. an up to date exclusion not to be updated but got %v", settings)
+	}
+}
+
 func populateSingleNode(clusterName string) []NodeTypeInterface {
 	nodes := []NodeTypeInterface{}
 	deployments := []runtime.Object{
@@ -76,3 +165,42 @@ func populateSingleNode(clusterName string) []NodeTypeInterface {
 	}
 	return nodes
 }
+
+func TestLeavingDataNodeNames(t *testing.T) {
+	const esNamespace = "openshift-logging"
+
+	current := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cdm-1", Namespace: esNamespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: pointer.Int32(3)},
+	}
+	k8sClient := fake.NewFakeClient(current)
+
+	node := &statefulSetNode{
+		self:     appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: current.Name, Namespace: esNamespace}},
+		replicas: 2,
+		dataNode: true,
+		client:   k8sClient,
+	}
+
+	if diff := cmp.Diff(leavingDataNodeNames(node, false), []string{"elasticsearch-cdm-1-2"}); diff != "" {
+		t.Errorf("Exp. the pods above the desired replicas to leave: %s", diff)
+	}
+	if diff := cmp.Diff(leavingDataNodeNames(node, true), []string{"elasticsearch-cdm-1-0", "elasticsearch-cdm-1-1", "elasticsearch-cdm-1-2"}); diff != "" {
+		t.Errorf("Exp. all pods of a removed statefulset to leave: %s", diff)
+	}
+
+	node.replicas = 3
+	if names := leavingDataNodeNames(node, false); len(names) != 0 {
+		t.Errorf("Exp. no pods to leave without scaling down but got %v", names)
+	}
+
+	node.dataNode = false
+	if names := leavingDataNodeNames(node, true); len(names) != 0 {
+		t.Errorf("Exp. no nodes without the data role to be drained but got %v", names)
+	}
+
+	deployment := &deploymentNode{self: appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cdm-2-1", Namespace: esNamespace}}}
+	if names := leavingDataNodeNames(deployment, false); len(names) != 0 {
+		t.Errorf("Exp. a kept deployment not to leave but got %v", names)
+	}
+}
@@ -1,12 +1,16 @@
 package elasticsearch
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	"github.com/go-logr/logr"
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/pod"
 	"github.com/openshift/elasticsearch-operator/internal/utils"
 	v1 "k8s.io/api/core/v1"
 )
@@ -171,16 +175,18 @@ func (er *ElasticsearchRequest) PerformNodeRestart(node NodeTypeInterface) error
 		scheduledNodes:   scheduledNode,
 	}
 
+	healthCheck := er.rollingHealthCheck(r)
+
 	restarter := Restarter{
 		log:              er.ll,
 		scheduledNodes:   scheduledNode,
 		clusterName:      er.cluster.Name,
 		clusterNamespace: er.cluster.Namespace,
-		precheck:         r.ensureClusterHealthValid,
+		precheck:         healthCheck,
 		prep:             r.optionalSetPrimariesShardsAndFlush,
 		main:             r.scaleDownThenUpNodes,
 		post:             r.waitAllNodesRejoinAndSetAllShards,
-		recovery:         r.ensureClusterHealthValid,
+		recovery:         er.ensureNodesStable(scheduledNode, healthCheck),
 	}
 
 	updateStatus := func() {
@@ -196,45 +202,76 @@ func (er *ElasticsearchRequest) PerformNodeRestart(node NodeTypeInterface) error
 }
 
 func (er *ElasticsearchRequest) PerformNodeUpdate(node NodeTypeInterface) error {
-	scheduledNode := []NodeTypeInterface{node}
+	return er.PerformNodesUpdate([]NodeTypeInterface{node})
+}
 
+// PerformNodesUpdate upgrades a batch of nodes together. The nodes share the
+// upgrade phase of the first node, so that an interrupted batch resumes as a
+// whole. Batches with a master eligible node hold only that node.
+func (er *ElasticsearchRequest) PerformNodesUpdate(batch []NodeTypeInterface) error {
 	r := ClusterRestart{
 		log:              er.ll,
 		client:           er.esClient,
 		clusterName:      er.cluster.Name,
 		clusterNamespace: er.cluster.Namespace,
-		scheduledNodes:   scheduledNode,
+		scheduledNodes:   batch,
+	}
+
+	healthCheck := er.rollingHealthCheck(r)
+	if isMasterEligibleNode(batch[0]) {
+		healthCheck = r.ensureHealthyWithMaster(healthCheck)
 	}
 
 	restarter := Restarter{
 		log:              er.ll,
-		scheduledNodes:   scheduledNode,
+		scheduledNodes:   batch,
 		clusterName:      er.cluster.Name,
 		clusterNamespace: er.cluster.Namespace,
-		precheck:         r.ensureClusterHealthValid,
+		precheck:         healthCheck,
 		prep:             r.requiredSetPrimariesShardsAndFlush,
 		main:             r.pushNodeUpdates,
 		post:             r.waitAllNodesRejoinAndSetAllShards,
-		recovery:         r.ensureClusterHealthValid,
+		recovery:         er.ensureNodesStable(batch, healthCheck),
 	}
 
 	updateStatus := func() {
-		if err := er.setNodeStatus(node, restarter.nodeStatus, &er.cluster.Status); err != nil {
-			er.ll.Error(err, "unable to update node status")
+		for _, node := range batch {
+			nodeStatus := er.getNodeState(node)
+			nodeStatus.UpgradeStatus = restarter.nodeStatus.UpgradeStatus
+
+			if err := er.setNodeStatus(node, nodeStatus, &er.cluster.Status); err != nil {
+				er.ll.Error(err, "unable to update node status")
+			}
 		}
 	}
 
 	restarter.setNodeConditions(updateStatus)
 
-	restarter.nodeStatus = er.getNodeState(node)
+	restarter.nodeStatus = er.getNodeState(batch[0])
 	return restarter.restartCluster()
 }
 
+// PerformRollingUpdate upgrades the nodes in batches, the nodes without the
+// master role first and the master eligible nodes last. Up to maxUnavailable
+// nodes without the master role are upgraded together, the master eligible
+// nodes one at a time. The cluster health is checked between each batch and an
+// elected master is required before and after upgrading a master eligible node.
 func (er *ElasticsearchRequest) PerformRollingUpdate(nodes []NodeTypeInterface) error {
-	for _, node := range nodes {
-		if err := er.PerformNodeUpdate(node); err != nil {
+	nodes = orderUpgradeNodes(nodes)
+	pending := nodes
+	for _, batch := range upgradeBatches(nodes, maxUnavailableNodes(er.cluster)) {
+		if err := er.updateUpgradeProgress(newUpgradeProgress(pending)); err != nil {
+			er.ll.Error(err, "unable to update upgrade progress")
+		}
+
+		if err := er.PerformNodesUpdate(batch); err != nil {
 			return err
 		}
+		pending = pending[len(batch):]
+	}
+
+	if err := er.updateUpgradeProgress(nil); err != nil {
+		er.ll.Error(err, "unable to update upgrade progress")
 	}
 
 	return nil
@@ -250,6 +287,79 @@ func (er *ElasticsearchRequest) PerformRollingRestart(nodes []NodeTypeInterface)
 	return nil
 }
 
+// rollingHealthCheck returns the health check used before and after restarting a
+// single node. With more than one data node the cluster must be green, so that no
+// shard is left without a copy while the next node goes down. A single data node
+// cluster never gets its replicas assigned, so yellow is accepted there.
+func (er *ElasticsearchRequest) rollingHealthCheck(clusterRestart ClusterRestart) func() error {
+	if GetDataCount(er.cluster) > 1 {
+		return clusterRestart.ensureClusterHealthGreen
+	}
+	return clusterRestart.ensureClusterHealthValid
+}
+
+// ensureNodesStable returns the health check followed by a check that the pods
+// of the nodes have been ready for the min ready seconds of their node, so that
+// elasticsearch settles before the next nodes are restarted
+func (er *ElasticsearchRequest) ensureNodesStable(nodes []NodeTypeInterface, healthCheck func() error) func() error {
+	return func() error {
+		if err := healthCheck(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, node := range nodes {
+			minReadySeconds := node.getMinReadySeconds()
+			if minReadySeconds <= 0 {
+				continue
+			}
+
+			pods, err := pod.List(context.TODO(), er.client, er.cluster.Namespace, map[string]string{"node-name": node.name()})
+			if err != nil {
+				return kverrors.Wrap(err, "failed to list the pods of the node", "node", node.name())
+			}
+			if unstable := unstablePods(pods, minReadySeconds, now); len(unstable) > 0 {
+				return kverrors.New("Waiting for the restarted pods to stabilize",
+					"namespace", er.cluster.Namespace,
+					"cluster", er.cluster.Name,
+					"node", node.name(),
+					"pods", unstable,
+					"minReadySeconds", minReadySeconds)
+			}
+		}
+
+		return nil
+	}
+}
+
+// unstablePods returns the names of the pods which have not been ready for at
+// least minReadySeconds at the given time
+func unstablePods(pods []v1.Pod, minReadySeconds int32, now time.Time) []string {
+	minReady := time.Duration(minReadySeconds) * time.Second
+
+	var unstable []string
+	for _, p := range pods {
+		readySince, ready := podReadySince(p)
+		if !ready || now.Sub(readySince) < minReady {
+			unstable = append(unstable, p.Name)
+		}
+	}
+	return unstable
+}
+
+// podReadySince returns the time the pod became ready and whether it is ready
+func podReadySince(p v1.Pod) (time.Time, bool) {
+	if p.Status.Phase != v1.PodRunning {
+		return time.Time{}, false
+	}
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status == v1.ConditionTrue
+		}
+	}
+	return time.Time{}, false
+}
+
 // scaleDownThenUpFunc returns a func() error that uses the ElasticsearchRequest function AnyNodeReady
 // to determine if the cluster has any nodes running. If we use the NodeInterface function waitForNodeLeaveCluster
 // we may get stuck because we have no cluster nodes to query from.
@@ -288,6 +398,38 @@ func (cr ClusterRestart) ensureClusterHealthValid() error {
 	return nil
 }
 
+func (cr ClusterRestart) ensureClusterHealthGreen() error {
+	if status, _ := cr.client.GetClusterHealthStatus(); status != greenClusterState {
+		return kverrors.New("Waiting for cluster to be green",
+			"namespace", cr.clusterNamespace,
+			"cluster", cr.clusterName,
+			"status", status)
+	}
+
+	return nil
+}
+
+// ensureHealthyWithMaster returns the health check followed by a check that
+// the cluster has an elected master
+func (cr ClusterRestart) ensureHealthyWithMaster(healthCheck func() error) func() error {
+	return func() error {
+		if err := healthCheck(); err != nil {
+			return err
+		}
+		return cr.ensureMasterElected()
+	}
+}
+
+func (cr ClusterRestart) ensureMasterElected() error {
+	if master, _ := cr.client.GetElectedMaster(); master == "" {
+		return kverrors.New("Waiting for a master to be elected",
+			"namespace", cr.clusterNamespace,
+			"cluster", cr.clusterName)
+	}
+
+	return nil
+}
+
 func (cr ClusterRestart) requiredSetPrimariesShardsAndFlush() error {
 	// set shard allocation as primaries
 	if ok, err := cr.client.SetShardAllocation(api.ShardAllocationPrimaries); !ok {
@@ -492,8 +634,7 @@ func (r *Restarter) setNodeConditions(updateStatus func()) {
 	r.precheckSignaler = func() {
 		r.nodeStatus.UpgradeStatus.UnderUpgrade = v1.ConditionTrue
 
-		// for node restarts there should be only a single node
-		r.log.Info("Beginning restart of node", "node", r.scheduledNodes[0].name())
+		r.log.Info("Beginning restart of node", "node", r.scheduledNodeNames())
 		updateStatus()
 	}
 
@@ -516,8 +657,7 @@ func (r *Restarter) setNodeConditions(updateStatus func()) {
 	}
 
 	r.recoverySignaler = func() {
-		// for node restarts there should be only a single node
-		r.log.Info("Completed restart of node", "node", r.scheduledNodes[0].name())
+		r.log.Info("Completed restart of node", "node", r.scheduledNodeNames())
 
 		r.nodeStatus.UpgradeStatus.UpgradePhase = api.ControllerUpdated
 		r.nodeStatus.UpgradeStatus.UnderUpgrade = ""
@@ -528,6 +668,16 @@ func (r *Restarter) setNodeConditions(updateStatus func()) {
 	}
 }
 
+// scheduledNodeNames returns the comma separated names of the scheduled nodes, a
+// single one for node restarts and up to maxUnavailable for batched updates
+func (r *Restarter) scheduledNodeNames() string {
+	names := make([]string, 0, len(r.scheduledNodes))
+	for _, node := range r.scheduledNodes {
+		names = append(names, node.name())
+	}
+	return strings.Join(names, ",")
+}
+
 // template function used for all restarts
 func (r Restarter) restartCluster() error {
 	if r.precheckCondition() {
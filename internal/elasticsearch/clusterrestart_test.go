@@ -1,13 +1,21 @@
 package elasticsearch
 
 import (
+	"reflect"
+	"testing"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	"github.com/ViaQ/logerr/v2/log"
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var (
@@ -432,3 +440,109 @@ var _ = Describe("clusterrestart", func() {
 func (cr ClusterRestart) restartFail() error {
 	return kverrors.New("we apologise for the fault in this function. Those responsible have been sacked.")
 }
+
+// newReadyPod returns a running pod of the node which became ready at the given time
+func newReadyPod(name, nodeName string, readySince time.Time) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			Labels:    map[string]string{"node-name": nodeName},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(readySince)},
+			},
+		},
+	}
+}
+
+func TestUnstablePods(t *testing.T) {
+	now := time.Now()
+	pending := newReadyPod("pending", "node", now.Add(-time.Minute))
+	pending.Status.Phase = v1.PodPending
+	notReady := newReadyPod("not-ready", "node", now.Add(-time.Minute))
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+
+	pods := []v1.Pod{
+		*newReadyPod("stable", "node", now.Add(-time.Minute)),
+		*newReadyPod("settling", "node", now.Add(-10*time.Second)),
+		*pending,
+		*notReady,
+	}
+
+	want := []string{"settling", "pending", "not-ready"}
+	if got := unstablePods(pods, 30, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := unstablePods(pods[:2], 5, now); len(got) != 0 {
+		t.Errorf("Exp. the pods ready for longer than min ready seconds to be stable but got %v", got)
+	}
+}
+
+func TestEnsureNodesStableWaitsMinReadySeconds(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		desc            string
+		readySince      time.Time
+		minReadySeconds int32
+		stable          bool
+	}{
+		{desc: "ready for less than min ready seconds", readySince: now.Add(-10 * time.Second), minReadySeconds: 30},
+		{desc: "ready for longer than min ready seconds", readySince: now.Add(-time.Minute), minReadySeconds: 30, stable: true},
+		{desc: "no min ready seconds", readySince: now, stable: true},
+	}
+
+	for _, test := range tests {
+		er := &ElasticsearchRequest{
+			client: fake.NewFakeClient(newReadyPod("elasticsearch-cd-data-0", "elasticsearch-cd-data", test.readySince)),
+			cluster: &api.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "test-namespace"},
+			},
+			ll: log.NewLogger("cluster-restart-testing"),
+		}
+		node := &statefulSetNode{
+			self:            apps.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cd-data"}},
+			minReadySeconds: test.minReadySeconds,
+		}
+
+		err := er.ensureNodesStable([]NodeTypeInterface{node}, ClusterRestart{}.restartNoop)()
+		if test.stable && err != nil {
+			t.Errorf("%s: Exp. the node to be stable but got %v", test.desc, err)
+		}
+		if !test.stable && err == nil {
+			t.Errorf("%s: Exp. to wait for the node to stabilize", test.desc)
+		}
+	}
+
+	er := &ElasticsearchRequest{client: fake.NewFakeClient(), cluster: &api.Elasticsearch{}}
+	if err := er.ensureNodesStable(nil, ClusterRestart{}.restartFail)(); err == nil {
+		t.Errorf("Exp. the health check to run before the stability check")
+	}
+}
+
+func TestGetMinReadySeconds(t *testing.T) {
+	data := api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}}
+	master := api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}}
+	common := api.ElasticsearchNodeSpec{MinReadySeconds: pointer.Int32(60)}
+	override := api.ElasticsearchNode{Roles: data.Roles, MinReadySeconds: pointer.Int32(5)}
+
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		want       int32
+	}{
+		{desc: "data node default", node: data, want: defaultDataNodeMinReadySeconds},
+		{desc: "master node default", node: master, want: 0},
+		{desc: "common spec", node: master, commonSpec: common, want: 60},
+		{desc: "node spec", node: override, commonSpec: common, want: 5},
+	}
+
+	for _, test := range tests {
+		if got := getMinReadySeconds(test.node, test.commonSpec); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package elasticsearch
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// clusterSettingsBody returns the body of the cluster settings API request
+// applying the cluster settings of the spec and the settings left out because
+// the operator manages them
+func clusterSettingsBody(spec api.ElasticsearchClusterSettingsSpec) (map[string]interface{}, []string) {
+	settings := map[string]interface{}{}
+	ignored := []string{}
+	for key, raw := range spec.Settings {
+		if isOperatorClusterSetting(key) {
+			ignored = append(ignored, key)
+			continue
+		}
+		settings[key] = parseSettingValue(raw)
+	}
+	sort.Strings(ignored)
+
+	settingsType := "persistent"
+	if spec.Type == api.ClusterSettingsTransient {
+		settingsType = "transient"
+	}
+	return map[string]interface{}{settingsType: settings}, ignored
+}
+
+// applyClusterSettings applies the cluster settings of the spec once the cluster
+// is green. They are applied again only when the spec changes, values changed
+// through the API in the meantime are left alone.
+func (er *ElasticsearchRequest) applyClusterSettings() {
+	desired := er.cluster.Spec.ClusterSettings.DeepCopy()
+	if desired == nil || reflect.DeepEqual(desired, er.cluster.Status.ClusterSettings) {
+		return
+	}
+	if !er.ClusterReady() {
+		return
+	}
+
+	health, err := er.esClient.GetClusterHealthStatus()
+	if err != nil {
+		er.L().Error(err, "Unable to get cluster health")
+		return
+	}
+	if health != greenClusterState {
+		return
+	}
+
+	body, ignored := clusterSettingsBody(*desired)
+	if len(ignored) > 0 {
+		er.L().Info("Ignoring cluster settings managed by the operator", "settings", ignored)
+	}
+	if len(desired.Settings) > len(ignored) {
+		er.L().Info("Applying cluster settings", "settings", body)
+		if err := er.esClient.UpdateClusterSettings(body); err != nil {
+			er.L().Error(err, "Unable to apply cluster settings")
+			return
+		}
+	}
+
+	if err := er.updateClusterSettingsStatus(desired); err != nil {
+		er.L().Error(err, "Unable to update cluster settings status")
+	}
+}
+
+func (er *ElasticsearchRequest) updateClusterSettingsStatus(applied *api.ElasticsearchClusterSettingsSpec) error {
+	cluster := er.cluster
+
+	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := er.client.Get(context.TODO(), types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cluster); err != nil {
+			return err
+		}
+
+		cluster.Status.ClusterSettings = applied.DeepCopy()
+		return er.client.Status().Update(context.TODO(), cluster)
+	})
+
+	if retryErr != nil {
+		return kverrors.Wrap(retryErr, "failed to update cluster settings status",
+			"cluster", cluster.Name,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+)
+
+func TestClusterSettingsBody(t *testing.T) {
+	tests := []struct {
+		desc        string
+		spec        api.ElasticsearchClusterSettingsSpec
+		want        map[string]interface{}
+		wantIgnored []string
+	}{
+		{
+			desc: "persistent by default",
+			spec: api.ElasticsearchClusterSettingsSpec{
+				Settings: map[string]string{
+					"action.destructive_requires_name":               "true",
+					"cluster.routing.allocation.disk.watermark.high": "90%",
+					"cluster.max_shards_per_node":                    "2000",
+				},
+			},
+			want: map[string]interface{}{
+				"persistent": map[string]interface{}{
+					"action.destructive_requires_name":               true,
+					"cluster.routing.allocation.disk.watermark.high": "90%",
+					"cluster.max_shards_per_node":                    2000,
+				},
+			},
+			wantIgnored: []string{},
+		},
+		{
+			desc: "transient",
+			spec: api.ElasticsearchClusterSettingsSpec{
+				Type:     api.ClusterSettingsTransient,
+				Settings: map[string]string{"indices.recovery.max_bytes_per_sec": "100mb"},
+			},
+			want: map[string]interface{}{
+				"transient": map[string]interface{}{"indices.recovery.max_bytes_per_sec": "100mb"},
+			},
+			wantIgnored: []string{},
+		},
+		{
+			desc: "settings managed by the operator are ignored",
+			spec: api.ElasticsearchClusterSettingsSpec{
+				Type: api.ClusterSettingsPersistent,
+				Settings: map[string]string{
+					"cluster.routing.allocation.enable":  "none",
+					"discovery.zen.minimum_master_nodes": "1",
+					"search.max_buckets":                 "20000",
+				},
+			},
+			want: map[string]interface{}{
+				"persistent": map[string]interface{}{"search.max_buckets": 20000},
+			},
+			wantIgnored: []string{"cluster.routing.allocation.enable", "discovery.zen.minimum_master_nodes"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got, ignored := clusterSettingsBody(test.spec)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+			if !reflect.DeepEqual(ignored, test.wantIgnored) {
+				t.Errorf("got ignored %v, want %v", ignored, test.wantIgnored)
+			}
+		})
+	}
+}
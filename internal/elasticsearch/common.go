@@ -3,8 +3,11 @@ package elasticsearch
 import (
 	"context"
 	"fmt"
+	"path"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/constants"
@@ -16,16 +19,28 @@ import (
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var excludeConfigMapKeys = []string{"index_settings"}
 
+// pvcCreateBackoff bounds the retries creating the claim of a node. The node is
+// not created without its claim and the reconciliation is requeued instead.
+var pvcCreateBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
 var defaultResources = map[string]v1.ResourceRequirements{
 	"proxy": {
 		Limits: v1.ResourceList{
@@ -45,6 +60,40 @@ var defaultResources = map[string]v1.ResourceRequirements{
 			v1.ResourceMemory: resource.MustParse(defaultESMemoryRequest),
 		},
 	},
+	"exporter": {
+		Limits: v1.ResourceList{
+			v1.ResourceMemory: resource.MustParse(defaultExporterMemoryLimit),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultExporterCPURequest),
+			v1.ResourceMemory: resource.MustParse(defaultExporterMemoryRequest),
+		},
+	},
+	"elasticsearch-master": {
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultMasterCPULimit),
+			v1.ResourceMemory: resource.MustParse(defaultESMemoryLimit),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultMasterCPURequest),
+			v1.ResourceMemory: resource.MustParse(defaultESMemoryRequest),
+		},
+	},
+	"elasticsearch-data": {
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultDataCPULimit),
+			v1.ResourceMemory: resource.MustParse(defaultESMemoryLimit),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultESCpuRequest),
+			v1.ResourceMemory: resource.MustParse(defaultESMemoryRequest),
+		},
+	},
+}
+
+// serviceAccountName is the serviceaccount the elasticsearch pods run with
+func serviceAccountName(dplName string) string {
+	return dplName
 }
 
 func serviceMonitorServiceAccountName(dplName string) string {
@@ -55,22 +104,93 @@ func serviceMonitorServiceAccountTokenName(dplName string) string {
 	return fmt.Sprintf("%s-token", serviceMonitorServiceAccountName(dplName))
 }
 
-func serviceCABundleName(dplName string) string {
-	return fmt.Sprintf("%s-ca-bundle", dplName)
+// resourceName returns the name of a resource generated for the cluster, the
+// cluster name with the resource name prefix of the spec and the suffix if any
+func resourceName(clusterName string, commonSpec api.ElasticsearchNodeSpec, suffix string) string {
+	name := commonSpec.ResourceNamePrefix + clusterName
+	if suffix == "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", name, suffix)
+}
+
+func configMapName(clusterName string, commonSpec api.ElasticsearchNodeSpec) string {
+	return resourceName(clusterName, commonSpec, "")
+}
+
+func serviceCABundleName(clusterName string, commonSpec api.ElasticsearchNodeSpec) string {
+	return resourceName(clusterName, commonSpec, "ca-bundle")
+}
+
+func discoveryServiceName(clusterName string, commonSpec api.ElasticsearchNodeSpec) string {
+	return resourceName(clusterName, commonSpec, "cluster")
+}
+
+func metricsServiceName(clusterName string, commonSpec api.ElasticsearchNodeSpec) string {
+	return resourceName(clusterName, commonSpec, "metrics")
 }
 
 func getESImage() string {
 	return utils.LookupEnvWithDefault("RELATED_IMAGE_ELASTICSEARCH", constants.ElasticsearchDefaultImage)
 }
 
+// getClusterImage returns the image of the common spec falling back to the
+// image of the operator
+func getClusterImage(commonSpec api.ElasticsearchNodeSpec) string {
+	if commonSpec.Image != "" {
+		return commonSpec.Image
+	}
+	return getESImage()
+}
+
+// getNodeImage returns the image of the node falling back to the image of the
+// cluster
+func getNodeImage(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) string {
+	if node.Image != "" {
+		return node.Image
+	}
+	return getClusterImage(commonSpec)
+}
+
+// getImagePullPolicy returns the requested pull policy or, if none is set,
+// IfNotPresent for images with an explicit tag or digest and Always for
+// images tagged latest or without a tag.
+func getImagePullPolicy(image string, policy v1.PullPolicy) v1.PullPolicy {
+	if policy != "" {
+		return policy
+	}
+
+	if strings.Contains(image, "@") {
+		return v1.PullIfNotPresent
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		if tag := name[i+1:]; tag != "" && tag != "latest" {
+			return v1.PullIfNotPresent
+		}
+	}
+
+	return v1.PullAlways
+}
+
 func getESProxyImage() string {
 	return utils.LookupEnvWithDefault("RELATED_IMAGE_ELASTICSEARCH_PROXY", constants.ProxyDefaultImage)
 }
 
+func getExporterImage() string {
+	return utils.LookupEnvWithDefault("RELATED_IMAGE_ELASTICSEARCH_EXPORTER", constants.ExporterDefaultImage)
+}
+
+func isMonitoringEnabled(monitoring *api.ElasticsearchMonitoringSpec) bool {
+	return monitoring != nil && monitoring.Enabled
+}
+
 func getNodeRoleMap(node api.ElasticsearchNode) map[api.ElasticsearchNodeRole]bool {
 	isClient := false
 	isData := false
 	isMaster := false
+	isIngest := false
 
 	for _, role := range node.Roles {
 		if role == api.ElasticsearchRoleClient {
@@ -84,11 +204,17 @@ func getNodeRoleMap(node api.ElasticsearchNode) map[api.ElasticsearchNodeRole]bo
 		if role == api.ElasticsearchRoleMaster {
 			isMaster = true
 		}
+
+		if role == api.ElasticsearchRoleIngest {
+			isIngest = true
+		}
 	}
 	return map[api.ElasticsearchNodeRole]bool{
 		api.ElasticsearchRoleClient: isClient,
 		api.ElasticsearchRoleData:   isData,
 		api.ElasticsearchRoleMaster: isMaster,
+		// data nodes run ingest pipelines as well, like with the elasticsearch defaults
+		api.ElasticsearchRoleIngest: isIngest || isData,
 	}
 }
 
@@ -102,6 +228,31 @@ func isMasterNode(node api.ElasticsearchNode) bool {
 	return false
 }
 
+// getClusterNodeRoleMap returns the roles the node runs with in the cluster.
+// Like with the elasticsearch defaults every node runs ingest pipelines, unless
+// some node of the cluster declares the ingest role: then only the ingest and
+// data nodes do, so existing clusters keep their ingest nodes.
+func getClusterNodeRoleMap(node api.ElasticsearchNode, nodes []api.ElasticsearchNode) map[api.ElasticsearchNodeRole]bool {
+	roleMap := getNodeRoleMap(node)
+	if !hasIngestNodes(nodes) {
+		roleMap[api.ElasticsearchRoleIngest] = true
+	}
+	return roleMap
+}
+
+// hasIngestNodes returns true if any of the nodes declares the ingest role
+func hasIngestNodes(nodes []api.ElasticsearchNode) bool {
+	for _, node := range nodes {
+		for _, role := range node.Roles {
+			if role == api.ElasticsearchRoleIngest {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func isDataNode(node api.ElasticsearchNode) bool {
 	for _, role := range node.Roles {
 		if role == api.ElasticsearchRoleData {
@@ -112,7 +263,63 @@ func isDataNode(node api.ElasticsearchNode) bool {
 	return false
 }
 
-func newAffinity(roleMap map[api.ElasticsearchNodeRole]bool) *v1.Affinity {
+// isDeploymentDataNode returns true for data nodes run as one deployment per replica
+func isDeploymentDataNode(node api.ElasticsearchNode) bool {
+	return isDataNode(node) && node.Workload != api.StatefulSetWorkload
+}
+
+// hasDataVolumeClaimTemplate returns true for data nodes run as statefulset with
+// persistent storage, their data volumes are claimed by the statefulset
+func hasDataVolumeClaimTemplate(node api.ElasticsearchNode) bool {
+	return hasStorageClaimTemplate(node, node.Storage)
+}
+
+// hasAdditionalDataVolumeClaimTemplate returns true if the additional data
+// volume is provided by a claim template of the statefulset of the node
+func hasAdditionalDataVolumeClaimTemplate(node api.ElasticsearchNode, dataVolume api.ElasticsearchDataVolume) bool {
+	return hasStorageClaimTemplate(node, dataVolume.Storage)
+}
+
+// hasStorageClaimTemplate returns true if the persistent storage is provided by a
+// claim template of the statefulset of the data node, i.e. one claim per replica
+func hasStorageClaimTemplate(node api.ElasticsearchNode, storage api.ElasticsearchStorageSpec) bool {
+	return isDataNode(node) && !isDeploymentDataNode(node) && storage.Size != nil
+}
+
+// additionalDataVolumeName returns the pod volume name of an additional data volume
+func additionalDataVolumeName(name string) string {
+	return fmt.Sprintf("%s-%s", dataVolumeName, name)
+}
+
+// dataVolumeMountPath returns the mount path of an additional data volume
+func dataVolumeMountPath(name string) string {
+	return path.Join(additionalDataPath, name)
+}
+
+// getDataPaths returns the path.data entries of the node, the default data path
+// followed by one per additional data volume
+func getDataPaths(clusterName string, node api.ElasticsearchNode) []string {
+	paths := []string{path.Join(elasticsearchDataPath, clusterName, "data")}
+	for _, dataVolume := range node.DataVolumes {
+		paths = append(paths, path.Join(dataVolumeMountPath(dataVolume.Name), clusterName, "data"))
+	}
+	return paths
+}
+
+// isCoordinatingNode returns true for client nodes without master, data and
+// ingest roles, which only coordinate requests
+func isCoordinatingNode(node api.ElasticsearchNode) bool {
+	roleMap := getNodeRoleMap(node)
+	return roleMap[api.ElasticsearchRoleClient] && !roleMap[api.ElasticsearchRoleMaster] && !roleMap[api.ElasticsearchRoleData] && !roleMap[api.ElasticsearchRoleIngest]
+}
+
+// newAffinity returns the anti-affinity keeping pods of the same roles off the
+// same host or nil if the anti-affinity is disabled
+func newAffinity(roleMap map[api.ElasticsearchNodeRole]bool, mode api.AntiAffinityMode) *v1.Affinity {
+	if mode == api.AntiAffinityDisabled {
+		return nil
+	}
+
 	labelSelectorReqs := []metav1.LabelSelectorRequirement{}
 	if roleMap[api.ElasticsearchRoleClient] {
 		labelSelectorReqs = append(labelSelectorReqs, metav1.LabelSelectorRequirement{
@@ -136,56 +343,206 @@ func newAffinity(roleMap map[api.ElasticsearchNodeRole]bool) *v1.Affinity {
 		})
 	}
 
+	term := v1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: labelSelectorReqs,
+		},
+		TopologyKey: "kubernetes.io/hostname",
+	}
+
+	if mode == api.AntiAffinityRequired {
+		return &v1.Affinity{
+			PodAntiAffinity: &v1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{term},
+			},
+		}
+	}
+
 	return &v1.Affinity{
 		PodAntiAffinity: &v1.PodAntiAffinity{
 			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
 				{
-					Weight: 100,
-					PodAffinityTerm: v1.PodAffinityTerm{
-						LabelSelector: &metav1.LabelSelector{
-							MatchExpressions: labelSelectorReqs,
-						},
-						TopologyKey: "kubernetes.io/hostname",
-					},
+					Weight:          100,
+					PodAffinityTerm: term,
 				},
 			},
 		},
 	}
 }
 
-func newElasticsearchContainer(imageName string, envVars []v1.EnvVar, resourceRequirements v1.ResourceRequirements) v1.Container {
+// mergeAffinity merges custom affinity rules into the default affinity.
+// Node and pod affinity are taken from the custom rules as given, while custom
+// pod anti-affinity terms are added to the default ones so that the role
+// based anti-affinity is never lost.
+func mergeAffinity(defaultAffinity, custom *v1.Affinity) *v1.Affinity {
+	if defaultAffinity == nil {
+		return custom.DeepCopy()
+	}
+
+	merged := defaultAffinity.DeepCopy()
+	if custom == nil {
+		return merged
+	}
+
+	merged.NodeAffinity = custom.NodeAffinity.DeepCopy()
+	merged.PodAffinity = custom.PodAffinity.DeepCopy()
+
+	if antiAffinity := custom.PodAntiAffinity.DeepCopy(); antiAffinity != nil {
+		if merged.PodAntiAffinity == nil {
+			merged.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+
+		merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...,
+		)
+		merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...,
+		)
+	}
+
+	return merged
+}
+
+// getAffinity returns the custom affinity rules of the node falling back to
+// the ones from the common spec
+func getAffinity(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) *v1.Affinity {
+	if node.Affinity != nil {
+		return node.Affinity
+	}
+	return commonSpec.Affinity
+}
+
+// getTopologySpreadConstraints returns the topology spread constraints of the node falling
+// back to the ones from the common spec. Constraints without label selector select the pods
+// of the cluster with the same roles.
+func getTopologySpreadConstraints(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec, clusterName string, roleMap map[api.ElasticsearchNodeRole]bool) []v1.TopologySpreadConstraint {
+	custom := node.TopologySpreadConstraints
+	if len(custom) == 0 {
+		custom = commonSpec.TopologySpreadConstraints
+	}
+	if len(custom) == 0 {
+		return nil
+	}
+
+	constraints := make([]v1.TopologySpreadConstraint, 0, len(custom))
+	for _, c := range custom {
+		constraint := *c.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = newRolesLabelSelector(clusterName, roleMap)
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
+
+// newRolesLabelSelector selects the pods of the cluster with the given roles
+func newRolesLabelSelector(clusterName string, roleMap map[api.ElasticsearchNodeRole]bool) *metav1.LabelSelector {
+	matchLabels := map[string]string{
+		"cluster-name": clusterName,
+	}
+	if roleMap[api.ElasticsearchRoleClient] {
+		matchLabels["es-node-client"] = "true"
+	}
+	if roleMap[api.ElasticsearchRoleData] {
+		matchLabels["es-node-data"] = "true"
+	}
+	if roleMap[api.ElasticsearchRoleMaster] {
+		matchLabels["es-node-master"] = "true"
+	}
+	return &metav1.LabelSelector{MatchLabels: matchLabels}
+}
+
+// getUpdateStrategy returns the update strategy type and partition of the node
+// defaulting to a rolling update of all pods
+func getUpdateStrategy(strategy *api.ElasticsearchUpdateStrategy) (api.ElasticsearchUpdateStrategyType, int32) {
+	if strategy == nil {
+		return api.RollingUpdateStrategy, 0
+	}
+
+	strategyType := strategy.Type
+	if strategyType == "" {
+		strategyType = api.RollingUpdateStrategy
+	}
+
+	partition := int32(0)
+	if strategy.Partition != nil && *strategy.Partition > 0 {
+		partition = *strategy.Partition
+	}
+
+	return strategyType, partition
+}
+
+// getAntiAffinityMode returns the anti-affinity mode of the node falling back
+// to the one from the common spec
+func getAntiAffinityMode(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) api.AntiAffinityMode {
+	if node.AntiAffinityMode != "" {
+		return node.AntiAffinityMode
+	}
+	return commonSpec.AntiAffinityMode
+}
+
+// elasticsearchPorts are the REST API and transport ports of the elasticsearch
+// container, and the names of the ports the services target
+type elasticsearchPorts struct {
+	HTTP      int32
+	Transport int32
+
+	TransportName string
+	RESTAPIName   string
+}
+
+// getPorts returns the ports of the common spec falling back to the defaults
+func getPorts(commonSpec api.ElasticsearchNodeSpec) elasticsearchPorts {
+	ports := elasticsearchPorts{
+		HTTP:          defaultHTTPPort,
+		Transport:     defaultTransportPort,
+		TransportName: legacyTransportPortName,
+		RESTAPIName:   legacyRESTAPIPortName,
+	}
+	if commonSpec.HTTPPort != 0 {
+		ports.HTTP = commonSpec.HTTPPort
+	}
+	if commonSpec.TransportPort != 0 {
+		ports.Transport = commonSpec.TransportPort
+	}
+	if commonSpec.PortNaming == api.PortNamingConventional {
+		ports.TransportName = transportPortName
+		ports.RESTAPIName = restAPIPortName
+	}
+	return ports
+}
+
+// hasCustomPorts returns true if the REST API or transport port differs from the default
+func hasCustomPorts(ports elasticsearchPorts) bool {
+	return ports.HTTP != defaultHTTPPort || ports.Transport != defaultTransportPort
+}
+
+func newElasticsearchContainer(imageName string, pullPolicy v1.PullPolicy, envVars []v1.EnvVar, resourceRequirements v1.ResourceRequirements, ports elasticsearchPorts, readinessProbe, livenessProbe *v1.Probe) v1.Container {
 	return v1.Container{
 		Name:            "elasticsearch",
 		Image:           imageName,
-		ImagePullPolicy: "IfNotPresent",
+		ImagePullPolicy: pullPolicy,
 		Env:             envVars,
 		Ports: []v1.ContainerPort{
 			{
-				Name:          "cluster",
-				ContainerPort: 9300,
+				Name:          ports.TransportName,
+				ContainerPort: ports.Transport,
 				Protocol:      v1.ProtocolTCP,
 			},
 			{
-				ContainerPort: 9200,
+				ContainerPort: ports.HTTP,
 				Protocol:      v1.ProtocolTCP,
 			},
 		},
-		ReadinessProbe: &v1.Probe{
-			TimeoutSeconds:      30,
-			InitialDelaySeconds: 10,
-			PeriodSeconds:       5,
-			ProbeHandler: v1.ProbeHandler{
-				Exec: &v1.ExecAction{
-					Command: []string{
-						"/usr/share/elasticsearch/probe/readiness.sh",
-					},
-				},
-			},
-		},
+		ReadinessProbe: readinessProbe,
+		LivenessProbe:  livenessProbe,
+		Lifecycle:      newPreStopLifecycle(ports.HTTP),
 		VolumeMounts: []v1.VolumeMount{
 			{
-				Name:      "elasticsearch-storage",
-				MountPath: "/elasticsearch/persistent",
+				Name:      dataVolumeName,
+				MountPath: elasticsearchDataPath,
 			},
 			{
 				Name:      "elasticsearch-config",
@@ -201,14 +558,473 @@ func newElasticsearchContainer(imageName string, envVars []v1.EnvVar, resourceRe
 	}
 }
 
-func newProxyContainer(imageName, clusterName, namespace string, logConfig LogConfig, resourceRequirements v1.ResourceRequirements) v1.Container {
+// newExporterContainer returns the prometheus elasticsearch exporter sidecar. It scrapes
+// the local node with the admin certificates of the cluster secret and exposes the
+// metrics unauthenticated on the exporter port.
+func newExporterContainer(image string, pullPolicy v1.PullPolicy, httpPort int32) v1.Container {
+	return v1.Container{
+		Name:            "exporter",
+		Image:           image,
+		ImagePullPolicy: pullPolicy,
+		Args: []string{
+			fmt.Sprintf("--es.uri=https://localhost:%d", httpPort),
+			fmt.Sprintf("--es.ca=%s/admin-ca", elasticsearchCertsPath),
+			fmt.Sprintf("--es.client-cert=%s/admin-cert", elasticsearchCertsPath),
+			fmt.Sprintf("--es.client-private-key=%s/admin-key", elasticsearchCertsPath),
+			fmt.Sprintf("--web.listen-address=:%d", exporterPort),
+		},
+		Ports: []v1.ContainerPort{
+			{
+				Name:          "exporter",
+				ContainerPort: exporterPort,
+				Protocol:      v1.ProtocolTCP,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "certificates",
+				MountPath: elasticsearchCertsPath,
+				ReadOnly:  true,
+			},
+		},
+		Resources:       defaultResources["exporter"],
+		SecurityContext: utils.ContainerSecurityContext(),
+	}
+}
+
+// newPreStopLifecycle returns a lifecycle restricting the shard allocation to primaries
+// and flushing the indices before the container stops, so that the node recovers quickly
+// when it returns. The operator enables the shard allocation again once the node is back.
+func newPreStopLifecycle(httpPort int32) *v1.Lifecycle {
+	curl := newAdminCurl()
+	url := fmt.Sprintf("https://localhost:%d", httpPort)
+
+	script := strings.Join([]string{
+		fmt.Sprintf(`%s -XPUT -H "Content-Type: application/json" %s/_cluster/settings -d '{"persistent":{"cluster.routing.allocation.enable":"primaries"}}'`, curl, url),
+		fmt.Sprintf("%s -XPOST %s/_flush/synced", curl, url),
+		"true",
+	}, "; ")
+
+	return &v1.Lifecycle{
+		PreStop: &v1.LifecycleHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{"/bin/bash", "-c", script},
+			},
+		},
+	}
+}
+
+// newAdminCurl returns the curl command authenticating to the REST API of the
+// node with the admin certificate, as the security plugin requires
+func newAdminCurl() string {
+	return fmt.Sprintf("curl -s --max-time 30 --cacert %[1]s/admin-ca --cert %[1]s/admin-cert --key %[1]s/admin-key", elasticsearchCertsPath)
+}
+
+// newHTTPProbeScript returns the script requesting the cluster health on the
+// REST API port, failing on any response other than a success
+func newHTTPProbeScript(httpPort int32) string {
+	return fmt.Sprintf("%s --fail https://localhost:%d%s", newAdminCurl(), httpPort, clusterHealthPath)
+}
+
+// keystoreScript creates the keystore in ES_PATH_CONF from the secure settings in
+// KEYSTORE_SECRET_PATH, one setting per file, or copies a complete keystore file
+const keystoreScript = `set -e
+keystore="${ES_HOME:-/usr/share/elasticsearch}/bin/elasticsearch-keystore"
+rm -f "${ES_PATH_CONF}/elasticsearch.keystore"
+if [ -f "${KEYSTORE_SECRET_PATH}/elasticsearch.keystore" ]; then
+  cp "${KEYSTORE_SECRET_PATH}/elasticsearch.keystore" "${ES_PATH_CONF}/elasticsearch.keystore"
+  exit 0
+fi
+"${keystore}" create
+for setting in "${KEYSTORE_SECRET_PATH}"/*; do
+  [ -f "${setting}" ] || continue
+  "${keystore}" add --stdin --force "$(basename "${setting}")" < "${setting}"
+done
+`
+
+// newKeystoreInitContainer returns the init container populating the keystore volume
+// from the secure settings secret before Elasticsearch starts
+func newKeystoreInitContainer(imageName string, pullPolicy v1.PullPolicy) v1.Container {
+	return v1.Container{
+		Name:            "keystore",
+		Image:           imageName,
+		ImagePullPolicy: pullPolicy,
+		Command:         []string{"sh", "-c", keystoreScript},
+		Env: []v1.EnvVar{
+			{Name: "ES_PATH_CONF", Value: keystoreVolumePath},
+			{Name: "KEYSTORE_SECRET_PATH", Value: keystoreSecretPath},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "elasticsearch-keystore-secret",
+				MountPath: keystoreSecretPath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      "elasticsearch-keystore",
+				MountPath: keystoreVolumePath,
+			},
+		},
+		SecurityContext: utils.ContainerSecurityContext(),
+	}
+}
+
+// newKeystoreVolumes returns the secret volume of the secure settings and the volume
+// the keystore init container writes the keystore to
+func newKeystoreVolumes(keystore *api.ElasticsearchKeystoreSpec) []v1.Volume {
+	return []v1.Volume{
+		{
+			Name: "elasticsearch-keystore-secret",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: keystore.SecretName,
+				},
+			},
+		},
+		{
+			Name:         "elasticsearch-keystore",
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		},
+	}
+}
+
+// truststoreScript copies the truststore of the JVM to TRUSTSTORE_PATH and
+// imports each certificate of the PEM bundle CA_BUNDLE_FILE into it
+const truststoreScript = `set -e
+java_home="${ES_JAVA_HOME:-${JAVA_HOME:-}}"
+if [ -z "${java_home}" ]; then
+  java_home="$(dirname "$(dirname "$(readlink -f "$(command -v java)")")")"
+fi
+truststore="${TRUSTSTORE_PATH}/cacerts"
+rm -f "${truststore}"
+cp -L "${java_home}/lib/security/cacerts" "${truststore}"
+chmod u+w "${truststore}"
+awk -v dir="${TRUSTSTORE_PATH}" '/-----BEGIN CERTIFICATE-----/ { n++ } n { print > (dir "/ca-bundle-" n ".pem") }' "${CA_BUNDLE_FILE}"
+for cert in "${TRUSTSTORE_PATH}"/ca-bundle-*.pem; do
+  [ -f "${cert}" ] || continue
+  "${java_home}/bin/keytool" -importcert -noprompt -keystore "${truststore}" -storepass "${TRUSTSTORE_PASSWORD}" -alias "$(basename "${cert}" .pem)" -file "${cert}"
+  rm -f "${cert}"
+done
+`
+
+// newTruststoreInitContainer returns the init container writing the JVM truststore
+// with the certificates of the CA bundle to the truststore volume
+func newTruststoreInitContainer(imageName string, pullPolicy v1.PullPolicy, caBundle *api.ElasticsearchCABundleSpec) v1.Container {
+	return v1.Container{
+		Name:            "truststore",
+		Image:           imageName,
+		ImagePullPolicy: pullPolicy,
+		Command:         []string{"sh", "-c", truststoreScript},
+		Env: []v1.EnvVar{
+			{Name: "CA_BUNDLE_FILE", Value: path.Join(caBundlePath, getCABundleKey(caBundle))},
+			{Name: "TRUSTSTORE_PATH", Value: truststoreVolumePath},
+			{Name: "TRUSTSTORE_PASSWORD", Value: truststorePassword},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "elasticsearch-ca-bundle",
+				MountPath: caBundlePath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      "elasticsearch-truststore",
+				MountPath: truststoreVolumePath,
+			},
+		},
+		SecurityContext: utils.ContainerSecurityContext(),
+	}
+}
+
+// newTruststoreVolumes returns the volume of the CA bundle, from its config map or
+// secret, and the volume the truststore init container writes the truststore to
+func newTruststoreVolumes(caBundle *api.ElasticsearchCABundleSpec) []v1.Volume {
+	source := v1.VolumeSource{
+		ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: caBundle.ConfigMapName},
+		},
+	}
+	if caBundle.SecretName != "" {
+		source = v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: caBundle.SecretName},
+		}
+	}
+
+	return []v1.Volume{
+		{
+			Name:         "elasticsearch-ca-bundle",
+			VolumeSource: source,
+		},
+		{
+			Name:         "elasticsearch-truststore",
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		},
+	}
+}
+
+// getCABundleKey returns the key of the config map or secret holding the CA bundle
+func getCABundleKey(caBundle *api.ElasticsearchCABundleSpec) string {
+	if caBundle.Key != "" {
+		return caBundle.Key
+	}
+	return defaultCABundleKey
+}
+
+// newTruststoreOptions returns the JVM options replacing the default truststore
+// with the one written by the truststore init container
+func newTruststoreOptions() string {
+	return fmt.Sprintf("-Djavax.net.ssl.trustStore=%s -Djavax.net.ssl.trustStorePassword=%s",
+		path.Join(truststoreVolumePath, truststoreFileName), truststorePassword)
+}
+
+// pluginsScript installs the plugins listed in ES_PLUGINS and copies the plugins
+// of the image, including the installed ones, to PLUGINS_PATH
+const pluginsScript = `set -e
+home="${ES_HOME:-/usr/share/elasticsearch}"
+for plugin in ${ES_PLUGINS}; do
+  "${home}/bin/elasticsearch-plugin" install --batch "${plugin}"
+done
+cp -a "${home}/plugins/." "${PLUGINS_PATH}/"
+`
+
+// newPluginsInitContainer returns the init container installing the plugins into
+// the plugins volume before Elasticsearch starts
+func newPluginsInitContainer(imageName string, pullPolicy v1.PullPolicy, plugins []string) v1.Container {
+	return v1.Container{
+		Name:            "plugins",
+		Image:           imageName,
+		ImagePullPolicy: pullPolicy,
+		Command:         []string{"sh", "-c", pluginsScript},
+		Env: []v1.EnvVar{
+			{Name: "ES_PLUGINS", Value: strings.Join(plugins, " ")},
+			{Name: "PLUGINS_PATH", Value: pluginsVolumePath},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "elasticsearch-plugins",
+				MountPath: pluginsVolumePath,
+			},
+		},
+		SecurityContext: utils.ContainerSecurityContext(),
+	}
+}
+
+// newSysctlInitContainer returns a privileged init container raising
+// vm.max_map_count on the host to the given value
+func newSysctlInitContainer(imageName string, pullPolicy v1.PullPolicy, maxMapCount int64) v1.Container {
+	return v1.Container{
+		Name:            "sysctl",
+		Image:           imageName,
+		ImagePullPolicy: pullPolicy,
+		Command: []string{
+			"sysctl",
+			"-w",
+			fmt.Sprintf("vm.max_map_count=%d", maxMapCount),
+		},
+		SecurityContext: &v1.SecurityContext{
+			Privileged:   pointer.Bool(true),
+			RunAsUser:    pointer.Int64(0),
+			RunAsNonRoot: pointer.Bool(false),
+		},
+	}
+}
+
+// newReadinessProbe returns the readiness probe for the elasticsearch container.
+// Settings not provided by the spec fall back to the defaults of the image
+// readiness script.
+func newReadinessProbe(probeSpec *api.ElasticsearchProbeSpec, ports elasticsearchPorts) *v1.Probe {
+	probe := &v1.Probe{
+		TimeoutSeconds:      defaultReadinessProbeTimeoutSeconds,
+		InitialDelaySeconds: defaultReadinessProbeInitialDelaySeconds,
+		PeriodSeconds:       defaultReadinessProbePeriodSeconds,
+		SuccessThreshold:    1,
+		FailureThreshold:    defaultReadinessProbeFailureThreshold,
+		ProbeHandler:        newProbeHandler(api.ProbeTypeExec, nil, ports),
+	}
+
+	return applyProbeSpec(probe, probeSpec, ports)
+}
+
+// newNodeReadinessProbe returns the readiness probe of the node depending on
+// its roles. Coordinating nodes hold no shards and are ready once the REST API
+// accepts connections, the other nodes use the readiness script unless a probe
+// type is configured. The script always queries the default REST API port, so
+// nodes with custom ports check the transport port with TCP instead.
+func newNodeReadinessProbe(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec, ports elasticsearchPorts) *v1.Probe {
+	probeSpec := getReadinessProbeSpec(node, commonSpec)
+	if probeSpec == nil || probeSpec.Type == "" {
+		var port *int32
+		switch {
+		case isCoordinatingNode(node):
+			port = &ports.HTTP
+		case hasCustomPorts(ports):
+			port = &ports.Transport
+		}
+
+		if port != nil {
+			spec := &api.ElasticsearchProbeSpec{}
+			if probeSpec != nil {
+				spec = probeSpec.DeepCopy()
+			}
+			spec.Type = api.ProbeTypeTCP
+			if spec.Port == nil {
+				spec.Port = port
+			}
+			probeSpec = spec
+		}
+	}
+
+	return newReadinessProbe(probeSpec, ports)
+}
+
+// newLivenessProbe returns the liveness probe for the elasticsearch container.
+// It only checks that the transport port accepts connections and tolerates
+// far more failures than the readiness probe, so that a node that is alive
+// but slowly recovering is not restarted.
+func newLivenessProbe(probeSpec *api.ElasticsearchProbeSpec, ports elasticsearchPorts) *v1.Probe {
+	probe := &v1.Probe{
+		TimeoutSeconds:      defaultLivenessProbeTimeoutSeconds,
+		InitialDelaySeconds: defaultLivenessProbeInitialDelaySeconds,
+		PeriodSeconds:       defaultLivenessProbePeriodSeconds,
+		SuccessThreshold:    1,
+		FailureThreshold:    defaultLivenessProbeFailureThreshold,
+		ProbeHandler:        newProbeHandler(api.ProbeTypeTCP, nil, ports),
+	}
+
+	return applyProbeSpec(probe, probeSpec, ports)
+}
+
+// newStartupProbe returns the startup probe for the elasticsearch container or
+// nil if not configured. It checks the HTTP port with TCP by default and
+// tolerates enough failures for large heaps to load before the liveness probe
+// takes over.
+func newStartupProbe(probeSpec *api.ElasticsearchProbeSpec, ports elasticsearchPorts) *v1.Probe {
+	if probeSpec == nil {
+		return nil
+	}
+
+	probe := &v1.Probe{
+		TimeoutSeconds:   defaultStartupProbeTimeoutSeconds,
+		PeriodSeconds:    defaultStartupProbePeriodSeconds,
+		SuccessThreshold: 1,
+		FailureThreshold: defaultStartupProbeFailureThreshold,
+	}
+
+	spec := probeSpec.DeepCopy()
+	if spec.Type == "" {
+		spec.Type = api.ProbeTypeTCP
+	}
+	if spec.Port == nil {
+		spec.Port = &ports.HTTP
+	}
+	return applyProbeSpec(probe, spec, ports)
+}
+
+func applyProbeSpec(probe *v1.Probe, probeSpec *api.ElasticsearchProbeSpec, ports elasticsearchPorts) *v1.Probe {
+	if probeSpec == nil {
+		return probe
+	}
+
+	if probeSpec.Type != "" {
+		probe.ProbeHandler = newProbeHandler(probeSpec.Type, probeSpec.Port, ports)
+	}
+	if probeSpec.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *probeSpec.InitialDelaySeconds
+	}
+	if probeSpec.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *probeSpec.TimeoutSeconds
+	}
+	if probeSpec.FailureThreshold != nil {
+		probe.FailureThreshold = *probeSpec.FailureThreshold
+	}
+
+	return probe
+}
+
+func newProbeHandler(probeType api.ElasticsearchProbeType, port *int32, ports elasticsearchPorts) v1.ProbeHandler {
+	switch probeType {
+	case api.ProbeTypeTCP:
+		return v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{
+				Port: intstr.FromInt(int(probePort(port, ports.Transport))),
+			},
+		}
+	case api.ProbeTypeHTTP:
+		// The REST API is always served over TLS and the security plugin denies
+		// requests without client certificate, which the kubelet cannot present
+		// with HTTPGet probes. The cluster health is requested with curl from
+		// the container instead, authenticated with the admin certificate.
+		return v1.ProbeHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{"/bin/bash", "-c", newHTTPProbeScript(probePort(port, ports.HTTP))},
+			},
+		}
+	default:
+		return v1.ProbeHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{
+					readinessProbeScript,
+				},
+			},
+		}
+	}
+}
+
+func probePort(port *int32, defaultPort int32) int32 {
+	if port == nil {
+		return defaultPort
+	}
+	return *port
+}
+
+// getLivenessProbeSpec returns the liveness probe settings of the node
+// falling back to the ones from the common spec
+func getLivenessProbeSpec(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) *api.ElasticsearchProbeSpec {
+	if node.LivenessProbe != nil {
+		return node.LivenessProbe
+	}
+	return commonSpec.LivenessProbe
+}
+
+// getStartupProbeSpec returns the startup probe settings of the node
+// falling back to the ones from the common spec
+func getStartupProbeSpec(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) *api.ElasticsearchProbeSpec {
+	if node.StartupProbe != nil {
+		return node.StartupProbe
+	}
+	return commonSpec.StartupProbe
+}
+
+// getReadinessProbeTimeout returns the seconds the readiness script waits for
+// elasticsearch: the timeout of the node, else of the common spec, else the
+// default. The env var holds for the life of the pod, the first boot window is
+// granted by the startup probe alone
+func getReadinessProbeTimeout(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) int32 {
+	if node.ReadinessProbeTimeoutSeconds != nil {
+		return *node.ReadinessProbeTimeoutSeconds
+	}
+	if commonSpec.ReadinessProbeTimeoutSeconds != nil {
+		return *commonSpec.ReadinessProbeTimeoutSeconds
+	}
+	return defaultReadinessScriptTimeout
+}
+
+// getReadinessProbeSpec returns the readiness probe settings of the node
+// falling back to the ones from the common spec
+func getReadinessProbeSpec(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) *api.ElasticsearchProbeSpec {
+	if node.ReadinessProbe != nil {
+		return node.ReadinessProbe
+	}
+	return commonSpec.ReadinessProbe
+}
+
+func newProxyContainer(imageName, clusterName, namespace string, logConfig LogConfig, resourceRequirements v1.ResourceRequirements, ports elasticsearchPorts) v1.Container {
 	container := v1.Container{
 		Name:            "proxy",
 		Image:           imageName,
 		ImagePullPolicy: "IfNotPresent",
 		Ports: []v1.ContainerPort{
 			{
-				Name:          "restapi",
+				Name:          ports.RESTAPIName,
 				ContainerPort: 60000,
 				Protocol:      v1.ProtocolTCP,
 			},
@@ -264,10 +1080,14 @@ func newProxyContainer(imageName, clusterName, namespace string, logConfig LogCo
 
 	container.SecurityContext.ReadOnlyRootFilesystem = pointer.BoolPtr(true)
 
+	if ports.HTTP != defaultHTTPPort {
+		container.Args = append(container.Args, fmt.Sprintf("--elasticsearch-url=https://localhost:%d", ports.HTTP))
+	}
+
 	return container
 }
 
-func newEnvVars(nodeName, clusterName, instanceRAM string, roleMap map[api.ElasticsearchNodeRole]bool) []v1.EnvVar {
+func newEnvVars(nodeName, clusterName, serviceDNS, instanceRAM, heapDumpLocation, recoverAfterTime string, readinessProbeTimeout int32, roleMap map[api.ElasticsearchNodeRole]bool) []v1.EnvVar {
 	return []v1.EnvVar{
 		{
 			Name:  "DC_NAME",
@@ -281,6 +1101,14 @@ func newEnvVars(nodeName, clusterName, instanceRAM string, roleMap map[api.Elast
 				},
 			},
 		},
+		{
+			Name: "POD_NAME",
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{
+					FieldPath: "metadata.name",
+				},
+			},
+		},
 		{
 			Name: "POD_IP",
 			ValueFrom: &v1.EnvVarSource{
@@ -299,7 +1127,7 @@ func newEnvVars(nodeName, clusterName, instanceRAM string, roleMap map[api.Elast
 		},
 		{
 			Name:  "SERVICE_DNS",
-			Value: fmt.Sprintf("%s-cluster", clusterName),
+			Value: serviceDNS,
 		},
 		{
 			Name:  "CLUSTER_NAME",
@@ -315,11 +1143,11 @@ func newEnvVars(nodeName, clusterName, instanceRAM string, roleMap map[api.Elast
 		},
 		{
 			Name:  "RECOVER_AFTER_TIME",
-			Value: "5m",
+			Value: recoverAfterTime,
 		},
 		{
 			Name:  "READINESS_PROBE_TIMEOUT",
-			Value: "30",
+			Value: strconv.Itoa(int(readinessProbeTimeout)),
 		},
 		{
 			Name:  "POD_LABEL",
@@ -333,6 +1161,10 @@ func newEnvVars(nodeName, clusterName, instanceRAM string, roleMap map[api.Elast
 			Name:  "HAS_DATA",
 			Value: strconv.FormatBool(roleMap[api.ElasticsearchRoleData]),
 		},
+		{
+			Name:  "IS_INGEST",
+			Value: strconv.FormatBool(roleMap[api.ElasticsearchRoleIngest]),
+		},
 	}
 }
 
@@ -348,6 +1180,292 @@ func newLabels(clusterName, nodeName string, roleMap map[api.ElasticsearchNodeRo
 	}
 }
 
+// newPodSecurityContext returns the custom pod security context if set or
+// else runs the pod as the elasticsearch user of the image. With persistent
+// volumes, the fsGroup makes the kubelet give their ownership to the group of
+// the elasticsearch user unless another group is requested.
+func newPodSecurityContext(commonSpec api.ElasticsearchNodeSpec, persistent bool) v1.PodSecurityContext {
+	if commonSpec.PodSecurityContext != nil {
+		return *commonSpec.PodSecurityContext.DeepCopy()
+	}
+
+	sc := utils.PodSecurityContext()
+	sc.RunAsUser = pointer.Int64(elasticsearchUID)
+	if persistent {
+		sc.FSGroup = pointer.Int64(elasticsearchUID)
+		if commonSpec.FSGroup != nil {
+			sc.FSGroup = pointer.Int64(*commonSpec.FSGroup)
+		}
+	}
+	return sc
+}
+
+// newExtraVolume returns a copy of the extra volume. Host paths default to the
+// type DirectoryOrCreate, so that a missing directory is created explicitly
+// instead of depending on the kubelet defaults.
+func newExtraVolume(logger logr.Logger, nodeName string, volume v1.Volume) v1.Volume {
+	volume = *volume.DeepCopy()
+	if volume.HostPath == nil {
+		return volume
+	}
+
+	logger.Info("WARNING: Node mounts a hostPath volume. Its data stays on the host and the pods cannot move to other hosts without losing it",
+		"node", nodeName,
+		"volume", volume.Name,
+		"path", volume.HostPath.Path)
+	if volume.HostPath.Type == nil || *volume.HostPath.Type == v1.HostPathUnset {
+		hostPathType := v1.HostPathDirectoryOrCreate
+		volume.HostPath.Type = &hostPathType
+	}
+	return volume
+}
+
+// hasPersistentVolume returns true if the pods of the node mount a persistent
+// volume claim, including the one of the statefulset claim template, or a host path
+func hasPersistentVolume(node api.ElasticsearchNode, volumes []v1.Volume) bool {
+	if hasDataVolumeClaimTemplate(node) {
+		return true
+	}
+	for _, dataVolume := range node.DataVolumes {
+		if hasAdditionalDataVolumeClaimTemplate(node, dataVolume) {
+			return true
+		}
+	}
+	for _, volume := range volumes {
+		if volume.PersistentVolumeClaim != nil || volume.HostPath != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getHeapSize returns the JVM heap size of the node falling back to the
+// one from the common spec. A nil result leaves the heap to be derived from
+// INSTANCE_RAM.
+func getHeapSize(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) *resource.Quantity {
+	if node.HeapSize != nil {
+		return node.HeapSize
+	}
+	return commonSpec.HeapSize
+}
+
+// mergeEnvVars appends the user defined env vars of the node and of the common spec
+// to the env vars set by the operator. On name collision the operator env vars take
+// precedence over the user ones and the node env vars over the common ones.
+func mergeEnvVars(logger logr.Logger, envVars, nodeEnv, commonEnv []v1.EnvVar) []v1.EnvVar {
+	merged := make([]v1.EnvVar, len(envVars))
+	copy(merged, envVars)
+
+	operatorNames := map[string]bool{}
+	for _, env := range envVars {
+		operatorNames[env.Name] = true
+	}
+
+	userNames := map[string]bool{}
+	for _, env := range append(append([]v1.EnvVar{}, nodeEnv...), commonEnv...) {
+		if operatorNames[env.Name] {
+			logger.Info("Ignoring user defined env var set by the operator", "name", env.Name)
+			continue
+		}
+		if userNames[env.Name] {
+			continue
+		}
+		userNames[env.Name] = true
+		merged = append(merged, *env.DeepCopy())
+	}
+
+	return merged
+}
+
+// mergePodMetadata merges the user defined labels or annotations of the node and of the
+// common spec into the ones set by the operator. The operator keys cannot be overridden
+// and the node keys take precedence over the common ones.
+func mergePodMetadata(logger logr.Logger, kind string, operator, node, common map[string]string) map[string]string {
+	if len(operator)+len(node)+len(common) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(operator)+len(node)+len(common))
+	for key, val := range common {
+		merged[key] = val
+	}
+	for key, val := range node {
+		merged[key] = val
+	}
+	for key, val := range operator {
+		if userVal, ok := merged[key]; ok && userVal != val {
+			logger.Info("Ignoring user defined pod metadata set by the operator", "kind", kind, "key", key)
+		}
+		merged[key] = val
+	}
+
+	return merged
+}
+
+// appendWritableVolumes backs the paths Elasticsearch writes to outside of its data
+// volume with emptyDir volumes, so that it runs with a read-only root filesystem
+func appendWritableVolumes(volumes []v1.Volume, mounts []v1.VolumeMount) ([]v1.Volume, []v1.VolumeMount) {
+	writable := []struct {
+		name string
+		path string
+	}{
+		{name: "elasticsearch-tmp", path: elasticsearchTmpPath},
+		{name: "elasticsearch-logs", path: elasticsearchLogsPath},
+	}
+
+	for _, w := range writable {
+		volumes = append(volumes, v1.Volume{
+			Name:         w.name,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+		mounts = append(mounts, v1.VolumeMount{
+			Name:      w.name,
+			MountPath: w.path,
+		})
+	}
+
+	return volumes, mounts
+}
+
+// getHeapDumpLocation returns the requested heap dump path or else a file on the
+// dedicated heap dump volume if one is requested or on the data volume
+func getHeapDumpLocation(heapDump *api.ElasticsearchHeapDumpSpec) string {
+	switch {
+	case heapDump == nil:
+		return defaultHeapDumpLocation
+	case heapDump.Path != "":
+		return heapDump.Path
+	case heapDump.Storage != nil:
+		return path.Join(heapDumpVolumePath, "heapdump.hprof")
+	default:
+		return defaultHeapDumpLocation
+	}
+}
+
+// isTransportHostnameVerificationEnabled returns true if the nodes verify that
+// the transport certificates of their peers match their addresses
+func isTransportHostnameVerificationEnabled(commonSpec api.ElasticsearchNodeSpec) bool {
+	return commonSpec.TransportTLS != nil && commonSpec.TransportTLS.EnforceHostnameVerification
+}
+
+// getRecoverAfterTime returns how long the cluster waits for the expected nodes
+// before recovering after a full cluster restart
+func getRecoverAfterTime(recovery *api.ElasticsearchRecoverySpec) string {
+	if recovery == nil || recovery.AfterTime == "" {
+		return defaultRecoverAfterTime
+	}
+	return recovery.AfterTime
+}
+
+// getRecoverExpectedNodes returns the number of nodes the cluster waits for
+// before recovering after a full cluster restart, the data nodes by default
+func getRecoverExpectedNodes(dpl *api.Elasticsearch) int {
+	recovery := dpl.Spec.Spec.Recovery
+	if recovery == nil || recovery.ExpectedNodes == nil {
+		return int(GetDataCount(dpl))
+	}
+	return int(*recovery.ExpectedNodes)
+}
+
+// getTerminationGracePeriod returns the termination grace period of the common spec
+// falling back to the default
+func getTerminationGracePeriod(commonSpec api.ElasticsearchNodeSpec) time.Duration {
+	if commonSpec.TerminationGracePeriodSeconds != nil {
+		return time.Duration(*commonSpec.TerminationGracePeriodSeconds) * time.Second
+	}
+	return defaultTerminationGracePeriodSeconds * time.Second
+}
+
+// getPriorityClassName returns the priority class of the node falling back
+// to the one from the common spec
+func getPriorityClassName(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) string {
+	if node.PriorityClassName != "" {
+		return node.PriorityClassName
+	}
+	return commonSpec.PriorityClassName
+}
+
+// getMinReadySeconds returns the seconds the restarted pods of the node must have
+// been ready before the next node is restarted, falling back to the common spec
+// and then to a default for data nodes, which recover their shards after start
+func getMinReadySeconds(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) int32 {
+	if node.MinReadySeconds != nil {
+		return *node.MinReadySeconds
+	}
+	if commonSpec.MinReadySeconds != nil {
+		return *commonSpec.MinReadySeconds
+	}
+	if isDataNode(node) {
+		return defaultDataNodeMinReadySeconds
+	}
+	return 0
+}
+
+// newNodeNameEnvVar returns the env var rendered as node.name, the prefixed name
+// of the node. Statefulsets replace it with the pod name, see setNodeNameFromPod.
+func newNodeNameEnvVar(prefix, nodeName string) v1.EnvVar {
+	return v1.EnvVar{
+		Name:  nodeNameEnvVar,
+		Value: prefix + nodeName,
+	}
+}
+
+// nodeNameFromEnv returns the elasticsearch node name set in the env of the
+// elasticsearch container of the pod spec, expanding the pod name if needed
+func nodeNameFromEnv(spec v1.PodSpec, podName string) (string, bool) {
+	for _, container := range spec.Containers {
+		if container.Name != "elasticsearch" {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == nodeNameEnvVar {
+				return strings.ReplaceAll(env.Value, "$(POD_NAME)", podName), true
+			}
+		}
+	}
+	return "", false
+}
+
+// newHeapSizeEnvVar pins the minimum and maximum JVM heap to the given size,
+// rounded down to whole MiB
+func newHeapSizeEnvVar(heapSize resource.Quantity) v1.EnvVar {
+	mb := heapSize.Value() / minHeapSize
+	return v1.EnvVar{
+		Name:  "ES_JAVA_OPTS",
+		Value: fmt.Sprintf("-Xms%dm -Xmx%dm", mb, mb),
+	}
+}
+
+// appendJavaOpts appends the JVM options to the ES_JAVA_OPTS env var, adding the
+// env var if missing
+func appendJavaOpts(envVars []v1.EnvVar, opts string) []v1.EnvVar {
+	for i, env := range envVars {
+		if env.Name == "ES_JAVA_OPTS" {
+			envVars[i].Value = strings.TrimSpace(env.Value + " " + opts)
+			return envVars
+		}
+	}
+	return append(envVars, v1.EnvVar{
+		Name:  "ES_JAVA_OPTS",
+		Value: opts,
+	})
+}
+
+// newGCLoggingOptions returns the JVM options writing the garbage collection
+// logs to rotated files in the directory, bounded to 8 files of 64MB
+func newGCLoggingOptions(dir string) string {
+	return fmt.Sprintf("-Xlog:gc*,gc+age=trace,safepoint:file=%s:utctime,pid,tags:filecount=8,filesize=64m", path.Join(dir, "gc.log"))
+}
+
+// getGCLogDir returns the directory of the garbage collection logs, the
+// dedicated volume if one is requested or else the elasticsearch log directory
+func getGCLogDir(clusterName string, commonSpec api.ElasticsearchNodeSpec) string {
+	if commonSpec.GCLogStorage != nil {
+		return gcLogVolumePath
+	}
+	return path.Join(elasticsearchDataPath, clusterName, "logs")
+}
+
 func newLabelSelector(clusterName, nodeName string, roleMap map[api.ElasticsearchNodeRole]bool) map[string]string {
 	return map[string]string{
 		"es-node-client": strconv.FormatBool(roleMap[api.ElasticsearchRoleClient]),
@@ -359,7 +1477,7 @@ func newLabelSelector(clusterName, nodeName string, roleMap map[api.Elasticsearc
 }
 
 func newPodTemplateSpec(ctx context.Context, logger logr.Logger, nodeName, clusterName, namespace string, node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec, labels map[string]string, roleMap map[api.ElasticsearchNodeRole]bool, client client.Client, logConfig LogConfig) v1.PodTemplateSpec {
-	resourceRequirements := newESResourceRequirements(node.Resources, commonSpec.Resources)
+	resourceRequirements := newESNodeResourceRequirements(node, commonSpec)
 	proxyResourceRequirements := newESProxyResourceRequirements(node.ProxyResources, commonSpec.ProxyResources)
 
 	selectors := mergeSelectors(node.NodeSelector, commonSpec.NodeSelector)
@@ -373,47 +1491,292 @@ func newPodTemplateSpec(ctx context.Context, logger logr.Logger, nodeName, clust
 		},
 	})
 
+	envVars := newEnvVars(nodeName, clusterName, discoveryServiceName(clusterName, commonSpec), resourceRequirements.Limits.Memory().String(), getHeapDumpLocation(commonSpec.HeapDump), getRecoverAfterTime(commonSpec.Recovery), getReadinessProbeTimeout(node, commonSpec), roleMap)
+	envVars = append(envVars, newNodeNameEnvVar(commonSpec.NodeNamePrefix, nodeName))
+	if heapSize := getHeapSize(node, commonSpec); heapSize != nil {
+		envVars = append(envVars, newHeapSizeEnvVar(*heapSize))
+		if recommended := getHeapMemoryLimit(*heapSize, commonSpec); resourceRequirements.Limits.Memory().Cmp(recommended) < 0 {
+			logger.Info("Memory limit leaves little room for off-heap memory and the page cache next to the heap",
+				"heapSize", heapSize.String(),
+				"memoryLimit", resourceRequirements.Limits.Memory().String(),
+				"recommendedMemoryLimit", recommended.String())
+		}
+	}
+	if commonSpec.GCLogging {
+		envVars = appendJavaOpts(envVars, newGCLoggingOptions(getGCLogDir(clusterName, commonSpec)))
+	}
+	if commonSpec.CABundle != nil {
+		envVars = appendJavaOpts(envVars, newTruststoreOptions())
+	}
+	if len(node.DataVolumes) > 0 {
+		envVars = append(envVars, v1.EnvVar{Name: dataPathsEnvVar, Value: strings.Join(getDataPaths(clusterName, node), ",")})
+	}
+	envVars = append(envVars, newNodeAttributeEnvVars(node, commonSpec)...)
+	envVars = mergeEnvVars(logger, envVars, node.Env, commonSpec.Env)
+
+	image := getNodeImage(node, commonSpec)
+	ports := getPorts(commonSpec)
+
+	esContainer := newElasticsearchContainer(
+		image,
+		getImagePullPolicy(image, commonSpec.ImagePullPolicy),
+		envVars,
+		resourceRequirements,
+		ports,
+		newNodeReadinessProbe(node, commonSpec, ports),
+		newLivenessProbe(getLivenessProbeSpec(node, commonSpec), ports),
+	)
+	esContainer.StartupProbe = newStartupProbe(getStartupProbeSpec(node, commonSpec), ports)
+	esContainer.Command = append([]string(nil), commonSpec.Command...)
+	esContainer.Args = append([]string(nil), commonSpec.Args...)
+	if commonSpec.SecurityContext != nil {
+		esContainer.SecurityContext = commonSpec.SecurityContext.DeepCopy()
+	}
+
+	volumes := newVolumes(ctx, logger, clusterName, nodeName, namespace, node, commonSpec, client)
+
+	if heapDump := commonSpec.HeapDump; heapDump != nil && heapDump.Storage != nil {
+		// the statefulset adds the volume of its claim template
+		if !hasStorageClaimTemplate(node, *heapDump.Storage) {
+			claimName := fmt.Sprintf("%s-%s-heapdump", clusterName, nodeName)
+			volumes = append(volumes, v1.Volume{
+				Name:         heapDumpVolumeName,
+				VolumeSource: newStorageVolumeSource(ctx, logger, claimName, clusterName, namespace, *heapDump.Storage, client),
+			})
+		}
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      heapDumpVolumeName,
+			MountPath: heapDumpVolumePath,
+		})
+	}
+
+	if commonSpec.GCLogging && commonSpec.GCLogStorage != nil {
+		if !hasStorageClaimTemplate(node, *commonSpec.GCLogStorage) {
+			claimName := fmt.Sprintf("%s-%s-gclogs", clusterName, nodeName)
+			volumes = append(volumes, v1.Volume{
+				Name:         gcLogVolumeName,
+				VolumeSource: newStorageVolumeSource(ctx, logger, claimName, clusterName, namespace, *commonSpec.GCLogStorage, client),
+			})
+		}
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      gcLogVolumeName,
+			MountPath: gcLogVolumePath,
+		})
+	}
+
+	for _, dataVolume := range node.DataVolumes {
+		volumeName := additionalDataVolumeName(dataVolume.Name)
+		// the statefulset adds the volumes of its claim templates
+		if !hasAdditionalDataVolumeClaimTemplate(node, dataVolume) {
+			claimName := fmt.Sprintf("%s-%s-%s", clusterName, nodeName, dataVolume.Name)
+			volumes = append(volumes, v1.Volume{
+				Name:         volumeName,
+				VolumeSource: newStorageVolumeSource(ctx, logger, claimName, clusterName, namespace, dataVolume.Storage, client),
+			})
+		}
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      volumeName,
+			MountPath: dataVolumeMountPath(dataVolume.Name),
+		})
+	}
+
+	if commonSpec.ReadOnlyRootFilesystem {
+		volumes, esContainer.VolumeMounts = appendWritableVolumes(volumes, esContainer.VolumeMounts)
+		if esContainer.SecurityContext == nil {
+			esContainer.SecurityContext = utils.ContainerSecurityContext()
+		}
+		esContainer.SecurityContext.ReadOnlyRootFilesystem = pointer.Bool(true)
+	}
+
+	if commonSpec.Keystore != nil {
+		volumes = append(volumes, newKeystoreVolumes(commonSpec.Keystore)...)
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      "elasticsearch-keystore",
+			MountPath: path.Join(elasticsearchConfigPath, keystoreFileName),
+			SubPath:   keystoreFileName,
+			ReadOnly:  true,
+		})
+	}
+
+	if commonSpec.CABundle != nil {
+		volumes = append(volumes, newTruststoreVolumes(commonSpec.CABundle)...)
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      "elasticsearch-truststore",
+			MountPath: truststoreVolumePath,
+			ReadOnly:  true,
+		})
+	}
+
+	if len(commonSpec.Plugins) > 0 {
+		volumes = append(volumes, v1.Volume{
+			Name:         "elasticsearch-plugins",
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      "elasticsearch-plugins",
+			MountPath: pluginsMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	for _, volume := range commonSpec.ExtraVolumes {
+		volumes = append(volumes, newExtraVolume(logger, nodeName, volume))
+	}
+	for _, mount := range commonSpec.ExtraVolumeMounts {
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, *mount.DeepCopy())
+	}
+
+	if len(commonSpec.Sidecars) > 0 {
+		// share the log directory of elasticsearch with the sidecars, e.g. for log shippers to tail it
+		volumes = append(volumes, v1.Volume{
+			Name:         sharedLogsVolumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+		esContainer.VolumeMounts = append(esContainer.VolumeMounts, v1.VolumeMount{
+			Name:      sharedLogsVolumeName,
+			MountPath: path.Join(elasticsearchDataPath, clusterName, "logs"),
+		})
+	}
+
 	containers := []v1.Container{
-		newElasticsearchContainer(
-			getESImage(),
-			newEnvVars(nodeName, clusterName, resourceRequirements.Limits.Memory().String(), roleMap),
-			resourceRequirements,
-		),
+		esContainer,
 		newProxyContainer(
 			getESProxyImage(),
 			clusterName,
 			namespace,
 			logConfig,
 			proxyResourceRequirements,
+			ports,
 		),
 	}
+	for _, sidecar := range commonSpec.Sidecars {
+		containers = append(containers, *sidecar.DeepCopy())
+	}
 
-	volumes := newVolumes(ctx, logger, clusterName, nodeName, namespace, node, client)
+	var initContainers []v1.Container
+	if commonSpec.SysctlInitContainer {
+		maxMapCount := int64(defaultMaxMapCount)
+		if commonSpec.MaxMapCount != nil {
+			maxMapCount = *commonSpec.MaxMapCount
+		}
+		initContainers = append(initContainers, newSysctlInitContainer(image, esContainer.ImagePullPolicy, maxMapCount))
+	}
+	if commonSpec.Keystore != nil {
+		initContainers = append(initContainers, newKeystoreInitContainer(image, esContainer.ImagePullPolicy))
+	}
+	if commonSpec.CABundle != nil {
+		initContainers = append(initContainers, newTruststoreInitContainer(image, esContainer.ImagePullPolicy, commonSpec.CABundle))
+	}
+	if len(commonSpec.Plugins) > 0 {
+		initContainers = append(initContainers, newPluginsInitContainer(image, esContainer.ImagePullPolicy, commonSpec.Plugins))
+	}
 
-	podSpec := pod.NewSpec(clusterName, containers, volumes).
-		WithAffinity(newAffinity(roleMap)).
+	podSpec := pod.NewSpec(serviceAccountName(clusterName), containers, volumes).
+		WithInitContainers(initContainers...).
+		WithPriorityClassName(getPriorityClassName(node, commonSpec)).
+		WithTerminationGracePeriodSeconds(getTerminationGracePeriod(commonSpec)).
+		WithAffinity(mergeAffinity(newAffinity(roleMap, getAntiAffinityMode(node, commonSpec)), getAffinity(node, commonSpec))).
+		WithTopologySpreadConstraints(getTopologySpreadConstraints(node, commonSpec, clusterName, roleMap)...).
+		WithHostAliases(commonSpec.HostAliases...).
+		WithDNSPolicy(commonSpec.DNSPolicy).
+		WithDNSConfig(commonSpec.DNSConfig.DeepCopy()).
 		WithNodeSelectors(selectors).
 		WithTolerations(tolerations...).
-		WithSecurityContext(utils.PodSecurityContext()).
+		WithImagePullSecrets(commonSpec.ImagePullSecrets...).
+		WithSecurityContext(newPodSecurityContext(commonSpec, hasPersistentVolume(node, volumes))).
+		WithReadinessGates(newReadinessGates(node, commonSpec)...).
 		Build()
 
 	return v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: labels,
+			Labels:      mergePodMetadata(logger, "label", labels, node.Labels, commonSpec.Labels),
+			Annotations: mergePodMetadata(logger, "annotation", nil, node.Annotations, commonSpec.Annotations),
 		},
 		Spec: *podSpec,
 	}
 }
 
+// appendExporterContainer adds the exporter sidecar to the pod template if the monitoring
+// of the cluster is enabled
+func appendExporterContainer(template v1.PodTemplateSpec, cluster *api.Elasticsearch) v1.PodTemplateSpec {
+	if !isMonitoringEnabled(cluster.Spec.Monitoring) {
+		return template
+	}
+
+	image := getExporterImage()
+	pullPolicy := getImagePullPolicy(image, cluster.Spec.Spec.ImagePullPolicy)
+	template.Spec.Containers = append(template.Spec.Containers, newExporterContainer(image, pullPolicy, getPorts(cluster.Spec.Spec).HTTP))
+
+	return template
+}
+
 // createUpdatablePodTemplateSpec creates a pod template from a copy of the update with
 // some aspects of the current
 func createUpdatablePodTemplateSpec(current, desired v1.PodTemplateSpec) v1.PodTemplateSpec {
 	desiredCopy := desired
-	desiredCopy.Spec.Volumes = current.Spec.Volumes
+	desiredCopy.Spec.Volumes = append([]v1.Volume{}, current.Spec.Volumes...)
+
+	// add volumes newly requested, e.g. a dedicated heap dump volume
+	for _, volume := range desired.Spec.Volumes {
+		found := false
+		for _, currentVolume := range current.Spec.Volumes {
+			if currentVolume.Name == volume.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			desiredCopy.Spec.Volumes = append(desiredCopy.Spec.Volumes, volume)
+		}
+	}
 
 	return desiredCopy
 }
 
+// newESNodeResourceRequirements returns the resources of the elasticsearch container
+// of node, falling back to the defaults of its role: master-only nodes get small
+// CPU defaults, data nodes the larger ones.
+func newESNodeResourceRequirements(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) v1.ResourceRequirements {
+	var requirements v1.ResourceRequirements
+	switch {
+	case isDataNode(node):
+		requirements = newResourceRequirements(node.Resources, commonSpec.Resources, defaultResources["elasticsearch-data"])
+	case isMasterNode(node):
+		requirements = newResourceRequirements(node.Resources, commonSpec.Resources, defaultResources["elasticsearch-master"])
+	default:
+		requirements = newESResourceRequirements(node.Resources, commonSpec.Resources)
+	}
+
+	heapSize := getHeapSize(node, commonSpec)
+	if heapSize == nil || hasMemoryResources(node.Resources) || hasMemoryResources(commonSpec.Resources) {
+		return requirements
+	}
+
+	// without memory settings the limit follows the heap so that the role
+	// defaults do not leave the JVM without room for off-heap memory
+	limit := getHeapMemoryLimit(*heapSize, commonSpec)
+	requirements.Limits[v1.ResourceMemory] = limit
+	if request := requirements.Requests[v1.ResourceMemory]; request.Cmp(limit) > 0 {
+		requirements.Requests[v1.ResourceMemory] = limit
+	}
+	return requirements
+}
+
+// getHeapMemoryLimit returns the memory limit reserving room for off-heap memory
+// and the page cache next to the given heap
+func getHeapMemoryLimit(heapSize resource.Quantity, commonSpec api.ElasticsearchNodeSpec) resource.Quantity {
+	multiplier := int64(defaultHeapMemoryMultiplier)
+	if commonSpec.HeapMemoryMultiplier != nil {
+		multiplier = int64(*commonSpec.HeapMemoryMultiplier)
+	}
+	return *resource.NewQuantity(heapSize.Value()*multiplier, resource.BinarySI)
+}
+
+// hasMemoryResources returns true if a memory limit or request is set
+func hasMemoryResources(resources v1.ResourceRequirements) bool {
+	return !resources.Limits.Memory().IsZero() || !resources.Requests.Memory().IsZero()
+}
+
 func newESResourceRequirements(nodeResRequirements, commonResRequirements v1.ResourceRequirements) v1.ResourceRequirements {
 	return newResourceRequirements(nodeResRequirements, commonResRequirements, defaultResources["elasticsearch"])
 }
@@ -504,6 +1867,10 @@ func newResourceRequirements(nodeResRequirements, commonResRequirements, default
 			// no node settings, use defaults
 			rCPU, _ := defaultRequirements.Requests[v1.ResourceCPU]
 			requestCPU = &rCPU
+
+			if lCPU, ok := defaultRequirements.Limits[v1.ResourceCPU]; ok {
+				limitCPU = &lCPU
+			}
 		} else {
 			// either one is not zero or both aren't zero but common is empty
 			if nodeRequestCPU.IsZero() {
@@ -570,23 +1937,30 @@ func newResourceRequirements(nodeResRequirements, commonResRequirements, default
 	}
 }
 
-func newVolumes(ctx context.Context, logger logr.Logger, clusterName, nodeName, namespace string, node api.ElasticsearchNode, client client.Client) []v1.Volume {
-	return []v1.Volume{
+func newVolumes(ctx context.Context, logger logr.Logger, clusterName, nodeName, namespace string, node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec, client client.Client) []v1.Volume {
+	volumes := []v1.Volume{
 		{
 			Name: "elasticsearch-config",
 			VolumeSource: v1.VolumeSource{
 				ConfigMap: &v1.ConfigMapVolumeSource{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: clusterName,
+						Name: configMapName(clusterName, commonSpec),
 					},
 				},
 			},
 		},
-		{
-			Name:         "elasticsearch-storage",
+	}
+
+	// the statefulset adds the volumes of its claim template
+	if !hasDataVolumeClaimTemplate(node) {
+		volumes = append(volumes, v1.Volume{
+			Name:         dataVolumeName,
 			VolumeSource: newVolumeSource(ctx, logger, clusterName, nodeName, namespace, node, client),
-		},
-		{
+		})
+	}
+
+	return append(volumes,
+		v1.Volume{
 			Name: "certificates",
 			VolumeSource: v1.VolumeSource{
 				Secret: &v1.SecretVolumeSource{
@@ -594,37 +1968,153 @@ func newVolumes(ctx context.Context, logger logr.Logger, clusterName, nodeName,
 				},
 			},
 		},
-		{
+		v1.Volume{
 			Name: fmt.Sprintf("%s-%s", clusterName, "metrics"),
 			VolumeSource: v1.VolumeSource{
 				Secret: &v1.SecretVolumeSource{
-					SecretName: fmt.Sprintf("%s-%s", clusterName, "metrics"),
+					SecretName: metricsServiceName(clusterName, commonSpec),
 				},
 			},
 		},
+	)
+}
+
+// reservedVolumeNames returns the names of the volumes the operator adds to the pods
+func reservedVolumeNames(clusterName string) []string {
+	return []string{
+		dataVolumeName,
+		"elasticsearch-config",
+		"certificates",
+		fmt.Sprintf("%s-%s", clusterName, "metrics"),
+		heapDumpVolumeName,
+		gcLogVolumeName,
+		"elasticsearch-tmp",
+		"elasticsearch-logs",
+		"elasticsearch-keystore",
+		"elasticsearch-keystore-secret",
+		"elasticsearch-plugins",
+		"elasticsearch-ca-bundle",
+		"elasticsearch-truststore",
+		sharedLogsVolumeName,
+	}
+}
+
+// reservedMountPaths returns the paths the operator mounts volumes at in the
+// elasticsearch container
+func reservedMountPaths() []string {
+	return []string{
+		elasticsearchDataPath,
+		elasticsearchConfigPath,
+		elasticsearchCertsPath,
+		heapDumpVolumePath,
+		gcLogVolumePath,
+		additionalDataPath,
+		elasticsearchTmpPath,
+		elasticsearchLogsPath,
+		pluginsMountPath,
+		truststoreVolumePath,
+	}
+}
+
+// newVolumeClaimTemplates returns the claim templates of the statefulset of the
+// node, one for the data volume, one per sized additional data volume and one
+// each for the sized heap dump and gc log storage
+func newVolumeClaimTemplates(clusterName string, node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) []v1.PersistentVolumeClaim {
+	var templates []v1.PersistentVolumeClaim
+	if hasDataVolumeClaimTemplate(node) {
+		templates = append(templates, newVolumeClaimTemplate(dataVolumeName, clusterName, node.Storage))
+	}
+	for _, dataVolume := range node.DataVolumes {
+		if hasAdditionalDataVolumeClaimTemplate(node, dataVolume) {
+			templates = append(templates, newVolumeClaimTemplate(additionalDataVolumeName(dataVolume.Name), clusterName, dataVolume.Storage))
+		}
+	}
+	if heapDump := commonSpec.HeapDump; heapDump != nil && heapDump.Storage != nil && hasStorageClaimTemplate(node, *heapDump.Storage) {
+		templates = append(templates, newVolumeClaimTemplate(heapDumpVolumeName, clusterName, *heapDump.Storage))
+	}
+	if commonSpec.GCLogging && commonSpec.GCLogStorage != nil && hasStorageClaimTemplate(node, *commonSpec.GCLogStorage) {
+		templates = append(templates, newVolumeClaimTemplate(gcLogVolumeName, clusterName, *commonSpec.GCLogStorage))
+	}
+	return templates
+}
+
+// newVolumeClaimTemplate returns a claim template of a volume of a statefulset.
+// The claims are named <name>-<statefulset>-<ordinal>.
+func newVolumeClaimTemplate(name, clusterName string, specVol api.ElasticsearchStorageSpec) v1.PersistentVolumeClaim {
+	pvcLabels := map[string]string{
+		"logging-cluster": clusterName,
+	}
+	pvc := persistentvolume.NewPVC(name, "", pvcLabels)
+	pvc.Annotations = copyAnnotations(specVol.Annotations)
+	pvc.Spec = v1.PersistentVolumeClaimSpec{
+		AccessModes: []v1.PersistentVolumeAccessMode{
+			v1.ReadWriteOnce,
+		},
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceStorage: *specVol.Size,
+			},
+		},
+		StorageClassName: specVol.StorageClassName,
+	}
+	return *pvc
+}
+
+// copyAnnotations returns a copy of the annotations of a storage spec, nil if
+// there are none
+func copyAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
 	}
+	copied := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
+
+// dataVolumeClaimName returns the name of the data volume claim of a statefulset pod
+func dataVolumeClaimName(statefulSetName string, ordinal int32) string {
+	return volumeClaimName(dataVolumeName, statefulSetName, ordinal)
+}
+
+// volumeClaimName returns the name of the claim a statefulset creates for a pod
+// from one of its claim templates
+func volumeClaimName(templateName, statefulSetName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%s-%d", templateName, statefulSetName, ordinal)
 }
 
 func newVolumeSource(ctx context.Context, logger logr.Logger, clusterName, nodeName, namespace string, node api.ElasticsearchNode, client client.Client) v1.VolumeSource {
 	specVol := node.Storage
-	volSource := v1.VolumeSource{}
 
 	// Ephemeral storage
+	// in the case where we do not have a size provided we need to
+	// fall back to using ephemeral storage since a pvc requires a size
 	emptySpecVol := api.ElasticsearchStorageSpec{}
-	if reflect.DeepEqual(specVol, emptySpecVol) {
-		volSource.EmptyDir = &v1.EmptyDirVolumeSource{}
-		return volSource
+	if reflect.DeepEqual(specVol, emptySpecVol) || specVol.Size == nil {
+		if isDataNode(node) {
+			logger.Info("Data node is using ephemeral storage. Its data is lost when the pod is restarted",
+				"node", nodeName)
+		}
 	}
 
-	// in the case where we do not have a size provided we need to
-	// fall back to using ephemeral storage since a pvc requires a size
+	return newStorageVolumeSource(ctx, logger, fmt.Sprintf("%s-%s", clusterName, nodeName), clusterName, namespace, specVol, client)
+}
+
+// newStorageVolumeSource returns an emptyDir volume if the storage spec has no size or
+// else ensures the existence of the named claim and returns it as volume
+func newStorageVolumeSource(ctx context.Context, logger logr.Logger, claimName, clusterName, namespace string, specVol api.ElasticsearchStorageSpec, client client.Client) v1.VolumeSource {
+	volSource := v1.VolumeSource{}
+
 	if specVol.Size == nil {
 		volSource.EmptyDir = &v1.EmptyDirVolumeSource{}
+		if specVol.EmptyDir != nil {
+			volSource.EmptyDir = specVol.EmptyDir.DeepCopy()
+		}
 		return volSource
 	}
 
 	// Persistent storage
-	claimName := fmt.Sprintf("%s-%s", clusterName, nodeName)
 	volSource.PersistentVolumeClaim = &v1.PersistentVolumeClaimVolumeSource{
 		ClaimName: claimName,
 	}
@@ -633,6 +2123,7 @@ func newVolumeSource(ctx context.Context, logger logr.Logger, clusterName, nodeN
 		"logging-cluster": clusterName,
 	}
 	pvc := persistentvolume.NewPVC(claimName, namespace, pvcLabels)
+	pvc.Annotations = copyAnnotations(specVol.Annotations)
 	pvc.Spec = v1.PersistentVolumeClaimSpec{
 		AccessModes: []v1.PersistentVolumeAccessMode{
 			v1.ReadWriteOnce,
@@ -645,16 +2136,118 @@ func newVolumeSource(ctx context.Context, logger logr.Logger, clusterName, nodeN
 		StorageClassName: specVol.StorageClassName,
 	}
 
+	if specVol.StorageClassName != nil {
+		exists, err := storageClassExists(ctx, client, *specVol.StorageClassName)
+		if err != nil {
+			logger.Error(err, "Unable to verify StorageClass of PersistentVolumeClaim",
+				"storageClassName", *specVol.StorageClassName)
+		} else if !exists {
+			logger.Info("StorageClass not found. The PersistentVolumeClaim stays pending until it is created",
+				"storageClassName", *specVol.StorageClassName,
+				"claim", claimName)
+		}
+	}
+
 	// TODO: This create PVC functionality needs to move from being part of
 	// the template creation. It should idealy be in where the pod template
 	// (deployment/statefulset) is create or maintained.
-	err := persistentvolume.CreateOrUpdatePVC(ctx, client, pvc, persistentvolume.LabelsEqual, persistentvolume.MutateLabelsOnly)
+	err := retry.OnError(pvcCreateBackoff, func(error) bool { return true }, func() error {
+		return persistentvolume.CreateOrUpdatePVC(ctx, client, pvc, persistentvolume.LabelsAndAnnotationsEqual, persistentvolume.MutateLabelsAndAnnotations)
+	})
 	if err != nil {
 		logger.Error(err, "Unable to create PersistentVolumeClaim")
+		recordEvent(ctx, v1.EventTypeWarning, eventReasonPersistentVolumeClaimFailed, "Unable to create PersistentVolumeClaim %s: %v", claimName, err)
+		return volSource
+	}
+
+	if err := expandPersistentVolumeClaim(ctx, client, claimName, namespace, *specVol.Size); err != nil {
+		logger.Error(err, "Unable to resize PersistentVolumeClaim")
 	}
 	return volSource
 }
 
+// expandPersistentVolumeClaim raises the storage request of an existing claim
+// to the given size. Claims cannot be shrunk and can only grow if their
+// storage class allows volume expansion, otherwise an error is returned.
+func expandPersistentVolumeClaim(ctx context.Context, c client.Client, claimName, namespace string, size resource.Quantity) error {
+	key := client.ObjectKey{Name: claimName, Namespace: namespace}
+	current := &v1.PersistentVolumeClaim{}
+	if err := c.Get(ctx, key, current); err != nil {
+		return kverrors.Wrap(err, "failed to get persistentvolumeclaim",
+			"name", claimName,
+			"namespace", namespace,
+		)
+	}
+
+	currentSize := current.Spec.Resources.Requests.Storage()
+	switch size.Cmp(*currentSize) {
+	case 0:
+		return nil
+	case -1:
+		return kverrors.New("shrinking a PersistentVolumeClaim is not supported. Please restore the previous storage size",
+			"name", claimName,
+			"current", currentSize.String(),
+			"requested", size.String(),
+		)
+	}
+
+	if current.Spec.StorageClassName == nil || *current.Spec.StorageClassName == "" {
+		return kverrors.New("PersistentVolumeClaim without a storage class cannot be expanded. Please restore the previous storage size",
+			"name", claimName,
+		)
+	}
+
+	scName := *current.Spec.StorageClassName
+	sc := &storagev1.StorageClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: scName}, sc); err != nil {
+		return kverrors.Wrap(err, "failed to get storage class",
+			"name", scName,
+		)
+	}
+
+	if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+		return kverrors.New("storage class does not allow volume expansion. Please enable allowVolumeExpansion on the storage class or restore the previous storage size",
+			"name", claimName,
+			"storageClassName", scName,
+			"current", currentSize.String(),
+			"requested", size.String(),
+		)
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, key, current); err != nil {
+			return err
+		}
+
+		if current.Spec.Resources.Requests == nil {
+			current.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		current.Spec.Resources.Requests[v1.ResourceStorage] = size
+		return c.Update(ctx, current)
+	})
+	if err != nil {
+		return kverrors.Wrap(err, "failed to expand persistentvolumeclaim",
+			"name", claimName,
+			"namespace", namespace,
+		)
+	}
+
+	return nil
+}
+
+// storageClassExists checks whether a storage class with the given name exists
+func storageClassExists(ctx context.Context, c client.Client, name string) (bool, error) {
+	sc := &storagev1.StorageClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, sc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, kverrors.Wrap(err, "failed to get storage class", "name", name)
+	}
+
+	return true, nil
+}
+
 /*
 kind: NetworkPolicy
 apiVersion: networking.k8s.io/v1
@@ -4,7 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
@@ -16,6 +21,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -156,6 +162,53 @@ func TestResourcesNoCommonNoNodeDefined(t *testing.T) {
 	}
 }
 
+func TestResourcesDefaultsByRole(t *testing.T) {
+	tests := []struct {
+		desc     string
+		roles    []api.ElasticsearchNodeRole
+		cpuLimit string
+	}{
+		{
+			desc:     "master-only node",
+			roles:    []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+			cpuLimit: "100m",
+		},
+		{
+			desc:     "data node",
+			roles:    []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+			cpuLimit: "4000m",
+		},
+		{
+			desc:     "master and data node",
+			roles:    []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster, api.ElasticsearchRoleData},
+			cpuLimit: "4000m",
+		},
+	}
+
+	for _, test := range tests {
+		node := api.ElasticsearchNode{Roles: test.roles}
+		actual := newESNodeResourceRequirements(node, api.ElasticsearchNodeSpec{})
+
+		if got := actual.Limits[v1.ResourceCPU]; got.Cmp(resource.MustParse(test.cpuLimit)) != 0 {
+			t.Errorf("%s: expected CPU limit %s but got %s", test.desc, test.cpuLimit, got.String())
+		}
+		if got := actual.Limits[v1.ResourceMemory]; got.Cmp(defaultTestMemLimit) != 0 {
+			t.Errorf("%s: expected memory limit %s but got %s", test.desc, defaultTestMemLimit.String(), got.String())
+		}
+	}
+
+	// user settings win over the role defaults
+	node := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+		Resources: buildResourceOnlyLimits(nodeCPUValue, nodeMemValue),
+	}
+	actual := newESNodeResourceRequirements(node, api.ElasticsearchNodeSpec{})
+	expected := buildResource(nodeCPUValue, nodeCPUValue, nodeMemValue, nodeMemValue)
+	if !areResourcesSame(actual, expected) {
+		t.Errorf("Expected %v but got %v", printResource(expected), printResource(actual))
+	}
+}
+
 // 4
 func TestResourcesCommonAndNodeRequestDefined(t *testing.T) {
 	commonRequirements := buildResource(
@@ -465,7 +518,7 @@ func TestProxyContainerTLSClientAuthDefined(t *testing.T) {
 
 	empty := v1.ResourceRequirements{}
 	proxyResources := newESProxyResourceRequirements(empty, empty)
-	proxyContainer := newProxyContainer(imageName, clusterName, "openshift-logging", LogConfig{}, proxyResources)
+	proxyContainer := newProxyContainer(imageName, clusterName, "openshift-logging", LogConfig{}, proxyResources, getPorts(api.ElasticsearchNodeSpec{}))
 
 	want := []string{
 		"--tls-cert=/etc/proxy/elasticsearch/logging-es.crt",
@@ -499,7 +552,7 @@ func TestProxyContainerSpec(t *testing.T) {
 
 	empty := v1.ResourceRequirements{}
 	proxyResources := newESProxyResourceRequirements(empty, empty)
-	proxyContainer := newProxyContainer(imageName, clusterName, "openshift-logging", LogConfig{}, proxyResources)
+	proxyContainer := newProxyContainer(imageName, clusterName, "openshift-logging", LogConfig{}, proxyResources, getPorts(api.ElasticsearchNodeSpec{}))
 
 	wantArgs := []string{
 		"--metrics-listening-address=:60001",
@@ -565,148 +618,862 @@ func TestPodSpecHasTaintTolerations(t *testing.T) {
 	}
 }
 
-func TestElasticSearchSecurityContext(t *testing.T) {
-	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+func TestPodSpecHasNodeAndCommonTolerations(t *testing.T) {
+	commonToleration := v1.Toleration{
+		Key:      "dedicated",
+		Operator: v1.TolerationOpEqual,
+		Value:    "logging",
+		Effect:   v1.TaintEffectNoSchedule,
+	}
+	nodeToleration := v1.Toleration{
+		Key:      "high-memory",
+		Operator: v1.TolerationOpExists,
+		Effect:   v1.TaintEffectNoExecute,
+	}
 
-	expectedPod := &v1.PodSecurityContext{
-		RunAsNonRoot: pointer.Bool(true),
+	commonSpec := api.ElasticsearchNodeSpec{
+		Tolerations: make([]v1.Toleration, 1, 4),
 	}
+	commonSpec.Tolerations[0] = commonToleration
 
-	if diff := cmp.Diff(podTemplate.Spec.SecurityContext, expectedPod); diff != "" {
-		t.Errorf("ES pod SecurityContext error: %s", diff)
+	dataNode := api.ElasticsearchNode{Tolerations: []v1.Toleration{nodeToleration}}
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", dataNode, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	expectedTolerations := []v1.Toleration{
+		{
+			Key:      "node.kubernetes.io/disk-pressure",
+			Operator: v1.TolerationOpExists,
+			Effect:   v1.TaintEffectNoSchedule,
+		},
+		commonToleration,
+		nodeToleration,
+	}
+	if !comparators.AreTolerationsSame(podTemplateSpec.Spec.Tolerations, expectedTolerations) {
+		t.Errorf("Exp. the tolerations to be %v but was %v", expectedTolerations, podTemplateSpec.Spec.Tolerations)
 	}
 
-	expectedES := &v1.SecurityContext{
-		AllowPrivilegeEscalation: pointer.Bool(false),
-		Capabilities: &v1.Capabilities{
-			Drop: []v1.Capability{"ALL"},
+	// a second node group must not see the tolerations of the first one
+	podTemplateSpec = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	expectedTolerations = expectedTolerations[:2]
+	if !comparators.AreTolerationsSame(podTemplateSpec.Spec.Tolerations, expectedTolerations) {
+		t.Errorf("Exp. the tolerations to be %v but was %v", expectedTolerations, podTemplateSpec.Spec.Tolerations)
+	}
+}
+
+func TestAntiAffinityMode(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleMaster: true}
+
+	affinity := newAffinity(roleMap, "")
+	if len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 || affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		t.Errorf("Exp. preferred anti-affinity by default but was %v", affinity.PodAntiAffinity)
+	}
+
+	affinity = newAffinity(roleMap, api.AntiAffinityRequired)
+	required := affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required) != 1 || affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution != nil {
+		t.Fatalf("Exp. required anti-affinity but was %v", affinity.PodAntiAffinity)
+	}
+	if required[0].TopologyKey != "kubernetes.io/hostname" {
+		t.Errorf("Exp. the topology key to be kubernetes.io/hostname but was %q", required[0].TopologyKey)
+	}
+	expected := []metav1.LabelSelectorRequirement{
+		{Key: "es-node-master", Operator: metav1.LabelSelectorOpIn, Values: []string{"true"}},
+	}
+	if diff := cmp.Diff(required[0].LabelSelector.MatchExpressions, expected); diff != "" {
+		t.Errorf("Unexpected anti-affinity selector: %s", diff)
+	}
+
+	node := api.ElasticsearchNode{AntiAffinityMode: api.AntiAffinityPreferred}
+	commonSpec := api.ElasticsearchNodeSpec{AntiAffinityMode: api.AntiAffinityRequired}
+	if mode := getAntiAffinityMode(node, commonSpec); mode != api.AntiAffinityPreferred {
+		t.Errorf("Exp. the node anti-affinity mode to take precedence but was %q", mode)
+	}
+	if mode := getAntiAffinityMode(api.ElasticsearchNode{}, commonSpec); mode != api.AntiAffinityRequired {
+		t.Errorf("Exp. the common anti-affinity mode but was %q", mode)
+	}
+}
+
+func TestAntiAffinityDisabled(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleData: true}
+	if affinity := newAffinity(roleMap, api.AntiAffinityDisabled); affinity != nil {
+		t.Errorf("Exp. no anti-affinity when disabled but was %v", affinity)
+	}
+
+	commonSpec := api.ElasticsearchNodeSpec{AntiAffinityMode: api.AntiAffinityDisabled}
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, roleMap, nil, LogConfig{})
+	if podTemplateSpec.Spec.Affinity != nil {
+		t.Errorf("Exp. no affinity in the pod template when disabled but was %v", podTemplateSpec.Spec.Affinity)
+	}
+
+	// custom affinity rules are still used as given
+	zoneAffinity := &v1.Affinity{
+		NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+						},
+					},
+				},
+			},
 		},
 	}
+	if diff := cmp.Diff(mergeAffinity(nil, zoneAffinity), zoneAffinity); diff != "" {
+		t.Errorf("Exp. the custom affinity to be used as given: %s", diff)
+	}
+}
 
-	expectedProxy := &v1.SecurityContext{
-		AllowPrivilegeEscalation: pointer.Bool(false),
-		ReadOnlyRootFilesystem:   pointer.BoolPtr(true),
-		Capabilities: &v1.Capabilities{
-			Drop: []v1.Capability{"ALL"},
+func TestMergeAffinityKeepsDefaultAntiAffinity(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleData: true}
+	defaultAffinity := newAffinity(roleMap, "")
+
+	zoneAffinity := &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{
+				{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+					},
+				},
+			},
 		},
 	}
 
-	for _, c := range podTemplate.Spec.Containers {
-		if c.Name == "proxy" {
-			if diff := cmp.Diff(c.SecurityContext, expectedProxy); diff != "" {
-				t.Errorf("proxy container SecurityContext error: %s", diff)
-			}
+	merged := mergeAffinity(defaultAffinity, &v1.Affinity{NodeAffinity: zoneAffinity})
+
+	if diff := cmp.Diff(merged.NodeAffinity, zoneAffinity); diff != "" {
+		t.Errorf("Unexpected node affinity: %s", diff)
+	}
+	if diff := cmp.Diff(merged.PodAntiAffinity, defaultAffinity.PodAntiAffinity); diff != "" {
+		t.Errorf("Exp. the default pod anti-affinity to be kept: %s", diff)
+	}
+}
+
+func TestMergeAffinityAddsCustomAntiAffinity(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleData: true}
+	defaultAffinity := newAffinity(roleMap, "")
+
+	customTerm := v1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "kafka"}},
+		TopologyKey:   "kubernetes.io/hostname",
+	}
+	merged := mergeAffinity(defaultAffinity, &v1.Affinity{
+		PodAntiAffinity: &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{customTerm},
+		},
+	})
+
+	if len(merged.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Errorf("Exp. the default preferred anti-affinity term to be kept but was %v", merged.PodAntiAffinity)
+	}
+	if diff := cmp.Diff(merged.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, []v1.PodAffinityTerm{customTerm}); diff != "" {
+		t.Errorf("Exp. the custom anti-affinity term to be added: %s", diff)
+	}
+	if len(defaultAffinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 {
+		t.Errorf("Exp. the default affinity not to be modified")
+	}
+}
+
+func TestPodSpecHostAliases(t *testing.T) {
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	if len(podTemplateSpec.Spec.HostAliases) != 0 {
+		t.Errorf("Exp. no host aliases by default but was %v", podTemplateSpec.Spec.HostAliases)
+	}
+
+	aliases := []v1.HostAlias{
+		{IP: "10.0.0.10", Hostnames: []string{"s3.example.com"}},
+		{IP: "10.0.0.11", Hostnames: []string{"ldap.example.com", "ldap"}},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{HostAliases: aliases}
+	podTemplateSpec = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	if diff := cmp.Diff(podTemplateSpec.Spec.HostAliases, aliases); diff != "" {
+		t.Errorf("Unexpected host aliases: %s", diff)
+	}
+}
+
+func TestKeystoreDisabledByDefault(t *testing.T) {
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	if len(podTemplateSpec.Spec.InitContainers) != 0 {
+		t.Errorf("Exp. no init containers by default but was %v", podTemplateSpec.Spec.InitContainers)
+	}
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		if strings.HasPrefix(volume.Name, "elasticsearch-keystore") {
+			t.Errorf("Exp. no keystore volumes by default but found %v", volume)
 		}
+	}
+}
 
-		if c.Name == "elasticsearch" {
-			if diff := cmp.Diff(c.SecurityContext, expectedES); diff != "" {
-				t.Errorf("elasticsearch container SecurityContext error: %s", diff)
+func TestKeystoreInitContainer(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		Keystore: &api.ElasticsearchKeystoreSpec{SecretName: "es-secure-settings"},
+	}
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	if len(initContainers) != 1 || initContainers[0].Name != "keystore" {
+		t.Fatalf("Exp. the keystore init container but was %v", initContainers)
+	}
+	esContainer := podTemplateSpec.Spec.Containers[0]
+	if initContainers[0].Image != esContainer.Image {
+		t.Errorf("Exp. the init container to run the elasticsearch image %q but was %q", esContainer.Image, initContainers[0].Image)
+	}
+
+	volumes := map[string]v1.Volume{}
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	if secret := volumes["elasticsearch-keystore-secret"].Secret; secret == nil || secret.SecretName != "es-secure-settings" {
+		t.Errorf("Exp. the secure settings secret to be mounted but was %v", volumes["elasticsearch-keystore-secret"])
+	}
+	if volumes["elasticsearch-keystore"].EmptyDir == nil {
+		t.Errorf("Exp. the keystore to be written to an emptyDir but was %v", volumes["elasticsearch-keystore"])
+	}
+
+	expected := v1.VolumeMount{
+		Name:      "elasticsearch-keystore",
+		MountPath: "/usr/share/java/elasticsearch/config/elasticsearch.keystore",
+		SubPath:   "elasticsearch.keystore",
+		ReadOnly:  true,
+	}
+	found := false
+	for _, mount := range esContainer.VolumeMounts {
+		if mount.Name == expected.Name {
+			found = true
+			if diff := cmp.Diff(mount, expected); diff != "" {
+				t.Errorf("Unexpected keystore mount: %s", diff)
 			}
 		}
 	}
+	if !found {
+		t.Errorf("Exp. the keystore to be mounted into the elasticsearch container but was %v", esContainer.VolumeMounts)
+	}
 }
 
-// All pods created by Elasticsearch operator needs to be allocated to linux nodes.
-// See LOG-411
-func TestPodNodeSelectors(t *testing.T) {
-	var podSpec v1.PodSpec
+// runKeystoreScript runs the script of the keystore init container with a stub
+// elasticsearch-keystore recording the added settings as name=value lines
+func runKeystoreScript(t *testing.T, secrets map[string]string) string {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell available")
+	}
 
-	// Create podSpecTemplate providing nil/empty node selectors, we expect the PodTemplateSpec.Spec selectors
-	// will contain only the linux allocation selector.
-	podSpec = preparePodTemplateSpecProvidingNodeSelectors(nil).Spec
+	dir := t.TempDir()
+	home, secretPath, confPath := filepath.Join(dir, "home"), filepath.Join(dir, "secret"), filepath.Join(dir, "conf")
+	for _, d := range []string{filepath.Join(home, "bin"), secretPath, confPath} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-	if podSpec.NodeSelector == nil {
-		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	stub := `#!/bin/sh
+case "$1" in
+create) : > "${ES_PATH_CONF}/elasticsearch.keystore" ;;
+add) echo "$4=$(cat)" >> "${ES_PATH_CONF}/elasticsearch.keystore" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(home, "bin", "elasticsearch-keystore"), []byte(stub), 0o755); err != nil {
+		t.Fatal(err)
 	}
-	if len(podSpec.NodeSelector) != 1 {
-		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	for name, value := range secrets {
+		if err := os.WriteFile(filepath.Join(secretPath, name), []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
 	}
-	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
-		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+	// a keystore left by a previous run of the init container is replaced
+	if err := os.WriteFile(filepath.Join(confPath, keystoreFileName), []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
 	}
 
-	// Create podSpecTemplate providing some custom node selectors, we expect the PodTemplateSpec.Spec selectors
-	// will add linux node selector.
-	podSpec = preparePodTemplateSpecProvidingNodeSelectors(map[string]string{"foo": "bar", "baz": "foo"}).Spec
+	container := newKeystoreInitContainer("elasticsearch", v1.PullIfNotPresent)
+	cmd := exec.Command(sh, container.Command[1:]...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "ES_HOME=" + home, "ES_PATH_CONF=" + confPath, "KEYSTORE_SECRET_PATH=" + secretPath}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("keystore script failed: %s: %s", err, out)
+	}
 
-	if podSpec.NodeSelector == nil {
-		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	keystore, err := os.ReadFile(filepath.Join(confPath, keystoreFileName))
+	if err != nil {
+		t.Fatal(err)
 	}
-	if len(podSpec.NodeSelector) != 3 {
-		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	return string(keystore)
+}
+
+func TestKeystoreScriptAddsSettings(t *testing.T) {
+	keystore := runKeystoreScript(t, map[string]string{
+		"s3.client.default.access_key": "AKIA",
+		"s3.client.default.secret_key": "s3cr3t",
+	})
+
+	expected := "s3.client.default.access_key=AKIA\ns3.client.default.secret_key=s3cr3t\n"
+	if keystore != expected {
+		t.Errorf("Exp. the keystore to hold the secure settings %q but was %q", expected, keystore)
 	}
-	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
-		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+}
+
+func TestKeystoreScriptCopiesKeystoreFile(t *testing.T) {
+	keystore := runKeystoreScript(t, map[string]string{
+		keystoreFileName:               "prebuilt",
+		"s3.client.default.secret_key": "s3cr3t",
+	})
+
+	if keystore != "prebuilt" {
+		t.Errorf("Exp. the keystore file of the secret to be used as is but was %q", keystore)
 	}
+}
 
-	// Create podSpecTemplate providing node selector with some custom value, we expect the PodTemplateSpec.Spec selector
-	// will override the node selector to linux one.
-	podSpec = preparePodTemplateSpecProvidingNodeSelectors(map[string]string{utils.OsNodeLabel: "foo"}).Spec
+func TestTruststoreInitContainer(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		CABundle: &api.ElasticsearchCABundleSpec{ConfigMapName: "private-ca"},
+	}
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
 
-	if podSpec.NodeSelector == nil {
-		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	initContainers := podTemplateSpec.Spec.InitContainers
+	if len(initContainers) != 1 || initContainers[0].Name != "truststore" {
+		t.Fatalf("Exp. the truststore init container but was %v", initContainers)
 	}
-	if len(podSpec.NodeSelector) != 1 {
-		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	expectedEnv := []v1.EnvVar{
+		{Name: "CA_BUNDLE_FILE", Value: "/etc/elasticsearch/ca-bundle/ca-bundle.crt"},
+		{Name: "TRUSTSTORE_PATH", Value: "/elasticsearch/truststore"},
+		{Name: "TRUSTSTORE_PASSWORD", Value: "changeit"},
 	}
-	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
-		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+	if diff := cmp.Diff(initContainers[0].Env, expectedEnv); diff != "" {
+		t.Errorf("Unexpected truststore init container env: %s", diff)
+	}
+
+	volumes := map[string]v1.Volume{}
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	if configMap := volumes["elasticsearch-ca-bundle"].ConfigMap; configMap == nil || configMap.Name != "private-ca" {
+		t.Errorf("Exp. the CA bundle config map to be mounted but was %v", volumes["elasticsearch-ca-bundle"])
+	}
+	if volumes["elasticsearch-truststore"].EmptyDir == nil {
+		t.Errorf("Exp. the truststore to be written to an emptyDir but was %v", volumes["elasticsearch-truststore"])
+	}
+
+	esContainer := podTemplateSpec.Spec.Containers[0]
+	expected := v1.VolumeMount{
+		Name:      "elasticsearch-truststore",
+		MountPath: "/elasticsearch/truststore",
+		ReadOnly:  true,
+	}
+	found := false
+	for _, mount := range esContainer.VolumeMounts {
+		if mount.Name == expected.Name {
+			found = true
+			if diff := cmp.Diff(mount, expected); diff != "" {
+				t.Errorf("Unexpected truststore mount: %s", diff)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Exp. the truststore to be mounted into the elasticsearch container but was %v", esContainer.VolumeMounts)
+	}
+
+	javaOpts := ""
+	for _, env := range esContainer.Env {
+		if env.Name == "ES_JAVA_OPTS" {
+			javaOpts = env.Value
+		}
+	}
+	if !strings.Contains(javaOpts, "-Djavax.net.ssl.trustStore=/elasticsearch/truststore/cacerts") {
+		t.Errorf("Exp. the JVM to use the truststore of the init container but ES_JAVA_OPTS was %q", javaOpts)
 	}
 }
 
-func TestPodDiskToleration(t *testing.T) {
-	expectedToleration := []v1.Toleration{
-		{
-			Key:      "node.kubernetes.io/disk-pressure",
-			Operator: v1.TolerationOpExists,
-			Effect:   v1.TaintEffectNoSchedule,
-		},
+func TestTruststoreVolumeFromSecret(t *testing.T) {
+	volumes := newTruststoreVolumes(&api.ElasticsearchCABundleSpec{SecretName: "private-ca", Key: "ca.crt"})
+	if secret := volumes[0].Secret; secret == nil || secret.SecretName != "private-ca" || volumes[0].ConfigMap != nil {
+		t.Errorf("Exp. the CA bundle secret to be mounted but was %v", volumes[0])
 	}
 
-	// Create podSpecTemplate providing nil/empty tolerations, we expect the PodTemplateSpec.Spec tolerations
-	// will contain only the disk usage toleration
-	podSpec := preparePodTemplateSpecProvidingNodeSelectors(nil).Spec
+	container := newTruststoreInitContainer("elasticsearch", v1.PullIfNotPresent, &api.ElasticsearchCABundleSpec{SecretName: "private-ca", Key: "ca.crt"})
+	if container.Env[0].Value != "/etc/elasticsearch/ca-bundle/ca.crt" {
+		t.Errorf("Exp. the CA bundle to be read from the configured key but was %q", container.Env[0].Value)
+	}
+}
 
-	if podSpec.Tolerations == nil {
-		t.Errorf("Exp. the toleration to contains the disk usage toleration but was %T", podSpec.Tolerations)
+func TestTruststoreScriptImportsCertificates(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell available")
 	}
-	if len(podSpec.Tolerations) != 1 {
-		t.Errorf("Exp. single toleration but %d were found", len(podSpec.Tolerations))
+	if _, err := exec.LookPath("awk"); err != nil {
+		t.Skip("no awk available")
 	}
-	if !comparators.AreTolerationsSame(podSpec.Tolerations, expectedToleration) {
-		t.Errorf("Exp. the tolerations to contain %v", expectedToleration)
+
+	dir := t.TempDir()
+	javaHome, truststorePath := filepath.Join(dir, "jdk"), filepath.Join(dir, "truststore")
+	for _, d := range []string{filepath.Join(javaHome, "bin"), filepath.Join(javaHome, "lib", "security"), truststorePath} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the stub keytool appends the alias and the first line after the header of
+	// each imported certificate to the keystore
+	stub := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+  -keystore) keystore="$2"; shift ;;
+  -alias) alias="$2"; shift ;;
+  -file) file="$2"; shift ;;
+  esac
+  shift
+done
+echo "${alias}=$(sed -n 2p "${file}")" >> "${keystore}"
+`
+	if err := os.WriteFile(filepath.Join(javaHome, "bin", "keytool"), []byte(stub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(javaHome, "lib", "security", "cacerts"), []byte("jdk\n"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	bundle := "-----BEGIN CERTIFICATE-----\nfirst\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nsecond\n-----END CERTIFICATE-----\n"
+	bundleFile := filepath.Join(dir, "ca-bundle.crt")
+	if err := os.WriteFile(bundleFile, []byte(bundle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	container := newTruststoreInitContainer("elasticsearch", v1.PullIfNotPresent, &api.ElasticsearchCABundleSpec{ConfigMapName: "private-ca"})
+	cmd := exec.Command(sh, container.Command[1:]...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "JAVA_HOME=" + javaHome, "CA_BUNDLE_FILE=" + bundleFile, "TRUSTSTORE_PATH=" + truststorePath, "TRUSTSTORE_PASSWORD=" + truststorePassword}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("truststore script failed: %s: %s", err, out)
+	}
+
+	truststore, err := os.ReadFile(filepath.Join(truststorePath, truststoreFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "jdk\nca-bundle-1=first\nca-bundle-2=second\n"
+	if string(truststore) != expected {
+		t.Errorf("Exp. the truststore to hold the JVM and the bundle certificates %q but was %q", expected, truststore)
+	}
+
+	files, err := os.ReadDir(truststorePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Exp. only the truststore to be left in the volume but found %d files", len(files))
 	}
 }
 
-func TestNewVolumeSource(t *testing.T) {
-	const (
-		clusterName = "elastisearch"
-		nodeName    = "elasticsearch-cdm-1"
-		namespace   = "openshift-logging"
-	)
+func TestPluginsInitContainer(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		Plugins: []string{"analysis-icu", "https://mirror.example.com/repository-s3-6.8.1.zip"},
+	}
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
 
-	var (
-		claimName   = fmt.Sprintf("%s-%s", clusterName, nodeName)
-		gp2SCName   = "gp2"
-		storageSize = resource.MustParse("2Gi")
-	)
+	initContainers := podTemplateSpec.Spec.InitContainers
+	if len(initContainers) != 1 || initContainers[0].Name != "plugins" {
+		t.Fatalf("Exp. the plugins init container but was %v", initContainers)
+	}
+	esContainer := podTemplateSpec.Spec.Containers[0]
+	if initContainers[0].Image != esContainer.Image {
+		t.Errorf("Exp. the init container to run the elasticsearch image %q but was %q", esContainer.Image, initContainers[0].Image)
+	}
+	expectedEnv := []v1.EnvVar{
+		{Name: "ES_PLUGINS", Value: "analysis-icu https://mirror.example.com/repository-s3-6.8.1.zip"},
+		{Name: "PLUGINS_PATH", Value: "/elasticsearch/plugins"},
+	}
+	if diff := cmp.Diff(initContainers[0].Env, expectedEnv); diff != "" {
+		t.Errorf("Unexpected plugins init container env: %s", diff)
+	}
 
-	tests := []struct {
-		desc string
-		node api.ElasticsearchNode
-		vs   v1.VolumeSource
-		pvc  *v1.PersistentVolumeClaim
-	}{
-		{
-			desc: "ephemeral storage on empty storage spec",
-			node: api.ElasticsearchNode{},
-			vs: v1.VolumeSource{
-				EmptyDir: &v1.EmptyDirVolumeSource{},
-			},
-		},
-		{
-			desc: "persistent storage with default storage class",
-			node: api.ElasticsearchNode{
+	volumes := map[string]v1.Volume{}
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	if volumes["elasticsearch-plugins"].EmptyDir == nil {
+		t.Errorf("Exp. the plugins to be installed to an emptyDir but was %v", volumes["elasticsearch-plugins"])
+	}
+
+	expected := v1.VolumeMount{
+		Name:      "elasticsearch-plugins",
+		MountPath: "/usr/share/elasticsearch/plugins",
+		ReadOnly:  true,
+	}
+	found := false
+	for _, mount := range esContainer.VolumeMounts {
+		if mount.Name == expected.Name {
+			found = true
+			if diff := cmp.Diff(mount, expected); diff != "" {
+				t.Errorf("Unexpected plugins mount: %s", diff)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Exp. the plugins to be mounted into the elasticsearch container but was %v", esContainer.VolumeMounts)
+	}
+}
+
+func TestPluginsScriptInstallsPlugins(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell available")
+	}
+
+	dir := t.TempDir()
+	home, pluginsPath := filepath.Join(dir, "home"), filepath.Join(dir, "plugins")
+	for _, d := range []string{filepath.Join(home, "bin"), filepath.Join(home, "plugins", "opendistro_security"), pluginsPath} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the stub installs a plugin as a directory named after the last path element
+	stub := `#!/bin/sh
+[ "$1" = install ] && [ "$2" = --batch ] || exit 1
+mkdir "${ES_HOME}/plugins/$(basename "$3" .zip)"
+`
+	if err := os.WriteFile(filepath.Join(home, "bin", "elasticsearch-plugin"), []byte(stub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	container := newPluginsInitContainer("elasticsearch", v1.PullIfNotPresent, []string{"analysis-icu", "file:///mirror/repository-s3.zip"})
+	cmd := exec.Command(sh, container.Command[1:]...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "ES_HOME=" + home, "PLUGINS_PATH=" + pluginsPath}
+	for _, env := range container.Env {
+		if env.Name == "ES_PLUGINS" {
+			cmd.Env = append(cmd.Env, "ES_PLUGINS="+env.Value)
+		}
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("plugins script failed: %s: %s", err, out)
+	}
+
+	for _, plugin := range []string{"opendistro_security", "analysis-icu", "repository-s3"} {
+		if _, err := os.Stat(filepath.Join(pluginsPath, plugin)); err != nil {
+			t.Errorf("Exp. the plugin %s to be copied to the plugins volume: %v", plugin, err)
+		}
+	}
+}
+
+func TestPodSpecExtraVolumes(t *testing.T) {
+	nfsVolume := v1.Volume{
+		Name:         "backup",
+		VolumeSource: v1.VolumeSource{NFS: &v1.NFSVolumeSource{Server: "nfs.example.com", Path: "/exports/backup"}},
+	}
+	backupMount := v1.VolumeMount{Name: "backup", MountPath: "/elasticsearch/backup"}
+	commonSpec := api.ElasticsearchNodeSpec{
+		ExtraVolumes:      []v1.Volume{nfsVolume},
+		ExtraVolumeMounts: []v1.VolumeMount{backupMount},
+	}
+
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	volumes := podTemplateSpec.Spec.Volumes
+	if diff := cmp.Diff(volumes[len(volumes)-1], nfsVolume); diff != "" {
+		t.Errorf("Exp. the extra volume to be appended: %s", diff)
+	}
+	mounts := podTemplateSpec.Spec.Containers[0].VolumeMounts
+	if diff := cmp.Diff(mounts[len(mounts)-1], backupMount); diff != "" {
+		t.Errorf("Exp. the extra volume mount to be appended: %s", diff)
+	}
+	if mounts[0].Name != dataVolumeName {
+		t.Errorf("Exp. the operator volume mounts to come first but was %v", mounts)
+	}
+}
+
+func TestPodSpecDNS(t *testing.T) {
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	if podTemplateSpec.Spec.DNSPolicy != "" || podTemplateSpec.Spec.DNSConfig != nil {
+		t.Errorf("Exp. the default dns settings but was %q and %v", podTemplateSpec.Spec.DNSPolicy, podTemplateSpec.Spec.DNSConfig)
+	}
+
+	ndots := "1"
+	dnsConfig := &v1.PodDNSConfig{
+		Nameservers: []string{"10.0.0.2"},
+		Options:     []v1.PodDNSConfigOption{{Name: "ndots", Value: &ndots}},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{DNSPolicy: v1.DNSNone, DNSConfig: dnsConfig}
+	podTemplateSpec = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	if podTemplateSpec.Spec.DNSPolicy != v1.DNSNone {
+		t.Errorf("Exp. the dns policy None but was %q", podTemplateSpec.Spec.DNSPolicy)
+	}
+	if diff := cmp.Diff(podTemplateSpec.Spec.DNSConfig, dnsConfig); diff != "" {
+		t.Errorf("Unexpected dns config: %s", diff)
+	}
+}
+
+func TestPodSpecTopologySpreadConstraints(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleMaster: true}
+	zoneConstraint := v1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       "topology.kubernetes.io/zone",
+		WhenUnsatisfiable: v1.DoNotSchedule,
+	}
+	commonSpec := api.ElasticsearchNodeSpec{
+		TopologySpreadConstraints: []v1.TopologySpreadConstraint{zoneConstraint},
+	}
+
+	podTemplateSpec := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, roleMap, nil, LogConfig{})
+	if len(podTemplateSpec.Spec.TopologySpreadConstraints) != 0 {
+		t.Errorf("Exp. no topology spread constraints by default but was %v", podTemplateSpec.Spec.TopologySpreadConstraints)
+	}
+
+	podTemplateSpec = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, roleMap, nil, LogConfig{})
+	expected := []v1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: v1.DoNotSchedule,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"cluster-name":   "test-cluster-name",
+					"es-node-master": "true",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(podTemplateSpec.Spec.TopologySpreadConstraints, expected); diff != "" {
+		t.Errorf("Unexpected topology spread constraints: %s", diff)
+	}
+	if commonSpec.TopologySpreadConstraints[0].LabelSelector != nil {
+		t.Errorf("Exp. the common spec not to be modified")
+	}
+
+	hostConstraint := v1.TopologySpreadConstraint{
+		MaxSkew:           2,
+		TopologyKey:       "kubernetes.io/hostname",
+		WhenUnsatisfiable: v1.ScheduleAnyway,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"node-name": "test-node-name"}},
+	}
+	node := api.ElasticsearchNode{
+		TopologySpreadConstraints: []v1.TopologySpreadConstraint{hostConstraint},
+	}
+	podTemplateSpec = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", node, commonSpec, map[string]string{}, roleMap, nil, LogConfig{})
+	if diff := cmp.Diff(podTemplateSpec.Spec.TopologySpreadConstraints, []v1.TopologySpreadConstraint{hostConstraint}); diff != "" {
+		t.Errorf("Exp. the node constraints to take precedence: %s", diff)
+	}
+}
+
+func TestElasticSearchSecurityContext(t *testing.T) {
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	expectedPod := &v1.PodSecurityContext{
+		RunAsNonRoot: pointer.Bool(true),
+		RunAsUser:    pointer.Int64(1000),
+	}
+
+	if diff := cmp.Diff(podTemplate.Spec.SecurityContext, expectedPod); diff != "" {
+		t.Errorf("ES pod SecurityContext error: %s", diff)
+	}
+
+	expectedES := &v1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		Capabilities: &v1.Capabilities{
+			Drop: []v1.Capability{"ALL"},
+		},
+	}
+
+	expectedProxy := &v1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		ReadOnlyRootFilesystem:   pointer.BoolPtr(true),
+		Capabilities: &v1.Capabilities{
+			Drop: []v1.Capability{"ALL"},
+		},
+	}
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == "proxy" {
+			if diff := cmp.Diff(c.SecurityContext, expectedProxy); diff != "" {
+				t.Errorf("proxy container SecurityContext error: %s", diff)
+			}
+		}
+
+		if c.Name == "elasticsearch" {
+			if diff := cmp.Diff(c.SecurityContext, expectedES); diff != "" {
+				t.Errorf("elasticsearch container SecurityContext error: %s", diff)
+			}
+		}
+	}
+}
+
+func TestElasticSearchFSGroupWithPersistentVolumes(t *testing.T) {
+	size := resource.MustParse("10Gi")
+	dataNode := api.ElasticsearchNode{
+		Roles:    []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+		Workload: api.StatefulSetWorkload,
+		Storage:  api.ElasticsearchStorageSpec{Size: &size},
+	}
+	hostPath := v1.Volume{
+		Name:         "backup",
+		VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/mnt/backup"}},
+	}
+
+	tests := []struct {
+		desc    string
+		node    api.ElasticsearchNode
+		spec    api.ElasticsearchNodeSpec
+		fsGroup *int64
+	}{
+		{desc: "ephemeral storage"},
+		{desc: "persistent storage", node: dataNode, fsGroup: pointer.Int64(1000)},
+		{desc: "host path volume", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{hostPath}}, fsGroup: pointer.Int64(1000)},
+		{desc: "custom fsGroup", node: dataNode, spec: api.ElasticsearchNodeSpec{FSGroup: pointer.Int64(2000)}, fsGroup: pointer.Int64(2000)},
+		{desc: "custom fsGroup with ephemeral storage", spec: api.ElasticsearchNodeSpec{FSGroup: pointer.Int64(2000)}},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", test.node, test.spec, map[string]string{}, getNodeRoleMap(test.node), nil, LogConfig{})
+		if diff := cmp.Diff(test.fsGroup, podTemplate.Spec.SecurityContext.FSGroup); diff != "" {
+			t.Errorf("%s: unexpected fsGroup (-want +got):\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestElasticSearchCustomSecurityContext(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		PodSecurityContext: &v1.PodSecurityContext{
+			RunAsNonRoot: pointer.Bool(true),
+			FSGroup:      pointer.Int64(2000),
+		},
+		SecurityContext: &v1.SecurityContext{
+			RunAsUser:                pointer.Int64(1001),
+			AllowPrivilegeEscalation: pointer.Bool(false),
+		},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	if diff := cmp.Diff(podTemplate.Spec.SecurityContext, commonSpec.PodSecurityContext); diff != "" {
+		t.Errorf("ES pod SecurityContext error: %s", diff)
+	}
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name != "elasticsearch" {
+			continue
+		}
+		if c.SecurityContext == nil || c.SecurityContext.RunAsUser == nil || *c.SecurityContext.RunAsUser != 1001 {
+			t.Errorf("Exp. the elasticsearch container to run as user 1001 but was %v", c.SecurityContext)
+		}
+	}
+}
+
+// All pods created by Elasticsearch operator needs to be allocated to linux nodes.
+// See LOG-411
+func TestPodNodeSelectors(t *testing.T) {
+	var podSpec v1.PodSpec
+
+	// Create podSpecTemplate providing nil/empty node selectors, we expect the PodTemplateSpec.Spec selectors
+	// will contain only the linux allocation selector.
+	podSpec = preparePodTemplateSpecProvidingNodeSelectors(nil).Spec
+
+	if podSpec.NodeSelector == nil {
+		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	}
+	if len(podSpec.NodeSelector) != 1 {
+		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	}
+	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
+		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+	}
+
+	// Create podSpecTemplate providing some custom node selectors, we expect the PodTemplateSpec.Spec selectors
+	// will add linux node selector.
+	podSpec = preparePodTemplateSpecProvidingNodeSelectors(map[string]string{"foo": "bar", "baz": "foo"}).Spec
+
+	if podSpec.NodeSelector == nil {
+		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	}
+	if len(podSpec.NodeSelector) != 3 {
+		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	}
+	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
+		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+	}
+
+	// Create podSpecTemplate providing node selector with some custom value, we expect the PodTemplateSpec.Spec selector
+	// will override the node selector to linux one.
+	podSpec = preparePodTemplateSpecProvidingNodeSelectors(map[string]string{utils.OsNodeLabel: "foo"}).Spec
+
+	if podSpec.NodeSelector == nil {
+		t.Errorf("Exp. the nodeSelector to contains the linux allocation selector but was %T", podSpec.NodeSelector)
+	}
+	if len(podSpec.NodeSelector) != 1 {
+		t.Errorf("Exp. single nodeSelector but %d were found", len(podSpec.NodeSelector))
+	}
+	if podSpec.NodeSelector[utils.OsNodeLabel] != utils.LinuxValue {
+		t.Errorf("Exp. the nodeSelector to contains %s: %s pair", utils.OsNodeLabel, utils.LinuxValue)
+	}
+}
+
+func TestPodDiskToleration(t *testing.T) {
+	expectedToleration := []v1.Toleration{
+		{
+			Key:      "node.kubernetes.io/disk-pressure",
+			Operator: v1.TolerationOpExists,
+			Effect:   v1.TaintEffectNoSchedule,
+		},
+	}
+
+	// Create podSpecTemplate providing nil/empty tolerations, we expect the PodTemplateSpec.Spec tolerations
+	// will contain only the disk usage toleration
+	podSpec := preparePodTemplateSpecProvidingNodeSelectors(nil).Spec
+
+	if podSpec.Tolerations == nil {
+		t.Errorf("Exp. the toleration to contains the disk usage toleration but was %T", podSpec.Tolerations)
+	}
+	if len(podSpec.Tolerations) != 1 {
+		t.Errorf("Exp. single toleration but %d were found", len(podSpec.Tolerations))
+	}
+	if !comparators.AreTolerationsSame(podSpec.Tolerations, expectedToleration) {
+		t.Errorf("Exp. the tolerations to contain %v", expectedToleration)
+	}
+}
+
+func TestNewVolumeSource(t *testing.T) {
+	const (
+		clusterName = "elastisearch"
+		nodeName    = "elasticsearch-cdm-1"
+		namespace   = "openshift-logging"
+	)
+
+	var (
+		claimName   = fmt.Sprintf("%s-%s", clusterName, nodeName)
+		gp2SCName   = "gp2"
+		storageSize = resource.MustParse("2Gi")
+	)
+
+	tests := []struct {
+		desc string
+		node api.ElasticsearchNode
+		vs   v1.VolumeSource
+		pvc  *v1.PersistentVolumeClaim
+	}{
+		{
+			desc: "ephemeral storage on empty storage spec",
+			node: api.ElasticsearchNode{},
+			vs: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			desc: "ephemeral storage with memory medium and size limit",
+			node: api.ElasticsearchNode{
+				Storage: api.ElasticsearchStorageSpec{
+					EmptyDir: &v1.EmptyDirVolumeSource{
+						Medium:    v1.StorageMediumMemory,
+						SizeLimit: &storageSize,
+					},
+				},
+			},
+			vs: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{
+					Medium:    v1.StorageMediumMemory,
+					SizeLimit: &storageSize,
+				},
+			},
+		},
+		{
+			desc: "persistent storage with default storage class",
+			node: api.ElasticsearchNode{
 				Storage: api.ElasticsearchStorageSpec{
 					Size: &storageSize,
 				},
@@ -815,91 +1582,1475 @@ func TestNewVolumeSource(t *testing.T) {
 	}
 }
 
-// Return a fresh new PodTemplateSpec using provided node selectors.
-// Resulting selectors set always contains also the node selector with value of "linux", see LOG-411
-// This function wraps the call to newPodTemplateSpec in case its signature changes in the future
-// so that keeping unit tests up to date will be easier.
-func preparePodTemplateSpecProvidingNodeSelectors(selectors map[string]string) v1.PodTemplateSpec {
-	return newPodTemplateSpec(
-		context.Background(),
-		log.NewLogger("common-testing"),
-		"test-node-name",
-		"test-cluster-name",
-		"test-namespace-name",
-		api.ElasticsearchNode{NodeSelector: selectors},
-		api.ElasticsearchNodeSpec{},
-		map[string]string{},
-		map[api.ElasticsearchNodeRole]bool{},
-		nil,
+func TestImagePullPolicy(t *testing.T) {
+	tests := []struct {
+		image    string
+		policy   v1.PullPolicy
+		expected v1.PullPolicy
+	}{
+		{image: "quay.io/openshift-logging/elasticsearch6:6.8.1", expected: v1.PullIfNotPresent},
+		{image: "registry:5000/elasticsearch6@sha256:0123456789abcdef", expected: v1.PullIfNotPresent},
+		{image: "quay.io/openshift-logging/elasticsearch6:latest", expected: v1.PullAlways},
+		{image: "registry:5000/elasticsearch6", expected: v1.PullAlways},
+		{image: "quay.io/openshift-logging/elasticsearch6", policy: v1.PullNever, expected: v1.PullNever},
+	}
+
+	for _, test := range tests {
+		if got := getImagePullPolicy(test.image, test.policy); got != test.expected {
+			t.Errorf("Exp. pull policy %q for image %q and policy %q but was %q", test.expected, test.image, test.policy, got)
+		}
+	}
+}
+
+func TestNodeImagePrecedence(t *testing.T) {
+	t.Setenv("RELATED_IMAGE_ELASTICSEARCH", "quay.io/openshift-logging/elasticsearch6:6.8.1")
+
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		expected   string
+	}{
+		{desc: "operator default", expected: "quay.io/openshift-logging/elasticsearch6:6.8.1"},
+		{desc: "cluster", commonSpec: api.ElasticsearchNodeSpec{Image: "registry:5000/elasticsearch6:6.8.1-1"}, expected: "registry:5000/elasticsearch6:6.8.1-1"},
+		{desc: "node precedence", node: api.ElasticsearchNode{Image: "registry:5000/elasticsearch6:canary"}, commonSpec: api.ElasticsearchNodeSpec{Image: "registry:5000/elasticsearch6:6.8.1-1"}, expected: "registry:5000/elasticsearch6:canary"},
+		{desc: "node over operator default", node: api.ElasticsearchNode{Image: "registry:5000/elasticsearch6:canary"}, expected: "registry:5000/elasticsearch6:canary"},
+	}
+
+	for _, test := range tests {
+		test.commonSpec.Keystore = &api.ElasticsearchKeystoreSpec{SecretName: "es-secure-settings"}
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", test.node, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		if got := podTemplate.Spec.Containers[0].Image; got != test.expected {
+			t.Errorf("%s: Exp. the elasticsearch image %q but was %q", test.desc, test.expected, got)
+		}
+		if got := podTemplate.Spec.InitContainers[0].Image; got != test.expected {
+			t.Errorf("%s: Exp. the init container to run the elasticsearch image %q but was %q", test.desc, test.expected, got)
+		}
+		if got := getNodeImage(test.node, test.commonSpec); got != test.expected {
+			t.Errorf("%s: Exp. the resolved image %q but was %q", test.desc, test.expected, got)
+		}
+	}
+}
+
+func TestHeapSizeEnvVar(t *testing.T) {
+	commonHeap := resource.MustParse("4Gi")
+	nodeHeap := resource.MustParse("8Gi")
+	decimalHeap := resource.MustParse("1G")
+
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		expected   string
+	}{
+		{desc: "unset"},
+		{desc: "common", commonSpec: api.ElasticsearchNodeSpec{HeapSize: &commonHeap}, expected: "-Xms4096m -Xmx4096m"},
+		{desc: "node precedence", node: api.ElasticsearchNode{HeapSize: &nodeHeap}, commonSpec: api.ElasticsearchNodeSpec{HeapSize: &commonHeap}, expected: "-Xms8192m -Xmx8192m"},
+		{desc: "rounded down", node: api.ElasticsearchNode{HeapSize: &decimalHeap}, expected: "-Xms953m -Xmx953m"},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", test.node, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		value := ""
+		for _, env := range podTemplate.Spec.Containers[0].Env {
+			if env.Name == "ES_JAVA_OPTS" {
+				value = env.Value
+			}
+		}
+
+		if value != test.expected {
+			t.Errorf("%s: Exp. ES_JAVA_OPTS to be %q but was %q", test.desc, test.expected, value)
+		}
+	}
+}
+
+func TestHeapMemoryLimitDefault(t *testing.T) {
+	heapSize := resource.MustParse("4Gi")
+	smallHeapSize := resource.MustParse("256Mi")
+
+	tests := []struct {
+		desc          string
+		node          api.ElasticsearchNode
+		commonSpec    api.ElasticsearchNodeSpec
+		memoryLimit   string
+		memoryRequest string
+	}{
+		{
+			desc:          "no heap size",
+			node:          api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}},
+			memoryLimit:   defaultESMemoryLimit,
+			memoryRequest: defaultESMemoryRequest,
+		},
+		{
+			desc:          "default multiplier",
+			node:          api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, HeapSize: &heapSize},
+			memoryLimit:   "8Gi",
+			memoryRequest: defaultESMemoryRequest,
+		},
+		{
+			desc:          "configured multiplier",
+			node:          api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}},
+			commonSpec:    api.ElasticsearchNodeSpec{HeapSize: &heapSize, HeapMemoryMultiplier: pointer.Int32(3)},
+			memoryLimit:   "12Gi",
+			memoryRequest: defaultESMemoryRequest,
+		},
+		{
+			desc:          "request capped by the limit",
+			node:          api.ElasticsearchNode{HeapSize: &smallHeapSize},
+			memoryLimit:   "512Mi",
+			memoryRequest: "512Mi",
+		},
+		{
+			desc: "explicit memory limit",
+			node: api.ElasticsearchNode{HeapSize: &heapSize},
+			commonSpec: api.ElasticsearchNodeSpec{
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("6Gi")},
+				},
+			},
+			memoryLimit:   "6Gi",
+			memoryRequest: "6Gi",
+		},
+	}
+
+	for _, test := range tests {
+		actual := newESNodeResourceRequirements(test.node, test.commonSpec)
+
+		if got := actual.Limits[v1.ResourceMemory]; got.Cmp(resource.MustParse(test.memoryLimit)) != 0 {
+			t.Errorf("%s: expected memory limit %s but got %s", test.desc, test.memoryLimit, got.String())
+		}
+		if got := actual.Requests[v1.ResourceMemory]; got.Cmp(resource.MustParse(test.memoryRequest)) != 0 {
+			t.Errorf("%s: expected memory request %s but got %s", test.desc, test.memoryRequest, got.String())
+		}
+	}
+}
+
+func TestGCLoggingJavaOpts(t *testing.T) {
+	heapSize := resource.MustParse("4Gi")
+
+	tests := []struct {
+		desc       string
+		commonSpec api.ElasticsearchNodeSpec
+		expected   string
+	}{
+		{desc: "disabled"},
+		{
+			desc:       "log directory",
+			commonSpec: api.ElasticsearchNodeSpec{GCLogging: true},
+			expected:   "-Xlog:gc*,gc+age=trace,safepoint:file=/elasticsearch/persistent/test-cluster-name/logs/gc.log:utctime,pid,tags:filecount=8,filesize=64m",
+		},
+		{
+			desc:       "dedicated volume",
+			commonSpec: api.ElasticsearchNodeSpec{GCLogging: true, GCLogStorage: &api.ElasticsearchStorageSpec{}},
+			expected:   "-Xlog:gc*,gc+age=trace,safepoint:file=/elasticsearch/gclogs/gc.log:utctime,pid,tags:filecount=8,filesize=64m",
+		},
+		{
+			desc:       "appended to the heap size",
+			commonSpec: api.ElasticsearchNodeSpec{GCLogging: true, HeapSize: &heapSize},
+			expected:   "-Xms4096m -Xmx4096m -Xlog:gc*,gc+age=trace,safepoint:file=/elasticsearch/persistent/test-cluster-name/logs/gc.log:utctime,pid,tags:filecount=8,filesize=64m",
+		},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		value := ""
+		for _, env := range podTemplate.Spec.Containers[0].Env {
+			if env.Name == "ES_JAVA_OPTS" {
+				value = env.Value
+			}
+		}
+		if value != test.expected {
+			t.Errorf("%s: Exp. ES_JAVA_OPTS to be %q but was %q", test.desc, test.expected, value)
+		}
+
+		mounted := false
+		for _, mount := range podTemplate.Spec.Containers[0].VolumeMounts {
+			if mount.Name == "elasticsearch-gclogs" {
+				mounted = mount.MountPath == gcLogVolumePath
+			}
+		}
+		if want := test.commonSpec.GCLogStorage != nil; mounted != want {
+			t.Errorf("%s: Exp. the gc log volume to be mounted: %t", test.desc, want)
+		}
+	}
+}
+
+func TestReadinessProbeDefault(t *testing.T) {
+	probe := newReadinessProbe(nil, getPorts(api.ElasticsearchNodeSpec{}))
+
+	if probe.Exec == nil || len(probe.Exec.Command) != 1 || probe.Exec.Command[0] != readinessProbeScript {
+		t.Errorf("Exp. the default readiness probe to exec %q but was %v", readinessProbeScript, probe.ProbeHandler)
+	}
+	if probe.TimeoutSeconds != 30 || probe.InitialDelaySeconds != 10 || probe.PeriodSeconds != 5 {
+		t.Errorf("Exp. the default readiness probe timings to be 30/10/5 but were %d/%d/%d",
+			probe.TimeoutSeconds, probe.InitialDelaySeconds, probe.PeriodSeconds)
+	}
+}
+
+func TestReadinessProbeTCPCustomPort(t *testing.T) {
+	probe := newReadinessProbe(&api.ElasticsearchProbeSpec{
+		Type:             api.ProbeTypeTCP,
+		Port:             pointer.Int32(9301),
+		TimeoutSeconds:   pointer.Int32(5),
+		FailureThreshold: pointer.Int32(6),
+	}, getPorts(api.ElasticsearchNodeSpec{}))
+
+	if probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != 9301 {
+		t.Errorf("Exp. a tcp readiness probe on port 9301 but was %v", probe.ProbeHandler)
+	}
+	if probe.Exec != nil {
+		t.Errorf("Exp. no exec action for a tcp readiness probe")
+	}
+	if probe.TimeoutSeconds != 5 || probe.FailureThreshold != 6 {
+		t.Errorf("Exp. timeout 5 and failure threshold 6 but were %d and %d", probe.TimeoutSeconds, probe.FailureThreshold)
+	}
+	if probe.InitialDelaySeconds != 10 {
+		t.Errorf("Exp. the default initial delay of 10 but was %d", probe.InitialDelaySeconds)
+	}
+}
+
+// isHTTPProbe returns true if the probe requests the cluster health on the port
+func isHTTPProbe(probe *v1.Probe, port int32) bool {
+	if probe == nil || probe.Exec == nil || len(probe.Exec.Command) != 3 {
+		return false
+	}
+	return probe.Exec.Command[2] == newHTTPProbeScript(port)
+}
+
+func TestReadinessProbeHTTPDefaultPort(t *testing.T) {
+	probe := newReadinessProbe(&api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP}, getPorts(api.ElasticsearchNodeSpec{}))
+
+	if !isHTTPProbe(probe, 9200) {
+		t.Errorf("Exp. a https readiness probe on 9200%s but was %v", clusterHealthPath, probe.ProbeHandler)
+	}
+}
+
+func TestNodeReadinessProbeByRole(t *testing.T) {
+	ports := getPorts(api.ElasticsearchNodeSpec{})
+	tests := []struct {
+		desc      string
+		roles     []api.ElasticsearchNodeRole
+		probe     *api.ElasticsearchProbeSpec
+		probeType api.ElasticsearchProbeType
+		port      int
+	}{
+		{desc: "coordinating", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}, probeType: api.ProbeTypeTCP, port: 9200},
+		{desc: "data", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData}, probeType: api.ProbeTypeExec},
+		{desc: "master", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, probeType: api.ProbeTypeExec},
+		{
+			desc:      "coordinating with probe type",
+			roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient},
+			probe:     &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP},
+			probeType: api.ProbeTypeHTTP,
+			port:      9200,
+		},
+		{
+			desc:      "coordinating with probe settings",
+			roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient},
+			probe:     &api.ElasticsearchProbeSpec{FailureThreshold: pointer.Int32(6)},
+			probeType: api.ProbeTypeTCP,
+			port:      9200,
+		},
+	}
+
+	for _, test := range tests {
+		node := api.ElasticsearchNode{Roles: test.roles, ReadinessProbe: test.probe}
+		probe := newNodeReadinessProbe(node, api.ElasticsearchNodeSpec{}, ports)
+
+		switch test.probeType {
+		case api.ProbeTypeTCP:
+			if probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != test.port {
+				t.Errorf("%s: Exp. a tcp readiness probe on port %d but was %v", test.desc, test.port, probe.ProbeHandler)
+			}
+		case api.ProbeTypeHTTP:
+			if !isHTTPProbe(probe, int32(test.port)) {
+				t.Errorf("%s: Exp. a http readiness probe on port %d but was %v", test.desc, test.port, probe.ProbeHandler)
+			}
+		default:
+			if probe.Exec == nil {
+				t.Errorf("%s: Exp. the readiness script but was %v", test.desc, probe.ProbeHandler)
+			}
+		}
+		if test.probe != nil && test.probe.FailureThreshold != nil && probe.FailureThreshold != *test.probe.FailureThreshold {
+			t.Errorf("%s: Exp. failure threshold %d but was %d", test.desc, *test.probe.FailureThreshold, probe.FailureThreshold)
+		}
+	}
+}
+
+func TestNodeReadinessProbeCustomPorts(t *testing.T) {
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		roles []api.ElasticsearchNodeRole
+		port  int
+	}{
+		{desc: "custom transport port", spec: api.ElasticsearchNodeSpec{TransportPort: 9301}, roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData, api.ElasticsearchRoleMaster}, port: 9301},
+		{desc: "custom http port", spec: api.ElasticsearchNodeSpec{HTTPPort: 9201}, roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, port: 9300},
+		{desc: "coordinating", spec: api.ElasticsearchNodeSpec{HTTPPort: 9201}, roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}, port: 9201},
+	}
+
+	for _, test := range tests {
+		node := api.ElasticsearchNode{Roles: test.roles}
+		probe := newNodeReadinessProbe(node, test.spec, getPorts(test.spec))
+
+		if probe.Exec != nil {
+			t.Errorf("%s: Exp. no readiness script for custom ports but was %v", test.desc, probe.Exec)
+		}
+		if probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != test.port {
+			t.Errorf("%s: Exp. a tcp readiness probe on port %d but was %v", test.desc, test.port, probe.ProbeHandler)
+		}
+	}
+}
+
+func TestLivenessProbeDefault(t *testing.T) {
+	liveness := newLivenessProbe(nil, getPorts(api.ElasticsearchNodeSpec{}))
+	readiness := newReadinessProbe(nil, getPorts(api.ElasticsearchNodeSpec{}))
+
+	if liveness.TCPSocket == nil || liveness.TCPSocket.Port.IntValue() != 9300 {
+		t.Errorf("Exp. the default liveness probe to check the transport port but was %v", liveness.ProbeHandler)
+	}
+	if liveness.FailureThreshold <= readiness.FailureThreshold {
+		t.Errorf("Exp. the liveness failure threshold %d to be higher than the readiness one %d", liveness.FailureThreshold, readiness.FailureThreshold)
+	}
+	if liveness.InitialDelaySeconds <= readiness.InitialDelaySeconds {
+		t.Errorf("Exp. the liveness initial delay %d to be higher than the readiness one %d", liveness.InitialDelaySeconds, readiness.InitialDelaySeconds)
+	}
+}
+
+func TestLivenessProbeIndependentOfReadiness(t *testing.T) {
+	node := api.ElasticsearchNode{
+		ReadinessProbe: &api.ElasticsearchProbeSpec{FailureThreshold: pointer.Int32(2)},
+		LivenessProbe:  &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP, FailureThreshold: pointer.Int32(20)},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", node, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name != "elasticsearch" {
+			continue
+		}
+		if c.ReadinessProbe == nil || c.ReadinessProbe.Exec == nil || c.ReadinessProbe.FailureThreshold != 2 {
+			t.Errorf("Exp. an exec readiness probe with failure threshold 2 but was %v", c.ReadinessProbe)
+		}
+		if !isHTTPProbe(c.LivenessProbe, 9200) || c.LivenessProbe.FailureThreshold != 20 {
+			t.Errorf("Exp. a http liveness probe with failure threshold 20 but was %v", c.LivenessProbe)
+		}
+		if c.LivenessProbe.InitialDelaySeconds != defaultLivenessProbeInitialDelaySeconds {
+			t.Errorf("Exp. the default liveness initial delay but was %d", c.LivenessProbe.InitialDelaySeconds)
+		}
+	}
+}
+
+func TestReadinessProbeNodeTakesPrecedence(t *testing.T) {
+	node := api.ElasticsearchNode{
+		ReadinessProbe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeTCP, Port: pointer.Int32(9400)},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{
+		ReadinessProbe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeTCP, Port: pointer.Int32(9500)},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", node, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name != "elasticsearch" {
+			continue
+		}
+		if c.ReadinessProbe == nil || c.ReadinessProbe.TCPSocket == nil || c.ReadinessProbe.TCPSocket.Port.IntValue() != 9400 {
+			t.Errorf("Exp. the node readiness probe on port 9400 but was %v", c.ReadinessProbe)
+		}
+	}
+
+	podTemplate = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name != "elasticsearch" {
+			continue
+		}
+		if c.ReadinessProbe == nil || c.ReadinessProbe.TCPSocket == nil || c.ReadinessProbe.TCPSocket.Port.IntValue() != 9500 {
+			t.Errorf("Exp. the common readiness probe on port 9500 but was %v", c.ReadinessProbe)
+		}
+	}
+}
+
+func TestStartupProbeDisabledByDefault(t *testing.T) {
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.StartupProbe != nil {
+			t.Errorf("Exp. no startup probe for container %q but was %v", c.Name, c.StartupProbe)
+		}
+	}
+}
+
+func TestStartupProbeDefaults(t *testing.T) {
+	probe := newStartupProbe(&api.ElasticsearchProbeSpec{}, getPorts(api.ElasticsearchNodeSpec{}))
+
+	if probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != 9200 {
+		t.Errorf("Exp. the default startup probe to check the http port but was %v", probe.ProbeHandler)
+	}
+	if probe.PeriodSeconds*probe.FailureThreshold != 600 {
+		t.Errorf("Exp. the default startup probe to allow 600 seconds to start but was %d", probe.PeriodSeconds*probe.FailureThreshold)
+	}
+}
+
+func TestStartupProbeHTTP(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		StartupProbe:  &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP, FailureThreshold: pointer.Int32(90)},
+		LivenessProbe: &api.ElasticsearchProbeSpec{InitialDelaySeconds: pointer.Int32(0)},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name != "elasticsearch" {
+			continue
+		}
+		probe := c.StartupProbe
+		if probe == nil {
+			t.Fatal("Exp. a http startup probe but was nil")
+		}
+		if !isHTTPProbe(probe, 9200) {
+			t.Errorf("Exp. a https startup probe on 9200%s but was %v", clusterHealthPath, probe.ProbeHandler)
+		}
+		if probe.FailureThreshold != 90 {
+			t.Errorf("Exp. a startup failure threshold of 90 but was %d", probe.FailureThreshold)
+		}
+		if c.LivenessProbe == nil || c.LivenessProbe.InitialDelaySeconds != 0 {
+			t.Errorf("Exp. the liveness probe to start right after the startup probe but was %v", c.LivenessProbe)
+		}
+	}
+}
+
+func TestStartupProbeNodeTakesPrecedence(t *testing.T) {
+	node := api.ElasticsearchNode{
+		StartupProbe: &api.ElasticsearchProbeSpec{Port: pointer.Int32(9300)},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{
+		StartupProbe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP},
+	}
+
+	probe := newStartupProbe(getStartupProbeSpec(node, commonSpec), getPorts(commonSpec))
+	if probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != 9300 {
+		t.Errorf("Exp. the node startup probe on port 9300 but was %v", probe.ProbeHandler)
+	}
+}
+
+func TestReadinessProbeTimeout(t *testing.T) {
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		want       string
+	}{
+		{desc: "default", want: "30"},
+		{
+			desc:       "startup probe keeps the default",
+			commonSpec: api.ElasticsearchNodeSpec{StartupProbe: &api.ElasticsearchProbeSpec{}},
+			want:       "30",
+		},
+		{
+			desc: "custom startup probe keeps the default",
+			node: api.ElasticsearchNode{
+				StartupProbe: &api.ElasticsearchProbeSpec{InitialDelaySeconds: pointer.Int32(30), FailureThreshold: pointer.Int32(90)},
+			},
+			want: "30",
+		},
+		{
+			desc: "common timeout",
+			commonSpec: api.ElasticsearchNodeSpec{
+				StartupProbe:                 &api.ElasticsearchProbeSpec{},
+				ReadinessProbeTimeoutSeconds: pointer.Int32(120),
+			},
+			want: "120",
+		},
+		{
+			desc:       "node timeout",
+			node:       api.ElasticsearchNode{ReadinessProbeTimeoutSeconds: pointer.Int32(45)},
+			commonSpec: api.ElasticsearchNodeSpec{ReadinessProbeTimeoutSeconds: pointer.Int32(120)},
+			want:       "45",
+		},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", test.node, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		got := ""
+		for _, env := range podTemplate.Spec.Containers[0].Env {
+			if env.Name == "READINESS_PROBE_TIMEOUT" {
+				got = env.Value
+			}
+		}
+		if got != test.want {
+			t.Errorf("%s: Exp. READINESS_PROBE_TIMEOUT %q but was %q", test.desc, test.want, got)
+		}
+
+		// the readiness probe itself keeps its steady-state timeout
+		if probe := podTemplate.Spec.Containers[0].ReadinessProbe; probe.TimeoutSeconds != defaultReadinessProbeTimeoutSeconds {
+			t.Errorf("%s: Exp. the readiness probe to time out after %d seconds but was %d", test.desc, defaultReadinessProbeTimeoutSeconds, probe.TimeoutSeconds)
+		}
+	}
+}
+
+func TestNewVolumeSourceStorageClassPerNode(t *testing.T) {
+	const (
+		clusterName = "elasticsearch"
+		namespace   = "openshift-logging"
+	)
+
+	var (
+		fastSC      = "fast-ssd"
+		cheapSC     = "standard"
+		storageSize = resource.MustParse("2Gi")
+	)
+
+	client := fake.NewFakeClient(&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: fastSC}})
+
+	nodes := map[string]api.ElasticsearchNode{
+		"elasticsearch-cdm-data-1": {
+			Roles:   []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+			Storage: api.ElasticsearchStorageSpec{StorageClassName: &fastSC, Size: &storageSize},
+		},
+		"elasticsearch-cm-master": {
+			Roles:   []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+			Storage: api.ElasticsearchStorageSpec{StorageClassName: &cheapSC, Size: &storageSize},
+		},
+	}
+
+	for nodeName, node := range nodes {
+		claimName := fmt.Sprintf("%s-%s", clusterName, nodeName)
+
+		vs := newVolumeSource(context.Background(), log.NewLogger("common-testing"), clusterName, nodeName, namespace, node, client)
+		if vs.PersistentVolumeClaim == nil || vs.PersistentVolumeClaim.ClaimName != claimName {
+			t.Errorf("Exp. claim %q for node %q but was %v", claimName, nodeName, vs)
+		}
+
+		pvc := &v1.PersistentVolumeClaim{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: claimName, Namespace: namespace}, pvc); err != nil {
+			t.Fatalf("got err: %s, want nil", err)
+		}
+		if diff := cmp.Diff(pvc.Spec.StorageClassName, node.Storage.StorageClassName); diff != "" {
+			t.Errorf("Unexpected storage class for node %q: %s", nodeName, diff)
+		}
+	}
+
+	exists, err := storageClassExists(context.TODO(), client, fastSC)
+	if err != nil || !exists {
+		t.Errorf("Exp. storage class %q to exist, got %t, %v", fastSC, exists, err)
+	}
+	exists, err = storageClassExists(context.TODO(), client, cheapSC)
+	if err != nil || exists {
+		t.Errorf("Exp. storage class %q not to exist, got %t, %v", cheapSC, exists, err)
+	}
+}
+
+func TestVolumeClaimAnnotations(t *testing.T) {
+	const (
+		clusterName = "elasticsearch"
+		nodeName    = "cdm-1"
+		claimName   = "elasticsearch-cdm-1"
+		namespace   = "openshift-logging"
+	)
+
+	storageSize := resource.MustParse("2Gi")
+	node := api.ElasticsearchNode{
+		Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+		Storage: api.ElasticsearchStorageSpec{
+			Size:        &storageSize,
+			Annotations: map[string]string{"backup.example.com/enabled": "true"},
+		},
+	}
+
+	client := fake.NewFakeClient()
+	newVolumeSource(context.Background(), log.NewLogger("common-testing"), clusterName, nodeName, namespace, node, client)
+
+	key := types.NamespacedName{Name: claimName, Namespace: namespace}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := client.Get(context.TODO(), key, pvc); err != nil {
+		t.Fatalf("got err: %s, want nil", err)
+	}
+	if diff := cmp.Diff(node.Storage.Annotations, pvc.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations of the created claim: %s", diff)
+	}
+
+	// annotations added by others are kept when the desired ones change
+	pvc.Annotations["pv.kubernetes.io/bind-completed"] = "yes"
+	if err := client.Update(context.TODO(), pvc); err != nil {
+		t.Fatalf("got err: %s, want nil", err)
+	}
+	node.Storage.Annotations = map[string]string{"backup.example.com/enabled": "false"}
+	newVolumeSource(context.Background(), log.NewLogger("common-testing"), clusterName, nodeName, namespace, node, client)
+
+	if err := client.Get(context.TODO(), key, pvc); err != nil {
+		t.Fatalf("got err: %s, want nil", err)
+	}
+	want := map[string]string{
+		"backup.example.com/enabled":      "false",
+		"pv.kubernetes.io/bind-completed": "yes",
+	}
+	if diff := cmp.Diff(want, pvc.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations of the updated claim: %s", diff)
+	}
+
+	node.Workload = api.StatefulSetWorkload
+	templates := newVolumeClaimTemplates(clusterName, node, api.ElasticsearchNodeSpec{})
+	if len(templates) != 1 {
+		t.Fatalf("Exp. one claim template but got %d", len(templates))
+	}
+	if diff := cmp.Diff(node.Storage.Annotations, templates[0].Annotations); diff != "" {
+		t.Errorf("Unexpected annotations of the claim template: %s", diff)
+	}
+}
+
+func TestHeapDumpAndGCLogClaimsPerReplica(t *testing.T) {
+	size := resource.MustParse("2Gi")
+	commonSpec := api.ElasticsearchNodeSpec{
+		HeapDump:     &api.ElasticsearchHeapDumpSpec{Storage: &api.ElasticsearchStorageSpec{Size: &size}},
+		GCLogging:    true,
+		GCLogStorage: &api.ElasticsearchStorageSpec{Size: &size},
+	}
+
+	tests := []struct {
+		desc          string
+		workload      api.ElasticsearchNodeWorkload
+		wantTemplates []string
+	}{
+		{desc: "statefulset", workload: api.StatefulSetWorkload, wantTemplates: []string{heapDumpVolumeName, gcLogVolumeName}},
+		{desc: "deployment", workload: api.DeploymentWorkload},
+	}
+
+	for _, test := range tests {
+		node := api.ElasticsearchNode{
+			Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+			NodeCount: 3,
+			Workload:  test.workload,
+		}
+
+		var names []string
+		for _, template := range newVolumeClaimTemplates("elasticsearch", node, commonSpec) {
+			names = append(names, template.Name)
+		}
+		if diff := cmp.Diff(test.wantTemplates, names); diff != "" {
+			t.Errorf("%s: unexpected claim templates: %s", test.desc, diff)
+		}
+
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "elasticsearch-cd-1", "elasticsearch", "openshift-logging", node, commonSpec, map[string]string{}, getNodeRoleMap(node), fake.NewFakeClient(), LogConfig{})
+		for _, name := range []string{heapDumpVolumeName, gcLogVolumeName} {
+			hasVolume := false
+			for _, volume := range podTemplate.Spec.Volumes {
+				hasVolume = hasVolume || volume.Name == name
+			}
+			if want := test.wantTemplates == nil; hasVolume != want {
+				t.Errorf("%s: Exp. a pod volume %s: %t", test.desc, name, want)
+			}
+
+			hasMount := false
+			for _, mount := range podTemplate.Spec.Containers[0].VolumeMounts {
+				hasMount = hasMount || mount.Name == name
+			}
+			if !hasMount {
+				t.Errorf("%s: Exp. the volume %s to be mounted", test.desc, name)
+			}
+		}
+	}
+}
+
+func TestExpandPersistentVolumeClaim(t *testing.T) {
+	const (
+		claimName = "elasticsearch-elasticsearch-cdm-1"
+		namespace = "openshift-logging"
+	)
+
+	newClaim := func(scName string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace},
+			Spec: v1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		}
+	}
+	newStorageClass := func(name string, allowExpansion bool) *storagev1.StorageClass {
+		return &storagev1.StorageClass{
+			ObjectMeta:           metav1.ObjectMeta{Name: name},
+			AllowVolumeExpansion: pointer.Bool(allowExpansion),
+		}
+	}
+
+	tests := []struct {
+		desc     string
+		sc       *storagev1.StorageClass
+		size     string
+		wantErr  bool
+		wantSize string
+	}{
+		{desc: "expand allowed", sc: newStorageClass("expandable", true), size: "20Gi", wantSize: "20Gi"},
+		{desc: "expand forbidden", sc: newStorageClass("fixed", false), size: "20Gi", wantErr: true, wantSize: "10Gi"},
+		{desc: "shrink", sc: newStorageClass("expandable", true), size: "5Gi", wantErr: true, wantSize: "10Gi"},
+		{desc: "unchanged", sc: newStorageClass("fixed", false), size: "10Gi", wantSize: "10Gi"},
+	}
+
+	for _, test := range tests {
+		client := fake.NewFakeClient(newClaim(test.sc.Name), test.sc)
+
+		err := expandPersistentVolumeClaim(context.TODO(), client, claimName, namespace, resource.MustParse(test.size))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got err %v, want error %t", test.desc, err, test.wantErr)
+		}
+
+		pvc := &v1.PersistentVolumeClaim{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: claimName, Namespace: namespace}, pvc); err != nil {
+			t.Fatalf("%s: got err: %s, want nil", test.desc, err)
+		}
+		want := resource.MustParse(test.wantSize)
+		if got := pvc.Spec.Resources.Requests.Storage(); got.Cmp(want) != 0 {
+			t.Errorf("%s: got claim size %s, want %s", test.desc, got.String(), test.wantSize)
+		}
+	}
+}
+
+// Return a fresh new PodTemplateSpec using provided node selectors.
+// Resulting selectors set always contains also the node selector with value of "linux", see LOG-411
+// This function wraps the call to newPodTemplateSpec in case its signature changes in the future
+// so that keeping unit tests up to date will be easier.
+func preparePodTemplateSpecProvidingNodeSelectors(selectors map[string]string) v1.PodTemplateSpec {
+	return newPodTemplateSpec(
+		context.Background(),
+		log.NewLogger("common-testing"),
+		"test-node-name",
+		"test-cluster-name",
+		"test-namespace-name",
+		api.ElasticsearchNode{NodeSelector: selectors},
+		api.ElasticsearchNodeSpec{},
+		map[string]string{},
+		map[api.ElasticsearchNodeRole]bool{},
+		nil,
 		LogConfig{})
 }
 
-func buildResource(cpuLimit, cpuRequest, memLimit, memRequest resource.Quantity) v1.ResourceRequirements {
-	return v1.ResourceRequirements{
-		Limits: v1.ResourceList{
-			v1.ResourceCPU:    cpuLimit,
-			v1.ResourceMemory: memLimit,
-		},
-		Requests: v1.ResourceList{
-			v1.ResourceCPU:    cpuRequest,
-			v1.ResourceMemory: memRequest,
-		},
+func buildResource(cpuLimit, cpuRequest, memLimit, memRequest resource.Quantity) v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    cpuLimit,
+			v1.ResourceMemory: memLimit,
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    cpuRequest,
+			v1.ResourceMemory: memRequest,
+		},
+	}
+}
+
+func buildResourceOnlyRequests(cpuRequest, memRequest resource.Quantity) v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    cpuRequest,
+			v1.ResourceMemory: memRequest,
+		},
+	}
+}
+
+func buildResourceOnlyLimits(cpuLimit, memLimit resource.Quantity) v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    cpuLimit,
+			v1.ResourceMemory: memLimit,
+		},
+	}
+}
+
+func buildNoCPULimitResource(cpuRequest, memLimit, memRequest resource.Quantity) v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceMemory: memLimit,
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    cpuRequest,
+			v1.ResourceMemory: memRequest,
+		},
+	}
+}
+
+func areResourcesSame(lhs, rhs v1.ResourceRequirements) bool {
+	return reflect.DeepEqual(lhs, rhs)
+}
+
+func printResource(resource v1.ResourceRequirements) string {
+	pretty, err := json.MarshalIndent(resource, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshalling to json: %v", pretty)
+	}
+	return string(pretty)
+}
+
+var _ = Describe("common.go", func() {
+	defer GinkgoRecover()
+
+	Describe("#newEnvVars", func() {
+		var envVars []v1.EnvVar
+		BeforeEach(func() {
+			envVars = newEnvVars("theNodeName", "theClusterName", "theClusterName-cluster", "theInstanceRam", defaultHeapDumpLocation, defaultRecoverAfterTime, defaultReadinessScriptTimeout, map[api.ElasticsearchNodeRole]bool{})
+		})
+
+		It("should define POD_IP so IPV4 or IPV6 deployments are possible", func() {
+			helpers.ExpectEnvVars(envVars).ToIncludeName("POD_IP").WithFieldRefPath("status.podIP")
+		})
+
+		It("should define POD_NAME from the name of the pod", func() {
+			helpers.ExpectEnvVars(envVars).ToIncludeName("POD_NAME").WithFieldRefPath("metadata.name")
+		})
+	})
+})
+
+func TestSysctlInitContainer(t *testing.T) {
+	customMaxMapCount := int64(524288)
+
+	tests := []struct {
+		desc       string
+		commonSpec api.ElasticsearchNodeSpec
+		expected   []string
+	}{
+		{desc: "disabled by default"},
+		{desc: "default value", commonSpec: api.ElasticsearchNodeSpec{SysctlInitContainer: true}, expected: []string{"sysctl", "-w", "vm.max_map_count=262144"}},
+		{desc: "custom value", commonSpec: api.ElasticsearchNodeSpec{SysctlInitContainer: true, MaxMapCount: &customMaxMapCount}, expected: []string{"sysctl", "-w", "vm.max_map_count=524288"}},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		if test.expected == nil {
+			if len(podTemplate.Spec.InitContainers) != 0 {
+				t.Errorf("%s: Exp. no init containers but was %v", test.desc, podTemplate.Spec.InitContainers)
+			}
+			continue
+		}
+
+		if len(podTemplate.Spec.InitContainers) != 1 {
+			t.Fatalf("%s: Exp. one init container but was %d", test.desc, len(podTemplate.Spec.InitContainers))
+		}
+
+		initContainer := podTemplate.Spec.InitContainers[0]
+		if diff := cmp.Diff(initContainer.Command, test.expected); diff != "" {
+			t.Errorf("%s: init container command error: %s", test.desc, diff)
+		}
+		if initContainer.Image != podTemplate.Spec.Containers[0].Image {
+			t.Errorf("%s: Exp. init container to use the elasticsearch image %q but was %q", test.desc, podTemplate.Spec.Containers[0].Image, initContainer.Image)
+		}
+		if initContainer.SecurityContext == nil || !pointer.BoolDeref(initContainer.SecurityContext.Privileged, false) {
+			t.Errorf("%s: Exp. init container to be privileged", test.desc)
+		}
+	}
+}
+
+func TestMergeEnvVars(t *testing.T) {
+	operatorEnv := []v1.EnvVar{
+		{Name: "CLUSTER_NAME", Value: "elasticsearch"},
+		{Name: "IS_MASTER", Value: "true"},
+	}
+	nodeEnv := []v1.EnvVar{
+		{Name: "CLUSTER_NAME", Value: "custom"},
+		{Name: "ES_PATH_CONF", Value: "/node/config"},
+	}
+	commonEnv := []v1.EnvVar{
+		{Name: "ES_PATH_CONF", Value: "/common/config"},
+		{Name: "PLUGIN_OPTS", Value: "enabled"},
+	}
+
+	expected := []v1.EnvVar{
+		{Name: "CLUSTER_NAME", Value: "elasticsearch"},
+		{Name: "IS_MASTER", Value: "true"},
+		{Name: "ES_PATH_CONF", Value: "/node/config"},
+		{Name: "PLUGIN_OPTS", Value: "enabled"},
+	}
+
+	merged := mergeEnvVars(log.NewLogger("common-testing"), operatorEnv, nodeEnv, commonEnv)
+	if diff := cmp.Diff(merged, expected); diff != "" {
+		t.Errorf("Exp. operator env vars to take precedence over node and common ones: %s", diff)
+	}
+	if len(operatorEnv) != 2 {
+		t.Errorf("Exp. the operator env vars not to be modified")
+	}
+}
+
+func TestPodTemplateMetadata(t *testing.T) {
+	operatorLabels := newLabels("test-cluster-name", "test-node-name", map[api.ElasticsearchNodeRole]bool{api.ElasticsearchRoleData: true})
+	node := api.ElasticsearchNode{
+		Labels:      map[string]string{"team": "logging", "component": "custom"},
+		Annotations: map[string]string{"prometheus.io/scrape": "true"},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{
+		Labels:      map[string]string{"team": "infra", "cost-center": "42"},
+		Annotations: map[string]string{"prometheus.io/scrape": "false", "prometheus.io/port": "9200"},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", node, commonSpec, operatorLabels, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	expectedLabels := map[string]string{
+		"es-node-client": "false",
+		"es-node-data":   "true",
+		"es-node-master": "false",
+		"cluster-name":   "test-cluster-name",
+		"component":      "elasticsearch",
+		"node-name":      "test-node-name",
+		"team":           "logging",
+		"cost-center":    "42",
+	}
+	if diff := cmp.Diff(expectedLabels, podTemplate.Labels); diff != "" {
+		t.Errorf("Exp. operator labels to take precedence over node and common ones: %s", diff)
+	}
+
+	expectedAnnotations := map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   "9200",
+	}
+	if diff := cmp.Diff(expectedAnnotations, podTemplate.Annotations); diff != "" {
+		t.Errorf("Exp. node annotations to take precedence over common ones: %s", diff)
+	}
+
+	if operatorLabels["team"] != "" {
+		t.Errorf("Exp. the operator labels not to be modified")
 	}
 }
 
-func buildResourceOnlyRequests(cpuRequest, memRequest resource.Quantity) v1.ResourceRequirements {
-	return v1.ResourceRequirements{
-		Requests: v1.ResourceList{
-			v1.ResourceCPU:    cpuRequest,
-			v1.ResourceMemory: memRequest,
+func TestExporterSidecar(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		Spec: api.ElasticsearchSpec{
+			Spec: api.ElasticsearchNodeSpec{HTTPPort: 9201},
 		},
 	}
+
+	template := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, cluster.Spec.Spec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	if got := appendExporterContainer(template, cluster); len(got.Spec.Containers) != 2 {
+		t.Errorf("Exp. no exporter sidecar without monitoring, got %d containers", len(got.Spec.Containers))
+	}
+
+	cluster.Spec.Monitoring = &api.ElasticsearchMonitoringSpec{Enabled: true}
+	got := appendExporterContainer(template, cluster)
+	if len(got.Spec.Containers) != 3 {
+		t.Fatalf("Exp. the exporter sidecar to be added, got %d containers", len(got.Spec.Containers))
+	}
+
+	exporter := got.Spec.Containers[2]
+	if exporter.Name != "exporter" {
+		t.Errorf("Exp. the sidecar to be named exporter, got %q", exporter.Name)
+	}
+	if len(exporter.Ports) != 1 || exporter.Ports[0].ContainerPort != 9114 || exporter.Ports[0].Name != "exporter" {
+		t.Errorf("Exp. the exporter to expose port 9114, got %v", exporter.Ports)
+	}
+	if !sliceContainsString(exporter.Args, "--es.uri=https://localhost:9201") {
+		t.Errorf("Exp. the exporter to scrape the local http port, got %v", exporter.Args)
+	}
+	if !sliceContainsString(exporter.Args, "--es.client-cert=/etc/openshift/elasticsearch/secret/admin-cert") {
+		t.Errorf("Exp. the exporter to use the certificates of the cluster secret, got %v", exporter.Args)
+	}
 }
 
-func buildResourceOnlyLimits(cpuLimit, memLimit resource.Quantity) v1.ResourceRequirements {
-	return v1.ResourceRequirements{
-		Limits: v1.ResourceList{
-			v1.ResourceCPU:    cpuLimit,
-			v1.ResourceMemory: memLimit,
+func TestHeapDumpLocation(t *testing.T) {
+	tests := []struct {
+		desc         string
+		heapDump     *api.ElasticsearchHeapDumpSpec
+		wantLocation string
+		wantVolume   bool
+	}{
+		{
+			desc:         "shared with the data volume",
+			wantLocation: "/elasticsearch/persistent/heapdump.hprof",
+		},
+		{
+			desc:         "custom path on the data volume",
+			heapDump:     &api.ElasticsearchHeapDumpSpec{Path: "/elasticsearch/persistent/dumps/heap.hprof"},
+			wantLocation: "/elasticsearch/persistent/dumps/heap.hprof",
 		},
+		{
+			desc: "dedicated volume",
+			heapDump: &api.ElasticsearchHeapDumpSpec{
+				Storage: &api.ElasticsearchStorageSpec{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			},
+			wantLocation: "/elasticsearch/heapdump/heapdump.hprof",
+			wantVolume:   true,
+		},
+	}
+
+	for _, test := range tests {
+		commonSpec := api.ElasticsearchNodeSpec{HeapDump: test.heapDump}
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		esContainer := podTemplate.Spec.Containers[0]
+		location := ""
+		for _, env := range esContainer.Env {
+			if env.Name == "HEAP_DUMP_LOCATION" {
+				location = env.Value
+			}
+		}
+		if location != test.wantLocation {
+			t.Errorf("%s: exp. HEAP_DUMP_LOCATION %q but was %q", test.desc, test.wantLocation, location)
+		}
+
+		hasMount := false
+		for _, mount := range esContainer.VolumeMounts {
+			if mount.Name == "elasticsearch-heapdump" {
+				hasMount = mount.MountPath == "/elasticsearch/heapdump"
+			}
+		}
+		hasVolume := false
+		for _, volume := range podTemplate.Spec.Volumes {
+			if volume.Name == "elasticsearch-heapdump" {
+				hasVolume = volume.EmptyDir != nil
+			}
+		}
+		if hasMount != test.wantVolume || hasVolume != test.wantVolume {
+			t.Errorf("%s: exp. dedicated heap dump volume %t but had mount %t and volume %t", test.desc, test.wantVolume, hasMount, hasVolume)
+		}
 	}
 }
 
-func buildNoCPULimitResource(cpuRequest, memLimit, memRequest resource.Quantity) v1.ResourceRequirements {
-	return v1.ResourceRequirements{
-		Limits: v1.ResourceList{
-			v1.ResourceMemory: memLimit,
+func TestReadOnlyRootFilesystem(t *testing.T) {
+	newTemplate := func(commonSpec api.ElasticsearchNodeSpec) v1.PodTemplateSpec {
+		return newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	}
+
+	writable := map[string]string{
+		"elasticsearch-tmp":  "/tmp",
+		"elasticsearch-logs": "/usr/share/elasticsearch/logs",
+	}
+
+	podTemplate := newTemplate(api.ElasticsearchNodeSpec{})
+	esContainer := podTemplate.Spec.Containers[0]
+	if esContainer.SecurityContext.ReadOnlyRootFilesystem != nil {
+		t.Errorf("Exp. the root filesystem to be writable by default")
+	}
+	for _, mount := range esContainer.VolumeMounts {
+		if _, ok := writable[mount.Name]; ok {
+			t.Errorf("Exp. no writable mount %q by default", mount.Name)
+		}
+	}
+
+	podTemplate = newTemplate(api.ElasticsearchNodeSpec{ReadOnlyRootFilesystem: true})
+	esContainer = podTemplate.Spec.Containers[0]
+	if esContainer.SecurityContext.ReadOnlyRootFilesystem == nil || !*esContainer.SecurityContext.ReadOnlyRootFilesystem {
+		t.Errorf("Exp. the root filesystem to be read-only")
+	}
+	if esContainer.SecurityContext.AllowPrivilegeEscalation == nil || *esContainer.SecurityContext.AllowPrivilegeEscalation {
+		t.Errorf("Exp. the default container security context to be kept")
+	}
+
+	for name, mountPath := range writable {
+		hasMount := false
+		for _, mount := range esContainer.VolumeMounts {
+			if mount.Name == name && mount.MountPath == mountPath {
+				hasMount = true
+			}
+		}
+		hasVolume := false
+		for _, volume := range podTemplate.Spec.Volumes {
+			if volume.Name == name && volume.EmptyDir != nil {
+				hasVolume = true
+			}
+		}
+		if !hasMount || !hasVolume {
+			t.Errorf("Exp. an emptyDir volume %q mounted at %s, had mount %t and volume %t", name, mountPath, hasMount, hasVolume)
+		}
+	}
+}
+
+func TestCustomPorts(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		HTTPPort:       9201,
+		TransportPort:  9301,
+		ReadinessProbe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeTCP},
+		LivenessProbe:  &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, c := range podTemplate.Spec.Containers {
+		switch c.Name {
+		case "elasticsearch":
+			expectedPorts := []int32{9301, 9201}
+			for i, port := range c.Ports {
+				if port.ContainerPort != expectedPorts[i] {
+					t.Errorf("Exp. container port %d but was %d", expectedPorts[i], port.ContainerPort)
+				}
+			}
+			if c.ReadinessProbe.TCPSocket == nil || c.ReadinessProbe.TCPSocket.Port.IntValue() != 9301 {
+				t.Errorf("Exp. the readiness probe to check the transport port 9301 but was %v", c.ReadinessProbe.ProbeHandler)
+			}
+			if !isHTTPProbe(c.LivenessProbe, 9201) {
+				t.Errorf("Exp. the liveness probe to query the http port 9201 but was %v", c.LivenessProbe.ProbeHandler)
+			}
+		case "proxy":
+			found := false
+			for _, arg := range c.Args {
+				found = found || arg == "--elasticsearch-url=https://localhost:9201"
+			}
+			if !found {
+				t.Errorf("Exp. the proxy to forward to the http port 9201 but args were %v", c.Args)
+			}
+		}
+	}
+}
+
+func TestHTTPProbesUseHTTPS(t *testing.T) {
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		probe *api.ElasticsearchProbeSpec
+		port  int
+	}{
+		{desc: "default port", probe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP}, port: 9200},
+		{desc: "custom http port", spec: api.ElasticsearchNodeSpec{HTTPPort: 9201}, probe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP}, port: 9201},
+		{desc: "probe port", probe: &api.ElasticsearchProbeSpec{Type: api.ProbeTypeHTTP, Port: pointer.Int32(9202)}, port: 9202},
+	}
+
+	for _, test := range tests {
+		for _, probe := range []*v1.Probe{newReadinessProbe(test.probe, getPorts(test.spec)), newLivenessProbe(test.probe, getPorts(test.spec))} {
+			if probe.Exec == nil || len(probe.Exec.Command) != 3 {
+				t.Fatalf("%s: Exp. a http probe but was %v", test.desc, probe.ProbeHandler)
+			}
+			script := probe.Exec.Command[2]
+			if url := fmt.Sprintf("https://localhost:%d%s", test.port, clusterHealthPath); !strings.Contains(script, url) {
+				t.Errorf("%s: Exp. the probe to request %s but was %q", test.desc, url, script)
+			}
+			if !strings.Contains(script, "--cert "+elasticsearchCertsPath+"/admin-cert") {
+				t.Errorf("%s: Exp. the probe to authenticate with the admin certificate but was %q", test.desc, script)
+			}
+		}
+	}
+}
+
+func TestIngestRole(t *testing.T) {
+	tests := []struct {
+		desc         string
+		roles        []api.ElasticsearchNodeRole
+		ingestNodes  bool
+		ingest       bool
+		coordinating bool
+	}{
+		{desc: "data", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, ingestNodes: true, ingest: true},
+		{desc: "dedicated ingest", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleIngest}, ingestNodes: true, ingest: true},
+		{desc: "master", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, ingestNodes: true},
+		{desc: "coordinating", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}, ingestNodes: true, coordinating: true},
+		{desc: "client and ingest", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleIngest}, ingestNodes: true, ingest: true},
+		{desc: "data without ingest nodes", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, ingest: true},
+		{desc: "master without ingest nodes", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, ingest: true},
+		{desc: "coordinating without ingest nodes", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}, ingest: true, coordinating: true},
+	}
+
+	for _, test := range tests {
+		node := api.ElasticsearchNode{Roles: test.roles, NodeCount: 1}
+		nodes := []api.ElasticsearchNode{node}
+		if test.ingestNodes {
+			nodes = append(nodes, api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleIngest}, NodeCount: 1})
+		}
+
+		if got := isCoordinatingNode(node); got != test.coordinating {
+			t.Errorf("%s: Exp. coordinating node to be %t but was %t", test.desc, test.coordinating, got)
+		}
+
+		er := &ElasticsearchRequest{
+			cluster: &api.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster-name", Namespace: "test-namespace-name"},
+				Spec:       api.ElasticsearchSpec{Nodes: nodes},
+			},
+			ll: log.NewLogger("common-testing"),
+		}
+
+		var template v1.PodTemplateSpec
+		switch n := er.GetNodeTypeInterface("test-uuid", node)[0].(type) {
+		case *deploymentNode:
+			template = n.self.Spec.Template
+		case *statefulSetNode:
+			template = n.self.Spec.Template
+		}
+
+		value := ""
+		for _, env := range template.Spec.Containers[0].Env {
+			if env.Name == "IS_INGEST" {
+				value = env.Value
+			}
+		}
+		if value != strconv.FormatBool(test.ingest) {
+			t.Errorf("%s: Exp. IS_INGEST to be %t but was %q", test.desc, test.ingest, value)
+		}
+	}
+}
+
+func TestPriorityClassName(t *testing.T) {
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		expected   string
+	}{
+		{desc: "unset"},
+		{desc: "common", commonSpec: api.ElasticsearchNodeSpec{PriorityClassName: "es-data"}, expected: "es-data"},
+		{desc: "node precedence", node: api.ElasticsearchNode{PriorityClassName: "es-master"}, commonSpec: api.ElasticsearchNodeSpec{PriorityClassName: "es-data"}, expected: "es-master"},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", test.node, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		if podTemplate.Spec.PriorityClassName != test.expected {
+			t.Errorf("%s: Exp. priority class %q but was %q", test.desc, test.expected, podTemplate.Spec.PriorityClassName)
+		}
+	}
+}
+
+func TestNodeSuffixIngest(t *testing.T) {
+	tests := []struct {
+		roles    []api.ElasticsearchNodeRole
+		expected string
+	}{
+		{roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData, api.ElasticsearchRoleMaster}, expected: "cdm-uuid"},
+		{roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData, api.ElasticsearchRoleIngest}, expected: "d-uuid"},
+		{roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleIngest}, expected: "i-uuid"},
+	}
+
+	for _, test := range tests {
+		roleMap := getNodeRoleMap(api.ElasticsearchNode{Roles: test.roles})
+		if got := getNodeSuffix("uuid", roleMap); got != test.expected {
+			t.Errorf("Exp. suffix %q for roles %v but was %q", test.expected, test.roles, got)
+		}
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	tests := []struct {
+		desc        string
+		commonSpec  api.ElasticsearchNodeSpec
+		gracePeriod int64
+		port        string
+	}{
+		{desc: "defaults", gracePeriod: 180, port: "https://localhost:9200/"},
+		{desc: "custom", commonSpec: api.ElasticsearchNodeSpec{TerminationGracePeriodSeconds: pointer.Int64(600), HTTPPort: 9201}, gracePeriod: 600, port: "https://localhost:9201/"},
+	}
+
+	for _, test := range tests {
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, test.commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+		if podTemplate.Spec.TerminationGracePeriodSeconds == nil || *podTemplate.Spec.TerminationGracePeriodSeconds != test.gracePeriod {
+			t.Errorf("%s: Exp. termination grace period %d but was %v", test.desc, test.gracePeriod, podTemplate.Spec.TerminationGracePeriodSeconds)
+		}
+
+		lifecycle := podTemplate.Spec.Containers[0].Lifecycle
+		if lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil {
+			t.Fatalf("%s: Exp. a preStop exec hook but was %v", test.desc, lifecycle)
+		}
+
+		script := lifecycle.PreStop.Exec.Command[len(lifecycle.PreStop.Exec.Command)-1]
+		for _, expected := range []string{
+			test.port + "_cluster/settings",
+			`"cluster.routing.allocation.enable":"primaries"`,
+			test.port + "_flush/synced",
+		} {
+			if !strings.Contains(script, expected) {
+				t.Errorf("%s: Exp. preStop hook to contain %q but was %q", test.desc, expected, script)
+			}
+		}
+	}
+}
+
+func TestRecoverySettings(t *testing.T) {
+	nodes := []api.ElasticsearchNode{
+		{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, NodeCount: 3},
+		{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, NodeCount: 4},
+	}
+
+	tests := []struct {
+		desc              string
+		recovery          *api.ElasticsearchRecoverySpec
+		wantAfterTime     string
+		wantExpectedNodes int
+	}{
+		{
+			desc:              "derived from the data nodes",
+			wantAfterTime:     "5m",
+			wantExpectedNodes: 4,
 		},
-		Requests: v1.ResourceList{
-			v1.ResourceCPU:    cpuRequest,
-			v1.ResourceMemory: memRequest,
+		{
+			desc:              "overridden",
+			recovery:          &api.ElasticsearchRecoverySpec{AfterTime: "10m", ExpectedNodes: pointer.Int32(7)},
+			wantAfterTime:     "10m",
+			wantExpectedNodes: 7,
+		},
+		{
+			desc:              "only the time overridden",
+			recovery:          &api.ElasticsearchRecoverySpec{AfterTime: "90s"},
+			wantAfterTime:     "90s",
+			wantExpectedNodes: 4,
 		},
 	}
+
+	for _, test := range tests {
+		cluster := &api.Elasticsearch{
+			Spec: api.ElasticsearchSpec{
+				Spec:  api.ElasticsearchNodeSpec{Recovery: test.recovery},
+				Nodes: nodes,
+			},
+		}
+
+		if got := getRecoverExpectedNodes(cluster); got != test.wantExpectedNodes {
+			t.Errorf("%s: exp. %d expected nodes but was %d", test.desc, test.wantExpectedNodes, got)
+		}
+
+		podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", nodes[1], cluster.Spec.Spec, map[string]string{}, getNodeRoleMap(nodes[1]), nil, LogConfig{})
+		afterTime := ""
+		for _, env := range podTemplate.Spec.Containers[0].Env {
+			if env.Name == "RECOVER_AFTER_TIME" {
+				afterTime = env.Value
+			}
+		}
+		if afterTime != test.wantAfterTime {
+			t.Errorf("%s: exp. RECOVER_AFTER_TIME %q but was %q", test.desc, test.wantAfterTime, afterTime)
+		}
+	}
 }
 
-func areResourcesSame(lhs, rhs v1.ResourceRequirements) bool {
-	return reflect.DeepEqual(lhs, rhs)
+func TestElasticsearchContainerCommandAndArgs(t *testing.T) {
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	if c := podTemplate.Spec.Containers[0]; c.Command != nil || c.Args != nil {
+		t.Errorf("Exp. the image entrypoint to be used but was command %v and args %v", c.Command, c.Args)
+	}
+
+	commonSpec := api.ElasticsearchNodeSpec{
+		Command: []string{"/bin/bash", "-c"},
+		Args:    []string{"sleep infinity"},
+	}
+	podTemplate = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	c := podTemplate.Spec.Containers[0]
+	if c.Name != "elasticsearch" {
+		t.Fatalf("Exp. the elasticsearch container first but was %q", c.Name)
+	}
+	if !reflect.DeepEqual(c.Command, commonSpec.Command) {
+		t.Errorf("Exp. command %v but was %v", commonSpec.Command, c.Command)
+	}
+	if !reflect.DeepEqual(c.Args, commonSpec.Args) {
+		t.Errorf("Exp. args %v but was %v", commonSpec.Args, c.Args)
+	}
 }
 
-func printResource(resource v1.ResourceRequirements) string {
-	pretty, err := json.MarshalIndent(resource, "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshalling to json: %v", pretty)
+func TestSidecarsShareTheLogVolume(t *testing.T) {
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+	for _, volume := range podTemplate.Spec.Volumes {
+		if volume.Name == sharedLogsVolumeName {
+			t.Errorf("Exp. no shared log volume without sidecars")
+		}
+	}
+
+	shipper := v1.Container{
+		Name:  "log-shipper",
+		Image: "fluent/fluent-bit:2.0",
+		VolumeMounts: []v1.VolumeMount{
+			{Name: sharedLogsVolumeName, MountPath: "/var/log/elasticsearch", ReadOnly: true},
+		},
+	}
+	commonSpec := api.ElasticsearchNodeSpec{Sidecars: []v1.Container{shipper}}
+	podTemplate = newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	var volume *v1.Volume
+	for i := range podTemplate.Spec.Volumes {
+		if podTemplate.Spec.Volumes[i].Name == sharedLogsVolumeName {
+			volume = &podTemplate.Spec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.EmptyDir == nil {
+		t.Fatalf("Exp. an emptyDir volume %q but was %v", sharedLogsVolumeName, volume)
+	}
+
+	containers := podTemplate.Spec.Containers
+	if len(containers) != 3 || !reflect.DeepEqual(containers[2], shipper) {
+		t.Fatalf("Exp. the sidecar to be appended to the containers but was %v", containers)
+	}
+
+	mounted := false
+	for _, mount := range containers[0].VolumeMounts {
+		if mount.Name == sharedLogsVolumeName && mount.MountPath == "/elasticsearch/persistent/test-cluster-name/logs" {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("Exp. the shared log volume to be mounted at the log path of elasticsearch but was %v", containers[0].VolumeMounts)
 	}
-	return string(pretty)
 }
 
-var _ = Describe("common.go", func() {
-	defer GinkgoRecover()
+func TestNewExtraVolumeDefaultsHostPathType(t *testing.T) {
+	socket := v1.HostPathSocket
+	tests := []struct {
+		desc   string
+		source v1.HostPathVolumeSource
+		want   v1.HostPathType
+	}{
+		{desc: "unset", source: v1.HostPathVolumeSource{Path: "/mnt/backup"}, want: v1.HostPathDirectoryOrCreate},
+		{desc: "explicit", source: v1.HostPathVolumeSource{Path: "/var/run/agent.sock", Type: &socket}, want: v1.HostPathSocket},
+	}
 
-	Describe("#newEnvVars", func() {
-		var envVars []v1.EnvVar
-		BeforeEach(func() {
-			envVars = newEnvVars("theNodeName", "theClusterName", "theInstanceRam", map[api.ElasticsearchNodeRole]bool{})
-		})
+	for _, test := range tests {
+		source := test.source
+		volume := newExtraVolume(log.NewLogger("common-testing"), "test-node-name", v1.Volume{Name: "host", VolumeSource: v1.VolumeSource{HostPath: &source}})
+		if volume.HostPath.Type == nil || *volume.HostPath.Type != test.want {
+			t.Errorf("%s: exp. host path type %q but was %v", test.desc, test.want, volume.HostPath.Type)
+		}
+		if !reflect.DeepEqual(source, test.source) {
+			t.Errorf("%s: exp. the extra volume of the spec to be left unchanged but was %v", test.desc, source)
+		}
+	}
+}
 
-		It("should define POD_IP so IPV4 or IPV6 deployments are possible", func() {
-			helpers.ExpectEnvVars(envVars).ToIncludeName("POD_IP").WithFieldRefPath("status.podIP")
+func TestResourceName(t *testing.T) {
+	prefixed := api.ElasticsearchNodeSpec{ResourceNamePrefix: "team-a-"}
+
+	tests := []struct {
+		desc       string
+		commonSpec api.ElasticsearchNodeSpec
+		suffix     string
+		want       string
+	}{
+		{desc: "no prefix", want: "elasticsearch"},
+		{desc: "no prefix with suffix", suffix: "cluster", want: "elasticsearch-cluster"},
+		{desc: "prefix", commonSpec: prefixed, want: "team-a-elasticsearch"},
+		{desc: "prefix with suffix", commonSpec: prefixed, suffix: "metrics", want: "team-a-elasticsearch-metrics"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			if got := resourceName("elasticsearch", test.commonSpec, test.suffix); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
 		})
-	})
-})
+	}
+}
+
+func TestPodTemplateReferencesPrefixedResources(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{ResourceNamePrefix: "team-a-"}
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("common-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", api.ElasticsearchNode{}, commonSpec, map[string]string{}, map[api.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	for _, volume := range podTemplate.Spec.Volumes {
+		switch volume.Name {
+		case "elasticsearch-config":
+			if name := volume.ConfigMap.Name; name != "team-a-test-cluster-name" {
+				t.Errorf("Exp. the config map %q to be mounted but was %q", "team-a-test-cluster-name", name)
+			}
+		case "certificates":
+			if name := volume.Secret.SecretName; name != "test-cluster-name" {
+				t.Errorf("Exp. the secret %q to be mounted but was %q", "test-cluster-name", name)
+			}
+		case "test-cluster-name-metrics":
+			if name := volume.Secret.SecretName; name != "team-a-test-cluster-name-metrics" {
+				t.Errorf("Exp. the secret %q to be mounted but was %q", "team-a-test-cluster-name-metrics", name)
+			}
+		}
+	}
+
+	for _, env := range podTemplate.Spec.Containers[0].Env {
+		if env.Name == "SERVICE_DNS" && env.Value != "team-a-test-cluster-name-cluster" {
+			t.Errorf("Exp. the discovery service %q but was %q", "team-a-test-cluster-name-cluster", env.Value)
+		}
+	}
+}
@@ -4,29 +4,54 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"fmt"
 	"html/template"
 	"io"
+	"path"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/manifests/configmap"
+	"github.com/openshift/elasticsearch-operator/internal/utils"
+	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	esConfig            = "elasticsearch.yml"
 	log4jConfig         = "log4j2.properties"
 	indexSettingsConfig = "index_settings"
+
+	// configChecksumAnnotation carries the checksum of the config map on the pod templates
+	configChecksumAnnotation = "elasticsearch.openshift.io/config-checksum"
 )
 
 // esYmlStruct is used to render esYmlTmpl to a proper elasticsearch.yml format
 type esYmlStruct struct {
+	ClusterName          string
 	KibanaIndexMode      string
 	EsUnicastHost        string
 	NodeQuorum           string
 	RecoverExpectedNodes string
 	SystemCallFilter     string
+	HTTPPort             string
+	TransportPort        string
+
+	TransportHostnameVerification string
+
+	WriteQueueSize  string
+	SearchQueueSize string
+
+	DataPaths string
+
+	NodeAttributes      []nodeAttribute
+	AwarenessAttributes string
 }
 
 type log4j2PropertiesStruct struct {
@@ -48,25 +73,42 @@ func (er *ElasticsearchRequest) CreateOrUpdateConfigMaps() error {
 	if err != nil {
 		return err
 	}
-	dataNodeCount := int(GetDataCount(dpl))
-	masterNodeCount := int(getMasterCount(dpl))
-
 	logConfig := getLogConfig(dpl.GetAnnotations())
 
 	cm := newConfigMap(
-		dpl.Name,
+		configMapName(dpl.Name, dpl.Spec.Spec),
 		dpl.Namespace,
 		dpl.Labels,
+		clusterNameSetting(dpl),
 		kibanaIndexMode,
-		esUnicastHost(dpl.Name, dpl.Namespace),
-		strconv.Itoa(masterNodeCount/2+1),
-		strconv.Itoa(dataNodeCount),
+		esUnicastHost(discoveryServiceName(dpl.Name, dpl.Spec.Spec), dpl.Namespace),
+		strconv.Itoa(CalculateNodeQuorum(dpl)),
+		strconv.Itoa(getRecoverExpectedNodes(dpl)),
 		strconv.Itoa(CalculatePrimaryCount(dpl)),
 		strconv.Itoa(CalculateReplicaCount(dpl)),
 		strconv.FormatBool(runtime.GOARCH == "amd64"),
+		portSetting(dpl.Spec.Spec.HTTPPort, defaultHTTPPort),
+		portSetting(dpl.Spec.Spec.TransportPort, defaultTransportPort),
+		strconv.FormatBool(isTransportHostnameVerificationEnabled(dpl.Spec.Spec)),
+		queueSizeSetting(dpl.Spec.Spec.WriteQueueSize),
+		queueSizeSetting(dpl.Spec.Spec.SearchQueueSize),
+		dataPathsSetting(dpl),
+		nodeAttributeSettings(dpl),
+		awarenessAttributesSetting(dpl.Spec.Spec),
 		logConfig,
 	)
 
+	userConfig, err := er.getUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := applyUserConfig(er.L(), cm.Data, userConfig); err != nil {
+		return kverrors.Wrap(err, "failed to apply user configuration",
+			"configmap", dpl.Spec.Spec.ConfigMapRef.Name,
+		)
+	}
+
 	dpl.AddOwnerRefTo(cm)
 
 	updated, err := configmap.CreateOrUpdate(context.TODO(), er.client, cm, configMapContentEqual, configmap.MutateDataOnly)
@@ -88,7 +130,7 @@ func (er *ElasticsearchRequest) CreateOrUpdateConfigMaps() error {
 		}
 	}
 
-	cm = configmap.New(serviceCABundleName(dpl.Name), dpl.Namespace, dpl.Labels, nil)
+	cm = configmap.New(serviceCABundleName(dpl.Name, dpl.Spec.Spec), dpl.Namespace, dpl.Labels, nil)
 	cm.Annotations = map[string]string{
 		"service.beta.openshift.io/inject-cabundle": "true",
 	}
@@ -106,10 +148,127 @@ func (er *ElasticsearchRequest) CreateOrUpdateConfigMaps() error {
 	return nil
 }
 
-func renderData(kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter string, logConfig LogConfig) (map[string]string, error) {
+// getUserConfig returns the elasticsearch settings of the ConfigMap referenced
+// in the common node spec, if any
+func (er *ElasticsearchRequest) getUserConfig() (map[string]string, error) {
+	ref := er.cluster.Spec.Spec.ConfigMapRef
+	if ref == nil || ref.Name == "" {
+		return nil, nil
+	}
+
+	key := client.ObjectKey{Name: ref.Name, Namespace: er.cluster.Namespace}
+	cm, err := configmap.Get(context.TODO(), er.client, key)
+	if err != nil {
+		return nil, kverrors.Wrap(err, "failed to get user elasticsearch configmap",
+			"cluster", er.cluster.Name,
+		)
+	}
+
+	return cm.Data, nil
+}
+
+// applyUserConfig replaces the rendered log4j2.properties with the one of the
+// user ConfigMap if provided and overlays its other static keys on elasticsearch.yml
+func applyUserConfig(log logr.Logger, data, userConfig map[string]string) error {
+	if len(userConfig) == 0 {
+		return nil
+	}
+
+	// Dynamic settings are applied through the cluster settings API and must
+	// not change the configmap
+	_, static := splitUserSettings(userConfig)
+
+	settings := map[string]string{}
+	for key, value := range static {
+		if key == log4jConfig {
+			data[log4jConfig] = value
+			continue
+		}
+		settings[key] = value
+	}
+
+	esYml, err := overlayUserConfig(log, data[esConfig], settings)
+	if err != nil {
+		return err
+	}
+	data[esConfig] = esYml
+
+	return nil
+}
+
+// overlayUserConfig appends the user settings to the rendered elasticsearch.yml.
+// Settings managed by the operator win, conflicting user settings are dropped
+// and logged.
+func overlayUserConfig(log logr.Logger, esYml string, userConfig map[string]string) (string, error) {
+	rendered := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(esYml), &rendered); err != nil {
+		return "", kverrors.Wrap(err, "failed to parse rendered elasticsearch.yml")
+	}
+
+	managed := map[string]bool{}
+	for key, value := range rendered {
+		flattenSettings(key, value, managed)
+	}
+
+	keys := make([]string, 0, len(userConfig))
+	for key := range userConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	overlay := map[string]interface{}{}
+	for _, key := range keys {
+		if isManagedSetting(key, managed) {
+			log.Info("Ignoring user setting managed by the operator", "setting", key)
+			continue
+		}
+
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(userConfig[key]), &value); err != nil {
+			value = userConfig[key]
+		}
+		overlay[key] = value
+	}
+
+	if len(overlay) == 0 {
+		return esYml, nil
+	}
+
+	out, err := yaml.Marshal(overlay)
+	if err != nil {
+		return "", kverrors.Wrap(err, "failed to render user settings")
+	}
+
+	return esYml + "\n\n" + string(out), nil
+}
+
+// flattenSettings collects the dotted names of the leaf settings of value
+func flattenSettings(prefix string, value interface{}, settings map[string]bool) {
+	nested, ok := value.(map[interface{}]interface{})
+	if !ok {
+		settings[prefix] = true
+		return
+	}
+	for key, child := range nested {
+		flattenSettings(fmt.Sprintf("%s.%v", prefix, key), child, settings)
+	}
+}
+
+// isManagedSetting returns true if the setting, one of its parents or one of
+// its children is rendered by the operator
+func isManagedSetting(key string, managed map[string]bool) bool {
+	for setting := range managed {
+		if setting == key || strings.HasPrefix(setting, key+".") || strings.HasPrefix(key, setting+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func renderData(clusterName, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter, httpPort, transportPort, transportHostnameVerification, writeQueueSize, searchQueueSize, dataPaths string, nodeAttributes []nodeAttribute, awarenessAttributes string, logConfig LogConfig) (map[string]string, error) {
 	data := map[string]string{}
 	buf := &bytes.Buffer{}
-	if err := renderEsYml(buf, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, systemCallFilter); err != nil {
+	if err := renderEsYml(buf, clusterName, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, systemCallFilter, httpPort, transportPort, transportHostnameVerification, writeQueueSize, searchQueueSize, dataPaths, nodeAttributes, awarenessAttributes); err != nil {
 		return data, err
 	}
 	data[esConfig] = buf.String()
@@ -131,8 +290,8 @@ func renderData(kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes
 
 // newConfigMap returns a v1.ConfigMap object
 func newConfigMap(configMapName, namespace string, labels map[string]string,
-	kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter string, logConfig LogConfig) *v1.ConfigMap {
-	data, err := renderData(kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter, logConfig)
+	clusterName, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter, httpPort, transportPort, transportHostnameVerification, writeQueueSize, searchQueueSize, dataPaths string, nodeAttributes []nodeAttribute, awarenessAttributes string, logConfig LogConfig) *v1.ConfigMap {
+	data, err := renderData(clusterName, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, primaryShardsCount, replicaShardsCount, systemCallFilter, httpPort, transportPort, transportHostnameVerification, writeQueueSize, searchQueueSize, dataPaths, nodeAttributes, awarenessAttributes, logConfig)
 	if err != nil {
 		return nil
 	}
@@ -165,7 +324,45 @@ func configMapContentEqual(old, new *v1.ConfigMap) bool {
 	return true
 }
 
-func renderEsYml(w io.Writer, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, systemCallFilter string) error {
+// configChecksum returns the hex encoded sha256 checksum of the config map data
+// without the keys which are not read by the nodes at start up
+func configChecksum(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if !utils.Contains(excludeConfigMapKeys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s\x00%s\x00", key, data[key])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// setConfigChecksumAnnotation annotates the pod template with the checksum of the
+// elasticsearch config map, so that a configuration change rolls the pods the same
+// way as any other change of the pod template
+func setConfigChecksumAnnotation(template *v1.PodTemplateSpec, c client.Client, cluster *api.Elasticsearch) {
+	if c == nil {
+		return
+	}
+
+	key := client.ObjectKey{Name: configMapName(cluster.Name, cluster.Spec.Spec), Namespace: cluster.Namespace}
+	cm, err := configmap.Get(context.TODO(), c, key)
+	if err != nil {
+		return
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[configChecksumAnnotation] = configChecksum(cm.Data)
+}
+
+func renderEsYml(w io.Writer, clusterName, kibanaIndexMode, esUnicastHost, nodeQuorum, recoverExpectedNodes, systemCallFilter, httpPort, transportPort, transportHostnameVerification, writeQueueSize, searchQueueSize, dataPaths string, nodeAttributes []nodeAttribute, awarenessAttributes string) error {
 	t := template.New("elasticsearch.yml")
 	config := esYmlTmpl
 	t, err := t.Parse(config)
@@ -173,16 +370,69 @@ func renderEsYml(w io.Writer, kibanaIndexMode, esUnicastHost, nodeQuorum, recove
 		return err
 	}
 	esy := esYmlStruct{
+		ClusterName:          clusterName,
 		KibanaIndexMode:      kibanaIndexMode,
 		EsUnicastHost:        esUnicastHost,
 		NodeQuorum:           nodeQuorum,
 		RecoverExpectedNodes: recoverExpectedNodes,
 		SystemCallFilter:     systemCallFilter,
+		HTTPPort:             httpPort,
+		TransportPort:        transportPort,
+
+		TransportHostnameVerification: transportHostnameVerification,
+
+		WriteQueueSize:  writeQueueSize,
+		SearchQueueSize: searchQueueSize,
+
+		DataPaths: dataPaths,
+
+		NodeAttributes:      nodeAttributes,
+		AwarenessAttributes: awarenessAttributes,
 	}
 
 	return t.Execute(w, esy)
 }
 
+// clusterNameSetting returns the cluster name to render into elasticsearch.yml or
+// an empty string to keep the name of the resource from the environment
+func clusterNameSetting(dpl *api.Elasticsearch) string {
+	if dpl.Spec.ClusterName == dpl.Name {
+		return ""
+	}
+	return dpl.Spec.ClusterName
+}
+
+// portSetting returns the port to render into elasticsearch.yml or an empty
+// string to keep the elasticsearch default
+func portSetting(port, defaultPort int32) string {
+	if port == 0 || port == defaultPort {
+		return ""
+	}
+	return strconv.Itoa(int(port))
+}
+
+// dataPathsSetting returns the data paths to render into elasticsearch.yml or an
+// empty string to keep the default one. With additional data volumes the paths
+// are read from the environment of each pod, falling back to the default path
+// for the nodes without any.
+func dataPathsSetting(dpl *api.Elasticsearch) string {
+	for _, node := range dpl.Spec.Nodes {
+		if len(node.DataVolumes) > 0 {
+			return "${" + dataPathsEnvVar + ":" + path.Join(elasticsearchDataPath, "${CLUSTER_NAME}", "data") + "}"
+		}
+	}
+	return ""
+}
+
+// queueSizeSetting returns the thread pool queue size to render into
+// elasticsearch.yml or an empty string to keep the Elasticsearch default
+func queueSizeSetting(size *int32) string {
+	if size == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*size))
+}
+
 func renderLog4j2Properties(w io.Writer, logConfig LogConfig) error {
 	t := template.New("log4j2.properties")
 	t, err := t.Parse(log4j2PropertiesTmpl)
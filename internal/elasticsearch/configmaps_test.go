@@ -4,9 +4,15 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/ViaQ/logerr/v2/log"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/configmap"
 	"github.com/openshift/elasticsearch-operator/test/helpers"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("configmaps.go", func() {
@@ -111,7 +117,7 @@ var _ = Describe("configmaps", func() {
 	Describe("#renderEsYml", func() {
 		It("should produce an elasticsearch.yml for our managed elasticsearch instance", func() {
 			result := &bytes.Buffer{}
-			Expect(renderEsYml(result, "", "my.unicast.host", "7", "4", "false")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
 			helpers.ExpectYaml(result.String()).ToEqual(`
 cluster:
   name: ${CLUSTER_NAME}
@@ -120,9 +126,10 @@ bootstrap:
   system_call_filter: false
 
 node:
-  name: ${DC_NAME}
+  name: ${NODE_NAME}
   master: ${IS_MASTER}
   data: ${HAS_DATA}
+  ingest: ${IS_INGEST}
   max_local_storage_nodes: 1
 
 action.auto_create_index: "-*-write,+*"
@@ -178,5 +185,215 @@ opendistro_security:
       truststore_filepath: /etc/elasticsearch/secret/truststore.p12
       truststore_password: tspass`)
 		})
+
+		It("should render custom http and transport ports", func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "9201", "9301", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("http.port: 9201\n"))
+			Expect(result.String()).To(ContainSubstring("transport.port: 9301\n"))
+		})
+
+		It("should render the thread pool queue sizes", func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "1000", "2000", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("\nthread_pool.write.queue_size: 1000\n"))
+			Expect(result.String()).To(ContainSubstring("\nthread_pool.search.queue_size: 2000\n"))
+
+			result = &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).NotTo(ContainSubstring("thread_pool"))
+		})
+
+		It("should render the data paths of the additional data volumes", func() {
+			dpl := &api.Elasticsearch{}
+			Expect(dataPathsSetting(dpl)).To(BeEmpty())
+
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("\n  data: /elasticsearch/persistent/${CLUSTER_NAME}/data\n"))
+
+			dpl.Spec.Nodes = []api.ElasticsearchNode{
+				{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}},
+				{
+					Roles:       []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+					DataVolumes: []api.ElasticsearchDataVolume{{Name: "hot"}},
+				},
+			}
+			result = &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", dataPathsSetting(dpl), nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("\n  data: ${ES_DATA_PATHS:/elasticsearch/persistent/${CLUSTER_NAME}/data}\n"))
+		})
+
+		It("should render the node attributes and allocation awareness", func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).NotTo(ContainSubstring("attr."))
+			Expect(result.String()).NotTo(ContainSubstring("awareness"))
+
+			attributes := []nodeAttribute{
+				{Name: "box_type", Value: "${NODE_ATTR_BOX_TYPE:}"},
+				{Name: "rack", Value: "${NODE_ATTR_RACK:}"},
+			}
+			result = &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", attributes, "rack,box_type")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("\n  max_local_storage_nodes: 1\n  attr.box_type: ${NODE_ATTR_BOX_TYPE:}\n  attr.rack: ${NODE_ATTR_RACK:}\n"))
+			Expect(result.String()).To(ContainSubstring("\n  routing.allocation.awareness.attributes: rack,box_type\n"))
+		})
+
+		It("should render the cluster name override", func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "es-prod", "", "my.unicast.host", "7", "4", "false", "", "", "false", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring("cluster:\n  name: es-prod\n"))
+			Expect(result.String()).To(ContainSubstring("data: /elasticsearch/persistent/${CLUSTER_NAME}/data\n"))
+		})
+
+		It("should enforce the transport hostname verification", func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "7", "4", "false", "", "", "true", "", "", "", nil, "")).To(BeNil(), "Exp. no errors when rendering the configuration")
+			Expect(result.String()).To(ContainSubstring(`
+    transport:
+      enabled: true
+      enforce_hostname_verification: true
+`))
+		})
+	})
+
+	Describe("#overlayUserConfig", func() {
+		var esYml string
+
+		BeforeEach(func() {
+			result := &bytes.Buffer{}
+			Expect(renderEsYml(result, "", "", "my.unicast.host", "2", "3", "false", "", "", "false", "", "", "", nil, "")).To(Succeed())
+			esYml = result.String()
+		})
+
+		It("should append the user settings", func() {
+			userConfig := map[string]string{
+				"thread_pool.write.queue_size":      "500",
+				"cluster.routing.allocation.enable": "all",
+				"discovery.zen.ping_timeout":        "10s",
+			}
+			out, err := overlayUserConfig(log.NewLogger("configmaps-testing"), esYml, userConfig)
+			Expect(err).To(BeNil())
+			Expect(out).To(HavePrefix(esYml))
+			Expect(out).To(ContainSubstring("\nthread_pool.write.queue_size: 500\n"))
+			Expect(out).To(ContainSubstring("\ncluster.routing.allocation.enable: all\n"))
+			Expect(out).To(ContainSubstring("\ndiscovery.zen.ping_timeout: 10s\n"))
+		})
+
+		It("should keep the operator managed settings on conflict", func() {
+			userConfig := map[string]string{
+				"cluster.name":                       "mycluster",
+				"gateway.expected_nodes":             "10",
+				"path":                               "/data",
+				"http.max_header_size.kb":            "1",
+				"indices.recovery.max_bytes_per_sec": "100mb",
+			}
+			out, err := overlayUserConfig(log.NewLogger("configmaps-testing"), esYml, userConfig)
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring("\nindices.recovery.max_bytes_per_sec: 100mb\n"))
+			Expect(out).To(ContainSubstring("expected_nodes: 3\n"))
+			Expect(out).NotTo(ContainSubstring("mycluster"))
+			Expect(out).NotTo(ContainSubstring("expected_nodes: 10"))
+			Expect(out).NotTo(ContainSubstring("path: /data"))
+			Expect(out).NotTo(ContainSubstring("max_header_size.kb"))
+		})
+
+		It("should leave the configuration untouched when every setting is managed", func() {
+			out, err := overlayUserConfig(log.NewLogger("configmaps-testing"), esYml, map[string]string{"node.master": "true"})
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal(esYml))
+		})
+	})
+
+	Describe("#applyUserConfig", func() {
+		var data map[string]string
+
+		BeforeEach(func() {
+			var err error
+			data, err = renderData("", "", "my.unicast.host", "2", "3", "1", "0", "false", "", "", "false", "", "", "", nil, "", LogConfig{"info", "info", "console"})
+			Expect(err).To(BeNil())
+		})
+
+		It("should keep the rendered log4j2.properties without a user one", func() {
+			rendered := data[log4jConfig]
+			Expect(applyUserConfig(log.NewLogger("configmaps-testing"), data, map[string]string{"thread_pool.write.queue_size": "500"})).To(Succeed())
+			Expect(data[log4jConfig]).To(Equal(rendered))
+			Expect(data[esConfig]).To(ContainSubstring("\nthread_pool.write.queue_size: 500\n"))
+		})
+
+		It("should replace the rendered log4j2.properties with the user one", func() {
+			userLog4j := "status = error\nlogger.action.name = org.elasticsearch.action\nlogger.action.level = debug\n"
+			esYml := data[esConfig]
+			Expect(applyUserConfig(log.NewLogger("configmaps-testing"), data, map[string]string{log4jConfig: userLog4j})).To(Succeed())
+			Expect(data[log4jConfig]).To(Equal(userLog4j))
+			Expect(data[esConfig]).To(Equal(esYml))
+		})
+	})
+
+	Describe("#portSetting", func() {
+		It("should keep the elasticsearch default for unset and default ports", func() {
+			Expect(portSetting(0, defaultHTTPPort)).To(BeEmpty())
+			Expect(portSetting(defaultHTTPPort, defaultHTTPPort)).To(BeEmpty())
+			Expect(portSetting(9201, defaultHTTPPort)).To(Equal("9201"))
+		})
+	})
+
+	Describe("#setConfigChecksumAnnotation", func() {
+		var cluster *api.Elasticsearch
+
+		BeforeEach(func() {
+			cluster = &api.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "elasticsearch",
+					Namespace: "openshift-logging",
+				},
+			}
+		})
+
+		newConfigMapData := func(esYml, indexSettings string) *v1.ConfigMap {
+			return configmap.New(configMapName(cluster.Name, cluster.Spec.Spec), cluster.Namespace, nil, map[string]string{
+				esConfig:            esYml,
+				log4jConfig:         "status = error\n",
+				indexSettingsConfig: indexSettings,
+			})
+		}
+
+		checksumOf := func(cm *v1.ConfigMap) string {
+			template := v1.PodTemplateSpec{}
+			setConfigChecksumAnnotation(&template, fake.NewFakeClient(cm), cluster)
+			return template.Annotations[configChecksumAnnotation]
+		}
+
+		It("should change the annotation when the config content changes", func() {
+			current := checksumOf(newConfigMapData("cluster.name: elasticsearch\n", "PRIMARY_SHARDS=1"))
+			Expect(current).NotTo(BeEmpty())
+
+			desired := checksumOf(newConfigMapData("cluster.name: elasticsearch\nthread_pool.write.queue_size: 500\n", "PRIMARY_SHARDS=1"))
+			Expect(desired).NotTo(Equal(current))
+		})
+
+		It("should keep the annotation when only the index settings change", func() {
+			current := checksumOf(newConfigMapData("cluster.name: elasticsearch\n", "PRIMARY_SHARDS=1"))
+			desired := checksumOf(newConfigMapData("cluster.name: elasticsearch\n", "PRIMARY_SHARDS=3"))
+			Expect(desired).To(Equal(current))
+		})
+
+		It("should keep the user annotations of the pod template", func() {
+			template := v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"prometheus.io/scrape": "true"},
+				},
+			}
+			setConfigChecksumAnnotation(&template, fake.NewFakeClient(newConfigMapData("cluster.name: elasticsearch\n", "")), cluster)
+			Expect(template.Annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+			Expect(template.Annotations).To(HaveKey(configChecksumAnnotation))
+		})
+
+		It("should leave the pod template unannotated without the config map", func() {
+			template := v1.PodTemplateSpec{}
+			setConfigChecksumAnnotation(&template, fake.NewFakeClient(), cluster)
+			Expect(template.Annotations).To(BeEmpty())
+		})
 	})
 })
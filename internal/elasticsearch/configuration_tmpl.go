@@ -2,22 +2,45 @@ package elasticsearch
 
 const esYmlTmpl = `
 cluster:
-  name: ${CLUSTER_NAME}
+  name: {{if .ClusterName}}{{.ClusterName}}{{else}}${CLUSTER_NAME}{{end}}
+{{- if .AwarenessAttributes}}
+  routing.allocation.awareness.attributes: {{.AwarenessAttributes}}
+{{- end}}
 
 bootstrap:
   system_call_filter: {{.SystemCallFilter}}
 
 node:
-  name: ${DC_NAME}
+  name: ${NODE_NAME}
   master: ${IS_MASTER}
   data: ${HAS_DATA}
+  ingest: ${IS_INGEST}
   max_local_storage_nodes: 1
+{{- range .NodeAttributes}}
+  attr.{{.Name}}: {{.Value}}
+{{- end}}
 
 action.auto_create_index: "-*-write,+*"
 
 network:
   publish_host: ${POD_IP}
   bind_host: ["${POD_IP}",_local_]
+{{- if .HTTPPort}}
+
+http.port: {{.HTTPPort}}
+{{- end}}
+{{- if .TransportPort}}
+
+transport.port: {{.TransportPort}}
+{{- end}}
+{{- if .WriteQueueSize}}
+
+thread_pool.write.queue_size: {{.WriteQueueSize}}
+{{- end}}
+{{- if .SearchQueueSize}}
+
+thread_pool.search.queue_size: {{.SearchQueueSize}}
+{{- end}}
 
 discovery.zen:
   ping.unicast.hosts: {{.EsUnicastHost}}
@@ -29,7 +52,7 @@ gateway:
   recover_after_time: ${RECOVER_AFTER_TIME}
 
 path:
-  data: /elasticsearch/persistent/${CLUSTER_NAME}/data
+  data: {{if .DataPaths}}{{.DataPaths}}{{else}}/elasticsearch/persistent/${CLUSTER_NAME}/data{{end}}
   logs: /elasticsearch/persistent/${CLUSTER_NAME}/logs
 
 prometheus:
@@ -49,7 +72,7 @@ opendistro_security:
   ssl:
     transport:
       enabled: true
-      enforce_hostname_verification: false
+      enforce_hostname_verification: {{.TransportHostnameVerification}}
       keystore_type: PKCS12
       keystore_filepath: /etc/elasticsearch/secret/searchguard-key.p12
       keystore_password: kspass
@@ -5,6 +5,8 @@ import (
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 )
 
 const (
@@ -16,17 +18,108 @@ const (
 	defaultESCpuRequest    = "100m"
 	defaultESMemoryLimit   = "4Gi"
 	defaultESMemoryRequest = "1Gi"
+	// ES nodes by role
+	defaultMasterCPULimit   = "100m"
+	defaultMasterCPURequest = "100m"
+	defaultDataCPULimit     = "4000m"
 	// ESProxy
 	defaultESProxyCPURequest    = "100m"
 	defaultESProxyMemoryLimit   = "256Mi"
 	defaultESProxyMemoryRequest = "256Mi"
+	// Exporter
+	defaultExporterCPURequest    = "10m"
+	defaultExporterMemoryLimit   = "128Mi"
+	defaultExporterMemoryRequest = "64Mi"
+	exporterPort                 = 9114
+
+	// Readiness probe
+	defaultReadinessProbeTimeoutSeconds      = 30
+	defaultReadinessProbeInitialDelaySeconds = 10
+	defaultReadinessProbePeriodSeconds       = 5
+	defaultReadinessProbeFailureThreshold    = 3
+
+	// Liveness probe
+	defaultLivenessProbeTimeoutSeconds      = 30
+	defaultLivenessProbeInitialDelaySeconds = 300
+	defaultLivenessProbePeriodSeconds       = 10
+	defaultLivenessProbeFailureThreshold    = 12
+
+	// Startup probe, allows 10 minutes to start by default
+	defaultStartupProbeTimeoutSeconds   = 30
+	defaultStartupProbePeriodSeconds    = 10
+	defaultStartupProbeFailureThreshold = 60
+
+	// seconds the readiness script of the image waits for elasticsearch
+	defaultReadinessScriptTimeout = 30
+
+	readinessProbeScript = "/usr/share/elasticsearch/probe/readiness.sh"
+	clusterHealthPath    = "/_cluster/health"
+
+	// smallest JVM heap, the heap size is passed to the JVM in whole MiB
+	minHeapSize = 1024 * 1024
+
+	// uid and gid of the elasticsearch user of the image
+	elasticsearchUID = 1000
+
+	// default ports of the elasticsearch REST API and transport
+	defaultHTTPPort      = 9200
+	defaultTransportPort = 9300
+
+	// names of the transport port and of the REST API port of the proxy
+	legacyTransportPortName = "cluster"
+	legacyRESTAPIPortName   = "restapi"
+	transportPortName       = "transport"
+	restAPIPortName         = "http"
+
+	// time granted to elasticsearch to flush and shut down
+	defaultTerminationGracePeriodSeconds = 180
+
+	// minimum vm.max_map_count required by elasticsearch
+	defaultMaxMapCount = 262144
 
 	maxMasterCount       = 3
 	maxPrimaryShardCount = 5
 
+	elasticsearchDataPath   = "/elasticsearch/persistent"
 	elasticsearchCertsPath  = "/etc/openshift/elasticsearch/secret"
 	elasticsearchConfigPath = "/usr/share/java/elasticsearch/config"
-	heapDumpLocation        = "/elasticsearch/persistent/heapdump.hprof"
+	defaultHeapDumpLocation = "/elasticsearch/persistent/heapdump.hprof"
+	heapDumpVolumePath      = "/elasticsearch/heapdump"
+	gcLogVolumePath         = "/elasticsearch/gclogs"
+	additionalDataPath      = "/elasticsearch/data"
+	dataPathsEnvVar         = "ES_DATA_PATHS"
+	nodeNameEnvVar          = "NODE_NAME"
+	dataVolumeName          = "elasticsearch-storage"
+	heapDumpVolumeName      = "elasticsearch-heapdump"
+	gcLogVolumeName         = "elasticsearch-gclogs"
+	sharedLogsVolumeName    = "elasticsearch-shared-logs"
+	elasticsearchTmpPath    = "/tmp"
+	elasticsearchLogsPath   = "/usr/share/elasticsearch/logs"
+	keystoreFileName        = "elasticsearch.keystore"
+	keystoreVolumePath      = "/elasticsearch/keystore"
+	keystoreSecretPath      = "/etc/elasticsearch/keystore-secret"
+	pluginsVolumePath       = "/elasticsearch/plugins"
+	pluginsMountPath        = "/usr/share/elasticsearch/plugins"
+	caBundlePath            = "/etc/elasticsearch/ca-bundle"
+	truststoreVolumePath    = "/elasticsearch/truststore"
+	truststoreFileName      = "cacerts"
+
+	// key of the config map or secret holding the CA bundle
+	defaultCABundleKey = "ca-bundle.crt"
+
+	// password of the truststore, the well known default of the JVM truststore
+	// it is copied from since it only holds public certificates
+	truststorePassword = "changeit"
+
+	// time to wait for the expected nodes before recovering after a full cluster restart
+	defaultRecoverAfterTime = "5m"
+
+	// ratio of the memory limit to an explicit heap size
+	defaultHeapMemoryMultiplier = 2
+
+	// time the restarted pods of a data node must have been ready before the
+	// next node is restarted
+	defaultDataNodeMinReadySeconds = 30
 
 	yellowClusterState = "yellow"
 	greenClusterState  = "green"
@@ -45,11 +138,15 @@ func kibanaIndexMode(mode string) (string, error) {
 		"mode", mode)
 }
 
-func esUnicastHost(clusterName, namespace string) string {
-	return fmt.Sprintf("%v-cluster.%v.svc", clusterName, namespace)
+func esUnicastHost(serviceName, namespace string) string {
+	return fmt.Sprintf("%v.%v.svc", serviceName, namespace)
 }
 
 func CalculatePrimaryCount(dpl *api.Elasticsearch) int {
+	if dpl.Spec.ShardsPerIndex != nil {
+		return int(*dpl.Spec.ShardsPerIndex)
+	}
+
 	dataNodeCount := int(GetDataCount(dpl))
 	if dataNodeCount > maxPrimaryShardCount {
 		return maxPrimaryShardCount
@@ -60,7 +157,17 @@ func CalculatePrimaryCount(dpl *api.Elasticsearch) int {
 	return dataNodeCount
 }
 
+// CalculateNodeQuorum returns the minimum number of master eligible nodes
+// required to elect a master, i.e. a majority of the master nodes
+func CalculateNodeQuorum(dpl *api.Elasticsearch) int {
+	return int(getMasterCount(dpl))/2 + 1
+}
+
 func CalculateReplicaCount(dpl *api.Elasticsearch) int {
+	if dpl.Spec.ReplicasPerIndex != nil {
+		return int(*dpl.Spec.ReplicasPerIndex)
+	}
+
 	dataNodeCount := int(GetDataCount(dpl))
 	repType := dpl.Spec.RedundancyPolicy
 	switch repType {
@@ -79,3 +186,103 @@ func CalculateReplicaCount(dpl *api.Elasticsearch) int {
 		return 1
 	}
 }
+
+// SetDefaults writes the defaults computed during the reconciliation into the
+// fields of the spec left empty: the resources of the elasticsearch and proxy
+// containers of each node and the number of primary and replica shards.
+// Fields of previous still holding the defaults computed for it are computed
+// again, so that the defaults follow the changes of the fields they derive from.
+func SetDefaults(dpl, previous *api.Elasticsearch) {
+	if previous != nil {
+		resetDefaults(dpl, previous)
+	}
+
+	for i := range dpl.Spec.Nodes {
+		node := &dpl.Spec.Nodes[i]
+		if isEmptyResources(node.Resources) {
+			node.Resources = newESNodeResourceRequirements(*node, dpl.Spec.Spec)
+		}
+		if isEmptyResources(node.ProxyResources) {
+			node.ProxyResources = newESProxyResourceRequirements(node.ProxyResources, dpl.Spec.Spec.ProxyResources)
+		}
+	}
+
+	// Shard counts are only defaulted for a valid topology, an invalid one is
+	// reported as is
+	dataCount := GetDataCount(dpl)
+	if dataCount == 0 {
+		return
+	}
+	if dpl.Spec.ShardsPerIndex == nil {
+		shards := int32(CalculatePrimaryCount(dpl))
+		dpl.Spec.ShardsPerIndex = &shards
+	}
+	if dpl.Spec.ReplicasPerIndex == nil {
+		replicas := int32(CalculateReplicaCount(dpl))
+		if replicas >= 0 && replicas < dataCount {
+			dpl.Spec.ReplicasPerIndex = &replicas
+		}
+	}
+}
+
+// resetDefaults empties the fields of dpl left unchanged since previous which
+// hold the defaults computed for previous
+func resetDefaults(dpl, previous *api.Elasticsearch) {
+	computed := previous.DeepCopy()
+	computed.Spec.ShardsPerIndex = nil
+	computed.Spec.ReplicasPerIndex = nil
+
+	if isDefaultCount(previous.Spec.ShardsPerIndex, CalculatePrimaryCount(computed)) &&
+		equality.Semantic.DeepEqual(dpl.Spec.ShardsPerIndex, previous.Spec.ShardsPerIndex) {
+		dpl.Spec.ShardsPerIndex = nil
+	}
+	if isDefaultCount(previous.Spec.ReplicasPerIndex, CalculateReplicaCount(computed)) &&
+		equality.Semantic.DeepEqual(dpl.Spec.ReplicasPerIndex, previous.Spec.ReplicasPerIndex) {
+		dpl.Spec.ReplicasPerIndex = nil
+	}
+
+	for i := range dpl.Spec.Nodes {
+		node := &dpl.Spec.Nodes[i]
+		prev := previousNode(previous, i, *node)
+		if prev == nil {
+			continue
+		}
+
+		empty := prev.DeepCopy()
+		empty.Resources = v1.ResourceRequirements{}
+		if equality.Semantic.DeepEqual(node.Resources, prev.Resources) &&
+			equality.Semantic.DeepEqual(prev.Resources, newESNodeResourceRequirements(*empty, previous.Spec.Spec)) {
+			node.Resources = v1.ResourceRequirements{}
+		}
+		if equality.Semantic.DeepEqual(node.ProxyResources, prev.ProxyResources) &&
+			equality.Semantic.DeepEqual(prev.ProxyResources, newESProxyResourceRequirements(v1.ResourceRequirements{}, previous.Spec.Spec.ProxyResources)) {
+			node.ProxyResources = v1.ResourceRequirements{}
+		}
+	}
+}
+
+// previousNode returns the node of previous matching the node at index i of the
+// updated spec, by GenUUID or by position until the operator assigned one
+func previousNode(previous *api.Elasticsearch, i int, node api.ElasticsearchNode) *api.ElasticsearchNode {
+	if node.GenUUID != nil {
+		for j, prev := range previous.Spec.Nodes {
+			if prev.GenUUID != nil && *prev.GenUUID == *node.GenUUID {
+				return &previous.Spec.Nodes[j]
+			}
+		}
+		return nil
+	}
+
+	if i < len(previous.Spec.Nodes) && previous.Spec.Nodes[i].GenUUID == nil {
+		return &previous.Spec.Nodes[i]
+	}
+	return nil
+}
+
+func isDefaultCount(count *int32, computed int) bool {
+	return count != nil && int(*count) == computed
+}
+
+func isEmptyResources(resources v1.ResourceRequirements) bool {
+	return len(resources.Limits) == 0 && len(resources.Requests) == 0
+}
@@ -4,6 +4,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
@@ -59,4 +62,127 @@ var _ = Describe("defaults", func() {
 			Expect(CalculatePrimaryCount(dpl)).To(Equal(dataNodeCount))
 		})
 	})
+
+	Describe("#CalculateNodeQuorum", func() {
+		newCluster := func(masterCount int32) *api.Elasticsearch {
+			return &api.Elasticsearch{
+				Spec: api.ElasticsearchSpec{
+					Nodes: []api.ElasticsearchNode{
+						{
+							Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+							NodeCount: masterCount,
+						},
+						dataNode,
+					},
+				},
+			}
+		}
+
+		It("should return 1 for a single master", func() {
+			Expect(CalculateNodeQuorum(newCluster(1))).To(Equal(1))
+		})
+		It("should return 2 for three masters", func() {
+			Expect(CalculateNodeQuorum(newCluster(3))).To(Equal(2))
+		})
+		It("should return 3 for five masters", func() {
+			Expect(CalculateNodeQuorum(newCluster(5))).To(Equal(3))
+		})
+	})
+
+	Describe("#SetDefaults", func() {
+		newCluster := func() *api.Elasticsearch {
+			return &api.Elasticsearch{
+				Spec: api.ElasticsearchSpec{
+					RedundancyPolicy: api.SingleRedundancy,
+					Nodes: []api.ElasticsearchNode{
+						{
+							Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+							NodeCount: 3,
+						},
+						{
+							Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData},
+							NodeCount: 2,
+						},
+					},
+				},
+			}
+		}
+
+		// expectReconcileDefaults checks that defaulted holds the defaults computed
+		// during the reconciliation of cluster, and leads to the same reconciliation
+		expectReconcileDefaults := func(defaulted, cluster *api.Elasticsearch) {
+			common := cluster.Spec.Spec
+			for i, node := range cluster.Spec.Nodes {
+				resources := newESNodeResourceRequirements(node, common)
+				proxyResources := newESProxyResourceRequirements(node.ProxyResources, common.ProxyResources)
+
+				Expect(equality.Semantic.DeepEqual(defaulted.Spec.Nodes[i].Resources, resources)).To(BeTrue())
+				Expect(equality.Semantic.DeepEqual(defaulted.Spec.Nodes[i].ProxyResources, proxyResources)).To(BeTrue())
+				Expect(equality.Semantic.DeepEqual(newESNodeResourceRequirements(defaulted.Spec.Nodes[i], defaulted.Spec.Spec), resources)).To(BeTrue())
+			}
+
+			Expect(defaulted.Spec.ShardsPerIndex).NotTo(BeNil())
+			Expect(int(*defaulted.Spec.ShardsPerIndex)).To(Equal(CalculatePrimaryCount(cluster)))
+			Expect(defaulted.Spec.ReplicasPerIndex).NotTo(BeNil())
+			Expect(int(*defaulted.Spec.ReplicasPerIndex)).To(Equal(CalculateReplicaCount(cluster)))
+		}
+
+		It("should write the reconcile-time defaults", func() {
+			cluster := newCluster()
+			defaulted := cluster.DeepCopy()
+			SetDefaults(defaulted, nil)
+			expectReconcileDefaults(defaulted, cluster)
+		})
+
+		It("should derive the node resources from the common ones", func() {
+			cluster := newCluster()
+			cluster.Spec.Spec.Resources = v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("8Gi")},
+			}
+			defaulted := cluster.DeepCopy()
+			SetDefaults(defaulted, nil)
+			expectReconcileDefaults(defaulted, cluster)
+			Expect(defaulted.Spec.Nodes[1].Resources.Limits.Memory().String()).To(Equal("8Gi"))
+		})
+
+		It("should keep the user settings", func() {
+			shards := int32(1)
+			cluster := newCluster()
+			cluster.Spec.ShardsPerIndex = &shards
+			cluster.Spec.Nodes[1].Resources = v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			}
+			defaulted := cluster.DeepCopy()
+			SetDefaults(defaulted, nil)
+			Expect(*defaulted.Spec.ShardsPerIndex).To(Equal(int32(1)))
+			Expect(defaulted.Spec.Nodes[1].Resources).To(Equal(cluster.Spec.Nodes[1].Resources))
+		})
+
+		It("should compute the defaults again when the fields they derive from change", func() {
+			previous := newCluster()
+			SetDefaults(previous, nil)
+
+			updated := previous.DeepCopy()
+			updated.Spec.RedundancyPolicy = api.ZeroRedundancy
+			updated.Spec.Nodes[1].NodeCount = 4
+			updated.Spec.Spec.Resources = v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("8Gi")},
+			}
+			SetDefaults(updated, previous)
+
+			cluster := newCluster()
+			cluster.Spec.RedundancyPolicy = api.ZeroRedundancy
+			cluster.Spec.Nodes[1].NodeCount = 4
+			cluster.Spec.Spec.Resources = updated.Spec.Spec.Resources
+			expectReconcileDefaults(updated, cluster)
+		})
+
+		It("should not default the shards without data nodes", func() {
+			cluster := newCluster()
+			cluster.Spec.Nodes = cluster.Spec.Nodes[:1]
+			SetDefaults(cluster, nil)
+			Expect(cluster.Spec.ShardsPerIndex).To(BeNil())
+			Expect(cluster.Spec.ReplicasPerIndex).To(BeNil())
+		})
+	})
 })
@@ -18,6 +18,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -31,11 +32,25 @@ type deploymentNode struct {
 
 	clusterName string
 
+	// name of the config map holding the elasticsearch configuration
+	configMapName string
+
 	replicas int32
 
+	// the ordinal of the replica within its node like of statefulset pods
+	ordinal int32
+
+	updateStrategy *api.ElasticsearchUpdateStrategy
+
+	// seconds the restarted pod must have been ready before the next node is restarted
+	minReadySeconds int32
+
 	client client.Client
 
 	esClient esclient.Client
+
+	// records the events of the node on the cluster
+	recorder record.EventRecorder
 }
 
 func (node *deploymentNode) populateReference(nodeName string, n api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, replicas int32, client client.Client, esClient esclient.Client) {
@@ -43,7 +58,10 @@ func (node *deploymentNode) populateReference(nodeName string, n api.Elasticsear
 
 	progressDeadlineSeconds := int32(1800)
 	logConfig := getLogConfig(cluster.GetAnnotations())
-	template := newPodTemplateSpec(context.TODO(), node.log, nodeName, cluster.Name, cluster.Namespace, n, cluster.Spec.Spec, labels, roleMap, client, logConfig)
+	ctx := withEventRecorder(context.TODO(), node.recorder, cluster)
+	template := newPodTemplateSpec(ctx, node.log, nodeName, cluster.Name, cluster.Namespace, n, cluster.Spec.Spec, labels, roleMap, client, logConfig)
+	template = appendExporterContainer(template, cluster)
+	setConfigChecksumAnnotation(&template, client, cluster)
 
 	dpl := deployment.New(nodeName, cluster.Namespace, labels, replicas).
 		WithSelector(metav1.LabelSelector{
@@ -59,14 +77,22 @@ func (node *deploymentNode) populateReference(nodeName string, n api.Elasticsear
 
 	node.self = *dpl
 	node.clusterName = cluster.Name
+	node.configMapName = configMapName(cluster.Name, cluster.Spec.Spec)
 	node.replicas = replicas
+	node.updateStrategy = n.UpdateStrategy
+	node.minReadySeconds = getMinReadySeconds(n, cluster.Spec.Spec)
 
 	node.client = client
 	node.esClient = esClient
 }
 
 func (node *deploymentNode) updateReference(n NodeTypeInterface) {
-	node.self = n.(*deploymentNode).self
+	desired := n.(*deploymentNode)
+	node.self = desired.self
+	node.updateStrategy = desired.updateStrategy
+	node.minReadySeconds = desired.minReadySeconds
+	node.client = desired.client
+	node.esClient = desired.esClient
 }
 
 func (node *deploymentNode) scaleDown() error {
@@ -85,6 +111,10 @@ func (node *deploymentNode) getSecretHash() string {
 	return node.secretHash
 }
 
+func (node *deploymentNode) getMinReadySeconds() int32 {
+	return node.minReadySeconds
+}
+
 func (node *deploymentNode) state() api.ElasticsearchNodeStatus {
 	// var rolloutForReload v1.ConditionStatus
 	var rolloutForUpdate v1.ConditionStatus
@@ -126,6 +156,9 @@ func (node *deploymentNode) delete() error {
 
 func (node *deploymentNode) create() error {
 	if node.self.ObjectMeta.ResourceVersion == "" {
+		if err := ensureVolumeClaimsExist(context.TODO(), node.client, node.self.Namespace, node.self.Spec.Template.Spec); err != nil {
+			return err
+		}
 
 		err := deployment.Create(context.TODO(), node.client, &node.self)
 		if err != nil {
@@ -282,9 +315,18 @@ func (node *deploymentNode) replicaCount() (int32, error) {
 	return dpl.Status.Replicas, nil
 }
 
+// elasticsearchNodeName returns the name the node joins the cluster with, the
+// prefixed deployment name
+func (node *deploymentNode) elasticsearchNodeName() string {
+	if name, ok := nodeNameFromEnv(node.self.Spec.Template.Spec, ""); ok {
+		return name
+	}
+	return node.name()
+}
+
 func (node *deploymentNode) waitForNodeRejoinCluster() (bool, error) {
 	err := wait.Poll(time.Second*1, time.Second*60, func() (done bool, err error) {
-		return node.esClient.IsNodeInCluster(node.name())
+		return node.esClient.IsNodeInCluster(node.elasticsearchNodeName())
 	})
 
 	return err == nil, err
@@ -292,7 +334,7 @@ func (node *deploymentNode) waitForNodeRejoinCluster() (bool, error) {
 
 func (node *deploymentNode) waitForNodeLeaveCluster() (bool, error) {
 	err := wait.Poll(time.Second*1, time.Second*60, func() (done bool, err error) {
-		inCluster, checkErr := node.esClient.IsNodeInCluster(node.name())
+		inCluster, checkErr := node.esClient.IsNodeInCluster(node.elasticsearchNodeName())
 
 		return !inCluster, checkErr
 	})
@@ -332,7 +374,19 @@ func (node *deploymentNode) executeUpdate() error {
 	return nil
 }
 
+// isHeldBack returns whether the update strategy of the node keeps the changes
+// from being rolled out to this replica. With OnDelete the replica is updated
+// once its deployment is deleted and recreated.
+func (node *deploymentNode) isHeldBack() bool {
+	strategyType, partition := getUpdateStrategy(node.updateStrategy)
+	return strategyType == api.OnDeleteStrategy || node.ordinal < partition
+}
+
 func (node *deploymentNode) progressNodeChanges() error {
+	if node.isHeldBack() {
+		return nil
+	}
+
 	if !node.isChanged() && node.podSpecMatches() {
 		return nil
 	}
@@ -364,13 +418,13 @@ func (node *deploymentNode) progressNodeChanges() error {
 }
 
 func (node *deploymentNode) refreshHashes() {
-	key := client.ObjectKey{Name: node.clusterName, Namespace: node.self.Namespace}
-
+	key := client.ObjectKey{Name: node.configMapName, Namespace: node.self.Namespace}
 	newConfigmapHash := configmap.GetDataSHA256(context.TODO(), node.client, key, excludeConfigMapKeys)
 	if newConfigmapHash != "" && newConfigmapHash != node.configmapHash {
 		node.configmapHash = newConfigmapHash
 	}
 
+	key = client.ObjectKey{Name: node.clusterName, Namespace: node.self.Namespace}
 	newSecretHash := secret.GetDataSHA256(context.TODO(), node.client, key)
 	if newSecretHash != "" && newSecretHash != node.secretHash {
 		node.secretHash = newSecretHash
@@ -378,6 +432,10 @@ func (node *deploymentNode) refreshHashes() {
 }
 
 func (node *deploymentNode) isChanged() bool {
+	if node.isHeldBack() {
+		return false
+	}
+
 	key := client.ObjectKey{Name: node.self.Name, Namespace: node.self.Namespace}
 	current, err := deployment.Get(context.TODO(), node.client, key)
 	if err != nil {
@@ -34,11 +34,14 @@ var _ = Describe("deployment", func() {
 		}
 		client = fake.NewFakeClient(&current.self)
 
-		elasticsearch = newElasticsearchContainer("someImage",
-			newEnvVars("mynodename", "clustername", "", map[loggingv1.ElasticsearchNodeRole]bool{}),
+		elasticsearch = newElasticsearchContainer("someImage", v1.PullIfNotPresent,
+			newEnvVars("mynodename", "clustername", "clustername-cluster", "", defaultHeapDumpLocation, defaultRecoverAfterTime, defaultReadinessScriptTimeout, map[loggingv1.ElasticsearchNodeRole]bool{}),
 			v1.ResourceRequirements{
 				Limits: v1.ResourceList{},
-			})
+			},
+			getPorts(loggingv1.ElasticsearchNodeSpec{}),
+			newReadinessProbe(nil, getPorts(loggingv1.ElasticsearchNodeSpec{})),
+			newLivenessProbe(nil, getPorts(loggingv1.ElasticsearchNodeSpec{})))
 
 		newDesired = func(elasticsearch v1.Container) *deploymentNode {
 			return &deploymentNode{
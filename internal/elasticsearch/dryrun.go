@@ -0,0 +1,179 @@
+package elasticsearch
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const dryRunAnnotation = "elasticsearch.openshift.io/dry-run"
+
+// IsDryRun returns true if the reconciliation of the cluster only reports the
+// changes it would make instead of applying them
+func IsDryRun(cluster *api.Elasticsearch) bool {
+	dryRun, _ := strconv.ParseBool(cluster.GetAnnotations()[dryRunAnnotation])
+	return dryRun
+}
+
+// dryRunClient reads through the wrapped client and reports the writes, with
+// the diff against the current objects, instead of sending them
+type dryRunClient struct {
+	client.Client
+	log      logr.Logger
+	recorder record.EventRecorder
+	cluster  *api.Elasticsearch
+}
+
+// newDryRunClient returns a client reporting the writes of the reconciliation
+// of the cluster in the log and as events on the cluster
+func newDryRunClient(c client.Client, log logr.Logger, recorder record.EventRecorder, cluster *api.Elasticsearch) client.Client {
+	return &dryRunClient{
+		Client:   c,
+		log:      log.WithValues("dryRun", true),
+		recorder: recorder,
+		cluster:  cluster,
+	}
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, _ ...client.CreateOption) error {
+	current, err := c.current(ctx, obj)
+	if err == nil {
+		return apierrors.NewAlreadyExists(c.groupResource(current), obj.GetName())
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	c.report("create", obj, "")
+	return nil
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	current, err := c.current(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	c.report("update", obj, objectDiff(current, obj))
+	return nil
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	current, err := c.current(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	c.report("patch", obj, objectDiff(current, obj))
+	return nil
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	if _, err := c.current(ctx, obj); err != nil {
+		return err
+	}
+
+	c.report("delete", obj, "")
+	return nil
+}
+
+func (c *dryRunClient) DeleteAllOf(_ context.Context, obj client.Object, _ ...client.DeleteAllOfOption) error {
+	c.report("delete all of", obj, "")
+	return nil
+}
+
+func (c *dryRunClient) Status() client.StatusWriter {
+	return &dryRunStatusWriter{client: c}
+}
+
+// current returns the object as it is stored
+func (c *dryRunClient) current(ctx context.Context, obj client.Object) (client.Object, error) {
+	current, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return nil, kverrors.New("unexpected object type", "name", obj.GetName())
+	}
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// report logs the write skipped in dry-run mode and records it on the cluster
+func (c *dryRunClient) report(verb string, obj client.Object, diff string) {
+	if (verb == "update" || verb == "patch") && diff == "" {
+		return
+	}
+
+	kind := c.kind(obj)
+	c.log.Info("Skipping write in dry-run mode",
+		"verb", verb,
+		"kind", kind,
+		"name", obj.GetName(),
+		"diff", diff)
+	if c.recorder != nil {
+		c.recorder.Eventf(c.cluster, v1.EventTypeNormal, eventReasonDryRun, "Would %s %s %s", verb, kind, obj.GetName())
+	}
+}
+
+func (c *dryRunClient) kind(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return obj.GetObjectKind().GroupVersionKind().Kind
+	}
+	return gvk.Kind
+}
+
+func (c *dryRunClient) groupResource(obj client.Object) schema.GroupResource {
+	gvk, _ := apiutil.GVKForObject(obj, c.Scheme())
+	return schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}
+}
+
+// dryRunStatusWriter logs the status updates skipped in dry-run mode
+type dryRunStatusWriter struct {
+	client *dryRunClient
+}
+
+func (w *dryRunStatusWriter) Update(ctx context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	current, err := w.client.current(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	if diff := objectDiff(current, obj); diff != "" {
+		w.client.log.V(1).Info("Skipping status update in dry-run mode",
+			"kind", w.client.kind(obj),
+			"name", obj.GetName(),
+			"diff", diff)
+	}
+	return nil
+}
+
+func (w *dryRunStatusWriter) Patch(ctx context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	return w.Update(ctx, obj)
+}
+
+// objectDiff returns the differences between the current and the desired object,
+// compared through their unstructured representations
+func objectDiff(current, desired client.Object) string {
+	from, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return err.Error()
+	}
+	to, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return err.Error()
+	}
+	return cmp.Diff(from, to)
+}
@@ -0,0 +1,134 @@
+package elasticsearch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ViaQ/logerr/v2/log"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// writeCountingClient counts the writes reaching the wrapped client, including
+// the requested status writers
+type writeCountingClient struct {
+	client.Client
+	writes int
+}
+
+func (c *writeCountingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.writes++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.writes++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.writes++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *writeCountingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.writes++
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Status() client.StatusWriter {
+	c.writes++
+	return c.Client.Status()
+}
+
+func TestDryRunClientSkipsWrites(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "elasticsearch",
+			Namespace:   "openshift-logging",
+			Annotations: map[string]string{dryRunAnnotation: "true"},
+		},
+	}
+	current := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch-cluster",
+			Namespace: "openshift-logging",
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "172.30.0.10",
+		},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch-cdm-1-0",
+			Namespace: "openshift-logging",
+		},
+	}
+
+	if !IsDryRun(cluster) {
+		t.Fatal("Exp. the dry-run annotation to enable the dry-run mode")
+	}
+
+	counting := &writeCountingClient{Client: fake.NewFakeClient(current, pod)}
+	recorder := record.NewFakeRecorder(20)
+	req := &ElasticsearchRequest{
+		client:  newDryRunClient(counting, log.NewLogger("dryrun-testing"), recorder, cluster),
+		cluster: cluster,
+		ll:      log.NewLogger("dryrun-testing"),
+	}
+
+	if err := req.CreateOrUpdateServices(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	pod.Status.Phase = v1.PodRunning
+	if err := req.client.Status().Update(context.TODO(), pod); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	if counting.writes != 0 {
+		t.Errorf("Exp. no write to reach the client in dry-run mode but got %d", counting.writes)
+	}
+
+	got := &v1.Service{}
+	key := types.NamespacedName{Name: "elasticsearch-cluster", Namespace: "openshift-logging"}
+	if err := counting.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if got.Spec.ClusterIP != "172.30.0.10" {
+		t.Errorf("Exp. the cluster service to be left unchanged but cluster IP was %q", got.Spec.ClusterIP)
+	}
+
+	services := &v1.ServiceList{}
+	if err := counting.List(context.TODO(), services); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if len(services.Items) != 1 {
+		t.Errorf("Exp. no service to be created in dry-run mode but got %d services", len(services.Items))
+	}
+
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	for _, want := range []string{
+		"Normal DryRun Would delete Service elasticsearch-cluster",
+		"Normal DryRun Would create Service elasticsearch",
+	} {
+		found := false
+		for _, event := range events {
+			if strings.HasPrefix(event, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Exp. an event %q but got %v", want, events)
+		}
+	}
+}
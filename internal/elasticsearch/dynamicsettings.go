@@ -0,0 +1,215 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// dynamicSettingPrefixes lists the cluster settings elasticsearch can update at
+// runtime through the cluster settings API. An entry ending with a dot matches
+// every setting below it.
+var dynamicSettingPrefixes = []string{
+	"action.destructive_requires_name",
+	"cluster.blocks.",
+	"cluster.indices.close.enable",
+	"cluster.info.update.interval",
+	"cluster.max_shards_per_node",
+	"cluster.persistent_tasks.allocation.",
+	"cluster.routing.allocation.",
+	"cluster.routing.rebalance.enable",
+	"cluster.routing.use_adaptive_replica_selection",
+	"indices.breaker.",
+	"indices.lifecycle.poll_interval",
+	"indices.recovery.max_bytes_per_sec",
+	"logger.",
+	"script.max_compilations_rate",
+	"search.default_search_timeout",
+	"search.low_level_cancellation",
+	"search.max_buckets",
+	"transport.tracer.",
+}
+
+// operatorClusterSettings are dynamic settings the operator sets itself, either
+// in elasticsearch.yml or through the cluster settings API. User values for
+// them are never applied at runtime.
+var operatorClusterSettings = []string{
+	"action.auto_create_index",
+	"cluster.routing.allocation.awareness.attributes",
+	"cluster.routing.allocation.enable",
+	"cluster.routing.allocation.exclude._name",
+	"discovery.zen.minimum_master_nodes",
+}
+
+// isDynamicSetting returns true if the setting can be applied to a running
+// cluster without restarting its nodes
+func isDynamicSetting(key string) bool {
+	if isOperatorClusterSetting(key) {
+		return false
+	}
+
+	for _, prefix := range dynamicSettingPrefixes {
+		if key == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOperatorClusterSetting returns true if the setting, or the setting it is
+// part of, is set by the operator
+func isOperatorClusterSetting(key string) bool {
+	for _, setting := range operatorClusterSettings {
+		if key == setting || strings.HasPrefix(key, setting+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSettingValue parses a setting value as YAML, e.g. into a boolean or a
+// number, falling back to the raw string
+func parseSettingValue(raw string) interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+		return raw
+	}
+	return value
+}
+
+// splitUserSettings separates the user settings which are applied through the
+// cluster settings API from the ones which require a node restart
+func splitUserSettings(userConfig map[string]string) (map[string]string, map[string]string) {
+	dynamic := map[string]string{}
+	static := map[string]string{}
+	for key, value := range userConfig {
+		if key != log4jConfig && isDynamicSetting(key) {
+			dynamic[key] = value
+			continue
+		}
+		static[key] = value
+	}
+	return dynamic, static
+}
+
+// diskWatermarkSettings returns the cluster settings of the disk watermarks set
+// in the spec
+func diskWatermarkSettings(watermarks *api.ElasticsearchDiskWatermarksSpec) map[string]string {
+	settings := map[string]string{}
+	if watermarks == nil {
+		return settings
+	}
+	if watermarks.Low != "" {
+		settings["cluster.routing.allocation.disk.watermark.low"] = watermarks.Low
+	}
+	if watermarks.High != "" {
+		settings["cluster.routing.allocation.disk.watermark.high"] = watermarks.High
+	}
+	if watermarks.FloodStage != "" {
+		settings["cluster.routing.allocation.disk.watermark.flood_stage"] = watermarks.FloodStage
+	}
+	return settings
+}
+
+// dynamicSettingsChanges returns the persistent cluster settings to update for
+// the current settings to match the desired ones. Settings previously applied
+// by the operator and no longer desired are reset to their default.
+func dynamicSettingsChanges(desired map[string]string, current map[string]interface{}, applied []string) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for key, raw := range desired {
+		value := parseSettingValue(raw)
+		if cur, ok := current[key]; ok && fmt.Sprint(cur) == fmt.Sprint(value) {
+			continue
+		}
+		changes[key] = value
+	}
+
+	for _, key := range applied {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if _, ok := current[key]; ok {
+			changes[key] = nil
+		}
+	}
+
+	return changes
+}
+
+// updateDynamicSettings applies the dynamic user settings to the running
+// cluster through the cluster settings API
+func (er *ElasticsearchRequest) updateDynamicSettings() {
+	if !er.ClusterReady() {
+		return
+	}
+
+	userConfig, err := er.getUserConfig()
+	if err != nil {
+		er.L().Error(err, "Unable to get user configuration")
+		return
+	}
+	desired, _ := splitUserSettings(userConfig)
+	for key, value := range diskWatermarkSettings(er.cluster.Spec.DiskWatermarks) {
+		desired[key] = value
+	}
+
+	current, err := er.esClient.GetPersistentClusterSettings()
+	if err != nil {
+		er.L().Error(err, "Unable to get persistent cluster settings")
+		return
+	}
+
+	changes := dynamicSettingsChanges(desired, current, er.cluster.Status.DynamicSettings)
+	if len(changes) > 0 {
+		er.L().Info("Updating dynamic cluster settings", "settings", changes)
+		if err := er.esClient.UpdatePersistentClusterSettings(changes); err != nil {
+			er.L().Error(err, "Unable to update dynamic cluster settings")
+			return
+		}
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := er.updateDynamicSettingsStatus(keys); err != nil {
+		er.L().Error(err, "Unable to update dynamic settings status")
+	}
+}
+
+func (er *ElasticsearchRequest) updateDynamicSettingsStatus(keys []string) error {
+	cluster := er.cluster
+	if len(keys) == 0 {
+		keys = nil
+	}
+	if reflect.DeepEqual(cluster.Status.DynamicSettings, keys) {
+		return nil
+	}
+
+	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := er.client.Get(context.TODO(), types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cluster); err != nil {
+			return err
+		}
+
+		cluster.Status.DynamicSettings = keys
+		return er.client.Status().Update(context.TODO(), cluster)
+	})
+
+	if retryErr != nil {
+		return kverrors.Wrap(retryErr, "failed to update dynamic settings status",
+			"cluster", cluster.Name,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitUserSettings(t *testing.T) {
+	userConfig := map[string]string{
+		"cluster.routing.allocation.disk.watermark.low": "80%",
+		"cluster.routing.allocation.enable":             "none",
+		"indices.recovery.max_bytes_per_sec":            "100mb",
+		"logger.org.elasticsearch.discovery":            "DEBUG",
+		"search.max_buckets":                            "20000",
+		"action.auto_create_index":                      "false",
+		"thread_pool.write.queue_size":                  "500",
+		"indices.memory.index_buffer_size":              "20%",
+		"discovery.zen.ping_timeout":                    "10s",
+		log4jConfig:                                     "status = error\n",
+	}
+
+	dynamic, static := splitUserSettings(userConfig)
+
+	wantDynamic := map[string]string{
+		"cluster.routing.allocation.disk.watermark.low": "80%",
+		"indices.recovery.max_bytes_per_sec":            "100mb",
+		"logger.org.elasticsearch.discovery":            "DEBUG",
+		"search.max_buckets":                            "20000",
+	}
+	wantStatic := map[string]string{
+		"cluster.routing.allocation.enable": "none",
+		"action.auto_create_index":          "false",
+		"thread_pool.write.queue_size":      "500",
+		"indices.memory.index_buffer_size":  "20%",
+		"discovery.zen.ping_timeout":        "10s",
+		log4jConfig:                         "status = error\n",
+	}
+
+	if !reflect.DeepEqual(dynamic, wantDynamic) {
+		t.Errorf("dynamic settings: got %v, want %v", dynamic, wantDynamic)
+	}
+	if !reflect.DeepEqual(static, wantStatic) {
+		t.Errorf("static settings: got %v, want %v", static, wantStatic)
+	}
+}
+
+func TestDynamicSettingsChanges(t *testing.T) {
+	tests := []struct {
+		desc    string
+		desired map[string]string
+		current map[string]interface{}
+		applied []string
+		want    map[string]interface{}
+	}{
+		{
+			desc:    "settings already applied",
+			desired: map[string]string{"search.max_buckets": "20000", "cluster.routing.rebalance.enable": "all"},
+			current: map[string]interface{}{"search.max_buckets": "20000", "cluster.routing.rebalance.enable": "all"},
+			applied: []string{"cluster.routing.rebalance.enable", "search.max_buckets"},
+			want:    map[string]interface{}{},
+		},
+		{
+			desc:    "new and changed settings",
+			desired: map[string]string{"search.max_buckets": "30000", "logger.org.elasticsearch.discovery": "DEBUG"},
+			current: map[string]interface{}{"search.max_buckets": "20000"},
+			applied: []string{"search.max_buckets"},
+			want:    map[string]interface{}{"search.max_buckets": 30000, "logger.org.elasticsearch.discovery": "DEBUG"},
+		},
+		{
+			desc:    "removed settings are reset",
+			desired: map[string]string{},
+			current: map[string]interface{}{"search.max_buckets": "20000", "cluster.routing.allocation.enable": "all"},
+			applied: []string{"search.max_buckets"},
+			want:    map[string]interface{}{"search.max_buckets": nil},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got := dynamicSettingsChanges(test.desired, test.current, test.applied)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
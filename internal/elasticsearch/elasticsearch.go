@@ -4,6 +4,7 @@ import (
 	"github.com/ViaQ/logerr/v2/kverrors"
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+	v1 "k8s.io/api/core/v1"
 )
 
 // this function should be called before we try doing operations to make sure all our nodes are
@@ -51,7 +52,7 @@ func (er *ElasticsearchRequest) updateMinMasters() {
 		er.L().Info("Unable to get current min master count")
 	}
 
-	desiredMasterCount := getMasterCount(er.cluster)/2 + 1
+	desiredMasterCount := int32(CalculateNodeQuorum(er.cluster))
 	currentNodeCount, err := er.esClient.GetClusterNodeCount()
 	if err != nil {
 		er.L().Error(err, "Unable to get cluster node count")
@@ -76,6 +77,57 @@ func (er *ElasticsearchRequest) tryEnsureAllShardAllocation() {
 	}
 }
 
+// ensureShardAllocationRecovered re-enables the shard allocation left disabled by
+// a restart the operator was interrupted in. It is checked once per cluster after
+// the operator started, as soon as a node is ready.
+func (er *ElasticsearchRequest) ensureShardAllocationRecovered() {
+	key := nodeMapKey(er.cluster.Name, er.cluster.Namespace)
+	if allocationCheckedMap == nil {
+		allocationCheckedMap = make(map[string]bool)
+	}
+	if allocationCheckedMap[key] || !er.AnyNodeReady() {
+		return
+	}
+
+	// a restart in progress enables the shard allocation again once it completes
+	if er.restartInProgress() {
+		allocationCheckedMap[key] = true
+		return
+	}
+
+	allocation, err := er.esClient.GetShardAllocation()
+	if err != nil || allocation == "" {
+		er.L().Info("Unable to get shard allocation", "error", err)
+		return
+	}
+
+	if allocation != string(api.ShardAllocationAll) {
+		er.L().Info("Re-enabling shard allocation left disabled without a restart in progress", "allocation", allocation)
+		if ok, err := er.esClient.SetShardAllocation(api.ShardAllocationAll); !ok {
+			er.L().Error(err, "Unable to enable shard allocation")
+			return
+		}
+		er.recordEvent(v1.EventTypeWarning, eventReasonShardAllocationReEnabled,
+			"Shard allocation was %q without a restart in progress and has been re-enabled", allocation)
+	}
+	allocationCheckedMap[key] = true
+}
+
+// restartInProgress returns true if a node of the cluster is being restarted or
+// a full cluster restart is in progress
+func (er *ElasticsearchRequest) restartInProgress() bool {
+	if containsClusterCondition(api.Recovering, v1.ConditionTrue, &er.cluster.Status) {
+		return true
+	}
+	for _, node := range er.cluster.Status.Nodes {
+		if node.UpgradeStatus.UnderUpgrade == v1.ConditionTrue ||
+			node.UpgradeStatus.ScheduledForCertRedeploy == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func (er *ElasticsearchRequest) tryEnsureNoTransitiveShardAllocations() {
 	if !er.AnyNodeReady() {
 		return
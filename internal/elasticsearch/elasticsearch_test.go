@@ -2,11 +2,17 @@ package elasticsearch
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/ViaQ/logerr/v2/log"
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/constants"
 	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
 	"github.com/openshift/elasticsearch-operator/test/helpers"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -140,3 +146,162 @@ func TestCreateSettingToUnblock(t *testing.T) {
 				}
 			}`)
 }
+
+func TestRollingHealthCheckRequiresGreenWithReplicas(t *testing.T) {
+	const (
+		esCluster   = "elasticsearch"
+		esNamespace = "openshift-logging"
+	)
+
+	tests := []struct {
+		desc      string
+		dataNodes int32
+		status    string
+		wantErr   bool
+	}{
+		{desc: "single data node yellow", dataNodes: 1, status: "yellow"},
+		{desc: "single data node red", dataNodes: 1, status: "red", wantErr: true},
+		{desc: "multiple data nodes green", dataNodes: 3, status: "green"},
+		{desc: "multiple data nodes yellow", dataNodes: 3, status: "yellow", wantErr: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			k8sClient := fake.NewFakeClient()
+			chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+				"_cluster/health": {
+					{
+						StatusCode: 200,
+						Body:       `{"status": "` + test.status + `"}`,
+					},
+				},
+			})
+			esClient := helpers.NewFakeElasticsearchClient(esCluster, esNamespace, k8sClient, chatter)
+
+			er := ElasticsearchRequest{
+				client:   k8sClient,
+				esClient: esClient,
+				cluster: &loggingv1.Elasticsearch{
+					ObjectMeta: metav1.ObjectMeta{Name: esCluster, Namespace: esNamespace},
+					Spec: loggingv1.ElasticsearchSpec{
+						Nodes: []loggingv1.ElasticsearchNode{
+							{
+								Roles:     []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleData},
+								NodeCount: test.dataNodes,
+							},
+						},
+					},
+				},
+			}
+			cr := ClusterRestart{
+				client:           esClient,
+				clusterName:      esCluster,
+				clusterNamespace: esNamespace,
+			}
+
+			err := er.rollingHealthCheck(cr)()
+			if test.wantErr && err == nil {
+				t.Errorf("expected health check to fail for status %q", test.status)
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnsureShardAllocationRecovered(t *testing.T) {
+	const (
+		esCluster   = "elasticsearch"
+		esNamespace = "openshift-logging"
+	)
+
+	underUpgrade := loggingv1.ElasticsearchStatus{
+		Nodes: []loggingv1.ElasticsearchNodeStatus{
+			{
+				DeploymentName: "elasticsearch-cdm-1",
+				UpgradeStatus:  loggingv1.ElasticsearchNodeUpgradeStatus{UnderUpgrade: v1.ConditionTrue},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc       string
+		allocation string
+		status     loggingv1.ElasticsearchStatus
+		wantEnable bool
+	}{
+		{desc: "allocation enabled", allocation: "all"},
+		{desc: "allocation left to primaries", allocation: "primaries", wantEnable: true},
+		{desc: "allocation left disabled", allocation: "none", wantEnable: true},
+		{desc: "allocation disabled by a restart in progress", allocation: "primaries", status: underUpgrade},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			allocationCheckedMap = nil
+
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "elasticsearch-cdm-1-0",
+					Namespace: esNamespace,
+					Labels: map[string]string{
+						"component":    "elasticsearch",
+						"cluster-name": esCluster,
+						"es-node-data": "true",
+					},
+				},
+				Status: v1.PodStatus{Phase: v1.PodRunning},
+			}
+			k8sClient := fake.NewFakeClient(pod)
+			chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+				"_cluster/settings?include_defaults=true": {
+					{
+						StatusCode: 200,
+						Body:       `{"persistent": {"cluster": {"routing": {"allocation": {"enable": "` + test.allocation + `"}}}}}`,
+					},
+				},
+				"_cluster/settings": {
+					{
+						StatusCode: 200,
+						Body:       `{"acknowledged": true}`,
+					},
+				},
+			})
+			recorder := record.NewFakeRecorder(10)
+
+			er := ElasticsearchRequest{
+				client:   k8sClient,
+				esClient: helpers.NewFakeElasticsearchClient(esCluster, esNamespace, k8sClient, chatter),
+				cluster: &loggingv1.Elasticsearch{
+					ObjectMeta: metav1.ObjectMeta{Name: esCluster, Namespace: esNamespace},
+					Status:     test.status,
+				},
+				ll:       log.NewLogger("elasticsearch-testing"),
+				recorder: recorder,
+			}
+
+			er.ensureShardAllocationRecovered()
+
+			req, found := chatter.GetRequest("_cluster/settings")
+			if test.wantEnable {
+				if !found || req.Method != http.MethodPut || !strings.Contains(req.Body, `"all"`) {
+					t.Errorf("Exp. the shard allocation to be re-enabled but got %v", chatter.Requests)
+				}
+				if len(recorder.Events) != 1 {
+					t.Errorf("Exp. an event for the re-enabled shard allocation")
+				}
+			} else if found {
+				t.Errorf("Exp. the shard allocation to be left as is but got %v", req)
+			}
+
+			// the check only runs once after the operator started
+			er.ensureShardAllocationRecovered()
+			if reqs := chatter.Requests["_cluster/settings?include_defaults=true"]; len(reqs) > 1 {
+				t.Errorf("Exp. the shard allocation to be checked once but got %d requests", len(reqs))
+			}
+		})
+	}
+}
@@ -11,9 +11,9 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
@@ -40,11 +40,15 @@ type Client interface {
 	GetDiskWatermarks() (interface{}, interface{}, interface{}, error)
 	GetMinMasterNodes() (int32, error)
 	SetMinMasterNodes(numberMasters int32) (bool, error)
+	GetPersistentClusterSettings() (map[string]interface{}, error)
+	UpdatePersistentClusterSettings(settings map[string]interface{}) error
+	UpdateClusterSettings(settings map[string]interface{}) error
 	DoSynchronizedFlush() (bool, error)
 
 	// Cluster State API
 	GetLowestClusterVersion() (string, error)
 	IsNodeInCluster(nodeName string) (bool, error)
+	GetElectedMaster() (string, error)
 
 	// Health API
 	GetClusterHealth() (api.ClusterHealth, error)
@@ -56,6 +60,8 @@ type Client interface {
 	CreateIndex(name string, index *estypes.Index) error
 	ReIndex(src, dst, script, lang string) error
 	GetAllIndices(name string) (estypes.CatIndicesResponses, error)
+	GetIndicesCreationDate(pattern string) (estypes.CatIndicesResponses, error)
+	DeleteIndex(name string) error
 
 	// Index Alias API
 	ListIndicesForAlias(aliasPattern string) ([]string, error)
@@ -68,6 +74,7 @@ type Client interface {
 
 	// Nodes API
 	GetNodeDiskUsage(nodeName string) (string, float64, error)
+	GetNodes() ([]estypes.CatNodesResponse, error)
 
 	// Replicas
 	UpdateReplicaCount(replicaCount int32) error
@@ -78,6 +85,10 @@ type Client interface {
 	ClearTransientShardAllocation() (bool, error)
 	GetShardAllocation() (string, error)
 	SetShardAllocation(state api.ShardAllocationState) (bool, error)
+	SetAllocationExcludedNodes(nodeNames []string) (bool, error)
+	GetAllocationExcludedNodes() ([]string, error)
+	GetNodeShardCount(nodeName string) (int32, error)
+	GetShards() ([]estypes.CatShardsResponse, error)
 
 	// Index Templates API
 	CreateIndexTemplate(name string, template *estypes.IndexTemplate) error
@@ -86,6 +97,13 @@ type Client interface {
 	GetIndexTemplates() (map[string]estypes.GetIndexTemplate, error)
 	UpdateTemplatePrimaryShards(shardCount int32) error
 
+	// Snapshot API
+	GetSnapshotRepository(name string) (*estypes.SnapshotRepository, error)
+	CreateSnapshotRepository(name string, repository *estypes.SnapshotRepository) error
+	DeleteSnapshotRepository(name string) error
+	CreateSnapshot(repository, name string, snapshot *estypes.Snapshot) error
+	GetSnapshot(repository, name string) (*estypes.SnapshotInfo, error)
+
 	SetSendRequestFn(fn FnEsSendRequest)
 }
 
@@ -119,6 +137,34 @@ func NewClient(log logr.Logger, cluster, namespace string, client k8sclient.Clie
 	}
 }
 
+// NewDryRunClient returns a client only sending the requests reading from the
+// cluster. The other requests fail without being sent.
+func NewDryRunClient(log logr.Logger, cluster, namespace string, client k8sclient.Client) Client {
+	return &esClient{
+		log:             log,
+		cluster:         cluster,
+		namespace:       namespace,
+		k8sClient:       client,
+		fnSendEsRequest: dryRunSendRequestFn(sendEsRequest),
+	}
+}
+
+// dryRunSendRequestFn returns a function sending the GET requests with send and
+// failing the others
+func dryRunSendRequestFn(send FnEsSendRequest) FnEsSendRequest {
+	return func(log logr.Logger, cluster, namespace string, payload *EsRequest, client k8sclient.Client) {
+		if payload.Method == http.MethodGet {
+			send(log, cluster, namespace, payload, client)
+			return
+		}
+
+		log.Info("Skipping request in dry-run mode", "method", payload.Method, "url", payload.URI)
+		payload.Error = kverrors.New("request not sent in dry-run mode",
+			"method", payload.Method,
+			"url", payload.URI)
+	}
+}
+
 func (ec *esClient) SetSendRequestFn(fn FnEsSendRequest) {
 	ec.fnSendEsRequest = fn
 }
@@ -134,159 +180,90 @@ func (ec *esClient) errorCtx() kverrors.Context {
 	)
 }
 
-// FIXME: this needs to return an error instead of swallowing
+// clusterURL returns the base URL of the REST API behind the cluster service
+func clusterURL(cluster, namespace string) string {
+	return fmt.Sprintf("https://%s.%s.svc:9200", cluster, namespace)
+}
+
 func sendEsRequest(log logr.Logger, cluster, namespace string, payload *EsRequest, client k8sclient.Client) {
-	u := fmt.Sprintf("https://%s.%s.svc:9200/%s", cluster, namespace, payload.URI)
-	urlURL, err := url.Parse(u)
-	if err != nil {
-		log.Error(err, "failed to parse URL", "url", u)
-		return
+	// we use the insecure TLS client here because we are providing the SA token.
+	httpClient := getTLSClient(log, cluster, namespace, client)
+	sendPayload(log, clusterURL(cluster, namespace), httpClient, ensureTokenHeader(log, nil), payload)
+
+	// TODO: eventually remove after all ES images have been updated to use SA token auth for EO?
+	if payload.StatusCode == http.StatusForbidden ||
+		payload.StatusCode == http.StatusUnauthorized {
+		log.Info("failed sending payload using bearer token", "method", payload.Method, "url", payload.URI)
+		// if we get a 401 that means that we couldn't read from the token and provided
+		// no header.
+		// if we get a 403 that means the ES cluster doesn't allow us to use
+		// our SA token.
+		// in both cases, try the old way.
+		sendRequestWithMTlsClient(log, cluster, namespace, payload, client)
 	}
+}
 
-	request := &http.Request{
-		Method: payload.Method,
-		URL:    urlURL,
-	}
+func sendRequestWithMTlsClient(log logr.Logger, clusterName, namespace string, payload *EsRequest, client k8sclient.Client) {
+	httpClient := getMTlsClient(log, clusterName, namespace, client)
+	sendPayload(log, clusterURL(clusterName, namespace), httpClient, nil, payload)
 
-	switch payload.Method {
-	case http.MethodGet:
-		// no more to do to request...
-	case http.MethodPost:
-		if payload.RequestBody != "" {
-			// add to the request
-			request.Header = map[string][]string{
-				"Content-Type": {
-					"application/json",
-				},
-			}
-			request.Body = ioutil.NopCloser(bytes.NewReader([]byte(payload.RequestBody)))
-		}
+	if payload.StatusCode == http.StatusForbidden || payload.StatusCode == http.StatusUnauthorized {
+		log.Info("failed sending payload using mTLS PKI", "method", payload.Method, "url", payload.URI)
+	}
+}
 
-	case http.MethodPut:
-		if payload.RequestBody != "" {
-			// add to the request
-			request.Header = map[string][]string{
-				"Content-Type": {
-					"application/json",
-				},
-			}
-			request.Body = ioutil.NopCloser(bytes.NewReader([]byte(payload.RequestBody)))
-		}
+// sendPayload sends the payload to the REST API at baseURL and records the response in it
+func sendPayload(log logr.Logger, baseURL string, httpClient *http.Client, header http.Header, payload *EsRequest) {
+	payload.StatusCode = 0
+	payload.RawResponseBody = ""
+	payload.ResponseBody = nil
 
-	default:
-		// unsupported method -- do nothing
+	request, err := newRequest(baseURL, payload)
+	if err != nil {
+		log.Error(err, "failed to create request", "method", payload.Method, "url", payload.URI)
+		payload.Error = err
 		return
 	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
 
-	request.Header = ensureTokenHeader(log, request.Header)
-	// we use the insecure TLS client here because we are providing the SA token.
-	httpClient := getTLSClient(log, cluster, namespace, client)
 	resp, err := httpClient.Do(request)
 	if err != nil {
-		if resp == nil {
-			payload.Error = err
-			return
-		}
+		payload.Error = err
+		return
 	}
 	defer resp.Body.Close()
 
-	if resp != nil {
-		// TODO: eventually remove after all ES images have been updated to use SA token auth for EO?
-		if resp.StatusCode == http.StatusForbidden ||
-			resp.StatusCode == http.StatusUnauthorized {
-			log.Info("failed sending payload using bearer token", "method", payload.Method, "url", payload.URI)
-			// if we get a 401 that means that we couldn't read from the token and provided
-			// no header.
-			// if we get a 403 that means the ES cluster doesn't allow us to use
-			// our SA token.
-			// in both cases, try the old way.
-
-			// Not sure why, but just trying to reuse the request with the old client
-			// resulted in a 400 every time. Doing it this way got a 200 response as expected.
-			sendRequestWithMTlsClient(log, cluster, namespace, payload, client)
-			return
-		}
-
-		payload.StatusCode = resp.StatusCode
-		if payload.RawResponseBody, err = getRawBody(resp.Body); err != nil {
-			log.Error(err, "failed to get raw response body")
-		}
-		if payload.ResponseBody, err = getMapFromBody(payload.RawResponseBody); err != nil {
-			log.Error(err, "getMapFromBody failed")
-		}
-	}
-
-	payload.Error = err
-}
-
-func sendRequestWithMTlsClient(log logr.Logger, clusterName, namespace string, payload *EsRequest, client k8sclient.Client) {
-	u := fmt.Sprintf("https://%s.%s.svc:9200/%s", clusterName, namespace, payload.URI)
-	urlURL, err := url.Parse(u)
-	if err != nil {
-		log.Error(err, "unable to parse URL", "url", u)
-		return
+	payload.StatusCode = resp.StatusCode
+	payload.Error = nil
+	if payload.RawResponseBody, err = getRawBody(resp.Body); err != nil {
+		log.Error(err, "failed to get raw response body")
 	}
-
-	request := &http.Request{
-		Method: payload.Method,
-		URL:    urlURL,
+	if payload.ResponseBody, err = getMapFromBody(payload.RawResponseBody); err != nil {
+		log.Error(err, "getMapFromBody failed")
 	}
+}
 
-	switch payload.Method {
-	case http.MethodGet:
-		// no more to do to request...
-	case http.MethodPost:
-		if payload.RequestBody != "" {
-			// add to the request
-			request.Header = map[string][]string{
-				"Content-Type": {
-					"application/json",
-				},
-			}
-			request.Body = ioutil.NopCloser(bytes.NewReader([]byte(payload.RequestBody)))
-		}
-
-	case http.MethodPut:
-		if payload.RequestBody != "" {
-			// add to the request
-			request.Header = map[string][]string{
-				"Content-Type": {
-					"application/json",
-				},
-			}
-			request.Body = ioutil.NopCloser(bytes.NewReader([]byte(payload.RequestBody)))
-		}
-
-	default:
-		// unsupported method -- do nothing
-		return
+// newRequest builds the HTTP request of the payload, sending its body as JSON
+func newRequest(baseURL string, payload *EsRequest) (*http.Request, error) {
+	var body io.Reader
+	if payload.RequestBody != "" {
+		body = strings.NewReader(payload.RequestBody)
 	}
 
-	httpClient := getMTlsClient(log, clusterName, namespace, client)
-	resp, err := httpClient.Do(request)
+	request, err := http.NewRequest(payload.Method, fmt.Sprintf("%s/%s", baseURL, payload.URI), body)
 	if err != nil {
-		if resp == nil {
-			payload.Error = err
-			return
-		}
+		return nil, kverrors.Wrap(err, "failed to create request",
+			"method", payload.Method,
+			"uri", payload.URI,
+		)
 	}
-	defer resp.Body.Close()
-
-	if resp != nil {
-		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
-			log.Info("failed sending payload using mTLS PKI", "method", payload.Method, "url", payload.URI)
-		}
-
-		payload.StatusCode = resp.StatusCode
-		if payload.RawResponseBody, err = getRawBody(resp.Body); err != nil {
-			log.Error(err, "failed to get raw response body")
-		}
-		if payload.ResponseBody, err = getMapFromBody(payload.RawResponseBody); err != nil {
-			log.Error(err, "getMapFrombody failed")
-		}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
 	}
 
-	payload.Error = err
+	return request, nil
 }
 
 func ensureTokenHeader(log logr.Logger, header http.Header) http.Header {
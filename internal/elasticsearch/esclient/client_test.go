@@ -1,9 +1,17 @@
 package esclient
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/ViaQ/logerr/v2/log"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestHeaderGenEmptyToken(t *testing.T) {
@@ -44,3 +52,120 @@ func TestHeaderGenWithNoToken(t *testing.T) {
 		t.Errorf("Expected to be unable to read file [%s]", tokenFile)
 	}
 }
+
+type recordedRequest struct {
+	method        string
+	uri           string
+	contentType   string
+	authorization string
+	body          string
+}
+
+// newTestServer returns a TLS server answering like elasticsearch and a client sending to it
+func newTestServer(t *testing.T, header http.Header) (*httptest.Server, Client, *[]recordedRequest) {
+	requests := []recordedRequest{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{
+			method:        r.Method,
+			uri:           r.URL.RequestURI(),
+			contentType:   r.Header.Get("Content-Type"),
+			authorization: r.Header.Get("Authorization"),
+			body:          string(body),
+		})
+
+		switch r.URL.Path {
+		case "/_cluster/health":
+			_, _ = w.Write([]byte(`{"cluster_name":"elasticsearch","status":"green","number_of_nodes":3,"number_of_data_nodes":2,"active_primary_shards":5,"active_shards":10,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":0,"number_of_pending_tasks":0,"active_shards_percent_as_number":100.0}`))
+		case "/_cat/nodes":
+			_, _ = w.Write([]byte(`[{"name":"elasticsearch-cdm-1","ip":"10.128.2.10","node.role":"mdi","master":"*","heap.percent":"42","version":"6.8.1"},{"name":"elasticsearch-cm-1","ip":"10.128.2.11","node.role":"m","master":"-","heap.percent":"12","version":"6.8.1"}]`))
+		case "/_template/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"index_template_missing_exception","status":404}`))
+		default:
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(logr.Discard(), "elasticsearch", "openshift-logging", nil)
+	client.SetSendRequestFn(func(log logr.Logger, cluster, namespace string, payload *EsRequest, _ k8sclient.Client) {
+		sendPayload(log, server.URL, server.Client(), header, payload)
+	})
+	return server, client, &requests
+}
+
+func TestClusterURL(t *testing.T) {
+	want := "https://elasticsearch.openshift-logging.svc:9200"
+	if got := clusterURL("elasticsearch", "openshift-logging"); got != want {
+		t.Errorf("Exp. %q but got %q", want, got)
+	}
+}
+
+func TestSendPayloadDecodesResponses(t *testing.T) {
+	_, client, requests := newTestServer(t, http.Header{"Authorization": {"Bearer test"}})
+
+	health, err := client.GetClusterHealth()
+	if err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	wantHealth := api.ClusterHealth{Status: "green", NumNodes: 3, NumDataNodes: 2, ActivePrimaryShards: 5, ActiveShards: 10, ActiveShardsPercent: "100.0"}
+	if diff := cmp.Diff(wantHealth, health); diff != "" {
+		t.Errorf("unexpected cluster health (-want +got):\n%s", diff)
+	}
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	wantNodes := []estypes.CatNodesResponse{
+		{Name: "elasticsearch-cdm-1", IP: "10.128.2.10", NodeRole: "mdi", Master: "*", HeapPercent: "42", Version: "6.8.1"},
+		{Name: "elasticsearch-cm-1", IP: "10.128.2.11", NodeRole: "m", Master: "-", HeapPercent: "12", Version: "6.8.1"},
+	}
+	if diff := cmp.Diff(wantNodes, nodes); diff != "" {
+		t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+	}
+
+	wantURI := "/_cat/nodes?format=json&h=name,ip,node.role,master,heap.percent,version"
+	if got := (*requests)[1]; got.method != http.MethodGet || got.uri != wantURI {
+		t.Errorf("Exp. GET %s but got %s %s", wantURI, got.method, got.uri)
+	}
+	for _, req := range *requests {
+		if req.authorization != "Bearer test" {
+			t.Errorf("Exp. the authorization header to be sent with %s but got %q", req.uri, req.authorization)
+		}
+	}
+}
+
+func TestSendPayloadMethods(t *testing.T) {
+	_, client, requests := newTestServer(t, nil)
+
+	template := &estypes.IndexTemplate{Template: "app*"}
+	if err := client.CreateIndexTemplate("app", template); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if err := client.DeleteIndex("app-000001"); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if err := client.DeleteIndexTemplate("missing"); err != nil {
+		t.Fatalf("Exp. deleting a missing template to succeed but got: %s", err)
+	}
+
+	want := []recordedRequest{
+		{method: http.MethodPut, uri: "/_template/app", contentType: "application/json", body: `{"template":"app*","settings":{}}`},
+		{method: http.MethodDelete, uri: "/app-000001"},
+		{method: http.MethodDelete, uri: "/_template/missing"},
+	}
+	if diff := cmp.Diff(want, *requests, cmp.AllowUnexported(recordedRequest{})); diff != "" {
+		t.Errorf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestSendPayloadConnectionError(t *testing.T) {
+	server, client, _ := newTestServer(t, nil)
+	server.Close()
+
+	if _, err := client.GetNodes(); err == nil {
+		t.Error("Exp. an error when the cluster is unreachable")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+	"github.com/openshift/elasticsearch-operator/internal/utils"
 	"github.com/openshift/elasticsearch-operator/internal/utils/comparators"
 )
 
@@ -204,6 +205,72 @@ func (ec *esClient) GetMinMasterNodes() (int32, error) {
 	return masterCount, payload.Error
 }
 
+// GetPersistentClusterSettings returns the persistent cluster settings keyed by
+// their flat setting name
+func (ec *esClient) GetPersistentClusterSettings() (map[string]interface{}, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    "_cluster/settings?flat_settings=true",
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+
+	if payload.Error != nil {
+		return nil, payload.Error
+	}
+	if payload.StatusCode != 200 {
+		return nil, ec.errorCtx().New("failed to get persistent cluster settings",
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody)
+	}
+
+	settings := map[string]interface{}{}
+	if persistent, ok := payload.ResponseBody["persistent"].(map[string]interface{}); ok {
+		settings = persistent
+	}
+
+	return settings, nil
+}
+
+// UpdatePersistentClusterSettings sets the given persistent cluster settings,
+// a nil value resets the setting to its default
+func (ec *esClient) UpdatePersistentClusterSettings(settings map[string]interface{}) error {
+	return ec.UpdateClusterSettings(map[string]interface{}{"persistent": settings})
+}
+
+// UpdateClusterSettings sets the given cluster settings, keyed by persistent or
+// transient like the body of the cluster settings API
+func (ec *esClient) UpdateClusterSettings(settings map[string]interface{}) error {
+	body, err := utils.ToJSON(settings)
+	if err != nil {
+		return ec.errorCtx().Wrap(err, "failed to marshal cluster settings")
+	}
+
+	payload := &EsRequest{
+		Method:      http.MethodPut,
+		URI:         "_cluster/settings",
+		RequestBody: body,
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+
+	if payload.Error != nil {
+		return payload.Error
+	}
+
+	acknowledged := false
+	if acknowledgedBool, ok := payload.ResponseBody["acknowledged"].(bool); ok {
+		acknowledged = acknowledgedBool
+	}
+	if payload.StatusCode != 200 || !acknowledged {
+		return ec.errorCtx().New("failed to update cluster settings",
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody)
+	}
+
+	return nil
+}
+
 // TODO: also check that the number of shards in the response > 0?
 func (ec *esClient) DoSynchronizedFlush() (bool, error) {
 	payload := &EsRequest{
@@ -310,3 +377,34 @@ func (ec *esClient) IsNodeInCluster(nodeName string) (bool, error) {
 
 	return false, nil
 }
+
+// GetElectedMaster returns the name of the elected master node or an empty
+// string if the cluster has no master
+func (ec *esClient) GetElectedMaster() (string, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    "_cluster/state/master_node,nodes",
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil {
+		return "", payload.Error
+	}
+	if payload.StatusCode != http.StatusOK {
+		return "", ec.errorCtx().New("failed to get cluster state",
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody,
+		)
+	}
+
+	res := &estypes.MasterNodeAndNodeStateResponse{}
+	err := json.Unmarshal([]byte(payload.RawResponseBody), res)
+	if err != nil {
+		return "", ec.errorCtx().Wrap(err, "failed to decode raw response body into `estypes.MasterNodeAndNodeStateResponse`")
+	}
+
+	if res.MasterNode == "" {
+		return "", nil
+	}
+	return res.Nodes[res.MasterNode].Name, nil
+}
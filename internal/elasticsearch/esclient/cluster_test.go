@@ -135,3 +135,101 @@ func TestIsNodeInCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestGetElectedMaster(t *testing.T) {
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_cluster/state/master_node,nodes": {
+			{
+				StatusCode: 200,
+				Body:       `{"cluster_name": "elasticsearch", "nodes": {"nodeuuid1": {"name": "node1"}}}`,
+			},
+			{
+				StatusCode: 200,
+				Body:       `{"cluster_name": "elasticsearch", "master_node": "nodeuuid2", "nodes": {"nodeuuid1": {"name": "node1"}, "nodeuuid2": {"name": "node2"}}}`,
+			},
+		},
+	})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "test-namespace", fakeClient, chatter)
+
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{
+			desc: "no elected master",
+			want: "",
+		},
+		{
+			desc: "elected master",
+			want: "node2",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := esClient.GetElectedMaster()
+			if err != nil {
+				t.Errorf("got err: %s", err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetPersistentClusterSettings(t *testing.T) {
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_cluster/settings?flat_settings=true": {
+			{
+				StatusCode: 200,
+				Body:       `{"persistent": {"search.max_buckets": "20000", "logger.org.elasticsearch.discovery": "DEBUG"}, "transient": {}}`,
+			},
+		},
+	})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "test-namespace", fakeClient, chatter)
+
+	got, err := esClient.GetPersistentClusterSettings()
+	if err != nil {
+		t.Errorf("got err: %s", err)
+	}
+
+	want := map[string]interface{}{
+		"search.max_buckets":                 "20000",
+		"logger.org.elasticsearch.discovery": "DEBUG",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestUpdatePersistentClusterSettings(t *testing.T) {
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_cluster/settings": {
+			{
+				StatusCode: 200,
+				Body:       `{"acknowledged": true}`,
+			},
+		},
+	})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "test-namespace", fakeClient, chatter)
+
+	settings := map[string]interface{}{
+		"search.max_buckets":                 20000,
+		"logger.org.elasticsearch.discovery": nil,
+	}
+	if err := esClient.UpdatePersistentClusterSettings(settings); err != nil {
+		t.Errorf("got err: %s", err)
+	}
+
+	req, found := chatter.GetRequest("_cluster/settings")
+	if !found {
+		t.Fatal("expected a request to _cluster/settings")
+	}
+
+	want := `{"persistent":{"logger.org.elasticsearch.discovery":null,"search.max_buckets":20000}}`
+	if req.Method != "PUT" || req.Body != want {
+		t.Errorf("got %s %s, want PUT %s", req.Method, req.Body, want)
+	}
+}
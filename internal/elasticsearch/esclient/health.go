@@ -2,6 +2,7 @@ package esclient
 
 import (
 	"net/http"
+	"strconv"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 )
@@ -25,6 +26,9 @@ func (ec *esClient) GetClusterHealth() (api.ClusterHealth, error) {
 	clusterHealth.NumDataNodes = parseInt32("number_of_data_nodes", payload.ResponseBody)
 	clusterHealth.ActivePrimaryShards = parseInt32("active_primary_shards", payload.ResponseBody)
 	clusterHealth.ActiveShards = parseInt32("active_shards", payload.ResponseBody)
+	if percent := parseFloat64("active_shards_percent_as_number", payload.ResponseBody); percent >= 0 {
+		clusterHealth.ActiveShardsPercent = strconv.FormatFloat(percent, 'f', 1, 64)
+	}
 	clusterHealth.RelocatingShards = parseInt32("relocating_shards", payload.ResponseBody)
 	clusterHealth.InitializingShards = parseInt32("initializing_shards", payload.ResponseBody)
 	clusterHealth.UnassignedShards = parseInt32("unassigned_shards", payload.ResponseBody)
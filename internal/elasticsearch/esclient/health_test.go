@@ -0,0 +1,58 @@
+package esclient_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/test/helpers"
+)
+
+func TestGetClusterHealth(t *testing.T) {
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_cluster/health": {
+			{
+				StatusCode: 200,
+				Body: `{
+  "cluster_name": "elasticsearch",
+  "status": "yellow",
+  "timed_out": false,
+  "number_of_nodes": 3,
+  "number_of_data_nodes": 2,
+  "active_primary_shards": 10,
+  "active_shards": 15,
+  "relocating_shards": 1,
+  "initializing_shards": 2,
+  "unassigned_shards": 3,
+  "delayed_unassigned_shards": 0,
+  "number_of_pending_tasks": 4,
+  "number_of_in_flight_fetch": 0,
+  "task_max_waiting_in_queue_millis": 0,
+  "active_shards_percent_as_number": 75.0
+}`,
+			},
+		},
+	})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "test-namespace", fakeClient, chatter)
+
+	want := api.ClusterHealth{
+		Status:              "yellow",
+		NumNodes:            3,
+		NumDataNodes:        2,
+		ActivePrimaryShards: 10,
+		ActiveShards:        15,
+		ActiveShardsPercent: "75.0",
+		RelocatingShards:    1,
+		InitializingShards:  2,
+		UnassignedShards:    3,
+		PendingTasks:        4,
+	}
+
+	got, err := esClient.GetClusterHealth()
+	if err != nil {
+		t.Errorf("got err: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("cluster health mismatch (-want +got):\n%s", diff)
+	}
+}
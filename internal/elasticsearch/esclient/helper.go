@@ -1,9 +1,22 @@
 package esclient
 
 import (
+	"encoding/json"
 	"strings"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
 )
 
+// decodeResponse decodes the raw JSON response body of the payload into v
+func decodeResponse(payload *EsRequest, v interface{}) error {
+	if err := json.Unmarshal([]byte(payload.RawResponseBody), v); err != nil {
+		return kverrors.Wrap(err, "failed to decode response body",
+			"uri", payload.URI,
+		)
+	}
+	return nil
+}
+
 func parseBool(path string, interfaceMap map[string]interface{}) bool {
 	value := walkInterfaceMap(path, interfaceMap)
 
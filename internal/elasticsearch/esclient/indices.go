@@ -69,6 +69,52 @@ func (ec *esClient) GetAllIndices(name string) (estypes.CatIndicesResponses, err
 	return res, nil
 }
 
+// GetIndicesCreationDate returns the name and creation date of the indices matching the pattern
+func (ec *esClient) GetIndicesCreationDate(pattern string) (estypes.CatIndicesResponses, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    fmt.Sprintf("_cat/indices/%s?format=json&h=index,creation.date", pattern),
+	}
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if payload.Error != nil {
+		return nil, payload.Error
+	}
+	if payload.StatusCode != http.StatusOK {
+		return nil, ec.errorCtx().New("failed to get indices creation date",
+			"pattern", pattern,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody)
+	}
+
+	res := estypes.CatIndicesResponses{}
+	if err := json.Unmarshal([]byte(payload.RawResponseBody), &res); err != nil {
+		return nil, kverrors.Wrap(err, "failed to parse _cat/indices response body",
+			"pattern", pattern)
+	}
+	return res, nil
+}
+
+func (ec *esClient) DeleteIndex(name string) error {
+	payload := &EsRequest{
+		Method: http.MethodDelete,
+		URI:    name,
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error == nil && (payload.StatusCode == http.StatusNotFound || payload.StatusCode < 300) {
+		return nil
+	}
+
+	return ec.errorCtx().New("failed to delete index",
+		"index", name,
+		"response_status", payload.StatusCode,
+		"response_body", payload.ResponseBody,
+		"response_error", payload.Error)
+}
+
 func (ec *esClient) CreateIndex(name string, index *estypes.Index) error {
 	body, err := utils.ToJSON(index)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/inhies/go-bytesize"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
 )
 
 func (ec *esClient) GetNodeDiskUsage(nodeName string) (string, float64, error) {
@@ -36,3 +37,25 @@ func (ec *esClient) GetNodeDiskUsage(nodeName string) (string, float64, error) {
 
 	return usage, percentUsage, payload.Error
 }
+
+// GetNodes returns the nodes of the cluster as listed by _cat/nodes
+func (ec *esClient) GetNodes() ([]estypes.CatNodesResponse, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    "_cat/nodes?format=json&h=name,ip,node.role,master,heap.percent,version",
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil || payload.StatusCode != http.StatusOK {
+		return nil, ec.errorCtx().New("failed to list nodes",
+			"response_status", payload.StatusCode,
+			"response_body", payload.RawResponseBody,
+			"response_error", payload.Error)
+	}
+
+	nodes := []estypes.CatNodesResponse{}
+	if err := decodeResponse(payload, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
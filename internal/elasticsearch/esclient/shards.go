@@ -1,10 +1,14 @@
 package esclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
 )
 
 func (ec *esClient) ClearTransientShardAllocation() (bool, error) {
@@ -75,3 +79,111 @@ func (ec *esClient) GetShardAllocation() (string, error) {
 
 	return allocationString, payload.Error
 }
+
+// SetAllocationExcludedNodes excludes the named nodes from shard allocation,
+// relocating their shards to the other nodes. An empty list clears the exclusion.
+func (ec *esClient) SetAllocationExcludedNodes(nodeNames []string) (bool, error) {
+	value := "null"
+	if len(nodeNames) > 0 {
+		value = fmt.Sprintf("%q", strings.Join(nodeNames, ","))
+	}
+
+	payload := &EsRequest{
+		Method:      http.MethodPut,
+		URI:         "_cluster/settings",
+		RequestBody: fmt.Sprintf("{%q:{%q:%s}}", "persistent", "cluster.routing.allocation.exclude._name", value),
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+
+	acknowledged := false
+	if acknowledgedBool, ok := payload.ResponseBody["acknowledged"].(bool); ok {
+		acknowledged = acknowledgedBool
+	}
+	return payload.StatusCode == 200 && acknowledged, ec.errorCtx().Wrap(payload.Error, "failed to set allocation exclusion",
+		"response", payload.RawResponseBody)
+}
+
+// GetAllocationExcludedNodes returns the names of the nodes excluded from shard allocation
+func (ec *esClient) GetAllocationExcludedNodes() ([]string, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    "_cluster/settings",
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+
+	excluded, ok := walkInterfaceMap(
+		"persistent.cluster.routing.allocation.exclude._name",
+		payload.ResponseBody).(string)
+	if !ok || excluded == "" {
+		return []string{}, payload.Error
+	}
+
+	return strings.Split(excluded, ","), payload.Error
+}
+
+// GetNodeShardCount returns the number of shards allocated on the named node
+func (ec *esClient) GetNodeShardCount(nodeName string) (int32, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    fmt.Sprintf("_cat/allocation/%s?format=json&h=shards,node", nodeName),
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil {
+		return 0, payload.Error
+	}
+	if payload.StatusCode != http.StatusOK {
+		return 0, ec.errorCtx().New("failed to get node allocation",
+			"node", nodeName,
+			"response_status", payload.StatusCode,
+			"response_body", payload.RawResponseBody,
+		)
+	}
+
+	allocations := []map[string]string{}
+	if err := json.Unmarshal([]byte(payload.RawResponseBody), &allocations); err != nil {
+		return 0, ec.errorCtx().Wrap(err, "failed to decode node allocation",
+			"node", nodeName,
+		)
+	}
+
+	shards := int32(0)
+	for _, allocation := range allocations {
+		if allocation["node"] != nodeName {
+			continue
+		}
+		count, err := strconv.ParseInt(allocation["shards"], 10, 32)
+		if err != nil {
+			return 0, ec.errorCtx().Wrap(err, "failed to parse node shard count",
+				"node", nodeName,
+			)
+		}
+		shards += int32(count)
+	}
+
+	return shards, nil
+}
+
+// GetShards returns the shards of the cluster as listed by _cat/shards
+func (ec *esClient) GetShards() ([]estypes.CatShardsResponse, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    "_cat/shards?format=json&h=index,shard,prirep,state,node",
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil || payload.StatusCode != http.StatusOK {
+		return nil, ec.errorCtx().New("failed to list shards",
+			"response_status", payload.StatusCode,
+			"response_body", payload.RawResponseBody,
+			"response_error", payload.Error)
+	}
+
+	shards := []estypes.CatShardsResponse{}
+	if err := decodeResponse(payload, &shards); err != nil {
+		return nil, err
+	}
+	return shards, nil
+}
@@ -0,0 +1,150 @@
+package esclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+	"github.com/openshift/elasticsearch-operator/internal/utils"
+)
+
+// GetSnapshotRepository returns the registered repository or nil if there is none with this name
+func (ec *esClient) GetSnapshotRepository(name string) (*estypes.SnapshotRepository, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    fmt.Sprintf("_snapshot/%s", name),
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil {
+		return nil, payload.Error
+	}
+	if payload.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if payload.StatusCode != http.StatusOK {
+		return nil, ec.errorCtx().New("failed to get snapshot repository",
+			"repository", name,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody)
+	}
+
+	repositories := map[string]estypes.SnapshotRepository{}
+	if err := json.Unmarshal([]byte(payload.RawResponseBody), &repositories); err != nil {
+		return nil, kverrors.Wrap(err, "failed decoding raw response body into `estypes.SnapshotRepository`",
+			"repository", name)
+	}
+
+	repository, ok := repositories[name]
+	if !ok {
+		return nil, nil
+	}
+	return &repository, nil
+}
+
+func (ec *esClient) CreateSnapshotRepository(name string, repository *estypes.SnapshotRepository) error {
+	body, err := utils.ToJSON(repository)
+	if err != nil {
+		return err
+	}
+	payload := &EsRequest{
+		Method:      http.MethodPut,
+		URI:         fmt.Sprintf("_snapshot/%s", name),
+		RequestBody: body,
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil || payload.StatusCode != http.StatusOK {
+		return ec.errorCtx().New("failed to create snapshot repository",
+			"repository", name,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody,
+			"response_error", payload.Error)
+	}
+	return nil
+}
+
+// DeleteSnapshotRepository deregisters the repository, leaving the snapshots
+// stored in it untouched. A repository that is not registered is ignored.
+func (ec *esClient) DeleteSnapshotRepository(name string) error {
+	payload := &EsRequest{
+		Method: http.MethodDelete,
+		URI:    fmt.Sprintf("_snapshot/%s", name),
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error == nil && payload.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if payload.Error != nil || payload.StatusCode != http.StatusOK {
+		return ec.errorCtx().New("failed to delete snapshot repository",
+			"repository", name,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody,
+			"response_error", payload.Error)
+	}
+	return nil
+}
+
+// CreateSnapshot starts a snapshot without waiting for its completion
+func (ec *esClient) CreateSnapshot(repository, name string, snapshot *estypes.Snapshot) error {
+	body, err := utils.ToJSON(snapshot)
+	if err != nil {
+		return err
+	}
+	payload := &EsRequest{
+		Method:      http.MethodPut,
+		URI:         fmt.Sprintf("_snapshot/%s/%s", repository, name),
+		RequestBody: body,
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil || payload.StatusCode != http.StatusOK {
+		return ec.errorCtx().New("failed to create snapshot",
+			"repository", repository,
+			"snapshot", name,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody,
+			"response_error", payload.Error)
+	}
+	return nil
+}
+
+// GetSnapshot returns the snapshot or nil if there is none with this name
+func (ec *esClient) GetSnapshot(repository, name string) (*estypes.SnapshotInfo, error) {
+	payload := &EsRequest{
+		Method: http.MethodGet,
+		URI:    fmt.Sprintf("_snapshot/%s/%s", repository, name),
+	}
+
+	ec.fnSendEsRequest(ec.log, ec.cluster, ec.namespace, payload, ec.k8sClient)
+	if payload.Error != nil {
+		return nil, payload.Error
+	}
+	if payload.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if payload.StatusCode != http.StatusOK {
+		return nil, ec.errorCtx().New("failed to get snapshot",
+			"repository", repository,
+			"snapshot", name,
+			"response_status", payload.StatusCode,
+			"response_body", payload.ResponseBody)
+	}
+
+	res := estypes.SnapshotsResponse{}
+	if err := json.Unmarshal([]byte(payload.RawResponseBody), &res); err != nil {
+		return nil, kverrors.Wrap(err, "failed decoding raw response body into `estypes.SnapshotsResponse`",
+			"repository", repository,
+			"snapshot", name)
+	}
+
+	for _, snapshot := range res.Snapshots {
+		if snapshot.Snapshot == name {
+			return &snapshot, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"context"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events recorded on the Elasticsearch resource
+const (
+	eventReasonClusterFormed               = "ClusterFormed"
+	eventReasonClusterHealthChanged        = "ClusterHealthChanged"
+	eventReasonScalingUp                   = "ScalingUp"
+	eventReasonScaledUp                    = "ScaledUp"
+	eventReasonScaledDown                  = "ScaledDown"
+	eventReasonPersistentVolumeClaimFailed = "PersistentVolumeClaimFailed"
+	eventReasonDryRun                      = "DryRun"
+	eventReasonShardAllocationReEnabled    = "ShardAllocationReEnabled"
+)
+
+type eventRecorderKey struct{}
+
+// clusterEventRecorder records the events of a cluster
+type clusterEventRecorder struct {
+	recorder record.EventRecorder
+	cluster  *api.Elasticsearch
+}
+
+// withEventRecorder returns a context recording the events of the functions it
+// is passed to on the cluster. Without a recorder the context is returned as is.
+func withEventRecorder(ctx context.Context, recorder record.EventRecorder, cluster *api.Elasticsearch) context.Context {
+	if recorder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventRecorderKey{}, clusterEventRecorder{recorder: recorder, cluster: cluster})
+}
+
+// recordEvent records an event on the cluster of the context, if any
+func recordEvent(ctx context.Context, eventType, reason, messageFmt string, args ...interface{}) {
+	r, ok := ctx.Value(eventRecorderKey{}).(clusterEventRecorder)
+	if !ok {
+		return
+	}
+	r.recorder.Eventf(r.cluster, eventType, reason, messageFmt, args...)
+}
+
+// recordEvent records an event on the cluster of the request
+func (er *ElasticsearchRequest) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if er.recorder == nil {
+		return
+	}
+	er.recorder.Eventf(er.cluster, eventType, reason, messageFmt, args...)
+}
+
+// recordHealthEvent records the transitions of the cluster health. The first
+// health reported while the cluster was unreachable is its formation.
+func (er *ElasticsearchRequest) recordHealthEvent(previous, current string) {
+	switch {
+	case previous == current, previous == "" && current == healthUnknown:
+		return
+	case previous == "" || previous == healthUnknown:
+		er.recordEvent(v1.EventTypeNormal, eventReasonClusterFormed, "Elasticsearch cluster formed with health %s", current)
+	default:
+		eventType := v1.EventTypeWarning
+		if current == greenClusterState {
+			eventType = v1.EventTypeNormal
+		}
+		er.recordEvent(eventType, eventReasonClusterHealthChanged, "Elasticsearch cluster health changed from %s to %s", previous, current)
+	}
+}
@@ -0,0 +1,99 @@
+package elasticsearch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ViaQ/logerr/v2/log"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPersistentVolumeClaimFailureEvent(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	ctx := withEventRecorder(context.TODO(), recorder, cluster)
+
+	// a client without any registered type fails to create the claim
+	k8sClient := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+	size := resource.MustParse("10Gi")
+	newStorageVolumeSource(ctx, log.NewLogger("events-testing"), "elasticsearch-cdm-1", cluster.Name, cluster.Namespace,
+		api.ElasticsearchStorageSpec{Size: &size}, k8sClient)
+
+	select {
+	case event := <-recorder.Events:
+		want := "Warning PersistentVolumeClaimFailed Unable to create PersistentVolumeClaim elasticsearch-cdm-1"
+		if !strings.HasPrefix(event, want) {
+			t.Errorf("got event %q, want prefix %q", event, want)
+		}
+	default:
+		t.Error("Exp. an event recorded on the persistent volume claim failure")
+	}
+}
+
+func TestRecordHealthEvent(t *testing.T) {
+	tests := []struct {
+		desc     string
+		previous string
+		current  string
+		want     string
+	}{
+		{
+			desc:     "unchanged health",
+			previous: "green",
+			current:  "green",
+		},
+		{
+			desc:     "new cluster not reachable yet",
+			previous: "",
+			current:  healthUnknown,
+		},
+		{
+			desc:     "cluster formed",
+			previous: healthUnknown,
+			current:  "yellow",
+			want:     "Normal ClusterFormed Elasticsearch cluster formed with health yellow",
+		},
+		{
+			desc:     "health degraded",
+			previous: "green",
+			current:  "red",
+			want:     "Warning ClusterHealthChanged Elasticsearch cluster health changed from green to red",
+		},
+		{
+			desc:     "health recovered",
+			previous: "yellow",
+			current:  "green",
+			want:     "Normal ClusterHealthChanged Elasticsearch cluster health changed from yellow to green",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			er := &ElasticsearchRequest{
+				cluster:  &api.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"}},
+				recorder: recorder,
+			}
+
+			er.recordHealthEvent(test.previous, test.current)
+
+			got := ""
+			select {
+			case got = <-recorder.Events:
+			default:
+			}
+			if got != test.want {
+				t.Errorf("got event %q, want %q", got, test.want)
+			}
+		})
+	}
+}
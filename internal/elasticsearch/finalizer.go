@@ -0,0 +1,105 @@
+package elasticsearch
+
+import (
+	"context"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/persistentvolume"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// clusterFinalizer holds the deletion of a cluster until the state it leaves
+// behind outside of its owned resources is cleaned up
+const clusterFinalizer = "logging.openshift.io/elasticsearch-cleanup"
+
+// EnsureFinalizer adds the cleanup finalizer to the cluster
+func EnsureFinalizer(ctx context.Context, c client.Client, cluster *api.Elasticsearch) error {
+	if !controllerutil.AddFinalizer(cluster, clusterFinalizer) {
+		return nil
+	}
+
+	if err := c.Update(ctx, cluster); err != nil {
+		return kverrors.Wrap(err, "failed to add finalizer to elasticsearch cluster",
+			"cluster", cluster.Name,
+			"namespace", cluster.Namespace,
+		)
+	}
+	return nil
+}
+
+// Finalize cleans up after a deleted cluster and removes its finalizer to let
+// the deletion complete. Clusters not managed by the operator, paused or in
+// dry-run mode are released without any cleanup.
+func Finalize(log logr.Logger, cluster *api.Elasticsearch, c client.Client) error {
+	ll := log.WithValues(ClusterLogValues(cluster)...)
+	esClient := esclient.NewClient(ll, cluster.Name, cluster.Namespace, c)
+	return finalizeCluster(context.TODO(), ll, cluster, c, esClient)
+}
+
+func finalizeCluster(ctx context.Context, log logr.Logger, cluster *api.Elasticsearch, c client.Client, esClient esclient.Client) error {
+	if !controllerutil.ContainsFinalizer(cluster, clusterFinalizer) {
+		return nil
+	}
+
+	if cluster.Spec.ManagementState == api.ManagementStateManaged && !cluster.Spec.Paused && !IsDryRun(cluster) {
+		if err := cleanupCluster(ctx, log, cluster, c, esClient); err != nil {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(cluster, clusterFinalizer)
+	if err := c.Update(ctx, cluster); err != nil {
+		return kverrors.Wrap(err, "failed to remove finalizer from elasticsearch cluster",
+			"cluster", cluster.Name,
+			"namespace", cluster.Namespace,
+		)
+	}
+	log.Info("Released deleted cluster")
+	return nil
+}
+
+// cleanupCluster deregisters the snapshot repositories of the cluster and deletes
+// its persistent volume claims if the cleanup policy is Delete. A cluster that
+// cannot be reached anymore keeps its repositories, it is going away anyway.
+func cleanupCluster(ctx context.Context, log logr.Logger, cluster *api.Elasticsearch, c client.Client, esClient esclient.Client) error {
+	snapshots := &api.ElasticsearchSnapshotList{}
+	if err := c.List(ctx, snapshots, client.InNamespace(cluster.Namespace)); err != nil {
+		return kverrors.Wrap(err, "failed to list elasticsearch snapshots",
+			"namespace", cluster.Namespace,
+		)
+	}
+	for _, snapshot := range snapshots.Items {
+		if snapshot.Spec.ClusterName != cluster.Name {
+			continue
+		}
+
+		name := snapshot.Spec.Repository.Name
+		log.Info("Deregistering snapshot repository", "repository", name)
+		if err := esClient.DeleteSnapshotRepository(name); err != nil {
+			log.Error(err, "unable to deregister snapshot repository", "repository", name)
+		}
+	}
+
+	if cluster.Spec.VolumeClaimCleanupPolicy != api.VolumeClaimCleanupDelete {
+		return nil
+	}
+
+	selector := map[string]string{
+		"logging-cluster": cluster.Name,
+	}
+	claims, err := persistentvolume.ListPVC(ctx, c, cluster.Namespace, selector)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		log.Info("Deleting persistent volume claim of deleted cluster", "claim", claim.Name)
+		if err := persistentvolume.DeletePVC(ctx, c, client.ObjectKeyFromObject(&claim)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/test/helpers"
+
+	"github.com/ViaQ/logerr/v2/log"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func newFinalizerTestObjects(state api.ManagementState) []client.Object {
+	return []client.Object{
+		&api.Elasticsearch{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+			Spec: api.ElasticsearchSpec{
+				ManagementState:          state,
+				VolumeClaimCleanupPolicy: api.VolumeClaimCleanupDelete,
+			},
+		},
+		&api.ElasticsearchSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "openshift-logging"},
+			Spec: api.ElasticsearchSnapshotSpec{
+				ClusterName: "elasticsearch",
+				Repository:  api.SnapshotRepositorySpec{Name: "backups"},
+			},
+		},
+		&api.ElasticsearchSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-nightly", Namespace: "openshift-logging"},
+			Spec: api.ElasticsearchSnapshotSpec{
+				ClusterName: "other",
+				Repository:  api.SnapshotRepositorySpec{Name: "other-backups"},
+			},
+		},
+		newTestClaim("elasticsearch-elasticsearch-cdm-1-1", "elasticsearch"),
+		newTestClaim("elasticsearch-storage-elasticsearch-cd-1-0", "elasticsearch"),
+		newTestClaim("other-storage", "other"),
+	}
+}
+
+// deleteWithFinalizer adds the finalizer to the cluster and deletes it,
+// returning the cluster held by the finalizer
+func deleteWithFinalizer(t *testing.T, k8sClient client.Client) *api.Elasticsearch {
+	key := client.ObjectKey{Name: "elasticsearch", Namespace: "openshift-logging"}
+
+	for i := 0; i < 2; i++ {
+		cluster := &api.Elasticsearch{}
+		if err := k8sClient.Get(context.TODO(), key, cluster); err != nil {
+			t.Fatalf("Unable to get the cluster: %v", err)
+		}
+		if err := EnsureFinalizer(context.TODO(), k8sClient, cluster); err != nil {
+			t.Fatalf("failed with error: %s", err)
+		}
+	}
+
+	cluster := &api.Elasticsearch{}
+	if err := k8sClient.Get(context.TODO(), key, cluster); err != nil {
+		t.Fatalf("Unable to get the cluster: %v", err)
+	}
+	if got := cluster.GetFinalizers(); len(got) != 1 || got[0] != clusterFinalizer {
+		t.Fatalf("Exp. the cleanup finalizer to be added once but got %v", got)
+	}
+
+	if err := k8sClient.Delete(context.TODO(), cluster); err != nil {
+		t.Fatalf("Unable to delete the cluster: %v", err)
+	}
+	if err := k8sClient.Get(context.TODO(), key, cluster); err != nil {
+		t.Fatalf("Exp. the finalizer to hold the deletion of the cluster but got %v", err)
+	}
+	if cluster.DeletionTimestamp == nil {
+		t.Fatal("Exp. the cluster to be marked for deletion")
+	}
+	return cluster
+}
+
+func remainingClaims(t *testing.T, k8sClient client.Client) []string {
+	claims := &v1.PersistentVolumeClaimList{}
+	if err := k8sClient.List(context.TODO(), claims, client.InNamespace("openshift-logging")); err != nil {
+		t.Fatalf("Unable to list the claims: %v", err)
+	}
+	names := []string{}
+	for _, claim := range claims.Items {
+		names = append(names, claim.Name)
+	}
+	return names
+}
+
+func newFinalizerTestClient(state api.ManagementState) client.Client {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(api.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(newFinalizerTestObjects(state)...).Build()
+}
+
+func TestFinalizerLifecycle(t *testing.T) {
+	k8sClient := newFinalizerTestClient(api.ManagementStateManaged)
+	cluster := deleteWithFinalizer(t, k8sClient)
+
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_snapshot/backups": {
+			{StatusCode: 200, Body: `{"acknowledged":true}`},
+		},
+	})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "openshift-logging", k8sClient, chatter)
+
+	if err := finalizeCluster(context.TODO(), log.NewLogger("finalizer-testing"), cluster, k8sClient, esClient); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	if req, found := chatter.GetRequest("_snapshot/backups"); !found || req.Method != "DELETE" {
+		t.Errorf("Exp. the snapshot repository of the cluster to be deregistered")
+	}
+	if _, found := chatter.GetRequest("_snapshot/other-backups"); found {
+		t.Errorf("Exp. the snapshot repository of another cluster to be left registered")
+	}
+
+	if got := remainingClaims(t, k8sClient); len(got) != 1 || got[0] != "other-storage" {
+		t.Errorf("Exp. only the claims of the deleted cluster to be deleted but got %v", got)
+	}
+
+	err := k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &api.Elasticsearch{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Exp. the cluster to be deleted once the finalizer is removed but got %v", err)
+	}
+}
+
+func TestFinalizerReleasesUnmanagedCluster(t *testing.T) {
+	k8sClient := newFinalizerTestClient(api.ManagementStateUnmanaged)
+	cluster := deleteWithFinalizer(t, k8sClient)
+
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{})
+	esClient := helpers.NewFakeElasticsearchClient("elasticsearch", "openshift-logging", k8sClient, chatter)
+
+	if err := finalizeCluster(context.TODO(), log.NewLogger("finalizer-testing"), cluster, k8sClient, esClient); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	if len(chatter.Requests) != 0 {
+		t.Errorf("Exp. no request to an unmanaged cluster but got %v", chatter.Requests)
+	}
+	if got := remainingClaims(t, k8sClient); len(got) != 3 {
+		t.Errorf("Exp. the claims of an unmanaged cluster to be kept but got %v", got)
+	}
+	if controllerutil.ContainsFinalizer(cluster, clusterFinalizer) {
+		t.Errorf("Exp. the finalizer to be removed from an unmanaged cluster")
+	}
+
+	err := k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &api.Elasticsearch{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Exp. the cluster to be deleted once the finalizer is removed but got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package elasticsearch
+
+import (
+	"sort"
+	"strings"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+)
+
+// ClusterLogValues returns the key/value pairs identifying the cluster on every
+// log line of its reconciliation
+func ClusterLogValues(cluster *api.Elasticsearch) []interface{} {
+	return []interface{}{"cluster", cluster.Name, "namespace", cluster.Namespace}
+}
+
+// nodeLogValues returns the key/value pairs identifying a node of the cluster,
+// its roles and the workload running its pods
+func nodeLogValues(nodeName string, roleMap map[api.ElasticsearchNodeRole]bool, workload api.ElasticsearchNodeWorkload) []interface{} {
+	return []interface{}{"node", nodeName, "roles", nodeRolesValue(roleMap), "workload", workload}
+}
+
+// nodeRolesValue returns the sorted, comma separated roles of a node
+func nodeRolesValue(roleMap map[api.ElasticsearchNodeRole]bool) string {
+	roles := make([]string, 0, len(roleMap))
+	for role, enabled := range roleMap {
+		if enabled {
+			roles = append(roles, string(role))
+		}
+	}
+	sort.Strings(roles)
+	return strings.Join(roles, ",")
+}
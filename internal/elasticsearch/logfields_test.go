@@ -0,0 +1,34 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterLogValues(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+	}
+
+	want := []interface{}{"cluster", "elasticsearch", "namespace", "openshift-logging"}
+	if got := ClusterLogValues(cluster); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNodeLogValues(t *testing.T) {
+	roleMap := map[api.ElasticsearchNodeRole]bool{
+		api.ElasticsearchRoleMaster: true,
+		api.ElasticsearchRoleData:   true,
+		api.ElasticsearchRoleClient: true,
+		api.ElasticsearchRoleIngest: false,
+	}
+
+	want := []interface{}{"node", "elasticsearch-cdm-1", "roles", "client,data,master", "workload", api.DeploymentWorkload}
+	if got := nodeLogValues("elasticsearch-cdm-1", roleMap, api.DeploymentWorkload); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/utils"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// zoneAttributeName is the node attribute set from the zone of the pod with
+	// zone awareness
+	zoneAttributeName = "zone"
+	// zoneTopologyLabel is the well-known label of the zone of a node, copied
+	// to the pods scheduled on it
+	zoneTopologyLabel = "topology.kubernetes.io/zone"
+)
+
+// nodeAttribute is a node.attr.<name> setting of elasticsearch.yml
+type nodeAttribute struct {
+	Name  string
+	Value string
+}
+
+// nodeAttributeEnvVar returns the env var carrying the value of a node
+// attribute to the pods, e.g. NODE_ATTR_BOX_TYPE for box_type
+func nodeAttributeEnvVar(name string) string {
+	return "NODE_ATTR_" + strings.ToUpper(name)
+}
+
+// nodeAttributeSettings returns the node attributes of all nodes of the cluster
+// sorted by name. The config map is shared by all nodes, so every attribute
+// reads its value from the environment of the pod and is left empty for the
+// nodes without it.
+func nodeAttributeSettings(dpl *api.Elasticsearch) []nodeAttribute {
+	names := map[string]bool{}
+	if dpl.Spec.Spec.ZoneAwareness {
+		names[zoneAttributeName] = true
+	}
+	for _, node := range dpl.Spec.Nodes {
+		for name := range node.NodeAttributes {
+			names[name] = true
+		}
+	}
+
+	attributes := make([]nodeAttribute, 0, len(names))
+	for name := range names {
+		attributes = append(attributes, nodeAttribute{
+			Name:  name,
+			Value: "${" + nodeAttributeEnvVar(name) + ":}",
+		})
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Name < attributes[j].Name
+	})
+	return attributes
+}
+
+// newNodeAttributeEnvVars returns the env vars with the node attribute values
+// of the node sorted by name. With zone awareness the zone is read from the
+// zone label of the pod unless the node sets it explicitly.
+func newNodeAttributeEnvVars(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) []v1.EnvVar {
+	names := make([]string, 0, len(node.NodeAttributes)+1)
+	for name := range node.NodeAttributes {
+		names = append(names, name)
+	}
+	_, hasZone := node.NodeAttributes[zoneAttributeName]
+	if commonSpec.ZoneAwareness && !hasZone {
+		names = append(names, zoneAttributeName)
+	}
+	sort.Strings(names)
+
+	var envVars []v1.EnvVar
+	for _, name := range names {
+		value, ok := node.NodeAttributes[name]
+		if !ok {
+			envVars = append(envVars, v1.EnvVar{
+				Name: nodeAttributeEnvVar(name),
+				ValueFrom: &v1.EnvVarSource{
+					FieldRef: &v1.ObjectFieldSelector{
+						FieldPath: fmt.Sprintf("metadata.labels['%s']", zoneTopologyLabel),
+					},
+				},
+			})
+			continue
+		}
+		envVars = append(envVars, v1.EnvVar{
+			Name:  nodeAttributeEnvVar(name),
+			Value: value,
+		})
+	}
+	return envVars
+}
+
+// awarenessAttributesSetting returns the comma separated allocation awareness
+// attributes or an empty string to leave the setting out
+func awarenessAttributesSetting(spec api.ElasticsearchNodeSpec) string {
+	attributes := spec.AllocationAwarenessAttributes
+	if spec.ZoneAwareness && !utils.Contains(attributes, zoneAttributeName) {
+		attributes = append(append([]string(nil), attributes...), zoneAttributeName)
+	}
+	return strings.Join(attributes, ",")
+}
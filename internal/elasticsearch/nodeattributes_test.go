@@ -0,0 +1,103 @@
+package elasticsearch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ViaQ/logerr/v2/log"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNodeAttributeSettings(t *testing.T) {
+	dpl := &api.Elasticsearch{
+		Spec: api.ElasticsearchSpec{
+			Nodes: []api.ElasticsearchNode{
+				{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}},
+				{NodeAttributes: map[string]string{"box_type": "hot", "rack": "r1"}},
+				{NodeAttributes: map[string]string{"box_type": "warm"}},
+			},
+		},
+	}
+
+	want := []nodeAttribute{
+		{Name: "box_type", Value: "${NODE_ATTR_BOX_TYPE:}"},
+		{Name: "rack", Value: "${NODE_ATTR_RACK:}"},
+	}
+	if got := nodeAttributeSettings(dpl); !reflect.DeepEqual(got, want) {
+		t.Errorf("Exp. node attributes %v but got %v", want, got)
+	}
+}
+
+func TestNewNodeAttributeEnvVars(t *testing.T) {
+	node := api.ElasticsearchNode{
+		NodeAttributes: map[string]string{"rack": "r1", "box_type": "hot"},
+	}
+
+	want := []v1.EnvVar{
+		{Name: "NODE_ATTR_BOX_TYPE", Value: "hot"},
+		{Name: "NODE_ATTR_RACK", Value: "r1"},
+	}
+	if got := newNodeAttributeEnvVars(node, api.ElasticsearchNodeSpec{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Exp. env vars %v but got %v", want, got)
+	}
+
+	if got := newNodeAttributeEnvVars(api.ElasticsearchNode{}, api.ElasticsearchNodeSpec{}); got != nil {
+		t.Errorf("Exp. no env vars without node attributes but got %v", got)
+	}
+}
+
+func TestZoneAwareness(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		ZoneAwareness:                 true,
+		AllocationAwarenessAttributes: []string{"rack"},
+	}
+	node := api.ElasticsearchNode{
+		Roles:          []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+		NodeAttributes: map[string]string{"rack": "r1"},
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("nodeattributes-testing"), "test-node-name", "test-cluster-name", "test-namespace-name", node, commonSpec, map[string]string{}, getNodeRoleMap(node), nil, LogConfig{})
+
+	var zone *v1.EnvVar
+	for i, env := range podTemplate.Spec.Containers[0].Env {
+		if env.Name == "NODE_ATTR_ZONE" {
+			zone = &podTemplate.Spec.Containers[0].Env[i]
+		}
+	}
+	if zone == nil || zone.ValueFrom == nil || zone.ValueFrom.FieldRef == nil {
+		t.Fatalf("Exp. the zone to be read from the downward API but got %v", zone)
+	}
+	if got := zone.ValueFrom.FieldRef.FieldPath; got != "metadata.labels['topology.kubernetes.io/zone']" {
+		t.Errorf("Exp. the zone to be read from the zone label of the pod but got %q", got)
+	}
+
+	if got := awarenessAttributesSetting(commonSpec); got != "rack,zone" {
+		t.Errorf("Exp. the awareness attributes to be rack,zone but got %q", got)
+	}
+	commonSpec.AllocationAwarenessAttributes = []string{"zone"}
+	if got := awarenessAttributesSetting(commonSpec); got != "zone" {
+		t.Errorf("Exp. the zone to be listed once but got %q", got)
+	}
+
+	dpl := &api.Elasticsearch{Spec: api.ElasticsearchSpec{Spec: commonSpec, Nodes: []api.ElasticsearchNode{node}}}
+	want := []nodeAttribute{
+		{Name: "rack", Value: "${NODE_ATTR_RACK:}"},
+		{Name: "zone", Value: "${NODE_ATTR_ZONE:}"},
+	}
+	if got := nodeAttributeSettings(dpl); !reflect.DeepEqual(got, want) {
+		t.Errorf("Exp. node attributes %v but got %v", want, got)
+	}
+
+	// an explicit zone takes precedence over the label of the pod
+	node.NodeAttributes["zone"] = "us-east-1a"
+	envVars := newNodeAttributeEnvVars(node, commonSpec)
+	wantEnvVars := []v1.EnvVar{
+		{Name: "NODE_ATTR_RACK", Value: "r1"},
+		{Name: "NODE_ATTR_ZONE", Value: "us-east-1a"},
+	}
+	if !reflect.DeepEqual(envVars, wantEnvVars) {
+		t.Errorf("Exp. env vars %v but got %v", wantEnvVars, envVars)
+	}
+}
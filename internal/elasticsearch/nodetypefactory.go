@@ -7,6 +7,7 @@ import (
 	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
 
 	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -21,6 +22,7 @@ type NodeTypeInterface interface {
 	name() string
 	delete() error
 	getSecretHash() string
+	getMinReadySeconds() int32 // the seconds the restarted pods must have been ready before the next node is restarted
 
 	refreshHashes()
 	scaleDown() error
@@ -37,22 +39,24 @@ type NodeTypeFactory func(name, namespace string) NodeTypeInterface
 func (er *ElasticsearchRequest) GetNodeTypeInterface(uuid string, node api.ElasticsearchNode) []NodeTypeInterface {
 	nodes := []NodeTypeInterface{}
 
-	roleMap := getNodeRoleMap(node)
+	roleMap := getClusterNodeRoleMap(node, er.cluster.Spec.Nodes)
 
 	// common spec => cluster.Spec.Spec
-	nodeName := fmt.Sprintf("%s-%s", er.cluster.Name, getNodeSuffix(uuid, roleMap))
+	// the name follows the declared roles, so that enabling ingest on every
+	// node of a cluster without ingest nodes keeps the names unchanged
+	nodeName := fmt.Sprintf("%s-%s", er.cluster.Name, getNodeSuffix(uuid, getNodeRoleMap(node)))
 
-	// if we have a data node then we need to create one deployment per replica
-	if isDataNode(node) {
+	// data nodes not run as statefulset need one deployment per replica
+	if isDeploymentDataNode(node) {
 		// for loop from 1 to replica as replicaIndex
 		//   it is 1 instead of 0 because of legacy code
 		for replicaIndex := int32(1); replicaIndex <= node.NodeCount; replicaIndex++ {
 			dataNodeName := addDataNodeSuffix(nodeName, replicaIndex)
-			node := newDeploymentNode(er.ll, dataNodeName, node, er.cluster, roleMap, er.client, er.esClient)
+			node := newDeploymentNode(er.ll, dataNodeName, replicaIndex-1, node, er.cluster, roleMap, er.client, er.esClient, er.recorder)
 			nodes = append(nodes, node)
 		}
 	} else {
-		node := newStatefulSetNode(er.ll, nodeName, node, er.cluster, roleMap, er.client, er.esClient)
+		node := newStatefulSetNode(er.ll, nodeName, node, er.cluster, roleMap, er.client, er.esClient, er.recorder)
 		nodes = append(nodes, node)
 	}
 
@@ -73,6 +77,11 @@ func getNodeSuffix(uuid string, roleMap map[api.ElasticsearchNodeRole]bool) stri
 		suffix = fmt.Sprintf("%s%s", suffix, "m")
 	}
 
+	// data nodes are ingest nodes too, keep their names unchanged
+	if roleMap[api.ElasticsearchRoleIngest] && !roleMap[api.ElasticsearchRoleData] {
+		suffix = fmt.Sprintf("%s%s", suffix, "i")
+	}
+
 	return fmt.Sprintf("%s-%s", suffix, uuid)
 }
 
@@ -81,9 +90,11 @@ func addDataNodeSuffix(nodeName string, replicaNumber int32) string {
 }
 
 // newDeploymentNode constructs deploymentNode struct for data nodes
-func newDeploymentNode(log logr.Logger, nodeName string, node api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, client client.Client, esClient esclient.Client) NodeTypeInterface {
+func newDeploymentNode(log logr.Logger, nodeName string, ordinal int32, node api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, client client.Client, esClient esclient.Client, recorder record.EventRecorder) NodeTypeInterface {
 	deploymentNode := deploymentNode{
-		log: log.WithValues("node", nodeName),
+		log:      log.WithValues(nodeLogValues(nodeName, roleMap, api.DeploymentWorkload)...),
+		ordinal:  ordinal,
+		recorder: recorder,
 	}
 
 	deploymentNode.populateReference(nodeName, node, cluster, roleMap, int32(1), client, esClient)
@@ -91,10 +102,11 @@ func newDeploymentNode(log logr.Logger, nodeName string, node api.ElasticsearchN
 	return &deploymentNode
 }
 
-// newStatefulSetNode constructs statefulSetNode struct for non-data nodes
-func newStatefulSetNode(log logr.Logger, nodeName string, node api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, client client.Client, esClient esclient.Client) NodeTypeInterface {
+// newStatefulSetNode constructs statefulSetNode struct for non-data nodes and data nodes run as statefulset
+func newStatefulSetNode(log logr.Logger, nodeName string, node api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, client client.Client, esClient esclient.Client, recorder record.EventRecorder) NodeTypeInterface {
 	statefulSetNode := statefulSetNode{
-		l: log.WithValues("node", nodeName),
+		l:        log.WithValues(nodeLogValues(nodeName, roleMap, api.StatefulSetWorkload)...),
+		recorder: recorder,
 	}
 
 	statefulSetNode.populateReference(nodeName, node, cluster, roleMap, node.NodeCount, client, esClient)
@@ -0,0 +1,109 @@
+package elasticsearch
+
+import (
+	"context"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/persistentvolume"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteOrphanedVolumeClaims deletes the claims of the cluster none of the given
+// nodes uses anymore if the cleanup policy is Delete. Claims still mounted by a
+// terminating pod are only removed once the pod is gone.
+func (er *ElasticsearchRequest) deleteOrphanedVolumeClaims(currentNodes []NodeTypeInterface) {
+	if er.cluster.Spec.VolumeClaimCleanupPolicy != api.VolumeClaimCleanupDelete {
+		return
+	}
+
+	inUse, err := volumeClaimNames(currentNodes)
+	if err != nil {
+		er.ll.Error(err, "unable to determine the persistent volume claims in use, skipping cleanup")
+		return
+	}
+
+	selector := map[string]string{
+		"logging-cluster": er.cluster.Name,
+	}
+	claims, err := persistentvolume.ListPVC(context.TODO(), er.client, er.cluster.Namespace, selector)
+	if err != nil {
+		er.ll.Error(err, "unable to list persistent volume claims, skipping cleanup")
+		return
+	}
+
+	for _, claim := range claims {
+		if inUse.Has(claim.Name) || claim.DeletionTimestamp != nil {
+			continue
+		}
+
+		er.ll.Info("Deleting persistent volume claim of removed node", "claim", claim.Name)
+		key := client.ObjectKey{Name: claim.Name, Namespace: claim.Namespace}
+		if err := persistentvolume.DeletePVC(context.TODO(), er.client, key); err != nil {
+			er.ll.Error(err, "unable to delete persistent volume claim", "claim", claim.Name)
+		}
+	}
+}
+
+// volumeClaimNames returns the names of the claims used by the nodes. The claims
+// of a statefulset are counted up to its current or desired replicas, whichever
+// is higher, so that scaling nodes keep theirs.
+func volumeClaimNames(nodes []NodeTypeInterface) (sets.String, error) {
+	names := sets.NewString()
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *deploymentNode:
+			names.Insert(podVolumeClaimNames(n.self.Spec.Template.Spec)...)
+		case *statefulSetNode:
+			names.Insert(podVolumeClaimNames(n.self.Spec.Template.Spec)...)
+			if len(n.self.Spec.VolumeClaimTemplates) == 0 {
+				continue
+			}
+
+			replicas, err := n.currentReplicas()
+			if err != nil && !apierrors.IsNotFound(kverrors.Root(err)) {
+				return nil, err
+			}
+			if replicas < n.replicas {
+				replicas = n.replicas
+			}
+
+			for _, template := range n.self.Spec.VolumeClaimTemplates {
+				for ordinal := int32(0); ordinal < replicas; ordinal++ {
+					names.Insert(volumeClaimName(template.Name, n.name(), ordinal))
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+func podVolumeClaimNames(spec v1.PodSpec) []string {
+	names := []string{}
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			names = append(names, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return names
+}
+
+// ensureVolumeClaimsExist returns an error if a claim mounted by the pod spec is
+// missing, so that the reconciliation is retried instead of creating pods stuck
+// pending on the claim
+func ensureVolumeClaimsExist(ctx context.Context, c client.Client, namespace string, spec v1.PodSpec) error {
+	for _, name := range podVolumeClaimNames(spec) {
+		claim := &v1.PersistentVolumeClaim{}
+		if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, claim); err != nil {
+			return kverrors.Wrap(err, "failed to get persistent volume claim of the node",
+				"claim", name,
+				"namespace", namespace,
+			)
+		}
+	}
+	return nil
+}
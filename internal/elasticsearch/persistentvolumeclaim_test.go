@@ -0,0 +1,220 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	"github.com/ViaQ/logerr/v2/log"
+	"github.com/google/go-cmp/cmp"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestClaim(name, clusterName string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "openshift-logging",
+			Labels:    map[string]string{"logging-cluster": clusterName},
+		},
+	}
+}
+
+func newClaimVolumePodSpec(claimName string) v1.PodSpec {
+	return v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				Name: dataVolumeName,
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+				},
+			},
+		},
+	}
+}
+
+func TestDeleteOrphanedVolumeClaims(t *testing.T) {
+	tests := []struct {
+		desc   string
+		policy api.VolumeClaimCleanupPolicy
+		want   []string
+	}{
+		{
+			desc: "default retains the claims",
+			want: []string{
+				"elasticsearch-elasticsearch-cdm-1-1",
+				"elasticsearch-elasticsearch-cdm-old-1",
+				"elasticsearch-storage-elasticsearch-cd-1-0",
+				"elasticsearch-storage-elasticsearch-cd-1-1",
+				"elasticsearch-storage-elasticsearch-cd-1-2",
+				"other-storage",
+			},
+		},
+		{
+			desc:   "retain",
+			policy: api.VolumeClaimCleanupRetain,
+			want: []string{
+				"elasticsearch-elasticsearch-cdm-1-1",
+				"elasticsearch-elasticsearch-cdm-old-1",
+				"elasticsearch-storage-elasticsearch-cd-1-0",
+				"elasticsearch-storage-elasticsearch-cd-1-1",
+				"elasticsearch-storage-elasticsearch-cd-1-2",
+				"other-storage",
+			},
+		},
+		{
+			desc:   "delete",
+			policy: api.VolumeClaimCleanupDelete,
+			want: []string{
+				"elasticsearch-elasticsearch-cdm-1-1",
+				"elasticsearch-storage-elasticsearch-cd-1-0",
+				"elasticsearch-storage-elasticsearch-cd-1-1",
+				"other-storage",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cluster := &api.Elasticsearch{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+			Spec:       api.ElasticsearchSpec{VolumeClaimCleanupPolicy: test.policy},
+		}
+
+		// the data statefulset scaled down from 3 to 2 replicas
+		sts := &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cd-1", Namespace: "openshift-logging"},
+			Spec: apps.StatefulSetSpec{
+				Replicas: pointer.Int32(2),
+				VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+					{ObjectMeta: metav1.ObjectMeta{Name: dataVolumeName}},
+				},
+			},
+		}
+
+		c := fake.NewFakeClient(
+			sts,
+			newTestClaim("elasticsearch-elasticsearch-cdm-1-1", "elasticsearch"),
+			newTestClaim("elasticsearch-elasticsearch-cdm-old-1", "elasticsearch"),
+			newTestClaim("elasticsearch-storage-elasticsearch-cd-1-0", "elasticsearch"),
+			newTestClaim("elasticsearch-storage-elasticsearch-cd-1-1", "elasticsearch"),
+			newTestClaim("elasticsearch-storage-elasticsearch-cd-1-2", "elasticsearch"),
+			newTestClaim("other-storage", "other"),
+		)
+
+		dataDeployment := &deploymentNode{
+			self: apps.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cdm-1-1", Namespace: "openshift-logging"},
+				Spec: apps.DeploymentSpec{
+					Template: v1.PodTemplateSpec{Spec: newClaimVolumePodSpec("elasticsearch-elasticsearch-cdm-1-1")},
+				},
+			},
+			client: c,
+		}
+		dataStatefulSet := &statefulSetNode{
+			self:     *sts.DeepCopy(),
+			replicas: 2,
+			dataNode: true,
+			client:   c,
+		}
+
+		er := ElasticsearchRequest{
+			client:  c,
+			cluster: cluster,
+			ll:      log.NewLogger("persistentvolumeclaim-testing"),
+		}
+		er.deleteOrphanedVolumeClaims([]NodeTypeInterface{dataDeployment, dataStatefulSet})
+
+		claims := &v1.PersistentVolumeClaimList{}
+		if err := c.List(context.TODO(), claims, client.InNamespace("openshift-logging")); err != nil {
+			t.Fatalf("%s: failed to list claims: %s", test.desc, err)
+		}
+		got := sets.NewString()
+		for _, claim := range claims.Items {
+			got.Insert(claim.Name)
+		}
+
+		if diff := cmp.Diff(test.want, got.List()); diff != "" {
+			t.Errorf("%s: unexpected claims left: %s", test.desc, diff)
+		}
+	}
+}
+
+func TestVolumeClaimNamesKeepScalingUpStatefulSetClaims(t *testing.T) {
+	sts := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cd-1", Namespace: "openshift-logging"},
+		Spec: apps.StatefulSetSpec{
+			Replicas: pointer.Int32(1),
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: dataVolumeName}},
+				{ObjectMeta: metav1.ObjectMeta{Name: heapDumpVolumeName}},
+			},
+		},
+	}
+	node := &statefulSetNode{
+		self:     *sts.DeepCopy(),
+		replicas: 3,
+		client:   fake.NewFakeClient(sts),
+	}
+
+	names, err := volumeClaimNames([]NodeTypeInterface{node})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		"elasticsearch-heapdump-elasticsearch-cd-1-0",
+		"elasticsearch-heapdump-elasticsearch-cd-1-1",
+		"elasticsearch-heapdump-elasticsearch-cd-1-2",
+		"elasticsearch-storage-elasticsearch-cd-1-0",
+		"elasticsearch-storage-elasticsearch-cd-1-1",
+		"elasticsearch-storage-elasticsearch-cd-1-2",
+	}
+	if diff := cmp.Diff(want, names.List()); diff != "" {
+		t.Errorf("unexpected claims in use: %s", diff)
+	}
+}
+
+func TestCreateNodeWithMissingVolumeClaim(t *testing.T) {
+	k8sClient := fake.NewFakeClient()
+	node := &deploymentNode{
+		self: apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-cdm-1-1", Namespace: "openshift-logging"},
+			Spec: apps.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: newClaimVolumePodSpec("elasticsearch-elasticsearch-cdm-1-1"),
+				},
+			},
+		},
+		clusterName: "elasticsearch",
+		client:      k8sClient,
+	}
+
+	if err := node.create(); err == nil {
+		t.Fatal("Exp. an error to requeue the reconciliation without the claim of the node")
+	}
+
+	key := client.ObjectKey{Name: node.self.Name, Namespace: node.self.Namespace}
+	if err := k8sClient.Get(context.TODO(), key, &apps.Deployment{}); err == nil {
+		t.Error("Exp. the deployment not to be created without the claim of the node")
+	}
+}
+
+func TestEnsureVolumeClaimsExist(t *testing.T) {
+	spec := newClaimVolumePodSpec("elasticsearch-elasticsearch-cdm-1-1")
+
+	if err := ensureVolumeClaimsExist(context.TODO(), fake.NewFakeClient(), "openshift-logging", spec); err == nil {
+		t.Error("Exp. an error for the missing claim")
+	}
+
+	k8sClient := fake.NewFakeClient(newTestClaim("elasticsearch-elasticsearch-cdm-1-1", "elasticsearch"))
+	if err := ensureVolumeClaimsExist(context.TODO(), k8sClient, "openshift-logging", spec); err != nil {
+		t.Errorf("Exp. no error with the claim of the node but got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/poddisruptionbudget"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreateOrUpdatePodDisruptionBudgets ensures the pod disruption budgets of the master
+// and data nodes. Master nodes keep the quorum available. Data nodes that are no master
+// nodes may be unavailable up to the configured maximum. The budgets do not overlap since
+// the eviction of a pod matched by more than one budget is refused.
+func (er *ElasticsearchRequest) CreateOrUpdatePodDisruptionBudgets() error {
+	dpl := er.cluster
+
+	masterName := fmt.Sprintf("%s-master", dpl.Name)
+	if pdb := newMasterPodDisruptionBudget(dpl); pdb != nil {
+		if err := er.createOrUpdatePodDisruptionBudget(pdb); err != nil {
+			return err
+		}
+	} else if err := poddisruptionbudget.Delete(context.TODO(), er.client, client.ObjectKey{Name: masterName, Namespace: dpl.Namespace}); err != nil {
+		return err
+	}
+
+	dataName := fmt.Sprintf("%s-data", dpl.Name)
+	if pdb := newDataPodDisruptionBudget(dpl); pdb != nil {
+		if err := er.createOrUpdatePodDisruptionBudget(pdb); err != nil {
+			return err
+		}
+	} else if err := poddisruptionbudget.Delete(context.TODO(), er.client, client.ObjectKey{Name: dataName, Namespace: dpl.Namespace}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (er *ElasticsearchRequest) createOrUpdatePodDisruptionBudget(pdb *policyv1.PodDisruptionBudget) error {
+	er.cluster.AddOwnerRefTo(pdb)
+
+	err := poddisruptionbudget.CreateOrUpdate(context.TODO(), er.client, pdb, poddisruptionbudget.Equal, poddisruptionbudget.Mutate)
+	if err != nil {
+		return kverrors.Wrap(err, "failed to create or update elasticsearch poddisruptionbudget",
+			"cluster", er.cluster.Name,
+			"namespace", er.cluster.Namespace,
+		)
+	}
+
+	return nil
+}
+
+// newMasterPodDisruptionBudget returns a budget keeping the quorum of master nodes
+// available or nil for a single master, which would block any drain.
+func newMasterPodDisruptionBudget(dpl *api.Elasticsearch) *policyv1.PodDisruptionBudget {
+	if getMasterCount(dpl) < 2 {
+		return nil
+	}
+
+	return poddisruptionbudget.New(fmt.Sprintf("%s-master", dpl.Name), dpl.Namespace, appendDefaultLabel(dpl.Name, map[string]string{})).
+		WithSelector(selectorForES("es-node-master", dpl.Name)).
+		WithMinAvailable(intstr.FromInt(CalculateNodeQuorum(dpl))).
+		Build()
+}
+
+// newDataPodDisruptionBudget returns a budget for the data nodes without master role
+// or nil if there are none.
+func newDataPodDisruptionBudget(dpl *api.Elasticsearch) *policyv1.PodDisruptionBudget {
+	count := int32(0)
+	for _, node := range dpl.Spec.Nodes {
+		if isDataNode(node) && !isMasterNode(node) {
+			count += node.NodeCount
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	maxUnavailable := intstr.FromInt(1)
+	if dpl.Spec.PodDisruptionBudget != nil && dpl.Spec.PodDisruptionBudget.MaxUnavailable != nil {
+		maxUnavailable = *dpl.Spec.PodDisruptionBudget.MaxUnavailable
+	}
+
+	selector := selectorForES("es-node-data", dpl.Name)
+	selector["es-node-master"] = "false"
+
+	return poddisruptionbudget.New(fmt.Sprintf("%s-data", dpl.Name), dpl.Namespace, appendDefaultLabel(dpl.Name, map[string]string{})).
+		WithSelector(selector).
+		WithMaxUnavailable(maxUnavailable).
+		Build()
+}
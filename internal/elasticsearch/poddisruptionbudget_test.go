@@ -0,0 +1,109 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestCreateOrUpdatePodDisruptionBudgets(t *testing.T) {
+	masterRoles := []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleClient, loggingv1.ElasticsearchRoleMaster}
+	dataRoles := []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleClient, loggingv1.ElasticsearchRoleData}
+	customMaxUnavailable := intstr.FromString("25%")
+
+	tests := []struct {
+		desc               string
+		nodes              []loggingv1.ElasticsearchNode
+		pdbSpec            *loggingv1.ElasticsearchPodDisruptionBudgetSpec
+		wantMinAvailable   *intstr.IntOrString
+		wantMaxUnavailable *intstr.IntOrString
+	}{
+		{
+			desc:               "3 masters",
+			nodes:              []loggingv1.ElasticsearchNode{{Roles: masterRoles, NodeCount: 3}, {Roles: dataRoles, NodeCount: 3}},
+			wantMinAvailable:   intstrPtr(intstr.FromInt(2)),
+			wantMaxUnavailable: intstrPtr(intstr.FromInt(1)),
+		},
+		{
+			desc:               "5 masters",
+			nodes:              []loggingv1.ElasticsearchNode{{Roles: masterRoles, NodeCount: 5}, {Roles: dataRoles, NodeCount: 3}},
+			pdbSpec:            &loggingv1.ElasticsearchPodDisruptionBudgetSpec{MaxUnavailable: &customMaxUnavailable},
+			wantMinAvailable:   intstrPtr(intstr.FromInt(3)),
+			wantMaxUnavailable: &customMaxUnavailable,
+		},
+		{
+			desc:  "single master and data node",
+			nodes: []loggingv1.ElasticsearchNode{{Roles: append(masterRoles, loggingv1.ElasticsearchRoleData), NodeCount: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := &loggingv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "elasticsearch",
+					Namespace: "openshift-logging",
+				},
+				Spec: loggingv1.ElasticsearchSpec{
+					Nodes:               test.nodes,
+					PodDisruptionBudget: test.pdbSpec,
+				},
+			}
+
+			client := fake.NewFakeClient()
+			req := &ElasticsearchRequest{
+				client:  client,
+				cluster: cluster,
+				ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+			}
+
+			if err := req.CreateOrUpdatePodDisruptionBudgets(); err != nil {
+				t.Fatalf("failed with error: %s", err)
+			}
+
+			master := &policyv1.PodDisruptionBudget{}
+			err := client.Get(context.TODO(), types.NamespacedName{Name: "elasticsearch-master", Namespace: "openshift-logging"}, master)
+			checkPodDisruptionBudget(t, "master", master, err, test.wantMinAvailable, nil)
+
+			data := &policyv1.PodDisruptionBudget{}
+			err = client.Get(context.TODO(), types.NamespacedName{Name: "elasticsearch-data", Namespace: "openshift-logging"}, data)
+			checkPodDisruptionBudget(t, "data", data, err, nil, test.wantMaxUnavailable)
+		})
+	}
+}
+
+func checkPodDisruptionBudget(t *testing.T, name string, pdb *policyv1.PodDisruptionBudget, err error, minAvailable, maxUnavailable *intstr.IntOrString) {
+	if minAvailable == nil && maxUnavailable == nil {
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("Exp. no %s poddisruptionbudget but got %v", name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to get %s poddisruptionbudget: %s", name, err)
+	}
+
+	if minAvailable != nil && (pdb.Spec.MinAvailable == nil || *pdb.Spec.MinAvailable != *minAvailable) {
+		t.Errorf("Exp. %s minAvailable %v but was %v", name, minAvailable, pdb.Spec.MinAvailable)
+	}
+	if maxUnavailable != nil && (pdb.Spec.MaxUnavailable == nil || *pdb.Spec.MaxUnavailable != *maxUnavailable) {
+		t.Errorf("Exp. %s maxUnavailable %v but was %v", name, maxUnavailable, pdb.Spec.MaxUnavailable)
+	}
+	if len(pdb.OwnerReferences) != 1 || pdb.OwnerReferences[0].Name != "elasticsearch" {
+		t.Errorf("Exp. %s poddisruptionbudget to be owned by the cluster but was %v", name, pdb.OwnerReferences)
+	}
+}
+
+func intstrPtr(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
@@ -53,7 +53,7 @@ func (er *ElasticsearchRequest) CreateOrUpdateRBAC() error {
 	for _, es := range esList.Items {
 		subject := rbac.NewSubject(
 			"ServiceAccount",
-			es.Name,
+			serviceAccountName(es.Name),
 			es.Namespace,
 		)
 		subject.APIGroup = ""
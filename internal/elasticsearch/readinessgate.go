@@ -0,0 +1,235 @@
+package elasticsearch
+
+import (
+	"context"
+	"strings"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/pod"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// shardsRecoveredCondition is the pod condition backing the readiness gate
+	// of data nodes which is opened once the node has recovered its shards
+	shardsRecoveredCondition v1.PodConditionType = "logging.openshift.io/shards-recovered"
+
+	// masterElectedCondition is the pod condition backing the readiness gate
+	// of master nodes which is opened once the cluster has elected a master
+	masterElectedCondition v1.PodConditionType = "logging.openshift.io/master-elected"
+)
+
+// newReadinessGates returns the readiness gates of the elasticsearch pods of
+// the node depending on its roles. Data nodes wait for their shards to recover,
+// master nodes for a master to be elected. The other nodes hold no shards and
+// only need their readiness probe to pass.
+func newReadinessGates(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) []v1.PodReadinessGate {
+	if !commonSpec.ShardRecoveryReadinessGate {
+		return nil
+	}
+
+	switch {
+	case isDataNode(node):
+		return []v1.PodReadinessGate{
+			{ConditionType: shardsRecoveredCondition},
+		}
+	case isMasterNode(node):
+		return []v1.PodReadinessGate{
+			{ConditionType: masterElectedCondition},
+		}
+	default:
+		return nil
+	}
+}
+
+// updateShardRecoveryConditions opens the readiness gates of the pods: the
+// shard recovery gate of the pods whose node has no initializing or relocating
+// shards and the master election gate once the cluster has an elected master.
+// The gate of a pod is not closed again, it is reset when the pod is recreated.
+func (er *ElasticsearchRequest) updateShardRecoveryConditions() {
+	cluster := er.cluster
+	if !cluster.Spec.Spec.ShardRecoveryReadinessGate {
+		return
+	}
+
+	pods, err := pod.List(context.TODO(), er.client, cluster.Namespace, map[string]string{
+		"component":    "elasticsearch",
+		"cluster-name": cluster.Name,
+	})
+	if err != nil {
+		er.L().Error(err, "Unable to list pods for shard recovery conditions")
+		return
+	}
+
+	var recovered, elected []v1.Pod
+	if isRESTAPIServed(pods, selectorForRESTAPI(cluster)) {
+		shards, err := er.esClient.GetShards()
+		if err != nil {
+			er.L().Error(err, "Unable to get shards for shard recovery conditions")
+			return
+		}
+		recovered = recoveredPods(gatedPods(pods, shardsRecoveredCondition), shards)
+
+		master, err := er.esClient.GetElectedMaster()
+		if err != nil {
+			er.L().Error(err, "Unable to get the elected master for master election conditions")
+		}
+		elected = masterElectedPods(gatedPods(pods, masterElectedCondition), err == nil && master != "")
+	} else {
+		// no pod serves the REST API, e.g. after a full cluster restart, so
+		// elasticsearch cannot be asked and the gates are opened for the
+		// operator to reach the cluster again
+		recovered = recoveredPods(gatedPods(pods, shardsRecoveredCondition), nil)
+		elected = masterElectedPods(gatedPods(pods, masterElectedCondition), true)
+	}
+
+	er.openReadinessGates(recovered, v1.PodCondition{
+		Type:    shardsRecoveredCondition,
+		Reason:  "ShardsRecovered",
+		Message: "The node has no initializing or relocating shards",
+	})
+	er.openReadinessGates(elected, v1.PodCondition{
+		Type:    masterElectedCondition,
+		Reason:  "MasterElected",
+		Message: "The cluster has an elected master",
+	})
+}
+
+// openReadinessGates sets the condition of the readiness gate on the pods
+func (er *ElasticsearchRequest) openReadinessGates(pods []v1.Pod, condition v1.PodCondition) {
+	condition.Status = v1.ConditionTrue
+	condition.LastTransitionTime = metav1.Now()
+	for i := range pods {
+		p := &pods[i]
+		p.Status.Conditions = append(p.Status.Conditions, condition)
+		if err := er.client.Status().Update(context.TODO(), p); err != nil {
+			er.L().Error(err, "Unable to set readiness gate condition", "pod", p.Name, "condition", condition.Type)
+		}
+	}
+}
+
+// gatedPods returns the pods with the readiness gate of the condition
+func gatedPods(pods []v1.Pod, conditionType v1.PodConditionType) []v1.Pod {
+	var gated []v1.Pod
+	for _, p := range pods {
+		for _, gate := range p.Spec.ReadinessGates {
+			if gate.ConditionType == conditionType {
+				gated = append(gated, p)
+				break
+			}
+		}
+	}
+	return gated
+}
+
+// masterElectedPods returns the pods with ready containers and no master
+// election condition yet if the cluster has an elected master
+func masterElectedPods(pods []v1.Pod, elected bool) []v1.Pod {
+	if !elected {
+		return nil
+	}
+
+	var ready []v1.Pod
+	for _, p := range pods {
+		if p.Status.Phase != v1.PodRunning || !isPodReady(p) {
+			continue
+		}
+		if hasPodCondition(p, masterElectedCondition) {
+			continue
+		}
+		ready = append(ready, p)
+	}
+	return ready
+}
+
+// recoveredPods returns the pods with ready containers and no shard recovery
+// condition yet whose node has no initializing or relocating shards
+func recoveredPods(pods []v1.Pod, shards []estypes.CatShardsResponse) []v1.Pod {
+	recovering := recoveringNodes(shards)
+
+	var recovered []v1.Pod
+	for _, p := range pods {
+		if p.Status.Phase != v1.PodRunning || !isPodReady(p) {
+			continue
+		}
+		if hasPodCondition(p, shardsRecoveredCondition) {
+			continue
+		}
+		if recovering[esNodeName(p)] {
+			continue
+		}
+		recovered = append(recovered, p)
+	}
+	return recovered
+}
+
+// recoveringNodes returns the names of the nodes with initializing or
+// relocating shards. A relocating shard is listed with the node it is
+// moving from followed by the one it is moving to, e.g.
+// "node-a -> 10.128.2.10 FaGS0pVvTxahXXSMOYljXQ node-b".
+func recoveringNodes(shards []estypes.CatShardsResponse) map[string]bool {
+	recovering := map[string]bool{}
+	for _, shard := range shards {
+		switch shard.State {
+		case "INITIALIZING":
+			recovering[shard.Node] = true
+		case "RELOCATING":
+			parts := strings.SplitN(shard.Node, " -> ", 2)
+			recovering[parts[0]] = true
+			if len(parts) == 2 {
+				if fields := strings.Fields(parts[1]); len(fields) > 0 {
+					recovering[fields[len(fields)-1]] = true
+				}
+			}
+		}
+	}
+	return recovering
+}
+
+// esNodeName returns the elasticsearch node name of the pod which is the pod
+// name for statefulsets and the deployment name otherwise
+func esNodeName(p v1.Pod) string {
+	if name, ok := nodeNameFromEnv(p.Spec, p.Name); ok {
+		return name
+	}
+	// pods created before the node name env was introduced
+	for _, container := range p.Spec.Containers {
+		if container.Name != "elasticsearch" {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name != "DC_NAME" {
+				continue
+			}
+			if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.FieldPath == "metadata.name" {
+				return p.Name
+			}
+			return env.Value
+		}
+	}
+	return p.Labels["node-name"]
+}
+
+// isRESTAPIServed returns true if a ready pod is behind the REST API service
+func isRESTAPIServed(pods []v1.Pod, selector map[string]string) bool {
+	sel := labels.SelectorFromSet(selector)
+	for _, p := range pods {
+		if sel.Matches(labels.Set(p.Labels)) && hasPodCondition(p, v1.PodReady) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPodCondition(p v1.Pod, conditionType v1.PodConditionType) bool {
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
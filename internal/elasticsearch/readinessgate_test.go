@@ -0,0 +1,132 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const catShardsResponse = `[
+  {"index": "app-000001", "shard": "0", "prirep": "p", "state": "STARTED", "node": "elasticsearch-cdm-1"},
+  {"index": "app-000001", "shard": "0", "prirep": "r", "state": "INITIALIZING", "node": "elasticsearch-cdm-2"},
+  {"index": "infra-000001", "shard": "0", "prirep": "p", "state": "RELOCATING", "node": "elasticsearch-cdm-1 -> 10.128.2.12 FaGS0pVvTxahXXSMOYljXQ elasticsearch-cd-data-0"},
+  {"index": "infra-000001", "shard": "0", "prirep": "r", "state": "STARTED", "node": "elasticsearch-cd-data-1"},
+  {"index": "audit-000001", "shard": "0", "prirep": "r", "state": "UNASSIGNED", "node": null}
+]`
+
+func newGatedPod(name, dcName string, fromPodName bool, conditions ...v1.PodCondition) v1.Pod {
+	env := v1.EnvVar{Name: "DC_NAME", Value: dcName}
+	if fromPodName {
+		env = v1.EnvVar{Name: "DC_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}}}
+	}
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "elasticsearch", Env: []v1.EnvVar{env}},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			Conditions:        conditions,
+			ContainerStatuses: []v1.ContainerStatus{{Name: "elasticsearch", Ready: true}},
+		},
+	}
+}
+
+func TestRecoveredPods(t *testing.T) {
+	shards := []estypes.CatShardsResponse{}
+	if err := json.Unmarshal([]byte(catShardsResponse), &shards); err != nil {
+		t.Fatalf("failed to decode the _cat/shards response: %s", err)
+	}
+
+	notRunning := newGatedPod("elasticsearch-cdm-4-5d8f9c7b6-xk2lp", "elasticsearch-cdm-4", false)
+	notRunning.Status.Phase = v1.PodPending
+
+	pods := []v1.Pod{
+		// source of a relocating shard
+		newGatedPod("elasticsearch-cdm-1-5d8f9c7b6-abcde", "elasticsearch-cdm-1", false),
+		// initializing replica
+		newGatedPod("elasticsearch-cdm-2-7c9d8b5f4-fghij", "elasticsearch-cdm-2", false),
+		// no shards
+		newGatedPod("elasticsearch-cdm-3-6b7c8d9e5-klmno", "elasticsearch-cdm-3", false),
+		// target of a relocating shard
+		newGatedPod("elasticsearch-cd-data-0", "", true),
+		// started shards only
+		newGatedPod("elasticsearch-cd-data-1", "", true),
+		// gate already opened
+		newGatedPod("elasticsearch-cd-data-2", "", true, v1.PodCondition{Type: shardsRecoveredCondition, Status: v1.ConditionTrue}),
+		notRunning,
+	}
+
+	var got []string
+	for _, p := range recoveredPods(pods, shards) {
+		got = append(got, p.Name)
+	}
+
+	want := []string{"elasticsearch-cdm-3-6b7c8d9e5-klmno", "elasticsearch-cd-data-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNewReadinessGatesByRole(t *testing.T) {
+	tests := []struct {
+		desc  string
+		roles []api.ElasticsearchNodeRole
+		want  []v1.PodConditionType
+	}{
+		{desc: "coordinating", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}},
+		{desc: "data", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData}, want: []v1.PodConditionType{shardsRecoveredCondition}},
+		{desc: "master", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, want: []v1.PodConditionType{masterElectedCondition}},
+		{desc: "master and data", roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster, api.ElasticsearchRoleData}, want: []v1.PodConditionType{shardsRecoveredCondition}},
+	}
+
+	commonSpec := api.ElasticsearchNodeSpec{ShardRecoveryReadinessGate: true}
+	for _, test := range tests {
+		var got []v1.PodConditionType
+		for _, gate := range newReadinessGates(api.ElasticsearchNode{Roles: test.roles}, commonSpec) {
+			got = append(got, gate.ConditionType)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+
+	if gates := newReadinessGates(api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}}, api.ElasticsearchNodeSpec{}); gates != nil {
+		t.Errorf("Exp. no readiness gate unless enabled but got %v", gates)
+	}
+}
+
+func TestMasterElectedPods(t *testing.T) {
+	master := newGatedPod("elasticsearch-cm-1-0", "", true)
+	master.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: masterElectedCondition}}
+	opened := newGatedPod("elasticsearch-cm-1-1", "", true, v1.PodCondition{Type: masterElectedCondition, Status: v1.ConditionTrue})
+	opened.Spec.ReadinessGates = master.Spec.ReadinessGates
+	data := newGatedPod("elasticsearch-cd-data-0", "", true)
+	data.Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: shardsRecoveredCondition}}
+
+	pods := gatedPods([]v1.Pod{master, opened, data}, masterElectedCondition)
+	if len(pods) != 2 {
+		t.Fatalf("Exp. the master pods to be gated by the master election but got %d pods", len(pods))
+	}
+
+	if got := masterElectedPods(pods, false); len(got) != 0 {
+		t.Errorf("Exp. no gate opened without an elected master but got %d pods", len(got))
+	}
+
+	var got []string
+	for _, p := range masterElectedPods(pods, true) {
+		got = append(got, p.Name)
+	}
+	want := []string{"elasticsearch-cm-1-0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
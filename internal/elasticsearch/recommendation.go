@@ -0,0 +1,190 @@
+package elasticsearch
+
+import (
+	"context"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/pod"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// recommendationHeadroomPercent is the share of the usage added to the recommended requests
+	recommendationHeadroomPercent = 25
+
+	// recommended requests are rounded up to steps to keep them stable
+	recommendationCPUStepMillis  = 100
+	recommendationMemoryStepSize = 64 * 1024 * 1024
+)
+
+var podMetricsGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetrics"}
+
+// updateResourceRecommendations records the recommended resources of the elasticsearch
+// container in the status of each node if enabled, otherwise removes them
+func (er *ElasticsearchRequest) updateResourceRecommendations(status *api.ElasticsearchStatus) {
+	cluster := er.cluster
+	enabled := cluster.Spec.ResourceRecommendations != nil && cluster.Spec.ResourceRecommendations.Enabled
+
+	for nodeIndex, node := range status.Nodes {
+		nodeStatus := &status.Nodes[nodeIndex]
+		if !enabled {
+			nodeStatus.ResourceRecommendation = nil
+			continue
+		}
+
+		nodeName := node.DeploymentName
+		if nodeName == "" {
+			nodeName = node.StatefulSetName
+		}
+
+		matchingLabels := map[string]string{
+			"component":    "elasticsearch",
+			"cluster-name": cluster.GetName(),
+			"node-name":    nodeName,
+		}
+		nodePods, err := pod.List(context.TODO(), er.client, cluster.GetNamespace(), matchingLabels)
+		if err != nil {
+			er.ll.Error(err, "unable to list pods for resource recommendations", "node", nodeName)
+			continue
+		}
+
+		var requests v1.ResourceList
+		usages := []v1.ResourceList{}
+		for _, nodePod := range nodePods {
+			container, ok := getContainer(nodePod.Spec, "elasticsearch")
+			if !ok {
+				continue
+			}
+			requests = container.Resources.Requests
+
+			usage, err := er.getContainerUsage(nodePod.Name, "elasticsearch")
+			if err != nil {
+				if meta.IsNoMatchError(kverrors.Root(err)) {
+					er.ll.Info("Metrics server not available, no resource recommendations recorded")
+					return
+				}
+				er.ll.Error(err, "unable to get pod usage for resource recommendations", "pod", nodePod.Name)
+				continue
+			}
+			if usage != nil {
+				usages = append(usages, usage)
+			}
+		}
+
+		// keep the last recommendation until usage is reported
+		if len(usages) == 0 {
+			continue
+		}
+		recommendation := newResourceRecommendation(requests, usages)
+		if !equality.Semantic.DeepEqual(recommendation, nodeStatus.ResourceRecommendation) {
+			nodeStatus.ResourceRecommendation = recommendation
+		}
+	}
+}
+
+// getContainerUsage returns the usage of a container reported by the metrics
+// server or nil if the pod has no metrics yet
+func (er *ElasticsearchRequest) getContainerUsage(podName, containerName string) (v1.ResourceList, error) {
+	metrics := &unstructured.Unstructured{}
+	metrics.SetGroupVersionKind(podMetricsGVK)
+
+	key := client.ObjectKey{Name: podName, Namespace: er.cluster.Namespace}
+	if err := er.client.Get(context.TODO(), key, metrics); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, kverrors.Wrap(err, "failed to get pod metrics",
+			"pod", podName,
+			"namespace", er.cluster.Namespace,
+		)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(metrics.Object, "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != containerName {
+			continue
+		}
+
+		usage, _, _ := unstructured.NestedStringMap(container, "usage")
+		list := v1.ResourceList{}
+		for name, value := range usage {
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, kverrors.Wrap(err, "invalid pod metrics",
+					"pod", podName,
+					"resource", name,
+				)
+			}
+			list[v1.ResourceName(name)] = quantity
+		}
+		return list, nil
+	}
+
+	return nil, nil
+}
+
+func getContainer(spec v1.PodSpec, name string) (v1.Container, bool) {
+	for _, container := range spec.Containers {
+		if container.Name == name {
+			return container, true
+		}
+	}
+	return v1.Container{}, false
+}
+
+// newResourceRecommendation recommends cpu and memory requests from the highest
+// usage of the pods of a node plus headroom. The memory limit is recommended
+// equal to the memory request like the defaults of the operator.
+func newResourceRecommendation(requests v1.ResourceList, usages []v1.ResourceList) *api.ElasticsearchResourceRecommendation {
+	var cpuUsage, memoryUsage int64
+	for _, usage := range usages {
+		if cpu := usage.Cpu().MilliValue(); cpu > cpuUsage {
+			cpuUsage = cpu
+		}
+		if memory := usage.Memory().Value(); memory > memoryUsage {
+			memoryUsage = memory
+		}
+	}
+
+	cpu := resource.NewMilliQuantity(withHeadroom(cpuUsage, recommendationCPUStepMillis), resource.DecimalSI)
+	memory := resource.NewQuantity(withHeadroom(memoryUsage, recommendationMemoryStepSize), resource.BinarySI)
+
+	provisioning := api.Provisioned
+	cpuRequest, memoryRequest := requests.Cpu(), requests.Memory()
+	switch {
+	case cpuRequest.MilliValue() < cpuUsage || memoryRequest.Value() < memoryUsage:
+		provisioning = api.UnderProvisioned
+	case cpu.MilliValue()*2 <= cpuRequest.MilliValue() && memory.Value()*2 <= memoryRequest.Value():
+		provisioning = api.OverProvisioned
+	}
+
+	return &api.ElasticsearchResourceRecommendation{
+		Provisioning: provisioning,
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    *cpu,
+			v1.ResourceMemory: *memory,
+		},
+		Limits: v1.ResourceList{
+			v1.ResourceMemory: *memory,
+		},
+	}
+}
+
+// withHeadroom adds the headroom to the usage and rounds it up to the step
+func withHeadroom(usage, step int64) int64 {
+	value := usage + usage*recommendationHeadroomPercent/100
+	if value < step {
+		return step
+	}
+	return (value + step - 1) / step * step
+}
@@ -0,0 +1,99 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newTestResourceList(cpu, memory string) v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpu),
+		v1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func TestNewResourceRecommendation(t *testing.T) {
+	tests := []struct {
+		desc          string
+		requests      v1.ResourceList
+		usages        []v1.ResourceList
+		wantCPU       string
+		wantMemory    string
+		wantProvision api.ResourceProvisioning
+	}{
+		{
+			desc:     "usage above requests",
+			requests: newTestResourceList("1", "4Gi"),
+			usages: []v1.ResourceList{
+				newTestResourceList("300m", "3Gi"),
+				newTestResourceList("1200m", "3584Mi"),
+			},
+			wantCPU:       "1500m",
+			wantMemory:    "4480Mi",
+			wantProvision: api.UnderProvisioned,
+		},
+		{
+			desc:     "usage far below requests",
+			requests: newTestResourceList("4", "16Gi"),
+			usages: []v1.ResourceList{
+				newTestResourceList("200m", "2Gi"),
+			},
+			wantCPU:       "300m",
+			wantMemory:    "2560Mi",
+			wantProvision: api.OverProvisioned,
+		},
+		{
+			desc:     "usage fitting requests",
+			requests: newTestResourceList("1", "4Gi"),
+			usages: []v1.ResourceList{
+				newTestResourceList("700m", "3Gi"),
+			},
+			wantCPU:       "900m",
+			wantMemory:    "3840Mi",
+			wantProvision: api.Provisioned,
+		},
+		{
+			desc:     "idle node",
+			requests: newTestResourceList("100m", "64Mi"),
+			usages: []v1.ResourceList{
+				newTestResourceList("1m", "1Mi"),
+			},
+			wantCPU:       "100m",
+			wantMemory:    "64Mi",
+			wantProvision: api.Provisioned,
+		},
+		{
+			desc: "no requests",
+			usages: []v1.ResourceList{
+				newTestResourceList("500m", "1Gi"),
+			},
+			wantCPU:       "700m",
+			wantMemory:    "1280Mi",
+			wantProvision: api.UnderProvisioned,
+		},
+	}
+
+	for _, test := range tests {
+		got := newResourceRecommendation(test.requests, test.usages)
+
+		if got.Provisioning != test.wantProvision {
+			t.Errorf("%s: exp. provisioning %s but was %s", test.desc, test.wantProvision, got.Provisioning)
+		}
+		if cpu := got.Requests[v1.ResourceCPU]; cpu.Cmp(resource.MustParse(test.wantCPU)) != 0 {
+			t.Errorf("%s: exp. cpu request %s but was %s", test.desc, test.wantCPU, cpu.String())
+		}
+		if memory := got.Requests[v1.ResourceMemory]; memory.Cmp(resource.MustParse(test.wantMemory)) != 0 {
+			t.Errorf("%s: exp. memory request %s but was %s", test.desc, test.wantMemory, memory.String())
+		}
+		if memory := got.Limits[v1.ResourceMemory]; memory.Cmp(resource.MustParse(test.wantMemory)) != 0 {
+			t.Errorf("%s: exp. memory limit %s but was %s", test.desc, test.wantMemory, memory.String())
+		}
+		if _, ok := got.Limits[v1.ResourceCPU]; ok {
+			t.Errorf("%s: exp. no cpu limit recommended", test.desc)
+		}
+	}
+}
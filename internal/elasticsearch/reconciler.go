@@ -13,6 +13,7 @@ import (
 	"github.com/openshift/elasticsearch-operator/internal/manifests/secret"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -22,6 +23,7 @@ type ElasticsearchRequest struct {
 	cluster  *elasticsearchv1.Elasticsearch
 	esClient esclient.Client
 	ll       logr.Logger
+	recorder record.EventRecorder
 }
 
 // L is the logger used for this request.
@@ -34,10 +36,15 @@ func (er *ElasticsearchRequest) L() logr.Logger {
 func SecretReconcile(log logr.Logger, requestCluster *elasticsearchv1.Elasticsearch, requestClient client.Client) (bool, error) {
 	var secretChanged bool
 
+	ll := log.WithValues(ClusterLogValues(requestCluster)...)
+	if IsDryRun(requestCluster) {
+		requestClient = newDryRunClient(requestClient, ll, nil, requestCluster)
+	}
+
 	elasticsearchRequest := ElasticsearchRequest{
 		client:  requestClient,
 		cluster: requestCluster,
-		ll:      log.WithValues("cluster", requestCluster.Name, "namespace", requestCluster.Namespace),
+		ll:      ll,
 	}
 
 	// evaluate if we are missing the required secret/certs
@@ -115,23 +122,42 @@ func SecretReconcile(log logr.Logger, requestCluster *elasticsearchv1.Elasticsea
 	return true, nil
 }
 
-func Reconcile(log logr.Logger, requestCluster *elasticsearchv1.Elasticsearch, requestClient client.Client) error {
-	esClient := esclient.NewClient(log, requestCluster.Name, requestCluster.Namespace, requestClient)
+func Reconcile(log logr.Logger, requestCluster *elasticsearchv1.Elasticsearch, requestClient client.Client, recorder record.EventRecorder) error {
+	ll := log.WithValues(ClusterLogValues(requestCluster)...)
+	esClient := esclient.NewClient(ll, requestCluster.Name, requestCluster.Namespace, requestClient)
+	if IsDryRun(requestCluster) {
+		ll.Info("Reconciling in dry-run mode, the changes are reported instead of applied")
+		requestClient = newDryRunClient(requestClient, ll, recorder, requestCluster)
+		esClient = esclient.NewDryRunClient(ll, requestCluster.Name, requestCluster.Namespace, requestClient)
+	}
 
 	elasticsearchRequest := ElasticsearchRequest{
 		client:   requestClient,
 		cluster:  requestCluster,
 		esClient: esClient,
-		ll:       log.WithValues("cluster", requestCluster.Name, "namespace", requestCluster.Namespace),
+		ll:       ll,
+		recorder: recorder,
 	}
 
+	// use the certificates provided by the user if any, else
 	// check if we are doing ES cert management looking for annotation:
 	// logging.openshift.io/elasticsearch-cert-management: true
 	value, ok := requestCluster.Annotations[constants.EOCertManagementLabel]
-	if ok {
+	if requestCluster.Spec.CertificateSecret != nil {
+		if err := elasticsearchRequest.syncCertificateSecret(); err != nil {
+			if err := elasticsearchRequest.UpdateDegradedCondition(true, "Invalid Certificate Secret", err.Error()); err != nil {
+				elasticsearchRequest.ll.Error(err, "Unable to set Degraded condition")
+			}
+			return kverrors.Wrap(err, "Failed to reconcile the certificate secret for Elasticsearch cluster")
+		}
+	} else if ok {
 		manageBool, _ := strconv.ParseBool(value)
 		if manageBool {
 			cr := NewCertificateRequest(log, requestCluster.Name, requestCluster.Namespace, requestCluster.GetOwnerRef(), requestClient)
+			if requestCluster.Spec.Spec.ResourceNamePrefix != "" {
+				serviceName := discoveryServiceName(requestCluster.Name, requestCluster.Spec.Spec)
+				cr.addDNSNames("elasticsearch", serviceName, serviceName+"."+requestCluster.Namespace+".svc")
+			}
 			cr.GenerateElasticsearchCerts(requestCluster.Name)
 
 			// for any components specified like:
@@ -177,6 +203,10 @@ func Reconcile(log logr.Logger, requestCluster *elasticsearchv1.Elasticsearch, r
 		return kverrors.Wrap(err, "Failed to reconcile Services for Elasticsearch cluster")
 	}
 
+	if err := elasticsearchRequest.CreateOrUpdatePodDisruptionBudgets(); err != nil {
+		return kverrors.Wrap(err, "Failed to reconcile PodDisruptionBudgets for Elasticsearch cluster")
+	}
+
 	if err := elasticsearchRequest.CreateOrUpdateDashboards(); err != nil {
 		return kverrors.Wrap(err, "Failed to reconcile Dashboards for Elasticsearch cluster")
 	}
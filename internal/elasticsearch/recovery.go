@@ -40,7 +40,7 @@ func (er *ElasticsearchRequest) recoverOrphanedCluster() error {
 		selector := map[string]string{}
 		pvcList, err := persistentvolume.ListPVC(context.TODO(), er.client, er.cluster.Namespace, selector)
 		if err != nil {
-			er.ll.Error(err, "Unable to retrieve PVC list while recovering", "cluster", er.cluster.Name, "namespace", er.cluster.Namespace)
+			er.ll.Error(err, "Unable to retrieve PVC list while recovering")
 			return err
 		}
 
@@ -86,16 +86,17 @@ func (er *ElasticsearchRequest) podSpecMatchNode(node loggingv1.ElasticsearchNod
 		return false
 	}
 
-	nodeResources := newESResourceRequirements(node.Resources, er.cluster.Spec.Spec.Resources)
+	nodeResources := newESNodeResourceRequirements(node, er.cluster.Spec.Spec)
 	proxyResources := newESProxyResourceRequirements(node.ProxyResources, er.cluster.Spec.Spec.ProxyResources)
 
 	var deploymentNodeResources corev1.ResourceRequirements
 	var deploymentProxyResources corev1.ResourceRequirements
 
 	for _, container := range podSpec.Containers {
-		if container.Name == "elasticsearch" {
+		switch container.Name {
+		case "elasticsearch":
 			deploymentNodeResources = container.Resources
-		} else {
+		case "proxy":
 			deploymentProxyResources = container.Resources
 		}
 	}
@@ -147,7 +148,7 @@ func (er *ElasticsearchRequest) recoverFromDeployments(knownUUIDs []string, node
 
 		deploymentList, err := deployment.List(context.TODO(), er.client, er.cluster.Namespace, selector)
 		if err != nil {
-			er.ll.Error(err, "Unable to retrieve Deployment list while recovering", "cluster", er.cluster.Name, "namespace", er.cluster.Namespace)
+			er.ll.Error(err, "Unable to retrieve Deployment list while recovering")
 			return err
 		}
 
@@ -193,7 +194,7 @@ func (er *ElasticsearchRequest) recoverFromDeployments(knownUUIDs []string, node
 			var deploymentList []appsv1.Deployment
 			deploymentList, err := deployment.List(context.TODO(), er.client, er.cluster.Namespace, selector)
 			if err != nil {
-				er.ll.Error(err, "Unable to retrieve Deployment list while recovering", "cluster", er.cluster.Name, "namespace", er.cluster.Namespace)
+				er.ll.Error(err, "Unable to retrieve Deployment list while recovering")
 				return err
 			}
 
@@ -214,7 +215,7 @@ func (er *ElasticsearchRequest) recoverFromDeployments(knownUUIDs []string, node
 						er.cluster.Spec.Nodes[nodeIndex].GenUUID = &uuid
 						knownUUIDs = append(knownUUIDs, uuid)
 
-						er.setUUID(nodeIndex, uuid)
+						er.setUUID(nodeIndex, uuid, "")
 						break
 					}
 				}
@@ -223,7 +224,7 @@ func (er *ElasticsearchRequest) recoverFromDeployments(knownUUIDs []string, node
 			var statefulsetList []appsv1.StatefulSet
 			statefulsetList, err := statefulset.List(context.TODO(), er.client, er.cluster.Namespace, selector)
 			if err != nil {
-				er.ll.Error(err, "Unable to retrieve Statefulset list while recovering", "cluster", er.cluster.Name, "namespace", er.cluster.Namespace)
+				er.ll.Error(err, "Unable to retrieve Statefulset list while recovering")
 				return err
 			}
 
@@ -244,7 +245,7 @@ func (er *ElasticsearchRequest) recoverFromDeployments(knownUUIDs []string, node
 						er.cluster.Spec.Nodes[nodeIndex].GenUUID = &uuid
 						knownUUIDs = append(knownUUIDs, uuid)
 
-						er.setUUID(nodeIndex, uuid)
+						er.setUUID(nodeIndex, uuid, "")
 						break
 					}
 				}
@@ -263,7 +264,7 @@ func (er *ElasticsearchRequest) recoverFromPVCs(knownUUIDs []string, nodesToMatc
 
 	pvcList, err := persistentvolume.ListPVC(context.TODO(), er.client, er.cluster.Namespace, selector)
 	if err != nil {
-		er.ll.Error(err, "Unable to retrieve PVC list while recovering", "cluster", er.cluster.Name, "namespace", er.cluster.Namespace)
+		er.ll.Error(err, "Unable to retrieve PVC list while recovering")
 		return err
 	}
 
@@ -342,7 +343,7 @@ func (er *ElasticsearchRequest) recoverFromPVCs(knownUUIDs []string, nodesToMatc
 			er.cluster.Spec.Nodes[nodeIndex].GenUUID = &uuid
 			knownUUIDs = append(knownUUIDs, uuid)
 
-			er.setUUID(nodeIndex, uuid)
+			er.setUUID(nodeIndex, uuid, "")
 		}
 	}
 
@@ -46,12 +46,24 @@ func (er ElasticsearchRequest) hasRequiredSecrets() (bool, string) {
 		return false, fmt.Sprintf("Expected secret %q in namespace %q is missing", er.cluster.Name, er.cluster.Namespace)
 	}
 
+	missingCerts := missingSecretKeys(sec.Data)
+	if len(missingCerts) > 0 {
+		message = fmt.Sprintf("Secret %q fields are either missing or empty: [%s]", er.cluster.Name, strings.Join(missingCerts, ", "))
+		hasRequired = false
+	}
+
+	return hasRequired, message
+}
+
+// missingSecretKeys returns the expected certificate keys that are either
+// missing or empty in the secret data
+func missingSecretKeys(data map[string][]byte) []string {
 	var missingCerts []string
 	var secretKeys []string
 
-	for key, data := range sec.Data {
+	for key, value := range data {
 		// check that the fields aren't blank
-		if string(data) == "" {
+		if string(value) == "" {
 			missingCerts = append(missingCerts, key)
 		}
 
@@ -65,10 +77,31 @@ func (er ElasticsearchRequest) hasRequiredSecrets() (bool, string) {
 		}
 	}
 
-	if len(missingCerts) > 0 {
-		message = fmt.Sprintf("Secret %q fields are either missing or empty: [%s]", er.cluster.Name, strings.Join(missingCerts, ", "))
-		hasRequired = false
+	return missingCerts
+}
+
+// syncCertificateSecret copies the user provided certificate secret into the
+// secret named after the cluster, that the nodes and the operator read.
+func (er ElasticsearchRequest) syncCertificateSecret() error {
+	ref := er.cluster.Spec.CertificateSecret
+	if ref == nil || ref.Name == "" {
+		return nil
 	}
 
-	return hasRequired, message
+	key := client.ObjectKey{Name: ref.Name, Namespace: er.cluster.Namespace}
+	sec, err := secret.Get(context.TODO(), er.client, key)
+	if err != nil {
+		return kverrors.Wrap(err, "failed to get certificate secret",
+			"secret", ref.Name,
+		)
+	}
+
+	if missing := missingSecretKeys(sec.Data); len(missing) > 0 {
+		return kverrors.New("certificate secret fields are either missing or empty",
+			"secret", ref.Name,
+			"keys", strings.Join(missing, ", "),
+		)
+	}
+
+	return CreateOrUpdateSecretWithOwnerRef(er.cluster.Name, er.cluster.Namespace, sec.Data, er.client, er.cluster.GetOwnerRef())
 }
@@ -0,0 +1,90 @@
+package elasticsearch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/constants"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestSyncCertificateSecret(t *testing.T) {
+	data := map[string][]byte{}
+	for _, key := range constants.ExpectedSecretKeys {
+		data[key] = []byte(key + "-value")
+	}
+
+	tests := []struct {
+		desc        string
+		dropKey     string
+		emptyKey    string
+		wantMissing string
+	}{
+		{desc: "all keys provided"},
+		{desc: "missing key", dropKey: "elasticsearch.key", wantMissing: "elasticsearch.key"},
+		{desc: "empty key", emptyKey: "admin-ca", wantMissing: "admin-ca"},
+	}
+
+	for _, test := range tests {
+		userData := map[string][]byte{}
+		for key, value := range data {
+			userData[key] = value
+		}
+		delete(userData, test.dropKey)
+		if test.emptyKey != "" {
+			userData[test.emptyKey] = []byte{}
+		}
+
+		userSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-certs", Namespace: "openshift-logging"},
+			Data:       userData,
+		}
+		cluster := &loggingv1.Elasticsearch{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+			Spec: loggingv1.ElasticsearchSpec{
+				CertificateSecret: &corev1.LocalObjectReference{Name: "my-certs"},
+			},
+		}
+
+		client := fake.NewFakeClient(userSecret)
+		er := ElasticsearchRequest{
+			client:  client,
+			cluster: cluster,
+			ll:      log.Log.WithValues("cluster", "elasticsearch", "namespace", "openshift-logging"),
+		}
+
+		err := er.syncCertificateSecret()
+		got := &corev1.Secret{}
+		getErr := client.Get(context.TODO(), types.NamespacedName{Name: "elasticsearch", Namespace: "openshift-logging"}, got)
+
+		if test.wantMissing == "" {
+			if err != nil {
+				t.Errorf("%s: exp. no error but got %v", test.desc, err)
+			}
+			if getErr != nil {
+				t.Errorf("%s: exp. the cluster secret to be created but got %v", test.desc, getErr)
+			}
+			if string(got.Data["elasticsearch.crt"]) != "elasticsearch.crt-value" {
+				t.Errorf("%s: exp. the cluster secret to hold the user certificates but was %v", test.desc, got.Data)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), "missing or empty") {
+			t.Errorf("%s: exp. an error for the missing keys but got %v", test.desc, err)
+		}
+		if missing := missingSecretKeys(userData); len(missing) != 1 || missing[0] != test.wantMissing {
+			t.Errorf("%s: exp. %q to be reported missing but got %v", test.desc, test.wantMissing, missing)
+		}
+		if getErr == nil {
+			t.Errorf("%s: exp. no cluster secret to be created from an invalid secret", test.desc)
+		}
+	}
+}
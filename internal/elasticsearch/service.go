@@ -2,12 +2,14 @@ package elasticsearch
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	"github.com/openshift/elasticsearch-operator/internal/manifests/service"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // CreateOrUpdateServices ensures the existence of the services for Elasticsearch cluster
@@ -15,7 +17,8 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 	dpl := er.cluster
 
 	annotations := make(map[string]string)
-	serviceName := fmt.Sprintf("%s-%s", dpl.Name, "cluster")
+	serviceName := discoveryServiceName(dpl.Name, dpl.Spec.Spec)
+	ports := getPorts(dpl.Spec.Spec)
 
 	errCtx := kverrors.NewContext("service_name", serviceName,
 		"cluster", er.cluster.Name,
@@ -26,11 +29,14 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 		serviceName,
 		dpl.Namespace,
 		dpl.Name,
-		"cluster",
+		ports.TransportName,
 		9300,
 		selectorForES("es-node-master", dpl.Name),
 		annotations,
 		true,
+		true,
+		"",
+		"",
 		map[string]string{},
 	)
 	if err != nil {
@@ -41,11 +47,14 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 		dpl.Name,
 		dpl.Namespace,
 		dpl.Name,
-		"restapi",
+		ports.RESTAPIName,
 		9200,
-		selectorForES("es-node-client", dpl.Name),
-		annotations,
+		selectorForRESTAPI(dpl),
+		getServiceAnnotations(dpl.Spec.Service),
+		false,
 		false,
+		getServiceType(dpl.Spec.Service),
+		getExternalTrafficPolicy(dpl.Spec.Service),
 		map[string]string{},
 	)
 	if err != nil {
@@ -53,9 +62,9 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 	}
 
 	// legacy metrics service that likely can be rolled into the single service that goes through the proxy
-	annotations["service.beta.openshift.io/serving-cert-secret-name"] = fmt.Sprintf("%s-%s", dpl.Name, "metrics")
+	annotations["service.beta.openshift.io/serving-cert-secret-name"] = metricsServiceName(dpl.Name, dpl.Spec.Spec)
 	err = er.createOrUpdateService(
-		fmt.Sprintf("%s-%s", dpl.Name, "metrics"),
+		metricsServiceName(dpl.Name, dpl.Spec.Spec),
 		dpl.Namespace,
 		dpl.Name,
 		"metrics",
@@ -63,6 +72,9 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 		selectorForES("es-node-client", dpl.Name),
 		annotations,
 		false,
+		false,
+		"",
+		"",
 		map[string]string{
 			"scrape-metrics": "enabled",
 		},
@@ -70,16 +82,69 @@ func (er *ElasticsearchRequest) CreateOrUpdateServices() error {
 	if err != nil {
 		return errCtx.Wrap(err, "failed to create service")
 	}
+
+	exporterServiceName := resourceName(dpl.Name, dpl.Spec.Spec, "exporter")
+	if !isMonitoringEnabled(dpl.Spec.Monitoring) {
+		key := client.ObjectKey{Name: exporterServiceName, Namespace: dpl.Namespace}
+		if err := service.Delete(context.TODO(), er.client, key); err != nil {
+			return errCtx.Wrap(err, "failed to delete exporter service")
+		}
+		return nil
+	}
+
+	err = er.createOrUpdateService(
+		exporterServiceName,
+		dpl.Namespace,
+		dpl.Name,
+		"exporter",
+		exporterPort,
+		selectorForExporter(dpl.Name),
+		map[string]string{},
+		false,
+		false,
+		"",
+		"",
+		map[string]string{
+			"scrape-exporter": "enabled",
+		},
+	)
+	if err != nil {
+		return errCtx.Wrap(err, "failed to create exporter service")
+	}
 	return nil
 }
 
-func (er *ElasticsearchRequest) createOrUpdateService(serviceName, namespace, clusterName, targetPortName string, port int32, selector, annotations map[string]string, publishNotReady bool, labels map[string]string) error {
+// selectorForExporter selects all elasticsearch pods of the cluster, each running an exporter
+func selectorForExporter(clusterName string) map[string]string {
+	return map[string]string{
+		"cluster-name": clusterName,
+		"component":    "elasticsearch",
+	}
+}
+
+func (er *ElasticsearchRequest) createOrUpdateService(serviceName, namespace, clusterName, targetPortName string, port int32, selector, annotations map[string]string, publishNotReady, headless bool, serviceType v1.ServiceType, externalTrafficPolicy v1.ServiceExternalTrafficPolicyType, labels map[string]string) error {
 	client := er.client
 	cluster := er.cluster
 
 	labels = appendDefaultLabel(clusterName, labels)
 
-	svc := service.New(serviceName, namespace, labels).
+	builder := service.New(serviceName, namespace, labels)
+	if headless {
+		if err := er.deleteServiceWithClusterIP(serviceName, namespace); err != nil {
+			return err
+		}
+		builder = builder.WithClusterIP(v1.ClusterIPNone)
+	}
+
+	if serviceType != "" {
+		builder = builder.WithType(serviceType)
+	}
+
+	if externalTrafficPolicy != "" {
+		builder = builder.WithExternalTrafficPolicy(externalTrafficPolicy)
+	}
+
+	svc := builder.
 		WithAnnotations(annotations).
 		WithSelector(selector).
 		WithServicePorts(v1.ServicePort{
@@ -103,3 +168,67 @@ func (er *ElasticsearchRequest) createOrUpdateService(serviceName, namespace, cl
 
 	return nil
 }
+
+// getServiceType returns the type of the REST API service, ClusterIP if unset
+func getServiceType(spec *api.ElasticsearchServiceSpec) v1.ServiceType {
+	if spec == nil || spec.Type == "" {
+		return v1.ServiceTypeClusterIP
+	}
+	return spec.Type
+}
+
+// getServiceAnnotations returns a copy of the annotations of the REST API service
+func getServiceAnnotations(spec *api.ElasticsearchServiceSpec) map[string]string {
+	annotations := map[string]string{}
+	if spec == nil {
+		return annotations
+	}
+	for key, value := range spec.Annotations {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// getExternalTrafficPolicy returns the external traffic policy of the REST API
+// service, Cluster if unset. Services without external traffic have none.
+func getExternalTrafficPolicy(spec *api.ElasticsearchServiceSpec) v1.ServiceExternalTrafficPolicyType {
+	serviceType := getServiceType(spec)
+	if serviceType != v1.ServiceTypeNodePort && serviceType != v1.ServiceTypeLoadBalancer {
+		return ""
+	}
+	if spec.ExternalTrafficPolicy == "" {
+		return v1.ServiceExternalTrafficPolicyTypeCluster
+	}
+	return spec.ExternalTrafficPolicy
+}
+
+// deleteServiceWithClusterIP deletes the service if it has a cluster IP. The cluster IP
+// of a service is immutable, thus a service created by a previous version of the
+// operator needs to be recreated to become headless.
+func (er *ElasticsearchRequest) deleteServiceWithClusterIP(serviceName, namespace string) error {
+	current := &v1.Service{}
+	key := client.ObjectKey{Name: serviceName, Namespace: namespace}
+	if err := er.client.Get(context.TODO(), key, current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return kverrors.Wrap(err, "failed to get elasticsearch service",
+			"service", serviceName,
+			"namespace", namespace,
+		)
+	}
+
+	if current.Spec.ClusterIP == "" || current.Spec.ClusterIP == v1.ClusterIPNone {
+		return nil
+	}
+
+	er.ll.Info("Recreating service as headless service", "service", serviceName)
+	if err := er.client.Delete(context.TODO(), current); err != nil && !apierrors.IsNotFound(err) {
+		return kverrors.Wrap(err, "failed to delete elasticsearch service",
+			"service", serviceName,
+			"namespace", namespace,
+		)
+	}
+
+	return nil
+}
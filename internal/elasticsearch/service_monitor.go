@@ -11,6 +11,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -33,13 +34,13 @@ func (er *ElasticsearchRequest) CreateOrUpdateServiceMonitors() error {
 			CA: monitoringv1.SecretOrConfigMap{
 				ConfigMap: &corev1.ConfigMapKeySelector{
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: serviceCABundleName(dpl.Name),
+						Name: serviceCABundleName(dpl.Name, dpl.Spec.Spec),
 					},
 					Key: prometheusCAFile,
 				},
 			},
 			// ServerName can be e.g. elasticsearch-metrics.openshift-logging.svc
-			ServerName: fmt.Sprintf("%s-%s.%s.svc", dpl.Name, "metrics", dpl.Namespace),
+			ServerName: fmt.Sprintf("%s.%s.svc", metricsServiceName(dpl.Name, dpl.Spec.Spec), dpl.Namespace),
 		},
 	}
 
@@ -88,5 +89,53 @@ func (er *ElasticsearchRequest) CreateOrUpdateServiceMonitors() error {
 		)
 	}
 
+	return er.createOrUpdateExporterServiceMonitor()
+}
+
+// createOrUpdateExporterServiceMonitor ensures the ServiceMonitor of the exporter sidecars
+// exists only while the monitoring of the cluster is enabled
+func (er *ElasticsearchRequest) createOrUpdateExporterServiceMonitor() error {
+	dpl := er.cluster
+
+	serviceMonitorName := fmt.Sprintf("monitor-%s-%s", dpl.Name, "exporter")
+
+	if !isMonitoringEnabled(dpl.Spec.Monitoring) {
+		key := client.ObjectKey{Name: serviceMonitorName, Namespace: dpl.Namespace}
+		if err := servicemonitor.Delete(context.TODO(), er.client, key); err != nil {
+			return kverrors.Wrap(err, "failed to delete elasticsearch exporter servicemonitor",
+				"cluster", er.cluster.Name,
+				"namespace", er.cluster.Namespace,
+			)
+		}
+		return nil
+	}
+
+	monitor := servicemonitor.New(serviceMonitorName, dpl.Namespace, appendDefaultLabel(dpl.Name, dpl.Labels)).
+		WithJobLabel("monitor-elasticsearch-exporter").
+		WithSelector(metav1.LabelSelector{
+			MatchLabels: appendDefaultLabel(dpl.Name, map[string]string{
+				"scrape-exporter": "enabled",
+			}),
+		}).
+		WithNamespaceSelector(monitoringv1.NamespaceSelector{
+			MatchNames: []string{dpl.Namespace},
+		}).
+		WithEndpoints(monitoringv1.Endpoint{
+			Port:   dpl.Name,
+			Path:   "/metrics",
+			Scheme: "http",
+		}).
+		Build()
+
+	dpl.AddOwnerRefTo(monitor)
+
+	err := servicemonitor.CreateOrUpdate(context.TODO(), er.client, monitor, servicemonitor.Equal, servicemonitor.Mutate)
+	if err != nil {
+		return kverrors.Wrap(err, "failed to create or update elasticsearch exporter servicemonitor",
+			"cluster", er.cluster.Name,
+			"namespace", er.cluster.Namespace,
+		)
+	}
+
 	return nil
 }
@@ -286,3 +286,50 @@ func TestCreateOrUpdateServiceMonitor(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateOrUpdateExporterServiceMonitor(t *testing.T) {
+	scheme := scheme.Scheme
+	utilruntime.Must(monitoringv1.AddToScheme(scheme))
+
+	cluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch",
+			Namespace: "openshift-logging",
+		},
+		Spec: loggingv1.ElasticsearchSpec{
+			Monitoring: &loggingv1.ElasticsearchMonitoringSpec{Enabled: true},
+		},
+	}
+
+	client := fake.NewFakeClientWithScheme(scheme)
+	req := &ElasticsearchRequest{
+		client:  client,
+		cluster: cluster,
+		ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+	}
+
+	if err := req.CreateOrUpdateServiceMonitors(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	key := types.NamespacedName{Name: "monitor-elasticsearch-exporter", Namespace: "openshift-logging"}
+	got := &monitoringv1.ServiceMonitor{}
+	if err := client.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	if got.Spec.Selector.MatchLabels["scrape-exporter"] != "enabled" {
+		t.Errorf("Exp. the servicemonitor to select the exporter service but was %v", got.Spec.Selector)
+	}
+	if len(got.Spec.Endpoints) != 1 || got.Spec.Endpoints[0].Path != "/metrics" {
+		t.Errorf("Exp. a single /metrics endpoint but was %v", got.Spec.Endpoints)
+	}
+
+	cluster.Spec.Monitoring = nil
+	if err := req.CreateOrUpdateServiceMonitors(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if err := client.Get(context.TODO(), key, got); err == nil {
+		t.Error("Exp. the exporter servicemonitor to be deleted once monitoring is disabled")
+	}
+}
@@ -67,6 +67,7 @@ func TestCreateOrUpdateServices(t *testing.T) {
 							"cluster-name":   "elasticsearch",
 							"es-node-master": "true",
 						},
+						ClusterIP:                corev1.ClusterIPNone,
 						PublishNotReadyAddresses: true,
 					},
 				},
@@ -102,6 +103,7 @@ func TestCreateOrUpdateServices(t *testing.T) {
 							"cluster-name":   "elasticsearch",
 							"es-node-client": "true",
 						},
+						Type: corev1.ServiceTypeClusterIP,
 					},
 				},
 				"elasticsearch-metrics": {
@@ -229,6 +231,7 @@ func TestCreateOrUpdateServices(t *testing.T) {
 							"cluster-name":   "elasticsearch",
 							"es-node-master": "true",
 						},
+						ClusterIP:                corev1.ClusterIPNone,
 						PublishNotReadyAddresses: true,
 					},
 				},
@@ -264,6 +267,7 @@ func TestCreateOrUpdateServices(t *testing.T) {
 							"cluster-name":   "elasticsearch",
 							"es-node-client": "true",
 						},
+						Type: corev1.ServiceTypeClusterIP,
 					},
 				},
 				"elasticsearch-metrics": {
@@ -379,3 +383,264 @@ func TestCreateOrUpdateServices(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateOrUpdateServicesRecreatesClusterService(t *testing.T) {
+	cluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch",
+			Namespace: "openshift-logging",
+		},
+	}
+	current := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch-cluster",
+			Namespace: "openshift-logging",
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "172.30.0.10",
+		},
+	}
+
+	client := fake.NewFakeClient(current)
+	req := &ElasticsearchRequest{
+		client:  client,
+		cluster: cluster,
+		ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+	}
+
+	if err := req.CreateOrUpdateServices(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	got := &corev1.Service{}
+	key := types.NamespacedName{Name: "elasticsearch-cluster", Namespace: "openshift-logging"}
+	if err := client.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	if got.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Errorf("Exp. the cluster service to be headless but cluster IP was %q", got.Spec.ClusterIP)
+	}
+	if !got.Spec.PublishNotReadyAddresses {
+		t.Error("Exp. the cluster service to publish not ready addresses")
+	}
+	if got.Spec.Selector["es-node-master"] != "true" {
+		t.Errorf("Exp. the cluster service to select the master nodes but was %v", got.Spec.Selector)
+	}
+}
+
+func TestCreateOrUpdateServicesType(t *testing.T) {
+	tests := []struct {
+		desc       string
+		service    *loggingv1.ElasticsearchServiceSpec
+		want       corev1.ServiceType
+		wantPolicy corev1.ServiceExternalTrafficPolicyType
+	}{
+		{desc: "default", want: corev1.ServiceTypeClusterIP},
+		{desc: "cluster ip", service: &loggingv1.ElasticsearchServiceSpec{Type: corev1.ServiceTypeClusterIP}, want: corev1.ServiceTypeClusterIP},
+		{
+			desc:       "cluster ip ignores the external traffic policy",
+			service:    &loggingv1.ElasticsearchServiceSpec{Type: corev1.ServiceTypeClusterIP, ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal},
+			want:       corev1.ServiceTypeClusterIP,
+			wantPolicy: "",
+		},
+		{
+			desc:       "node port",
+			service:    &loggingv1.ElasticsearchServiceSpec{Type: corev1.ServiceTypeNodePort},
+			want:       corev1.ServiceTypeNodePort,
+			wantPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+		},
+		{
+			desc:       "load balancer",
+			service:    &loggingv1.ElasticsearchServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			want:       corev1.ServiceTypeLoadBalancer,
+			wantPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+		},
+		{
+			desc:       "load balancer preserving the client source IP",
+			service:    &loggingv1.ElasticsearchServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal},
+			want:       corev1.ServiceTypeLoadBalancer,
+			wantPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := &loggingv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "elasticsearch",
+					Namespace: "openshift-logging",
+				},
+				Spec: loggingv1.ElasticsearchSpec{
+					Service: test.service,
+				},
+			}
+
+			client := fake.NewFakeClient()
+			req := &ElasticsearchRequest{
+				client:  client,
+				cluster: cluster,
+				ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+			}
+
+			if err := req.CreateOrUpdateServices(); err != nil {
+				t.Fatalf("failed with error: %s", err)
+			}
+
+			got := &corev1.Service{}
+			key := types.NamespacedName{Name: "elasticsearch", Namespace: "openshift-logging"}
+			if err := client.Get(context.TODO(), key, got); err != nil {
+				t.Fatalf("failed with error: %s", err)
+			}
+
+			if got.Spec.Type != test.want {
+				t.Errorf("Exp. service type %q but was %q", test.want, got.Spec.Type)
+			}
+			if got.Spec.ExternalTrafficPolicy != test.wantPolicy {
+				t.Errorf("Exp. external traffic policy %q but was %q", test.wantPolicy, got.Spec.ExternalTrafficPolicy)
+			}
+			if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].Name != "elasticsearch" {
+				t.Errorf("Exp. the service to be owned by the cluster but was %v", got.OwnerReferences)
+			}
+			if got.Spec.Selector["es-node-client"] != "true" {
+				t.Errorf("Exp. the service to select the client nodes but was %v", got.Spec.Selector)
+			}
+		})
+	}
+}
+
+func TestCreateOrUpdateServicesAnnotations(t *testing.T) {
+	internalLB := map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-cert": "arn:aws:acm:us-east-1:123456789012:certificate/elasticsearch",
+	}
+	cluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch",
+			Namespace: "openshift-logging",
+		},
+		Spec: loggingv1.ElasticsearchSpec{
+			Service: &loggingv1.ElasticsearchServiceSpec{
+				Type:        corev1.ServiceTypeLoadBalancer,
+				Annotations: internalLB,
+			},
+		},
+	}
+
+	client := fake.NewFakeClient()
+	req := &ElasticsearchRequest{
+		client:  client,
+		cluster: cluster,
+		ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+	}
+
+	if err := req.CreateOrUpdateServices(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	got := &corev1.Service{}
+	key := types.NamespacedName{Name: "elasticsearch", Namespace: "openshift-logging"}
+	if err := client.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if diff := cmp.Diff(internalLB, got.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations of the REST API service: %s", diff)
+	}
+
+	// the annotations are only set on the REST API service
+	for _, name := range []string{"elasticsearch-cluster", "elasticsearch-metrics"} {
+		other := &corev1.Service{}
+		key := types.NamespacedName{Name: name, Namespace: "openshift-logging"}
+		if err := client.Get(context.TODO(), key, other); err != nil {
+			t.Fatalf("failed with error: %s", err)
+		}
+		if _, found := other.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"]; found {
+			t.Errorf("Exp. no load balancer annotation on service %q but got %v", name, other.Annotations)
+		}
+	}
+
+	// changes of the spec are applied to the existing service
+	cluster.Spec.Service.Annotations = map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "false",
+	}
+	if err := req.CreateOrUpdateServices(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if err := client.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if diff := cmp.Diff(cluster.Spec.Service.Annotations, got.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations of the updated REST API service: %s", diff)
+	}
+}
+
+func TestServicesTargetNamedContainerPorts(t *testing.T) {
+	tests := []struct {
+		desc          string
+		naming        loggingv1.ElasticsearchPortNaming
+		transportName string
+		restAPIName   string
+	}{
+		{desc: "default", transportName: "cluster", restAPIName: "restapi"},
+		{desc: "legacy", naming: loggingv1.PortNamingLegacy, transportName: "cluster", restAPIName: "restapi"},
+		{desc: "conventional", naming: loggingv1.PortNamingConventional, transportName: "transport", restAPIName: "http"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := &loggingv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "elasticsearch",
+					Namespace: "openshift-logging",
+				},
+				Spec: loggingv1.ElasticsearchSpec{
+					Spec: loggingv1.ElasticsearchNodeSpec{PortNaming: test.naming},
+				},
+			}
+
+			podTemplate := newPodTemplateSpec(context.Background(), log.Log, "elasticsearch-cdm-1", cluster.Name, cluster.Namespace, loggingv1.ElasticsearchNode{}, cluster.Spec.Spec, map[string]string{}, map[loggingv1.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+			containerPorts := map[string]string{}
+			for _, container := range podTemplate.Spec.Containers {
+				for _, port := range container.Ports {
+					if port.Name != "" {
+						containerPorts[port.Name] = container.Name
+					}
+				}
+			}
+			if containerPorts[test.transportName] != "elasticsearch" {
+				t.Errorf("Exp. the transport port to be named %q but the named ports were %v", test.transportName, containerPorts)
+			}
+			if containerPorts[test.restAPIName] != "proxy" {
+				t.Errorf("Exp. the REST API port of the proxy to be named %q but the named ports were %v", test.restAPIName, containerPorts)
+			}
+
+			client := fake.NewFakeClient()
+			req := &ElasticsearchRequest{
+				client:  client,
+				cluster: cluster,
+				ll:      log.Log.WithValues("cluster", "test-elasticsearch", "namespace", "test"),
+			}
+			if err := req.CreateOrUpdateServices(); err != nil {
+				t.Fatalf("failed with error: %s", err)
+			}
+
+			for name, want := range map[string]string{
+				"elasticsearch-cluster": test.transportName,
+				"elasticsearch":         test.restAPIName,
+			} {
+				got := &corev1.Service{}
+				key := types.NamespacedName{Name: name, Namespace: "openshift-logging"}
+				if err := client.Get(context.TODO(), key, got); err != nil {
+					t.Fatalf("failed with error: %s", err)
+				}
+				if target := got.Spec.Ports[0].TargetPort.StrVal; target != want {
+					t.Errorf("Exp. service %s to target port %q but was %q", name, want, target)
+				}
+			}
+		})
+	}
+}
@@ -13,12 +13,12 @@ import (
 )
 
 // CreateOrUpdateServiceAccounts ensures the existence of the following serviceaccounts for Elasticsearch cluster:
-// - elasticsearch: For mounting and using the custom SecurityContextConstraint to the elasticsearch pods
+// - elasticsearch: The dedicated serviceaccount of the elasticsearch pods, bound to the custom SecurityContextConstraint only
 // - elasticsearch-metrics: For allowing Prometheus ServiceMonitor (ClusterMonitoring and User-Workload-Monitoring) to scrape logs
 func (er *ElasticsearchRequest) CreateOrUpdateServiceAccounts() error {
 	dpl := er.cluster
 
-	sa := serviceaccount.New(dpl.Name, dpl.Namespace, map[string]string{})
+	sa := serviceaccount.New(serviceAccountName(dpl.Name), dpl.Namespace, map[string]string{})
 	er.cluster.AddOwnerRefTo(sa)
 
 	err := serviceaccount.CreateOrUpdate(context.TODO(), er.client, sa, serviceaccount.AnnotationsEqual, serviceaccount.MutateAnnotationsOnly)
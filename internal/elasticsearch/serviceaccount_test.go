@@ -0,0 +1,49 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	"github.com/ViaQ/logerr/v2/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPodTemplateUsesClusterServiceAccount(t *testing.T) {
+	cluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging", UID: "cluster-uid"},
+	}
+
+	client := fake.NewFakeClient()
+	er := ElasticsearchRequest{
+		client:  client,
+		cluster: cluster,
+		ll:      log.NewLogger("serviceaccount-testing"),
+	}
+
+	if err := er.CreateOrUpdateServiceAccounts(); err != nil {
+		t.Fatalf("failed to create the serviceaccounts: %s", err)
+	}
+
+	podTemplate := newPodTemplateSpec(context.Background(), log.NewLogger("serviceaccount-testing"), "elasticsearch-cdm-1", cluster.Name, cluster.Namespace, loggingv1.ElasticsearchNode{}, loggingv1.ElasticsearchNodeSpec{}, map[string]string{}, map[loggingv1.ElasticsearchNodeRole]bool{}, nil, LogConfig{})
+
+	saName := podTemplate.Spec.ServiceAccountName
+	if saName == "" || saName == "default" {
+		t.Fatalf("Exp. the pod template to reference a dedicated serviceaccount but was %q", saName)
+	}
+
+	sa := &corev1.ServiceAccount{}
+	key := types.NamespacedName{Name: saName, Namespace: cluster.Namespace}
+	if err := client.Get(context.TODO(), key, sa); err != nil {
+		t.Fatalf("Exp. the serviceaccount %q referenced by the pod template to be created but got: %s", saName, err)
+	}
+
+	refs := sa.GetOwnerReferences()
+	if len(refs) != 1 || refs[0].UID != cluster.UID || refs[0].Kind != "Elasticsearch" {
+		t.Errorf("Exp. the serviceaccount to be owned by the cluster but the owner references were %v", refs)
+	}
+}
@@ -2,6 +2,7 @@ package elasticsearch
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
@@ -15,6 +16,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
@@ -31,12 +33,27 @@ type statefulSetNode struct {
 
 	clusterName string
 
+	// name of the config map holding the elasticsearch configuration
+	configMapName string
+
 	replicas int32
 
+	// the lowest ordinal updated by a rolling update
+	minPartition int32
+
+	// data nodes are drained before scaling down
+	dataNode bool
+
+	// seconds the restarted pods must have been ready before the next node is restarted
+	minReadySeconds int32
+
 	client client.Client
 
 	esClient esclient.Client
 
+	// records the events of the node on the cluster
+	recorder record.EventRecorder
+
 	l logr.Logger
 }
 
@@ -49,26 +66,31 @@ func (n *statefulSetNode) L() logr.Logger {
 
 func (n *statefulSetNode) populateReference(nodeName string, node api.ElasticsearchNode, cluster *api.Elasticsearch, roleMap map[api.ElasticsearchNodeRole]bool, replicas int32, client client.Client, esClient esclient.Client) {
 	labels := newLabels(cluster.Name, nodeName, roleMap)
-	partition := int32(0)
+	_, partition := getUpdateStrategy(node.UpdateStrategy)
 	logConfig := getLogConfig(cluster.GetAnnotations())
 
-	template := newPodTemplateSpec(context.TODO(), n.L(),
+	ctx := withEventRecorder(context.TODO(), n.recorder, cluster)
+	template := newPodTemplateSpec(ctx, n.L(),
 		nodeName, cluster.Name, cluster.Namespace, node,
 		cluster.Spec.Spec, labels, roleMap, client, logConfig,
 	)
+	template = appendExporterContainer(template, cluster)
+	// every replica needs its own name, e.g. for data nodes to be drained by name
+	setNodeNameFromPod(&template, cluster.Spec.Spec.NodeNamePrefix)
+	setConfigChecksumAnnotation(&template, client, cluster)
 
-	sts := statefulset.New(nodeName, cluster.Namespace, labels, replicas).
+	builder := statefulset.New(nodeName, cluster.Namespace, labels, replicas).
 		WithSelector(metav1.LabelSelector{
 			MatchLabels: newLabelSelector(cluster.Name, nodeName, roleMap),
 		}).
 		WithTemplate(template).
-		WithUpdateStrategy(apps.StatefulSetUpdateStrategy{
-			Type: apps.RollingUpdateStatefulSetStrategyType,
-			RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
-				Partition: &partition,
-			},
-		}).
-		Build()
+		WithUpdateStrategy(newStatefulSetUpdateStrategy(node.UpdateStrategy))
+
+	if templates := newVolumeClaimTemplates(cluster.Name, node, cluster.Spec.Spec); len(templates) > 0 {
+		builder.WithVolumeClaimTemplates(templates...)
+	}
+
+	sts := builder.Build()
 
 	sts.Spec.Template.Spec.Containers[0].ReadinessProbe = nil
 
@@ -76,14 +98,69 @@ func (n *statefulSetNode) populateReference(nodeName string, node api.Elasticsea
 
 	n.self = *sts
 	n.clusterName = cluster.Name
+	n.configMapName = configMapName(cluster.Name, cluster.Spec.Spec)
 	n.replicas = replicas
+	n.minPartition = partition
+	n.dataNode = isDataNode(node)
+	n.minReadySeconds = getMinReadySeconds(node, cluster.Spec.Spec)
 
 	n.client = client
 	n.esClient = esClient
 }
 
+// newStatefulSetUpdateStrategy maps the update strategy of the node to the statefulset one
+func newStatefulSetUpdateStrategy(strategy *api.ElasticsearchUpdateStrategy) apps.StatefulSetUpdateStrategy {
+	strategyType, partition := getUpdateStrategy(strategy)
+	if strategyType == api.OnDeleteStrategy {
+		return apps.StatefulSetUpdateStrategy{
+			Type: apps.OnDeleteStatefulSetStrategyType,
+		}
+	}
+
+	return apps.StatefulSetUpdateStrategy{
+		Type: apps.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
+			Partition: &partition,
+		},
+	}
+}
+
+// isUpdateStrategySame compares the type and partition of the update strategies
+func isUpdateStrategySame(lhs, rhs apps.StatefulSetUpdateStrategy) bool {
+	if lhs.Type != rhs.Type {
+		return false
+	}
+	if lhs.Type == apps.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+
+	return getStatefulSetPartition(lhs) == getStatefulSetPartition(rhs)
+}
+
+func getStatefulSetPartition(strategy apps.StatefulSetUpdateStrategy) int32 {
+	if strategy.RollingUpdate == nil || strategy.RollingUpdate.Partition == nil {
+		return 0
+	}
+	return *strategy.RollingUpdate.Partition
+}
+
+// setNodeNameFromPod names the elasticsearch node after its pod instead of the statefulset
+func setNodeNameFromPod(template *v1.PodTemplateSpec, prefix string) {
+	env := template.Spec.Containers[0].Env
+	for i := range env {
+		if env[i].Name == nodeNameEnvVar {
+			env[i].Value = prefix + "$(POD_NAME)"
+		}
+	}
+}
+
 func (n *statefulSetNode) updateReference(desired NodeTypeInterface) {
 	n.self = desired.(*statefulSetNode).self
+	n.minPartition = desired.(*statefulSetNode).minPartition
+	n.replicas = desired.(*statefulSetNode).replicas
+	n.minReadySeconds = desired.(*statefulSetNode).minReadySeconds
+	n.client = desired.(*statefulSetNode).client
+	n.esClient = desired.(*statefulSetNode).esClient
 }
 
 func (n *statefulSetNode) scaleDown() error {
@@ -98,6 +175,10 @@ func (n *statefulSetNode) getSecretHash() string {
 	return n.secretHash
 }
 
+func (n *statefulSetNode) getMinReadySeconds() int32 {
+	return n.minReadySeconds
+}
+
 func (n *statefulSetNode) state() api.ElasticsearchNodeStatus {
 	var rolloutForUpdate v1.ConditionStatus
 	var rolloutForCertReload v1.ConditionStatus
@@ -164,14 +245,25 @@ func (n *statefulSetNode) waitForNodeLeaveCluster() (bool, error) {
 }
 
 func (n *statefulSetNode) setPartition(partitions int32) error {
+	strategy := apps.StatefulSetUpdateStrategy{
+		Type: apps.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
+			Partition: &partitions,
+		},
+	}
+	return n.setUpdateStrategy(strategy)
+}
+
+func (n *statefulSetNode) setUpdateStrategy(strategy apps.StatefulSetUpdateStrategy) error {
 	equalFunc := func(current, _ *apps.StatefulSet) bool {
-		if current.Spec.UpdateStrategy.RollingUpdate.Partition == nil {
+		if current.Spec.UpdateStrategy.Type == apps.RollingUpdateStatefulSetStrategyType &&
+			(current.Spec.UpdateStrategy.RollingUpdate == nil || current.Spec.UpdateStrategy.RollingUpdate.Partition == nil) {
 			return false
 		}
-		return *current.Spec.UpdateStrategy.RollingUpdate.Partition == partitions
+		return isUpdateStrategySame(current.Spec.UpdateStrategy, strategy)
 	}
 	mutateFunc := func(current, _ *apps.StatefulSet) {
-		current.Spec.UpdateStrategy.RollingUpdate.Partition = &partitions
+		current.Spec.UpdateStrategy = strategy
 	}
 
 	err := statefulset.Update(context.TODO(), n.client, &n.self, equalFunc, mutateFunc)
@@ -181,7 +273,7 @@ func (n *statefulSetNode) setPartition(partitions int32) error {
 		)
 	}
 
-	n.self.Spec.UpdateStrategy.RollingUpdate.Partition = &partitions
+	n.self.Spec.UpdateStrategy = strategy
 
 	return nil
 }
@@ -194,7 +286,7 @@ func (n *statefulSetNode) partition() (int32, error) {
 		return -1, err
 	}
 
-	return *sts.Spec.UpdateStrategy.RollingUpdate.Partition, nil
+	return getStatefulSetPartition(sts.Spec.UpdateStrategy), nil
 }
 
 func (n *statefulSetNode) setReplicaCount(replicas int32) error {
@@ -230,6 +322,34 @@ func (n *statefulSetNode) replicaCount() (int32, error) {
 	return sts.Status.Replicas, nil
 }
 
+// currentReplicas returns the desired replicas of the existing statefulset
+func (n *statefulSetNode) currentReplicas() (int32, error) {
+	key := client.ObjectKey{Name: n.name(), Namespace: n.self.Namespace}
+	sts, err := statefulset.Get(context.TODO(), n.client, key)
+	if err != nil {
+		return -1, err
+	}
+
+	if sts.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return *sts.Spec.Replicas, nil
+}
+
+// elasticsearchNodeNames returns the elasticsearch node names of the pods with
+// an ordinal in [from, to)
+func (n *statefulSetNode) elasticsearchNodeNames(from, to int32) []string {
+	names := []string{}
+	for ordinal := from; ordinal < to; ordinal++ {
+		podName := fmt.Sprintf("%s-%d", n.name(), ordinal)
+		if name, ok := nodeNameFromEnv(n.self.Spec.Template.Spec, podName); ok {
+			podName = name
+		}
+		names = append(names, podName)
+	}
+	return names
+}
+
 func (n *statefulSetNode) isMissing() bool {
 	key := client.ObjectKey{Name: n.name(), Namespace: n.self.Namespace}
 	_, err := statefulset.Get(context.TODO(), n.client, key)
@@ -249,6 +369,10 @@ func (n *statefulSetNode) delete() error {
 
 func (n *statefulSetNode) create() error {
 	if n.self.ObjectMeta.ResourceVersion == "" {
+		if err := ensureVolumeClaimsExist(context.TODO(), n.client, n.self.Namespace, n.self.Spec.Template.Spec); err != nil {
+			return err
+		}
+
 		err := statefulset.Create(context.TODO(), n.client, &n.self)
 		if err != nil {
 			if !apierrors.IsAlreadyExists(kverrors.Root(err)) {
@@ -290,13 +414,13 @@ func (n *statefulSetNode) executeUpdate() error {
 }
 
 func (n *statefulSetNode) refreshHashes() {
-	key := client.ObjectKey{Name: n.clusterName, Namespace: n.self.Namespace}
-
+	key := client.ObjectKey{Name: n.configMapName, Namespace: n.self.Namespace}
 	newConfigmapHash := configmap.GetDataSHA256(context.TODO(), n.client, key, excludeConfigMapKeys)
 	if newConfigmapHash != "" && newConfigmapHash != n.configmapHash {
 		n.configmapHash = newConfigmapHash
 	}
 
+	key = client.ObjectKey{Name: n.clusterName, Namespace: n.self.Namespace}
 	newSecretHash := secret.GetDataSHA256(context.TODO(), n.client, key)
 	if newSecretHash != "" && newSecretHash != n.secretHash {
 		n.secretHash = newSecretHash
@@ -310,6 +434,21 @@ func (n *statefulSetNode) scale() {
 		return
 	}
 
+	if n.dataNode {
+		// data nodes scale up right away, they scale down once drained
+		if *sts.Spec.Replicas < n.replicas {
+			n.L().Info("Scaling up data nodes", "from", *sts.Spec.Replicas, "to", n.replicas)
+			if err := n.setReplicaCount(n.replicas); err != nil {
+				n.L().Error(err, "unable to scale up data nodes")
+			}
+		} else {
+			n.self.Spec.Replicas = sts.Spec.Replicas
+		}
+
+		n.expandDataVolumeClaims(*n.self.Spec.Replicas)
+		return
+	}
+
 	if *sts.Spec.Replicas != *n.self.Spec.Replicas {
 		n.self.Spec.Replicas = sts.Spec.Replicas
 		n.L().Info("Resource has different container replicas than desired")
@@ -320,6 +459,21 @@ func (n *statefulSetNode) scale() {
 	}
 }
 
+// expandDataVolumeClaims raises the storage requests of the existing data volume
+// claims to the size of their claim template, which cannot be changed itself
+func (n *statefulSetNode) expandDataVolumeClaims(replicas int32) {
+	for _, template := range n.self.Spec.VolumeClaimTemplates {
+		size := template.Spec.Resources.Requests.Storage()
+		for ordinal := int32(0); ordinal < replicas; ordinal++ {
+			claimName := volumeClaimName(template.Name, n.name(), ordinal)
+			err := expandPersistentVolumeClaim(context.TODO(), n.client, claimName, n.self.Namespace, *size)
+			if err != nil && !apierrors.IsNotFound(kverrors.Root(err)) {
+				n.L().Error(err, "Unable to resize PersistentVolumeClaim", "claim", claimName)
+			}
+		}
+	}
+}
+
 func (n *statefulSetNode) isChanged() bool {
 	key := client.ObjectKey{Name: n.name(), Namespace: n.self.Namespace}
 	sts, err := statefulset.Get(context.TODO(), n.client, key)
@@ -327,6 +481,17 @@ func (n *statefulSetNode) isChanged() bool {
 		return false
 	}
 
+	return !pod.ArePodTemplateSpecEqual(sts.Spec.Template, n.self.Spec.Template) ||
+		!isUpdateStrategySame(sts.Spec.UpdateStrategy, n.self.Spec.UpdateStrategy)
+}
+
+func (n *statefulSetNode) isTemplateChanged() bool {
+	key := client.ObjectKey{Name: n.name(), Namespace: n.self.Namespace}
+	sts, err := statefulset.Get(context.TODO(), n.client, key)
+	if err != nil {
+		return false
+	}
+
 	return !pod.ArePodTemplateSpecEqual(sts.Spec.Template, n.self.Spec.Template)
 }
 
@@ -334,19 +499,37 @@ func (n *statefulSetNode) progressNodeChanges() error {
 	if !n.isChanged() {
 		return nil
 	}
-	replicas, err := n.replicaCount()
-	if err != nil {
-		return kverrors.Wrap(err, "Unable to get number of replicas prior to restart for node",
-			"node", n.name(),
-		)
-	}
 
-	if err := n.setPartition(replicas); err != nil {
-		n.L().Error(err, "unable to set partition")
+	if n.self.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType {
+		// the pods pick up the changes once they are deleted
+		if err := n.setUpdateStrategy(n.self.Spec.UpdateStrategy); err != nil {
+			return err
+		}
+		if err := n.executeUpdate(); err != nil {
+			return err
+		}
+
+		n.refreshHashes()
+		return nil
 	}
 
-	if err := n.executeUpdate(); err != nil {
-		return err
+	minPartition := n.minPartition
+
+	if n.isTemplateChanged() {
+		replicas, err := n.replicaCount()
+		if err != nil {
+			return kverrors.Wrap(err, "Unable to get number of replicas prior to restart for node",
+				"node", n.name(),
+			)
+		}
+
+		if err := n.setPartition(replicas); err != nil {
+			n.L().Error(err, "unable to set partition")
+		}
+
+		if err := n.executeUpdate(); err != nil {
+			return err
+		}
 	}
 
 	ordinal, err := n.partition()
@@ -354,9 +537,9 @@ func (n *statefulSetNode) progressNodeChanges() error {
 		return kverrors.Wrap(err, "unable to get node ordinal value")
 	}
 
-	// start partition at replicas and incrementally update it to 0
-	// making sure nodes rejoin between each one
-	for index := ordinal; index > 0; index-- {
+	// start partition at replicas and incrementally update it down to the
+	// partition of the update strategy making sure nodes rejoin between each one
+	for index := ordinal; index > minPartition; index-- {
 
 		// make sure we have all nodes in the cluster first -- always
 		if _, err := n.waitForNodeRejoinCluster(); err != nil {
@@ -378,6 +561,11 @@ func (n *statefulSetNode) progressNodeChanges() error {
 		}
 	}
 
+	// a raised partition or a change from OnDelete is set as is
+	if err := n.setPartition(minPartition); err != nil {
+		return err
+	}
+
 	// this is here again because we need to make sure all nodes have rejoined
 	// before we move on and say we're done
 	if _, err := n.waitForNodeRejoinCluster(); err != nil {
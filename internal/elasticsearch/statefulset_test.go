@@ -0,0 +1,318 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+
+	"github.com/ViaQ/logerr/v2/log"
+	"github.com/google/go-cmp/cmp"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestNewStatefulSetUpdateStrategy(t *testing.T) {
+	tests := []struct {
+		desc     string
+		strategy *api.ElasticsearchUpdateStrategy
+		want     apps.StatefulSetUpdateStrategy
+	}{
+		{
+			desc: "default",
+			want: apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32(0)},
+			},
+		},
+		{
+			desc:     "rolling update with partition",
+			strategy: &api.ElasticsearchUpdateStrategy{Type: api.RollingUpdateStrategy, Partition: pointer.Int32(2)},
+			want: apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32(2)},
+			},
+		},
+		{
+			desc:     "partition without type",
+			strategy: &api.ElasticsearchUpdateStrategy{Partition: pointer.Int32(1)},
+			want: apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32(1)},
+			},
+		},
+		{
+			desc:     "on delete",
+			strategy: &api.ElasticsearchUpdateStrategy{Type: api.OnDeleteStrategy, Partition: pointer.Int32(1)},
+			want:     apps.StatefulSetUpdateStrategy{Type: apps.OnDeleteStatefulSetStrategyType},
+		},
+	}
+
+	for _, test := range tests {
+		if diff := cmp.Diff(newStatefulSetUpdateStrategy(test.strategy), test.want); diff != "" {
+			t.Errorf("%s: unexpected update strategy: %s", test.desc, diff)
+		}
+	}
+}
+
+func TestStatefulSetNodeUpdateStrategy(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+	}
+	node := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+		NodeCount: 3,
+		UpdateStrategy: &api.ElasticsearchUpdateStrategy{
+			Partition: pointer.Int32(2),
+		},
+	}
+
+	n := &statefulSetNode{l: log.NewLogger("statefulset-testing")}
+	n.populateReference("elasticsearch-m-1", node, cluster, getNodeRoleMap(node), node.NodeCount, nil, nil)
+
+	if got := getStatefulSetPartition(n.self.Spec.UpdateStrategy); got != 2 {
+		t.Errorf("Exp. the statefulset to be created with partition 2 but was %d", got)
+	}
+	if n.minPartition != 2 {
+		t.Errorf("Exp. rolling updates to stop at ordinal 2 but was %d", n.minPartition)
+	}
+}
+
+func TestDeploymentNodeIsHeldBack(t *testing.T) {
+	tests := []struct {
+		desc     string
+		strategy *api.ElasticsearchUpdateStrategy
+		ordinal  int32
+		want     bool
+	}{
+		{desc: "default", ordinal: 0, want: false},
+		{desc: "ordinal below partition", strategy: &api.ElasticsearchUpdateStrategy{Partition: pointer.Int32(2)}, ordinal: 1, want: true},
+		{desc: "ordinal at partition", strategy: &api.ElasticsearchUpdateStrategy{Partition: pointer.Int32(2)}, ordinal: 2, want: false},
+		{desc: "on delete", strategy: &api.ElasticsearchUpdateStrategy{Type: api.OnDeleteStrategy}, ordinal: 2, want: true},
+	}
+
+	for _, test := range tests {
+		node := &deploymentNode{ordinal: test.ordinal, updateStrategy: test.strategy}
+		if got := node.isHeldBack(); got != test.want {
+			t.Errorf("%s: exp. held back to be %t but was %t", test.desc, test.want, got)
+		}
+	}
+}
+
+func TestDataStatefulSetVolumeClaimTemplate(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+	}
+	size := resource.MustParse("10Gi")
+	node := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData, api.ElasticsearchRoleMaster},
+		NodeCount: 3,
+		Workload:  api.StatefulSetWorkload,
+		Storage: api.ElasticsearchStorageSpec{
+			StorageClassName: pointer.String("gp2"),
+			Size:             &size,
+		},
+	}
+
+	n := &statefulSetNode{l: log.NewLogger("statefulset-testing")}
+	n.populateReference("elasticsearch-cdm-1", node, cluster, getNodeRoleMap(node), node.NodeCount, nil, nil)
+
+	templates := n.self.Spec.VolumeClaimTemplates
+	if len(templates) != 1 {
+		t.Fatalf("Exp. one volume claim template but got %v", templates)
+	}
+	if templates[0].Name != "elasticsearch-storage" {
+		t.Errorf("Exp. the claim template to be named elasticsearch-storage but was %q", templates[0].Name)
+	}
+	if got := dataVolumeClaimName(n.self.Name, 0); got != "elasticsearch-storage-elasticsearch-cdm-1-0" {
+		t.Errorf("Exp. the claim of the first replica to be elasticsearch-storage-elasticsearch-cdm-1-0 but was %q", got)
+	}
+	if got := templates[0].Spec.Resources.Requests.Storage(); got.Cmp(size) != 0 {
+		t.Errorf("Exp. the claims to request %s but got %s", size.String(), got.String())
+	}
+	if got := templates[0].Spec.StorageClassName; got == nil || *got != "gp2" {
+		t.Errorf("Exp. the claims to use the gp2 storage class but got %v", got)
+	}
+	if templates[0].Labels["logging-cluster"] != "elasticsearch" {
+		t.Errorf("Exp. the claims to be labeled with the cluster but got %v", templates[0].Labels)
+	}
+
+	for _, volume := range n.self.Spec.Template.Spec.Volumes {
+		if volume.Name == "elasticsearch-storage" {
+			t.Errorf("Exp. the data volume to come from the claim template but the pod template has %v", volume)
+		}
+	}
+
+	for _, env := range n.self.Spec.Template.Spec.Containers[0].Env {
+		if env.Name != "NODE_NAME" {
+			continue
+		}
+		if env.Value != "$(POD_NAME)" {
+			t.Errorf("Exp. the data nodes to be named after their pods but got %v", env)
+		}
+	}
+
+	if !n.dataNode {
+		t.Error("Exp. the statefulset to run data nodes")
+	}
+}
+
+func TestNodeNameEnvVar(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+		Spec: api.ElasticsearchSpec{
+			Spec: api.ElasticsearchNodeSpec{NodeNamePrefix: "es-"},
+		},
+	}
+	master := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+		NodeCount: 3,
+		Workload:  api.StatefulSetWorkload,
+	}
+	data := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData},
+		NodeCount: 1,
+	}
+
+	sts := &statefulSetNode{l: log.NewLogger("statefulset-testing")}
+	sts.populateReference("elasticsearch-cm-1", master, cluster, getNodeRoleMap(master), master.NodeCount, nil, nil)
+	dpl := &deploymentNode{log: log.NewLogger("deployment-testing")}
+	dpl.populateReference("elasticsearch-cd-1", data, cluster, getNodeRoleMap(data), data.NodeCount, nil, nil)
+
+	tests := []struct {
+		desc string
+		spec v1.PodSpec
+		want string
+	}{
+		{desc: "statefulset", spec: sts.self.Spec.Template.Spec, want: "es-$(POD_NAME)"},
+		{desc: "deployment", spec: dpl.self.Spec.Template.Spec, want: "es-elasticsearch-cd-1"},
+	}
+	for _, test := range tests {
+		value := ""
+		for _, env := range test.spec.Containers[0].Env {
+			if env.Name == "NODE_NAME" {
+				value = env.Value
+			}
+		}
+		if value != test.want {
+			t.Errorf("%s: exp. NODE_NAME to be %q but was %q", test.desc, test.want, value)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"es-elasticsearch-cm-1-1", "es-elasticsearch-cm-1-2"}, sts.elasticsearchNodeNames(1, 3)); diff != "" {
+		t.Errorf("unexpected node names of the statefulset: %s", diff)
+	}
+	if got := dpl.elasticsearchNodeName(); got != "es-elasticsearch-cd-1" {
+		t.Errorf("Exp. the deployment node to be named es-elasticsearch-cd-1 but was %q", got)
+	}
+}
+
+func TestDataStatefulSetAdditionalDataVolumes(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+	}
+	size := resource.MustParse("10Gi")
+	hotSize := resource.MustParse("20Gi")
+	node := api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+		NodeCount: 2,
+		Workload:  api.StatefulSetWorkload,
+		Storage:   api.ElasticsearchStorageSpec{Size: &size},
+		DataVolumes: []api.ElasticsearchDataVolume{
+			{Name: "hot", Storage: api.ElasticsearchStorageSpec{Size: &hotSize}},
+			{Name: "scratch"},
+		},
+	}
+
+	n := &statefulSetNode{l: log.NewLogger("statefulset-testing")}
+	n.populateReference("elasticsearch-cd-1", node, cluster, getNodeRoleMap(node), node.NodeCount, nil, nil)
+
+	templates := n.self.Spec.VolumeClaimTemplates
+	if len(templates) != 2 {
+		t.Fatalf("Exp. two volume claim templates but got %v", templates)
+	}
+	if templates[1].Name != "elasticsearch-storage-hot" {
+		t.Errorf("Exp. the second claim template to be named elasticsearch-storage-hot but was %q", templates[1].Name)
+	}
+	if got := templates[1].Spec.Resources.Requests.Storage(); got.Cmp(hotSize) != 0 {
+		t.Errorf("Exp. the hot claims to request %s but got %s", hotSize.String(), got.String())
+	}
+	if got := volumeClaimName(templates[1].Name, n.self.Name, 1); got != "elasticsearch-storage-hot-elasticsearch-cd-1-1" {
+		t.Errorf("Exp. the hot claim of the second replica to be elasticsearch-storage-hot-elasticsearch-cd-1-1 but was %q", got)
+	}
+
+	volumes := map[string]v1.Volume{}
+	for _, volume := range n.self.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	if _, ok := volumes["elasticsearch-storage-hot"]; ok {
+		t.Error("Exp. the hot volume to come from the claim template")
+	}
+	if volume, ok := volumes["elasticsearch-storage-scratch"]; !ok || volume.EmptyDir == nil {
+		t.Errorf("Exp. the scratch volume to be an emptyDir but got %v", volume)
+	}
+
+	esContainer := n.self.Spec.Template.Spec.Containers[0]
+	mounts := map[string]string{}
+	for _, mount := range esContainer.VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	wantMounts := map[string]string{
+		"elasticsearch-storage":         "/elasticsearch/persistent",
+		"elasticsearch-storage-hot":     "/elasticsearch/data/hot",
+		"elasticsearch-storage-scratch": "/elasticsearch/data/scratch",
+	}
+	for name, mountPath := range wantMounts {
+		if mounts[name] != mountPath {
+			t.Errorf("Exp. %s to be mounted at %s but got %q", name, mountPath, mounts[name])
+		}
+	}
+
+	dataPaths := ""
+	for _, env := range esContainer.Env {
+		if env.Name == "ES_DATA_PATHS" {
+			dataPaths = env.Value
+		}
+	}
+	want := "/elasticsearch/persistent/elasticsearch/data,/elasticsearch/data/hot/elasticsearch/data,/elasticsearch/data/scratch/elasticsearch/data"
+	if dataPaths != want {
+		t.Errorf("Exp. ES_DATA_PATHS to be %q but was %q", want, dataPaths)
+	}
+}
+
+func TestNewNodeWorkload(t *testing.T) {
+	tests := []struct {
+		desc string
+		node api.ElasticsearchNode
+		want api.ElasticsearchNodeWorkload
+	}{
+		{
+			desc: "new data node",
+			node: api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}},
+			want: api.StatefulSetWorkload,
+		},
+		{
+			desc: "new data node run as deployments",
+			node: api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}, Workload: api.DeploymentWorkload},
+			want: api.DeploymentWorkload,
+		},
+		{
+			desc: "new master node",
+			node: api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}},
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		if got := newNodeWorkload(test.node); got != test.want {
+			t.Errorf("%s: exp. workload %q but got %q", test.desc, test.want, got)
+		}
+	}
+
+	// existing entries without workload keep their deployments
+	if !isDeploymentDataNode(api.ElasticsearchNode{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}}) {
+		t.Error("Exp. data nodes without workload to run as deployments")
+	}
+}
@@ -38,6 +38,7 @@ func (er *ElasticsearchRequest) UpdateClusterStatus() error {
 	esClient := er.esClient
 
 	clusterStatus := cluster.Status.DeepCopy()
+	previousHealth := cluster.Status.Cluster.Status
 
 	health := api.ClusterHealth{
 		Status: healthUnknown,
@@ -76,6 +77,9 @@ func (er *ElasticsearchRequest) UpdateClusterStatus() error {
 		return err
 	}
 
+	er.updateResourceRecommendations(clusterStatus)
+	clusterStatus.EffectiveConfig = newEffectiveConfig(cluster)
+
 	if !reflect.DeepEqual(clusterStatus, cluster.Status) {
 		nretries := -1
 		retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -89,6 +93,7 @@ func (er *ElasticsearchRequest) UpdateClusterStatus() error {
 			cluster.Status.Pods = clusterStatus.Pods
 			cluster.Status.ShardAllocationEnabled = clusterStatus.ShardAllocationEnabled
 			cluster.Status.Nodes = clusterStatus.Nodes
+			cluster.Status.EffectiveConfig = clusterStatus.EffectiveConfig
 
 			if err := er.client.Status().Update(context.TODO(), cluster); err != nil {
 				return err
@@ -101,6 +106,8 @@ func (er *ElasticsearchRequest) UpdateClusterStatus() error {
 				"cluster", cluster.Name,
 				"retries", nretries)
 		}
+
+		er.recordHealthEvent(previousHealth, health.Status)
 	}
 
 	return nil
@@ -122,6 +129,42 @@ func (er *ElasticsearchRequest) setNodeStatus(node NodeTypeInterface, nodeStatus
 	return er.updateNodeStatus(*clusterStatus)
 }
 
+// newEffectiveConfig returns the configuration the nodes of the cluster run
+// with, resolved from the spec and the operator defaults like during the
+// creation of the pods
+func newEffectiveConfig(cluster *api.Elasticsearch) *api.ElasticsearchEffectiveConfig {
+	config := &api.ElasticsearchEffectiveConfig{
+		Image:         getClusterImage(cluster.Spec.Spec),
+		PrimaryShards: int32(CalculatePrimaryCount(cluster)),
+		ReplicaShards: int32(CalculateReplicaCount(cluster)),
+	}
+
+	commonSpec := cluster.Spec.Spec
+	for _, node := range cluster.Spec.Nodes {
+		roleMap := getClusterNodeRoleMap(node, cluster.Spec.Nodes)
+		nodeConfig := api.ElasticsearchNodeEffectiveConfig{
+			NodeCount:      node.NodeCount,
+			Master:         roleMap[api.ElasticsearchRoleMaster],
+			Data:           roleMap[api.ElasticsearchRoleData],
+			Client:         roleMap[api.ElasticsearchRoleClient],
+			Ingest:         roleMap[api.ElasticsearchRoleIngest],
+			Resources:      newESNodeResourceRequirements(node, commonSpec),
+			ProxyResources: newESProxyResourceRequirements(node.ProxyResources, commonSpec.ProxyResources),
+			Image:          getNodeImage(node, commonSpec),
+		}
+		if node.GenUUID != nil {
+			nodeConfig.GenUUID = *node.GenUUID
+		}
+		if heapSize := getHeapSize(node, commonSpec); heapSize != nil {
+			heapSize := heapSize.DeepCopy()
+			nodeConfig.HeapSize = &heapSize
+		}
+		config.Nodes = append(config.Nodes, nodeConfig)
+	}
+
+	return config
+}
+
 func (er *ElasticsearchRequest) updateNodeStatus(status api.ElasticsearchStatus) error {
 	cluster := er.cluster
 	// if there is nothing to update, don't
@@ -385,7 +428,7 @@ func (er *ElasticsearchRequest) updateStorageConditions(status *api.Elasticsearc
 		Status:             sizeStatus,
 		LastTransitionTime: metav1.Now(),
 		Reason:             "StorageSizeChangeIgnored",
-		Message:            "Resizing the storage for a custom resource is not supported",
+		Message:            "Shrinking the storage or growing it on a storage class without volume expansion is not supported",
 	})
 
 	return nil
@@ -595,7 +638,14 @@ func (er *ElasticsearchRequest) updatePodNodeConditions(status *api.Elasticsearc
 				continue
 			}
 
-			usage, percent, err := er.esClient.GetNodeDiskUsage(nodeName)
+			// the elasticsearch node name is prefixed and statefulset pods are
+			// named after the pod, resolve it like the pod does
+			esNodeName := nodeName
+			if name, ok := nodeNameFromEnv(nodePod.Spec, nodePod.Name); ok {
+				esNodeName = name
+			}
+
+			usage, percent, err := er.esClient.GetNodeDiskUsage(esNodeName)
 			if err != nil {
 				ll.Error(err, "Unable to get disk usage")
 				continue
@@ -935,22 +985,30 @@ func updateConditionWithRetry(dpl *api.Elasticsearch, value v1.ConditionStatus,
 	return kverrors.Wrap(retryErr, "failed to update elasticsearch status")
 }
 
-func updateInvalidMasterCountCondition(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
-	var message string
-	var reason string
-	if value == v1.ConditionTrue {
-		message = fmt.Sprintf("Invalid master nodes count. Please ensure there are no more than %v total nodes with master roles", maxMasterCount)
-		reason = "Invalid Settings"
-	} else {
-		message = ""
-		reason = ""
+// updateInvalidMasterCountCondition returns the update of the invalid master
+// count condition, telling a cluster without master nodes from one with too many
+func updateInvalidMasterCountCondition(masterCount int32) func(*api.ElasticsearchStatus, v1.ConditionStatus) bool {
+	return func(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
+		var message string
+		var reason string
+		if value == v1.ConditionTrue {
+			if masterCount == 0 {
+				message = "No master nodes requested. Please ensure there is at least 1 node with master roles, so that a master can be elected"
+			} else {
+				message = fmt.Sprintf("Invalid master nodes count. Please ensure there are no more than %v total nodes with master roles", maxMasterCount)
+			}
+			reason = "Invalid Settings"
+		} else {
+			message = ""
+			reason = ""
+		}
+		return updateESNodeCondition(status, &api.ClusterCondition{
+			Type:    api.InvalidMasters,
+			Status:  value,
+			Reason:  reason,
+			Message: message,
+		})
 	}
-	return updateESNodeCondition(status, &api.ClusterCondition{
-		Type:    api.InvalidMasters,
-		Status:  value,
-		Reason:  reason,
-		Message: message,
-	})
 }
 
 func updateInvalidDataCountCondition(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
@@ -994,6 +1052,27 @@ func updateInvalidUUIDChangeCondition(cluster *api.Elasticsearch, value v1.Condi
 	)
 }
 
+func updateInvalidSettingsCondition(cluster *api.Elasticsearch, value v1.ConditionStatus, message string, client client.Client) error {
+	var reason string
+	if value == v1.ConditionTrue {
+		reason = "Invalid Settings"
+	}
+
+	return updateConditionWithRetry(
+		cluster,
+		value,
+		func(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
+			return updateESNodeCondition(&cluster.Status, &api.ClusterCondition{
+				Type:    api.InvalidSettings,
+				Status:  value,
+				Reason:  reason,
+				Message: message,
+			})
+		},
+		client,
+	)
+}
+
 func updateInvalidReplicationCondition(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
 	var message string
 	var reason string
@@ -1052,6 +1131,34 @@ func (er *ElasticsearchRequest) UpdateDegradedCondition(value bool, reason, mess
 	)
 }
 
+// UpdatePausedCondition sets the ReconciliationPaused condition while the
+// reconciliation of the cluster is paused and removes it once resumed
+func UpdatePausedCondition(cluster *api.Elasticsearch, client client.Client) error {
+	value := v1.ConditionFalse
+	if cluster.Spec.Paused {
+		value = v1.ConditionTrue
+	} else if _, condition := getESNodeCondition(cluster.Status.Conditions, api.ReconciliationPaused); condition == nil {
+		return nil
+	}
+
+	return updateConditionWithRetry(cluster, value, updatePausedCondition, client)
+}
+
+func updatePausedCondition(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
+	var message string
+	var reason string
+	if value == v1.ConditionTrue {
+		message = "Reconciliation is paused, the operator does not change any resource of the cluster"
+		reason = "Paused"
+	}
+	return updateESNodeCondition(status, &api.ClusterCondition{
+		Type:    api.ReconciliationPaused,
+		Status:  value,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
 func updateUpdatingSettingsCondition(status *api.ElasticsearchStatus, value v1.ConditionStatus) bool {
 	return updateESNodeCondition(status, &api.ClusterCondition{
 		Type:   api.UpdatingSettings,
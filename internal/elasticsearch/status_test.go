@@ -5,11 +5,13 @@ import (
 	"time"
 
 	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/test/helpers"
 
 	"github.com/ViaQ/logerr/v2/log"
 	"github.com/google/go-cmp/cmp"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -448,3 +450,151 @@ func TestUnschedulableNodesConditions(t *testing.T) {
 		t.Errorf("Expected cluster node statuses to be same. Diff is %s", diff)
 	}
 }
+
+func TestDiskWatermarkConditionsStatefulSetPod(t *testing.T) {
+	const (
+		esCluster   = "elasticsearch"
+		esNamespace = "test-namespace"
+		stsName     = "elasticsearch-cd-deadbeef"
+		podName     = "elasticsearch-cd-deadbeef-1"
+	)
+
+	flood := float64(95)
+	DiskWatermarkFloodPct = &flood
+	defer func() { DiskWatermarkFloodPct = nil }()
+
+	testCluster := &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: esNamespace,
+			Name:      esCluster,
+		},
+		Status: loggingv1.ElasticsearchStatus{
+			Nodes: []loggingv1.ElasticsearchNodeStatus{
+				{StatefulSetName: stsName},
+			},
+		},
+	}
+
+	mockedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: esNamespace,
+			Labels: map[string]string{
+				"cluster-name": esCluster,
+				"component":    "elasticsearch",
+				"node-name":    stsName,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "elasticsearch",
+					Env:  []corev1.EnvVar{newNodeNameEnvVar("", "$(POD_NAME)")},
+				},
+			},
+		},
+	}
+
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_nodes/stats/fs": {
+			{
+				StatusCode: 200,
+				Body:       `{"nodes": {"7EN-Wa_EQC6LoANvWcoyHQ": {"name": "` + podName + `", "fs": {"total": {"total_in_bytes": 100000, "available_in_bytes": 1000}}}}}`,
+			},
+		},
+	})
+	k8sClient := fake.NewFakeClient(mockedPod)
+	er := &ElasticsearchRequest{
+		ll:       log.NewLogger("status-testing"),
+		client:   k8sClient,
+		cluster:  testCluster,
+		esClient: helpers.NewFakeElasticsearchClient(esCluster, esNamespace, k8sClient, chatter),
+	}
+
+	status := testCluster.Status.DeepCopy()
+	if err := er.updatePodNodeConditions(status, true); err != nil {
+		t.Fatalf("Received error while testing updating pod node conditions: %v", err)
+	}
+
+	_, condition := getPodCondition(&status.Nodes[0], loggingv1.NodeStorage)
+	if condition == nil || condition.Status != corev1.ConditionTrue || condition.Reason != "Disk Watermark Flood" {
+		t.Errorf("Exp. the flood watermark condition for the pod %s but was %v", podName, condition)
+	}
+}
+
+func TestEffectiveConfigReflectsOverrides(t *testing.T) {
+	uuid := "deadbeef"
+	shards := int32(3)
+	heapSize := resource.MustParse("2Gi")
+	cluster := &loggingv1.Elasticsearch{
+		Spec: loggingv1.ElasticsearchSpec{
+			RedundancyPolicy: loggingv1.ZeroRedundancy,
+			ShardsPerIndex:   &shards,
+			Spec: loggingv1.ElasticsearchNodeSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+				},
+			},
+			Nodes: []loggingv1.ElasticsearchNode{
+				{
+					Roles:     []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleMaster},
+					NodeCount: 3,
+					GenUUID:   &uuid,
+				},
+				{
+					Roles:     []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleData},
+					NodeCount: 2,
+					HeapSize:  &heapSize,
+					Resources: corev1.ResourceRequirements{
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+					},
+				},
+			},
+		},
+	}
+
+	config := newEffectiveConfig(cluster)
+
+	if config.Image != getESImage() {
+		t.Errorf("expected image %q, got %q", getESImage(), config.Image)
+	}
+	if config.PrimaryShards != 3 || config.ReplicaShards != 0 {
+		t.Errorf("expected 3 primary and 0 replica shards, got %d and %d", config.PrimaryShards, config.ReplicaShards)
+	}
+	if len(config.Nodes) != 2 {
+		t.Fatalf("expected a configuration for each node group, got %d", len(config.Nodes))
+	}
+
+	master := config.Nodes[0]
+	if master.GenUUID != uuid || master.NodeCount != 3 || !master.Master || master.Data || master.Ingest {
+		t.Errorf("unexpected master node group configuration: %#v", master)
+	}
+	if got := master.Resources.Limits.Memory().String(); got != "8Gi" {
+		t.Errorf("expected the common memory limit for the master nodes, got %s", got)
+	}
+	if master.HeapSize != nil {
+		t.Errorf("expected no heap size for the master nodes, got %s", master.HeapSize.String())
+	}
+	if master.Image != getESImage() {
+		t.Errorf("expected the cluster image for the master nodes, got %q", master.Image)
+	}
+
+	data := config.Nodes[1]
+	if data.Master || !data.Data || !data.Ingest {
+		t.Errorf("unexpected data node group roles: %#v", data)
+	}
+	if got := data.Resources.Limits.Memory().String(); got != "4Gi" {
+		t.Errorf("expected the node memory limit for the data nodes, got %s", got)
+	}
+	if got := data.Resources.Requests.Cpu().String(); got != "2" {
+		t.Errorf("expected the node cpu request for the data nodes, got %s", got)
+	}
+	if data.HeapSize == nil || data.HeapSize.String() != "2Gi" {
+		t.Errorf("expected the node heap size for the data nodes, got %v", data.HeapSize)
+	}
+	wantProxy := newESProxyResourceRequirements(corev1.ResourceRequirements{}, corev1.ResourceRequirements{})
+	if diff := cmp.Diff(wantProxy, data.ProxyResources); diff != "" {
+		t.Errorf("expected the default proxy resources. Diff is %s", diff)
+	}
+}
@@ -0,0 +1,130 @@
+package elasticsearch
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// orderUpgradeNodes returns the nodes in the order they are upgraded. The nodes
+// without the master role go first so that the cluster keeps its elected
+// master while data is moved around, the master eligible nodes follow. The
+// order within each group is kept.
+func orderUpgradeNodes(nodes []NodeTypeInterface) []NodeTypeInterface {
+	ordered := append([]NodeTypeInterface(nil), nodes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !isMasterEligibleNode(ordered[i]) && isMasterEligibleNode(ordered[j])
+	})
+	return ordered
+}
+
+// upgradeBatches splits the ordered nodes into the batches upgraded together.
+// The nodes without the master role are grouped up to maxUnavailable at a time,
+// the master eligible nodes are always upgraded on their own.
+func upgradeBatches(nodes []NodeTypeInterface, maxUnavailable int) [][]NodeTypeInterface {
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+
+	batches := [][]NodeTypeInterface{}
+	var batch []NodeTypeInterface
+	for _, node := range nodes {
+		if isMasterEligibleNode(node) {
+			if len(batch) > 0 {
+				batches = append(batches, batch)
+				batch = nil
+			}
+			batches = append(batches, []NodeTypeInterface{node})
+			continue
+		}
+
+		batch = append(batch, node)
+		if len(batch) == maxUnavailable {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// maxUnavailableNodes returns the number of nodes without the master role upgraded
+// at the same time. It is capped by the replicas per index, so that every shard
+// keeps a copy on the nodes left running.
+func maxUnavailableNodes(cluster *api.Elasticsearch) int {
+	if cluster.Spec.MaxUnavailable == nil {
+		return 1
+	}
+
+	maxUnavailable := int(*cluster.Spec.MaxUnavailable)
+	if replicas := CalculateReplicaCount(cluster); maxUnavailable > replicas {
+		maxUnavailable = replicas
+	}
+	if maxUnavailable < 1 {
+		return 1
+	}
+
+	return maxUnavailable
+}
+
+// isMasterEligibleNode returns true if the pods of the node are master eligible
+func isMasterEligibleNode(node NodeTypeInterface) bool {
+	switch n := node.(type) {
+	case *deploymentNode:
+		return n.self.Spec.Template.Labels["es-node-master"] == "true"
+	case *statefulSetNode:
+		return n.self.Spec.Template.Labels["es-node-master"] == "true"
+	}
+	return false
+}
+
+// newUpgradeProgress returns the upgrade progress with the given nodes left to
+// upgrade, or nil when there are none left
+func newUpgradeProgress(pending []NodeTypeInterface) *api.ElasticsearchUpgradeProgress {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	progress := &api.ElasticsearchUpgradeProgress{
+		Stage: api.UpgradeStageNonMasterNodes,
+	}
+	if isMasterEligibleNode(pending[0]) {
+		progress.Stage = api.UpgradeStageMasterNodes
+	}
+	for _, node := range pending {
+		progress.PendingNodes = append(progress.PendingNodes, node.name())
+	}
+	return progress
+}
+
+func (er *ElasticsearchRequest) updateUpgradeProgress(progress *api.ElasticsearchUpgradeProgress) error {
+	cluster := er.cluster
+	if reflect.DeepEqual(cluster.Status.Upgrade, progress) {
+		return nil
+	}
+
+	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := er.client.Get(context.TODO(), types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cluster); err != nil {
+			return err
+		}
+
+		cluster.Status.Upgrade = progress
+		return er.client.Status().Update(context.TODO(), cluster)
+	})
+
+	if retryErr != nil {
+		return kverrors.Wrap(retryErr, "failed to update upgrade progress status",
+			"cluster", cluster.Name,
+		)
+	}
+
+	return nil
+}
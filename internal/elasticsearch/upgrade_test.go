@@ -0,0 +1,244 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func newUpgradeTestDeployment(name string, roleLabels map[string]string) *deploymentNode {
+	node := &deploymentNode{self: appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name}}}
+	node.self.Spec.Template.Labels = roleLabels
+	return node
+}
+
+func newUpgradeTestStatefulSet(name string, roleLabels map[string]string) *statefulSetNode {
+	node := &statefulSetNode{self: appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name}}}
+	node.self.Spec.Template.Labels = roleLabels
+	return node
+}
+
+func nodeNames(nodes []NodeTypeInterface) []string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.name())
+	}
+	return names
+}
+
+func TestOrderUpgradeNodes(t *testing.T) {
+	master := map[string]string{"es-node-master": "true", "es-node-data": "false", "es-node-client": "false"}
+	masterData := map[string]string{"es-node-master": "true", "es-node-data": "true", "es-node-client": "true"}
+	data := map[string]string{"es-node-master": "false", "es-node-data": "true", "es-node-client": "true"}
+	client := map[string]string{"es-node-master": "false", "es-node-data": "false", "es-node-client": "true"}
+
+	tests := []struct {
+		desc  string
+		nodes []NodeTypeInterface
+		want  []string
+	}{
+		{
+			desc: "dedicated masters after data and client nodes",
+			nodes: []NodeTypeInterface{
+				newUpgradeTestStatefulSet("elasticsearch-m", master),
+				newUpgradeTestDeployment("elasticsearch-cd-1", data),
+				newUpgradeTestDeployment("elasticsearch-c-1", client),
+				newUpgradeTestDeployment("elasticsearch-cd-2", data),
+			},
+			want: []string{"elasticsearch-cd-1", "elasticsearch-c-1", "elasticsearch-cd-2", "elasticsearch-m"},
+		},
+		{
+			desc: "master eligible data nodes keep their order",
+			nodes: []NodeTypeInterface{
+				newUpgradeTestDeployment("elasticsearch-cdm-1", masterData),
+				newUpgradeTestDeployment("elasticsearch-cd-1", data),
+				newUpgradeTestDeployment("elasticsearch-cdm-2", masterData),
+				newUpgradeTestDeployment("elasticsearch-cdm-3", masterData),
+			},
+			want: []string{"elasticsearch-cd-1", "elasticsearch-cdm-1", "elasticsearch-cdm-2", "elasticsearch-cdm-3"},
+		},
+		{
+			desc: "only master nodes",
+			nodes: []NodeTypeInterface{
+				newUpgradeTestDeployment("elasticsearch-cdm-2", masterData),
+				newUpgradeTestDeployment("elasticsearch-cdm-1", masterData),
+			},
+			want: []string{"elasticsearch-cdm-2", "elasticsearch-cdm-1"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got := nodeNames(orderUpgradeNodes(test.nodes))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewUpgradeProgress(t *testing.T) {
+	nodes := orderUpgradeNodes([]NodeTypeInterface{
+		newUpgradeTestDeployment("elasticsearch-cdm-1", map[string]string{"es-node-master": "true", "es-node-data": "true"}),
+		newUpgradeTestDeployment("elasticsearch-cd-1", map[string]string{"es-node-master": "false", "es-node-data": "true"}),
+		newUpgradeTestStatefulSet("elasticsearch-m", map[string]string{"es-node-master": "true", "es-node-data": "false"}),
+	})
+
+	tests := []struct {
+		desc    string
+		pending []NodeTypeInterface
+		want    *api.ElasticsearchUpgradeProgress
+	}{
+		{
+			desc:    "data nodes left",
+			pending: nodes,
+			want: &api.ElasticsearchUpgradeProgress{
+				Stage:        api.UpgradeStageNonMasterNodes,
+				PendingNodes: []string{"elasticsearch-cd-1", "elasticsearch-cdm-1", "elasticsearch-m"},
+			},
+		},
+		{
+			desc:    "only master nodes left",
+			pending: nodes[1:],
+			want: &api.ElasticsearchUpgradeProgress{
+				Stage:        api.UpgradeStageMasterNodes,
+				PendingNodes: []string{"elasticsearch-cdm-1", "elasticsearch-m"},
+			},
+		},
+		{
+			desc:    "no nodes left",
+			pending: nodes[3:],
+			want:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got := newUpgradeProgress(test.pending)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeBatches(t *testing.T) {
+	master := map[string]string{"es-node-master": "true", "es-node-data": "true"}
+	data := map[string]string{"es-node-master": "false", "es-node-data": "true"}
+
+	nodes := orderUpgradeNodes([]NodeTypeInterface{
+		newUpgradeTestDeployment("elasticsearch-cdm-1", master),
+		newUpgradeTestDeployment("elasticsearch-cd-1", data),
+		newUpgradeTestDeployment("elasticsearch-cd-2", data),
+		newUpgradeTestDeployment("elasticsearch-cdm-2", master),
+		newUpgradeTestDeployment("elasticsearch-cd-3", data),
+		newUpgradeTestDeployment("elasticsearch-cd-4", data),
+		newUpgradeTestDeployment("elasticsearch-cd-5", data),
+	})
+
+	tests := []struct {
+		desc           string
+		maxUnavailable int
+		want           [][]string
+	}{
+		{
+			desc:           "one node at a time by default",
+			maxUnavailable: 1,
+			want: [][]string{
+				{"elasticsearch-cd-1"}, {"elasticsearch-cd-2"}, {"elasticsearch-cd-3"}, {"elasticsearch-cd-4"}, {"elasticsearch-cd-5"},
+				{"elasticsearch-cdm-1"}, {"elasticsearch-cdm-2"},
+			},
+		},
+		{
+			desc:           "data nodes in batches with a smaller last batch",
+			maxUnavailable: 2,
+			want: [][]string{
+				{"elasticsearch-cd-1", "elasticsearch-cd-2"}, {"elasticsearch-cd-3", "elasticsearch-cd-4"}, {"elasticsearch-cd-5"},
+				{"elasticsearch-cdm-1"}, {"elasticsearch-cdm-2"},
+			},
+		},
+		{
+			desc:           "all data nodes at once, masters still one at a time",
+			maxUnavailable: 10,
+			want: [][]string{
+				{"elasticsearch-cd-1", "elasticsearch-cd-2", "elasticsearch-cd-3", "elasticsearch-cd-4", "elasticsearch-cd-5"},
+				{"elasticsearch-cdm-1"}, {"elasticsearch-cdm-2"},
+			},
+		},
+		{
+			desc:           "invalid maximum falls back to one",
+			maxUnavailable: 0,
+			want: [][]string{
+				{"elasticsearch-cd-1"}, {"elasticsearch-cd-2"}, {"elasticsearch-cd-3"}, {"elasticsearch-cd-4"}, {"elasticsearch-cd-5"},
+				{"elasticsearch-cdm-1"}, {"elasticsearch-cdm-2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			got := [][]string{}
+			for _, batch := range upgradeBatches(nodes, test.maxUnavailable) {
+				got = append(got, nodeNames(batch))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMaxUnavailableNodes(t *testing.T) {
+	tests := []struct {
+		desc           string
+		maxUnavailable *int32
+		replicas       int32
+		want           int
+	}{
+		{
+			desc:     "defaults to one",
+			replicas: 2,
+			want:     1,
+		},
+		{
+			desc:           "below the replicas",
+			maxUnavailable: pointer.Int32(2),
+			replicas:       3,
+			want:           2,
+		},
+		{
+			desc:           "capped by the replicas",
+			maxUnavailable: pointer.Int32(3),
+			replicas:       1,
+			want:           1,
+		},
+		{
+			desc:           "one without replicas",
+			maxUnavailable: pointer.Int32(3),
+			replicas:       0,
+			want:           1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := &api.Elasticsearch{
+				Spec: api.ElasticsearchSpec{
+					MaxUnavailable:   test.maxUnavailable,
+					ReplicasPerIndex: pointer.Int32(test.replicas),
+				},
+			}
+			if got := maxUnavailableNodes(cluster); got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
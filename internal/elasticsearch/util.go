@@ -2,14 +2,41 @@ package elasticsearch
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/ViaQ/logerr/v2/kverrors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	api "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 )
 
+var (
+	// timeValueRegexp matches the elasticsearch time units
+	timeValueRegexp = regexp.MustCompile(`^[0-9]+(nanos|micros|ms|s|m|h|d)$`)
+	// clusterNameRegexp matches the cluster names elasticsearch accepts
+	clusterNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+	// nodeAttributeNameRegexp matches the node attribute names that map to env vars
+	nodeAttributeNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// diskWatermarkPercentRegexp matches the disk watermarks given as used disk
+	diskWatermarkPercentRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?)%$`)
+	// diskWatermarkBytesRegexp matches the disk watermarks given as free disk
+	diskWatermarkBytesRegexp = regexp.MustCompile(`^([0-9]+)(b|kb|mb|gb|tb|pb)$`)
+)
+
+// byteSizeUnits are the multipliers of the elasticsearch byte size units
+var byteSizeUnits = map[string]int64{
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+	"pb": 1 << 50,
+}
+
 const (
 	loglevelAnnotation          = "elasticsearch.openshift.io/loglevel"
 	serverLogAppenderAnnotation = "elasticsearch.openshift.io/develLogAppender"
@@ -52,6 +79,20 @@ func selectorForES(nodeRole string, clusterName string) map[string]string {
 	}
 }
 
+// selectorForRESTAPI selects the coordinating only nodes if the cluster has any
+// or else all client nodes
+func selectorForRESTAPI(dpl *api.Elasticsearch) map[string]string {
+	selector := selectorForES("es-node-client", dpl.Name)
+	for _, node := range dpl.Spec.Nodes {
+		if isCoordinatingNode(node) && node.NodeCount > 0 {
+			selector["es-node-master"] = "false"
+			selector["es-node-data"] = "false"
+			break
+		}
+	}
+	return selector
+}
+
 func appendDefaultLabel(clusterName string, labels map[string]string) map[string]string {
 	if _, ok := labels["cluster-name"]; ok {
 		return labels
@@ -75,12 +116,14 @@ func mergeSelectors(nodeSelectors, commonSelectors map[string]string) map[string
 	return commonSelectors
 }
 
+// appendTolerations returns a new slice with the common tolerations followed
+// by the node ones. A new slice is allocated so that appending node tolerations
+// never writes into the backing array of the common spec shared by all nodes.
 func appendTolerations(nodeTolerations, commonTolerations []v1.Toleration) []v1.Toleration {
-	if commonTolerations == nil {
-		commonTolerations = []v1.Toleration{}
-	}
+	tolerations := make([]v1.Toleration, 0, len(commonTolerations)+len(nodeTolerations))
+	tolerations = append(tolerations, commonTolerations...)
 
-	return append(commonTolerations, nodeTolerations...)
+	return append(tolerations, nodeTolerations...)
 }
 
 func getMasterCount(dpl *api.Elasticsearch) int32 {
@@ -104,6 +147,15 @@ func GetDataCount(dpl *api.Elasticsearch) int32 {
 	return dataCount
 }
 
+// getNodeCount returns the total number of nodes requested in the spec
+func getNodeCount(dpl *api.Elasticsearch) int32 {
+	nodeCount := int32(0)
+	for _, node := range dpl.Spec.Nodes {
+		nodeCount = nodeCount + node.NodeCount
+	}
+	return nodeCount
+}
+
 func isValidMasterCount(dpl *api.Elasticsearch) bool {
 	if len(dpl.Spec.Nodes) == 0 {
 		return true
@@ -181,13 +233,13 @@ func (er *ElasticsearchRequest) isValidConf() error {
 	dpl := er.cluster
 
 	if !isValidMasterCount(dpl) {
-		if err := updateConditionWithRetry(dpl, v1.ConditionTrue, updateInvalidMasterCountCondition, er.client); err != nil {
+		masterCount := getMasterCount(dpl)
+		if err := updateConditionWithRetry(dpl, v1.ConditionTrue, updateInvalidMasterCountCondition(masterCount), er.client); err != nil {
 			return err
 		}
-		return kverrors.New("invalid master nodes count. Please ensure the total nodes with master roles is less than the maximum",
-			"maximum", maxMasterCount)
+		return newInvalidMasterCountError(masterCount)
 	} else {
-		if err := updateConditionWithRetry(dpl, v1.ConditionFalse, updateInvalidMasterCountCondition, er.client); err != nil {
+		if err := updateConditionWithRetry(dpl, v1.ConditionFalse, updateInvalidMasterCountCondition(getMasterCount(dpl)), er.client); err != nil {
 			return kverrors.Wrap(err, "failed to set master count status")
 		}
 	}
@@ -196,7 +248,7 @@ func (er *ElasticsearchRequest) isValidConf() error {
 		if err := updateConditionWithRetry(dpl, v1.ConditionTrue, updateInvalidDataCountCondition, er.client); err != nil {
 			return kverrors.Wrap(err, "failed to set data count status")
 		}
-		return kverrors.New("no data nodes requested. Please ensure there is at least 1 node with data roles")
+		return newInvalidDataCountError()
 	} else {
 		if err := updateConditionWithRetry(dpl, v1.ConditionFalse, updateInvalidDataCountCondition, er.client); err != nil {
 			return kverrors.Wrap(err, "failed to set data count status")
@@ -207,8 +259,7 @@ func (er *ElasticsearchRequest) isValidConf() error {
 		if err := updateConditionWithRetry(dpl, v1.ConditionTrue, updateInvalidReplicationCondition, er.client); err != nil {
 			return kverrors.Wrap(err, "failed to set replication status")
 		}
-		return kverrors.New("wrong RedundancyPolicy selected. Choose different RedundancyPolicy or add more nodes with data roles",
-			"policy", dpl.Spec.RedundancyPolicy)
+		return newInvalidRedundancyPolicyError(dpl.Spec.RedundancyPolicy)
 	} else {
 		if err := updateConditionWithRetry(dpl, v1.ConditionFalse, updateInvalidReplicationCondition, er.client); err != nil {
 			return kverrors.Wrap(err, "failed to set replication status")
@@ -243,6 +294,578 @@ func (er *ElasticsearchRequest) isValidConf() error {
 		}
 	}
 
+	if err := validateSettings(dpl); err != nil {
+		if err := updateInvalidSettingsCondition(dpl, v1.ConditionTrue, err.Error(), er.client); err != nil {
+			return kverrors.Wrap(err, "failed to set settings status")
+		}
+		return kverrors.Wrap(err, "invalid elasticsearch settings")
+	} else {
+		if err := updateInvalidSettingsCondition(dpl, v1.ConditionFalse, "", er.client); err != nil {
+			return kverrors.Wrap(err, "failed to set settings status")
+		}
+	}
+
+	return nil
+}
+
+// ValidateSpec checks the spec of the cluster without looking at its current
+// state, returning the first error the reconciliation would report for it.
+func ValidateSpec(dpl *api.Elasticsearch) error {
+	if !isValidMasterCount(dpl) {
+		return newInvalidMasterCountError(getMasterCount(dpl))
+	}
+
+	if !isValidDataCount(dpl) {
+		return newInvalidDataCountError()
+	}
+
+	if !isValidRedundancyPolicy(dpl) {
+		return newInvalidRedundancyPolicyError(dpl.Spec.RedundancyPolicy)
+	}
+
+	return validateSettings(dpl)
+}
+
+// newInvalidMasterCountError returns the error of a cluster without master nodes,
+// where no master can be elected, or with more master nodes than the maximum
+func newInvalidMasterCountError(masterCount int32) error {
+	if masterCount == 0 {
+		return kverrors.New("no master nodes requested. Please ensure there is at least 1 node with master roles, so that a master can be elected")
+	}
+	return kverrors.New("invalid master nodes count. Please ensure the total nodes with master roles is less than the maximum",
+		"maximum", maxMasterCount,
+		"masterCount", masterCount)
+}
+
+func newInvalidDataCountError() error {
+	return kverrors.New("no data nodes requested. Please ensure there is at least 1 node with data roles")
+}
+
+func newInvalidRedundancyPolicyError(policy api.RedundancyPolicyType) error {
+	return kverrors.New("wrong RedundancyPolicy selected. Choose different RedundancyPolicy or add more nodes with data roles",
+		"policy", policy)
+}
+
+// validateSettings checks the node settings of the spec that cannot be
+// applied as requested
+func validateSettings(dpl *api.Elasticsearch) error {
+	if err := validateClusterName(dpl.Spec.ClusterName); err != nil {
+		return err
+	}
+
+	if err := validateImagePullPolicy(dpl.Spec.Spec.ImagePullPolicy); err != nil {
+		return err
+	}
+
+	if err := validatePorts(getPorts(dpl.Spec.Spec)); err != nil {
+		return err
+	}
+
+	if err := validateHeapDump(dpl.Spec.Spec.HeapDump); err != nil {
+		return err
+	}
+
+	if err := validateGCLogging(dpl.Spec.Spec); err != nil {
+		return err
+	}
+
+	if err := validateQueueSizes(dpl.Spec.Spec); err != nil {
+		return err
+	}
+
+	if err := validateAwarenessAttributes(dpl.Spec.Spec); err != nil {
+		return err
+	}
+
+	if err := validateRecovery(dpl.Spec.Spec.Recovery); err != nil {
+		return err
+	}
+
+	if err := validateShards(dpl); err != nil {
+		return err
+	}
+
+	if err := validateTransportTLS(dpl); err != nil {
+		return err
+	}
+
+	if err := validateRetention(dpl.Spec.Retention); err != nil {
+		return err
+	}
+
+	if err := validateDNS(dpl.Spec.Spec); err != nil {
+		return err
+	}
+
+	if err := validateExtraVolumes(dpl.Name, dpl.Spec.Spec); err != nil {
+		return err
+	}
+
+	if err := validateSidecars(dpl.Spec.Spec.Sidecars); err != nil {
+		return err
+	}
+
+	if err := validatePlugins(dpl.Spec.Spec.Plugins); err != nil {
+		return err
+	}
+
+	if err := validateCABundle(dpl.Spec.Spec.CABundle); err != nil {
+		return err
+	}
+
+	if err := validateDiskWatermarks(dpl.Spec.DiskWatermarks); err != nil {
+		return err
+	}
+
+	for i, node := range dpl.Spec.Nodes {
+		if err := validateResources(fmt.Sprintf("spec.nodes[%d]", i), node, dpl.Spec.Spec); err != nil {
+			return err
+		}
+		if err := validateHeapSize(node, dpl.Spec.Spec); err != nil {
+			return err
+		}
+		if err := validateStorage(node); err != nil {
+			return err
+		}
+		if err := validateDataVolumes(node); err != nil {
+			return err
+		}
+		if err := validateNodeAttributes(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePorts(ports elasticsearchPorts) error {
+	for _, port := range []int32{ports.HTTP, ports.Transport} {
+		if port < 1 || port > 65535 {
+			return kverrors.New("port is out of the valid range 1-65535", "port", port)
+		}
+		if port == 60000 || port == 60001 {
+			return kverrors.New("port is reserved for the proxy container", "port", port)
+		}
+	}
+
+	if ports.HTTP == ports.Transport {
+		return kverrors.New("http and transport ports must differ", "port", ports.HTTP)
+	}
+
+	return nil
+}
+
+func validateImagePullPolicy(policy v1.PullPolicy) error {
+	switch policy {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+		return nil
+	default:
+		return kverrors.New("unknown image pull policy. Please use one of Always, IfNotPresent or Never",
+			"imagePullPolicy", policy)
+	}
+}
+
+func validateHeapSize(node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) error {
+	heapSize := getHeapSize(node, commonSpec)
+	if heapSize == nil {
+		return nil
+	}
+
+	if heapSize.Value() < minHeapSize {
+		return kverrors.New("heap size must be at least 1Mi, since the JVM heap is set in whole MiB",
+			"heapSize", heapSize.String(),
+			"roles", node.Roles)
+	}
+
+	memoryLimit := newESNodeResourceRequirements(node, commonSpec).Limits.Memory()
+	if heapSize.Cmp(*memoryLimit) > 0 {
+		return kverrors.New("heap size exceeds the memory limit. Please lower the heap size or raise the memory limit",
+			"heapSize", heapSize.String(),
+			"memoryLimit", memoryLimit.String(),
+			"roles", node.Roles)
+	}
+
+	return nil
+}
+
+// validateResources rejects requests above the limits of the elasticsearch and proxy
+// containers, which would otherwise only surface as a failure to create the pods.
+func validateResources(nodeName string, node api.ElasticsearchNode, commonSpec api.ElasticsearchNodeSpec) error {
+	containers := map[string]v1.ResourceRequirements{
+		"elasticsearch": newESNodeResourceRequirements(node, commonSpec),
+		"proxy":         newESProxyResourceRequirements(node.ProxyResources, commonSpec.ProxyResources),
+	}
+
+	for _, container := range []string{"elasticsearch", "proxy"} {
+		resources := containers[container]
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			request, hasRequest := resources.Requests[name]
+			limit, hasLimit := resources.Limits[name]
+			if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+				return kverrors.New("resource request exceeds the limit. Please lower the request or raise the limit",
+					"node", nodeName,
+					"roles", node.Roles,
+					"container", container,
+					"resource", name,
+					"request", request.String(),
+					"limit", limit.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateHeapDump(heapDump *api.ElasticsearchHeapDumpSpec) error {
+	if heapDump == nil {
+		return nil
+	}
+
+	if heapDump.Path != "" && !path.IsAbs(heapDump.Path) {
+		return kverrors.New("heap dump path must be absolute", "path", heapDump.Path)
+	}
+
+	if heapDump.Storage != nil && heapDump.Storage.EmptyDir != nil && heapDump.Storage.Size != nil {
+		return kverrors.New("heap dump storage can either be an emptyDir or a persistent volume of a given size, not both")
+	}
+
+	return nil
+}
+
+func validateGCLogging(spec api.ElasticsearchNodeSpec) error {
+	storage := spec.GCLogStorage
+	if storage == nil {
+		return nil
+	}
+
+	if !spec.GCLogging {
+		return kverrors.New("gc log storage requires gc logging to be enabled")
+	}
+
+	if storage.EmptyDir != nil && storage.Size != nil {
+		return kverrors.New("gc log storage can either be an emptyDir or a persistent volume of a given size, not both")
+	}
+
+	return nil
+}
+
+func validateQueueSizes(spec api.ElasticsearchNodeSpec) error {
+	if spec.WriteQueueSize != nil && *spec.WriteQueueSize < 1 {
+		return kverrors.New("write queue size must be a positive integer", "writeQueueSize", *spec.WriteQueueSize)
+	}
+
+	if spec.SearchQueueSize != nil && *spec.SearchQueueSize < 1 {
+		return kverrors.New("search queue size must be a positive integer", "searchQueueSize", *spec.SearchQueueSize)
+	}
+
+	return nil
+}
+
+func validateRecovery(recovery *api.ElasticsearchRecoverySpec) error {
+	if recovery == nil {
+		return nil
+	}
+
+	if recovery.AfterTime != "" && !timeValueRegexp.MatchString(recovery.AfterTime) {
+		return kverrors.New("recovery afterTime must be a time value like 5m", "afterTime", recovery.AfterTime)
+	}
+
+	if recovery.ExpectedNodes != nil && *recovery.ExpectedNodes < 1 {
+		return kverrors.New("recovery expectedNodes must be at least 1", "expectedNodes", *recovery.ExpectedNodes)
+	}
+
+	return nil
+}
+
+// validateDiskWatermarks checks that the disk watermarks are all percentages
+// or all byte sizes, and that they are ordered from low to flood stage
+func validateDiskWatermarks(watermarks *api.ElasticsearchDiskWatermarksSpec) error {
+	if watermarks == nil {
+		return nil
+	}
+
+	names := []string{"low", "high", "floodStage"}
+	values := []string{watermarks.Low, watermarks.High, watermarks.FloodStage}
+
+	// unset percentages keep the elasticsearch defaults
+	percents := []float64{85, 90, 95}
+	freeBytes := make([]int64, len(values))
+	hasPercents, hasBytes := false, false
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		if match := diskWatermarkPercentRegexp.FindStringSubmatch(value); match != nil {
+			percents[i], _ = strconv.ParseFloat(match[1], 64)
+			hasPercents = true
+			continue
+		}
+		if match := diskWatermarkBytesRegexp.FindStringSubmatch(value); match != nil {
+			size, _ := strconv.ParseInt(match[1], 10, 64)
+			freeBytes[i] = size * byteSizeUnits[match[2]]
+			hasBytes = true
+			continue
+		}
+		return kverrors.New("disk watermarks must be percentages like 85% or byte sizes like 20gb",
+			"watermark", names[i],
+			"value", value)
+	}
+
+	switch {
+	case hasPercents && hasBytes:
+		return kverrors.New("disk watermarks must be either all percentages or all byte sizes")
+	case hasPercents:
+		if percents[2] > 100 || percents[0] >= percents[1] || percents[1] >= percents[2] {
+			return kverrors.New("disk watermarks must be ordered low < high < floodStage and at most 100%",
+				"low", percents[0],
+				"high", percents[1],
+				"floodStage", percents[2])
+		}
+	case hasBytes:
+		for i, value := range values {
+			if value == "" {
+				return kverrors.New("disk watermarks given as byte sizes must all be set", "watermark", names[i])
+			}
+		}
+		// byte sizes are the free disk left, so the higher watermarks leave less
+		if freeBytes[0] <= freeBytes[1] || freeBytes[1] <= freeBytes[2] {
+			return kverrors.New("disk watermarks given as free disk must leave less from low to high to floodStage",
+				"low", watermarks.Low,
+				"high", watermarks.High,
+				"floodStage", watermarks.FloodStage)
+		}
+	}
+
+	return nil
+}
+
+func validateShards(dpl *api.Elasticsearch) error {
+	if dpl.Spec.ShardsPerIndex != nil && *dpl.Spec.ShardsPerIndex < 1 {
+		return kverrors.New("shardsPerIndex must be at least 1", "shardsPerIndex", *dpl.Spec.ShardsPerIndex)
+	}
+
+	if dpl.Spec.ReplicasPerIndex != nil {
+		replicas := *dpl.Spec.ReplicasPerIndex
+		dataCount := GetDataCount(dpl)
+		if replicas < 0 || replicas >= dataCount {
+			return kverrors.New("replicasPerIndex must be less than the number of data nodes",
+				"replicasPerIndex", replicas,
+				"dataNodes", dataCount)
+		}
+	}
+
+	return nil
+}
+
+func validateTransportTLS(dpl *api.Elasticsearch) error {
+	if isTransportHostnameVerificationEnabled(dpl.Spec.Spec) && dpl.Spec.CertificateSecret == nil {
+		return kverrors.New("transport hostname verification requires node certificates with the pod IPs provided in spec.certificateSecret")
+	}
+
+	return nil
+}
+
+func validateRetention(policies []api.ElasticsearchRetentionPolicy) error {
+	for i, policy := range policies {
+		if policy.MaxAgeDays < 1 {
+			return kverrors.New("retention maxAgeDays must be at least 1",
+				"retention", fmt.Sprintf("spec.retention[%d]", i),
+				"maxAgeDays", policy.MaxAgeDays)
+		}
+		if _, err := path.Match(policy.IndexPattern, ""); err != nil || policy.IndexPattern == "" || strings.ContainsAny(policy.IndexPattern, ",/") {
+			return kverrors.New("retention indexPattern must be a single index name pattern",
+				"retention", fmt.Sprintf("spec.retention[%d]", i),
+				"indexPattern", policy.IndexPattern)
+		}
+	}
+
+	return nil
+}
+
+// validateDNS rejects the DNS policy None without nameservers, which leaves the
+// pods without name resolution
+func validateDNS(spec api.ElasticsearchNodeSpec) error {
+	if spec.DNSPolicy != v1.DNSNone {
+		return nil
+	}
+	if spec.DNSConfig == nil || len(spec.DNSConfig.Nameservers) == 0 {
+		return kverrors.New("dnsPolicy None requires nameservers in dnsConfig",
+			"dnsPolicy", spec.DNSPolicy)
+	}
+
+	return nil
+}
+
+// validateExtraVolumes rejects extra volumes named like the ones of the operator, relative
+// host paths, extra mounts at the paths of the operator and mounts of volumes that are not
+// extra volumes
+func validateExtraVolumes(clusterName string, spec api.ElasticsearchNodeSpec) error {
+	reserved := sets.NewString(reservedVolumeNames(clusterName)...)
+	names := sets.NewString()
+	for i, volume := range spec.ExtraVolumes {
+		if reserved.Has(volume.Name) {
+			return kverrors.New("extra volume name is reserved by the operator",
+				"volume", fmt.Sprintf("spec.nodeSpec.extraVolumes[%d]", i),
+				"name", volume.Name)
+		}
+		if names.Has(volume.Name) {
+			return kverrors.New("extra volume names must be unique",
+				"volume", fmt.Sprintf("spec.nodeSpec.extraVolumes[%d]", i),
+				"name", volume.Name)
+		}
+		if volume.HostPath != nil && !path.IsAbs(volume.HostPath.Path) {
+			return kverrors.New("extra host path volumes must have an absolute path",
+				"volume", fmt.Sprintf("spec.nodeSpec.extraVolumes[%d]", i),
+				"path", volume.HostPath.Path)
+		}
+		names.Insert(volume.Name)
+	}
+
+	reservedPaths := sets.NewString(reservedMountPaths()...)
+	for i, mount := range spec.ExtraVolumeMounts {
+		if !names.Has(mount.Name) {
+			return kverrors.New("extra volume mounts must refer to an extra volume",
+				"volumeMount", fmt.Sprintf("spec.nodeSpec.extraVolumeMounts[%d]", i),
+				"name", mount.Name)
+		}
+		if reservedPaths.Has(path.Clean(mount.MountPath)) {
+			return kverrors.New("extra volume mount path is reserved by the operator",
+				"volumeMount", fmt.Sprintf("spec.nodeSpec.extraVolumeMounts[%d]", i),
+				"mountPath", mount.MountPath)
+		}
+	}
+
+	return nil
+}
+
+// validateSidecars rejects sidecars named like the containers of the operator
+// and sidecars without a unique name
+func validateSidecars(sidecars []v1.Container) error {
+	names := sets.NewString("elasticsearch", "proxy", "exporter")
+	for i, sidecar := range sidecars {
+		if sidecar.Name == "" || names.Has(sidecar.Name) {
+			return kverrors.New("sidecar names must be unique and not reserved by the operator",
+				"sidecar", fmt.Sprintf("spec.nodeSpec.sidecars[%d]", i),
+				"name", sidecar.Name)
+		}
+		names.Insert(sidecar.Name)
+	}
+
+	return nil
+}
+
+// validatePlugins rejects empty plugins and plugins with whitespace, which
+// cannot be passed to the init container as a list
+func validatePlugins(plugins []string) error {
+	for i, plugin := range plugins {
+		if plugin == "" || strings.ContainsAny(plugin, " \t\n") {
+			return kverrors.New("plugins must not be empty or contain whitespace",
+				"plugin", fmt.Sprintf("spec.nodeSpec.plugins[%d]", i))
+		}
+	}
+
+	return nil
+}
+
+// validateCABundle requires the CA bundle to reference either a config map or a secret
+func validateCABundle(caBundle *api.ElasticsearchCABundleSpec) error {
+	if caBundle == nil {
+		return nil
+	}
+
+	if (caBundle.ConfigMapName == "") == (caBundle.SecretName == "") {
+		return kverrors.New("ca bundle requires exactly one of configMapName and secretName",
+			"configMapName", caBundle.ConfigMapName,
+			"secretName", caBundle.SecretName)
+	}
+
+	return nil
+}
+
+// validateClusterName rejects cluster names elasticsearch does not accept or that
+// cannot be used to address the cluster, e.g. names with colons or whitespace
+func validateClusterName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if len(name) > 255 || !clusterNameRegexp.MatchString(name) {
+		return kverrors.New("clusterName must start with a letter or digit and only contain letters, digits, '.', '_' and '-' up to 255 characters",
+			"clusterName", name)
+	}
+
+	return nil
+}
+
+func validateStorage(node api.ElasticsearchNode) error {
+	if node.Storage.EmptyDir != nil && node.Storage.Size != nil {
+		return kverrors.New("storage can either be an emptyDir or a persistent volume of a given size, not both",
+			"roles", node.Roles)
+	}
+
+	return nil
+}
+
+// validateDataVolumes checks that only data nodes have additional data volumes
+// and that their names are unique
+func validateDataVolumes(node api.ElasticsearchNode) error {
+	if len(node.DataVolumes) == 0 {
+		return nil
+	}
+	if !isDataNode(node) {
+		return kverrors.New("dataVolumes can only be added to nodes with the data role",
+			"roles", node.Roles)
+	}
+
+	names := map[string]bool{}
+	for _, dataVolume := range node.DataVolumes {
+		if names[dataVolume.Name] {
+			return kverrors.New("dataVolumes must have unique names",
+				"name", dataVolume.Name)
+		}
+		names[dataVolume.Name] = true
+
+		if dataVolume.Storage.EmptyDir != nil && dataVolume.Storage.Size != nil {
+			return kverrors.New("data volume storage can either be an emptyDir or a persistent volume of a given size, not both",
+				"name", dataVolume.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateNodeAttributes rejects node attributes whose name cannot be passed as
+// env var or whose value elasticsearch does not accept
+func validateNodeAttributes(node api.ElasticsearchNode) error {
+	for name, value := range node.NodeAttributes {
+		if !nodeAttributeNameRegexp.MatchString(name) {
+			return kverrors.New("node attribute names must start with a lowercase letter and only contain lowercase letters, digits and '_'",
+				"name", name)
+		}
+		if value == "" || strings.TrimSpace(value) != value {
+			return kverrors.New("node attribute values must not be empty or start or end with whitespace",
+				"name", name)
+		}
+	}
+
+	return nil
+}
+
+// validateAwarenessAttributes rejects invalid or duplicate allocation awareness
+// attributes
+func validateAwarenessAttributes(spec api.ElasticsearchNodeSpec) error {
+	seen := map[string]bool{}
+	for _, name := range spec.AllocationAwarenessAttributes {
+		if !nodeAttributeNameRegexp.MatchString(name) {
+			return kverrors.New("allocation awareness attributes must be valid node attribute names",
+				"name", name)
+		}
+		if seen[name] {
+			return kverrors.New("allocation awareness attributes must be unique",
+				"name", name)
+		}
+		seen[name] = true
+	}
+
 	return nil
 }
 
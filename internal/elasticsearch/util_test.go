@@ -1,8 +1,10 @@
 package elasticsearch
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/openshift/elasticsearch-operator/internal/utils/comparators"
 	"github.com/openshift/elasticsearch-operator/test/helpers"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -341,6 +344,85 @@ func TestSingleNodeNoReplicaCount(t *testing.T) {
 	}
 }
 
+func TestShardsPerIndex(t *testing.T) {
+	one := int32(1)
+	two := int32(2)
+	three := int32(3)
+
+	tests := []struct {
+		desc         string
+		dataNodes    int32
+		shards       *int32
+		replicas     *int32
+		wantShards   int
+		wantReplicas int
+	}{
+		{desc: "single node defaults", dataNodes: 1, wantShards: 1, wantReplicas: 0},
+		{desc: "multi node defaults", dataNodes: 3, wantShards: 3, wantReplicas: 1},
+		{desc: "single node overrides", dataNodes: 1, shards: &two, replicas: new(int32), wantShards: 2, wantReplicas: 0},
+		{desc: "multi node overrides", dataNodes: 3, shards: &one, replicas: &two, wantShards: 1, wantReplicas: 2},
+		{desc: "shards only", dataNodes: 7, shards: &three, wantShards: 3, wantReplicas: 1},
+	}
+
+	for _, test := range tests {
+		esCR := &api.Elasticsearch{
+			Spec: api.ElasticsearchSpec{
+				ShardsPerIndex:   test.shards,
+				ReplicasPerIndex: test.replicas,
+				Nodes: []api.ElasticsearchNode{
+					{Roles: []api.ElasticsearchNodeRole{"data"}, NodeCount: test.dataNodes},
+				},
+			},
+		}
+
+		if got := CalculatePrimaryCount(esCR); got != test.wantShards {
+			t.Errorf("%s: expected %d primary shards, got %d", test.desc, test.wantShards, got)
+		}
+		if got := CalculateReplicaCount(esCR); got != test.wantReplicas {
+			t.Errorf("%s: expected %d replica shards, got %d", test.desc, test.wantReplicas, got)
+		}
+	}
+}
+
+func TestValidateShards(t *testing.T) {
+	zero := int32(0)
+	two := int32(2)
+	three := int32(3)
+
+	tests := []struct {
+		desc     string
+		shards   *int32
+		replicas *int32
+		valid    bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "less replicas than data nodes", shards: &two, replicas: &two, valid: true},
+		{desc: "no replica", replicas: &zero, valid: true},
+		{desc: "as many replicas as data nodes", replicas: &three},
+		{desc: "no shard", shards: &zero},
+	}
+
+	for _, test := range tests {
+		esCR := &api.Elasticsearch{
+			Spec: api.ElasticsearchSpec{
+				ShardsPerIndex:   test.shards,
+				ReplicasPerIndex: test.replicas,
+				Nodes: []api.ElasticsearchNode{
+					{Roles: []api.ElasticsearchNodeRole{"data"}, NodeCount: 3},
+				},
+			},
+		}
+
+		err := validateShards(esCR)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected shard settings to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected shard settings to be rejected", test.desc)
+		}
+	}
+}
+
 func TestNoTolerations(t *testing.T) {
 	commonTolerations := []v1.Toleration{}
 
@@ -924,3 +1006,761 @@ func TestFullRedundancyScaleDownInvalid(t *testing.T) {
 		t.Errorf("Expected to be invalid scale down case")
 	}
 }
+
+func TestValidateImagePullPolicy(t *testing.T) {
+	for _, policy := range []v1.PullPolicy{"", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever} {
+		if err := validateImagePullPolicy(policy); err != nil {
+			t.Errorf("Expected image pull policy %q to be valid, got %v", policy, err)
+		}
+	}
+
+	if err := validateImagePullPolicy("Sometimes"); err == nil {
+		t.Error("Expected image pull policy \"Sometimes\" to be rejected")
+	}
+}
+
+func TestValidateHeapSize(t *testing.T) {
+	commonSpec := api.ElasticsearchNodeSpec{
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+			},
+		},
+	}
+
+	heapSize := resource.MustParse("8Gi")
+	node := api.ElasticsearchNode{HeapSize: &heapSize}
+	if err := validateHeapSize(node, commonSpec); err != nil {
+		t.Errorf("Expected 8Gi heap with 16Gi memory limit to be valid, got %v", err)
+	}
+
+	heapSize = resource.MustParse("32Gi")
+	node = api.ElasticsearchNode{HeapSize: &heapSize}
+	if err := validateHeapSize(node, commonSpec); err == nil {
+		t.Error("Expected 32Gi heap with 16Gi memory limit to be rejected")
+	}
+
+	if err := validateHeapSize(api.ElasticsearchNode{}, commonSpec); err != nil {
+		t.Errorf("Expected unset heap size to be valid, got %v", err)
+	}
+
+	for _, size := range []string{"0", "512Ki", "-1Gi"} {
+		heapSize = resource.MustParse(size)
+		node = api.ElasticsearchNode{HeapSize: &heapSize}
+		if err := validateHeapSize(node, commonSpec); err == nil {
+			t.Errorf("Expected %s heap to be rejected", size)
+		}
+	}
+
+	heapSize = resource.MustParse("1Mi")
+	node = api.ElasticsearchNode{HeapSize: &heapSize}
+	if err := validateHeapSize(node, commonSpec); err != nil {
+		t.Errorf("Expected 1Mi heap to be valid, got %v", err)
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		desc       string
+		node       api.ElasticsearchNode
+		commonSpec api.ElasticsearchNodeSpec
+		valid      bool
+	}{
+		{desc: "defaults", valid: true},
+		{
+			desc:  "requests equal to limits",
+			node:  api.ElasticsearchNode{Resources: buildResource(nodeCPUValue, nodeCPUValue, nodeMemValue, nodeMemValue)},
+			valid: true,
+		},
+		{
+			desc:  "requests below limits",
+			node:  api.ElasticsearchNode{Resources: buildResource(nodeCPUValue, commonCPUValue, nodeMemValue, commonMemValue)},
+			valid: true,
+		},
+		{
+			desc: "memory request above limit",
+			node: api.ElasticsearchNode{Resources: buildResource(nodeCPUValue, nodeCPUValue, commonMemValue, nodeMemValue)},
+		},
+		{
+			desc: "cpu request above limit",
+			node: api.ElasticsearchNode{Resources: buildResource(commonCPUValue, nodeCPUValue, nodeMemValue, nodeMemValue)},
+		},
+		{
+			desc: "node request above common limit",
+			node: api.ElasticsearchNode{Resources: buildResourceOnlyRequests(commonCPUValue, resource.MustParse("8Gi"))},
+			commonSpec: api.ElasticsearchNodeSpec{
+				Resources: buildResourceOnlyLimits(commonCPUValue, resource.MustParse("4Gi")),
+			},
+		},
+		{
+			desc: "proxy memory request above limit",
+			node: api.ElasticsearchNode{ProxyResources: buildResource(nodeCPUValue, nodeCPUValue, commonMemValue, nodeMemValue)},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateResources("spec.nodes[0]", test.node, test.commonSpec)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected resources to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected resources to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	size := resource.MustParse("2Gi")
+
+	node := api.ElasticsearchNode{
+		Storage: api.ElasticsearchStorageSpec{
+			EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory, SizeLimit: &size},
+		},
+	}
+	if err := validateStorage(node); err != nil {
+		t.Errorf("Expected emptyDir storage to be valid, got %v", err)
+	}
+
+	node.Storage.Size = &size
+	if err := validateStorage(node); err == nil {
+		t.Error("Expected emptyDir storage with a size to be rejected")
+	}
+}
+
+func TestValidateHeapDump(t *testing.T) {
+	size := resource.MustParse("10Gi")
+
+	tests := []struct {
+		desc     string
+		heapDump *api.ElasticsearchHeapDumpSpec
+		valid    bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "absolute path", heapDump: &api.ElasticsearchHeapDumpSpec{Path: "/tmp/dump.hprof"}, valid: true},
+		{desc: "relative path", heapDump: &api.ElasticsearchHeapDumpSpec{Path: "dump.hprof"}},
+		{
+			desc: "emptyDir and size",
+			heapDump: &api.ElasticsearchHeapDumpSpec{
+				Storage: &api.ElasticsearchStorageSpec{EmptyDir: &v1.EmptyDirVolumeSource{}, Size: &size},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateHeapDump(test.heapDump)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected heap dump to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected heap dump to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateGCLogging(t *testing.T) {
+	size := resource.MustParse("1Gi")
+
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		valid bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "enabled", spec: api.ElasticsearchNodeSpec{GCLogging: true}, valid: true},
+		{desc: "storage", spec: api.ElasticsearchNodeSpec{GCLogging: true, GCLogStorage: &api.ElasticsearchStorageSpec{Size: &size}}, valid: true},
+		{desc: "storage without gc logging", spec: api.ElasticsearchNodeSpec{GCLogStorage: &api.ElasticsearchStorageSpec{}}},
+		{
+			desc: "emptyDir and size",
+			spec: api.ElasticsearchNodeSpec{
+				GCLogging:    true,
+				GCLogStorage: &api.ElasticsearchStorageSpec{EmptyDir: &v1.EmptyDirVolumeSource{}, Size: &size},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateGCLogging(test.spec)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected gc logging to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected gc logging to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateQueueSizes(t *testing.T) {
+	zero := int32(0)
+	thousand := int32(1000)
+
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		valid bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "positive", spec: api.ElasticsearchNodeSpec{WriteQueueSize: &thousand, SearchQueueSize: &thousand}, valid: true},
+		{desc: "zero write queue size", spec: api.ElasticsearchNodeSpec{WriteQueueSize: &zero}},
+		{desc: "zero search queue size", spec: api.ElasticsearchNodeSpec{SearchQueueSize: &zero}},
+	}
+
+	for _, test := range tests {
+		err := validateQueueSizes(test.spec)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected queue sizes to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected queue sizes to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateDataVolumes(t *testing.T) {
+	size := resource.MustParse("1Gi")
+	dataRoles := []api.ElasticsearchNodeRole{api.ElasticsearchRoleData}
+
+	tests := []struct {
+		desc  string
+		node  api.ElasticsearchNode
+		valid bool
+	}{
+		{desc: "unset", valid: true},
+		{
+			desc: "data node",
+			node: api.ElasticsearchNode{
+				Roles:       dataRoles,
+				DataVolumes: []api.ElasticsearchDataVolume{{Name: "a", Storage: api.ElasticsearchStorageSpec{Size: &size}}, {Name: "b"}},
+			},
+			valid: true,
+		},
+		{
+			desc: "master node",
+			node: api.ElasticsearchNode{
+				Roles:       []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster},
+				DataVolumes: []api.ElasticsearchDataVolume{{Name: "a"}},
+			},
+		},
+		{
+			desc: "duplicate names",
+			node: api.ElasticsearchNode{
+				Roles:       dataRoles,
+				DataVolumes: []api.ElasticsearchDataVolume{{Name: "a"}, {Name: "a"}},
+			},
+		},
+		{
+			desc: "emptyDir and size",
+			node: api.ElasticsearchNode{
+				Roles:       dataRoles,
+				DataVolumes: []api.ElasticsearchDataVolume{{Name: "a", Storage: api.ElasticsearchStorageSpec{EmptyDir: &v1.EmptyDirVolumeSource{}, Size: &size}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateDataVolumes(test.node)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected data volumes to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected data volumes to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateNodeAttributes(t *testing.T) {
+	tests := []struct {
+		desc       string
+		attributes map[string]string
+		valid      bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "valid", attributes: map[string]string{"box_type": "hot", "rack2": "r1"}, valid: true},
+		{desc: "uppercase name", attributes: map[string]string{"BoxType": "hot"}},
+		{desc: "dotted name", attributes: map[string]string{"box.type": "hot"}},
+		{desc: "empty value", attributes: map[string]string{"box_type": ""}},
+		{desc: "value with whitespace", attributes: map[string]string{"box_type": " hot"}},
+	}
+
+	for _, test := range tests {
+		err := validateNodeAttributes(api.ElasticsearchNode{NodeAttributes: test.attributes})
+		if test.valid && err != nil {
+			t.Errorf("%s: expected node attributes to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected node attributes to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateAwarenessAttributes(t *testing.T) {
+	tests := []struct {
+		desc       string
+		attributes []string
+		valid      bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "valid", attributes: []string{"zone", "box_type"}, valid: true},
+		{desc: "invalid name", attributes: []string{"box-type"}},
+		{desc: "duplicate", attributes: []string{"zone", "zone"}},
+	}
+
+	for _, test := range tests {
+		err := validateAwarenessAttributes(api.ElasticsearchNodeSpec{AllocationAwarenessAttributes: test.attributes})
+		if test.valid && err != nil {
+			t.Errorf("%s: expected awareness attributes to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected awareness attributes to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateRecovery(t *testing.T) {
+	zero := int32(0)
+	three := int32(3)
+
+	tests := []struct {
+		desc     string
+		recovery *api.ElasticsearchRecoverySpec
+		valid    bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "minutes", recovery: &api.ElasticsearchRecoverySpec{AfterTime: "10m", ExpectedNodes: &three}, valid: true},
+		{desc: "milliseconds", recovery: &api.ElasticsearchRecoverySpec{AfterTime: "500ms"}, valid: true},
+		{desc: "missing unit", recovery: &api.ElasticsearchRecoverySpec{AfterTime: "10"}},
+		{desc: "unknown unit", recovery: &api.ElasticsearchRecoverySpec{AfterTime: "1w"}},
+		{desc: "go duration", recovery: &api.ElasticsearchRecoverySpec{AfterTime: "1h30m"}},
+		{desc: "no expected nodes", recovery: &api.ElasticsearchRecoverySpec{ExpectedNodes: &zero}},
+	}
+
+	for _, test := range tests {
+		err := validateRecovery(test.recovery)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected recovery settings to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected recovery settings to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateDiskWatermarks(t *testing.T) {
+	tests := []struct {
+		desc       string
+		watermarks *api.ElasticsearchDiskWatermarksSpec
+		valid      bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "percentages", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "70%", High: "80%", FloodStage: "90%"}, valid: true},
+		{desc: "low only", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "60.5%"}, valid: true},
+		{desc: "low above default high", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "92%"}},
+		{desc: "high below low", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "80%", High: "70%", FloodStage: "90%"}},
+		{desc: "high equal to flood stage", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "80%", High: "90%", FloodStage: "90%"}},
+		{desc: "above 100%", watermarks: &api.ElasticsearchDiskWatermarksSpec{FloodStage: "101%"}},
+		{desc: "free disk", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "10gb", High: "5gb", FloodStage: "1024mb"}, valid: true},
+		{desc: "free disk increasing", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "1gb", High: "5gb", FloodStage: "10gb"}},
+		{desc: "free disk partially set", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "10gb", High: "5gb"}},
+		{desc: "mixed", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "85%", High: "5gb", FloodStage: "1gb"}},
+		{desc: "malformed", watermarks: &api.ElasticsearchDiskWatermarksSpec{Low: "0.85"}},
+	}
+
+	for _, test := range tests {
+		err := validateDiskWatermarks(test.watermarks)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected disk watermarks to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected disk watermarks to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateTransportTLS(t *testing.T) {
+	enforced := &api.ElasticsearchTransportTLSSpec{EnforceHostnameVerification: true}
+
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchSpec
+		valid bool
+	}{
+		{desc: "unset", valid: true},
+		{desc: "generated certificates", spec: api.ElasticsearchSpec{Spec: api.ElasticsearchNodeSpec{TransportTLS: enforced}}},
+		{
+			desc: "user certificates",
+			spec: api.ElasticsearchSpec{
+				Spec:              api.ElasticsearchNodeSpec{TransportTLS: enforced},
+				CertificateSecret: &v1.LocalObjectReference{Name: "my-certs"},
+			},
+			valid: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := validateTransportTLS(&api.Elasticsearch{Spec: test.spec})
+		if test.valid && err != nil {
+			t.Errorf("%s: expected transport TLS settings to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected transport TLS settings to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateRetention(t *testing.T) {
+	tests := []struct {
+		desc   string
+		policy api.ElasticsearchRetentionPolicy
+		valid  bool
+	}{
+		{desc: "pattern", policy: api.ElasticsearchRetentionPolicy{IndexPattern: "app-*", MaxAgeDays: 7}, valid: true},
+		{desc: "no age", policy: api.ElasticsearchRetentionPolicy{IndexPattern: "app-*"}},
+		{desc: "no pattern", policy: api.ElasticsearchRetentionPolicy{MaxAgeDays: 7}},
+		{desc: "list of patterns", policy: api.ElasticsearchRetentionPolicy{IndexPattern: "app-*,infra-*", MaxAgeDays: 7}},
+		{desc: "malformed pattern", policy: api.ElasticsearchRetentionPolicy{IndexPattern: "app-[", MaxAgeDays: 7}},
+	}
+
+	for _, test := range tests {
+		err := validateRetention([]api.ElasticsearchRetentionPolicy{test.policy})
+		if test.valid && err != nil {
+			t.Errorf("%s: expected retention policy to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected retention policy to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateExtraVolumes(t *testing.T) {
+	secretVolume := func(name string) v1.Volume {
+		return v1.Volume{Name: name, VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: name}}}
+	}
+
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		valid bool
+	}{
+		{desc: "default", valid: true},
+		{
+			desc: "extra volume",
+			spec: api.ElasticsearchNodeSpec{
+				ExtraVolumes:      []v1.Volume{secretVolume("keystore")},
+				ExtraVolumeMounts: []v1.VolumeMount{{Name: "keystore", MountPath: "/etc/elasticsearch/keystore"}},
+			},
+			valid: true,
+		},
+		{desc: "data volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("elasticsearch-storage")}}},
+		{desc: "config volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("elasticsearch-config")}}},
+		{desc: "certificates volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("certificates")}}},
+		{desc: "keystore volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("elasticsearch-keystore")}}},
+		{desc: "metrics volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("elasticsearch-metrics")}}},
+		{desc: "shared logs volume name", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("elasticsearch-shared-logs")}}},
+		{
+			desc:  "absolute host path",
+			spec:  api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{{Name: "backup", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/mnt/backup"}}}}},
+			valid: true,
+		},
+		{desc: "relative host path", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{{Name: "backup", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "mnt/backup"}}}}}},
+		{desc: "duplicate names", spec: api.ElasticsearchNodeSpec{ExtraVolumes: []v1.Volume{secretVolume("keystore"), secretVolume("keystore")}}},
+		{
+			desc: "mount of an operator volume",
+			spec: api.ElasticsearchNodeSpec{
+				ExtraVolumeMounts: []v1.VolumeMount{{Name: "certificates", MountPath: "/etc/certs"}},
+			},
+		},
+		{
+			desc: "mount at the data path",
+			spec: api.ElasticsearchNodeSpec{
+				ExtraVolumes:      []v1.Volume{secretVolume("backup")},
+				ExtraVolumeMounts: []v1.VolumeMount{{Name: "backup", MountPath: "/elasticsearch/persistent/"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateExtraVolumes("elasticsearch", test.spec)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected extra volumes to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected extra volumes to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateSidecars(t *testing.T) {
+	tests := []struct {
+		desc     string
+		sidecars []v1.Container
+		valid    bool
+	}{
+		{desc: "none", valid: true},
+		{desc: "log shipper", sidecars: []v1.Container{{Name: "log-shipper"}}, valid: true},
+		{desc: "unnamed", sidecars: []v1.Container{{Image: "fluent/fluent-bit"}}},
+		{desc: "proxy name", sidecars: []v1.Container{{Name: "proxy"}}},
+		{desc: "duplicate names", sidecars: []v1.Container{{Name: "log-shipper"}, {Name: "log-shipper"}}},
+	}
+
+	for _, test := range tests {
+		err := validateSidecars(test.sidecars)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected sidecars to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected sidecars to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidatePlugins(t *testing.T) {
+	tests := []struct {
+		desc    string
+		plugins []string
+		valid   bool
+	}{
+		{desc: "none", valid: true},
+		{desc: "names and urls", plugins: []string{"analysis-icu", "https://mirror.example.com/repository-s3.zip"}, valid: true},
+		{desc: "empty", plugins: []string{""}},
+		{desc: "whitespace", plugins: []string{"analysis-icu repository-s3"}},
+	}
+
+	for _, test := range tests {
+		err := validatePlugins(test.plugins)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected plugins to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected plugins to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateCABundle(t *testing.T) {
+	tests := []struct {
+		desc     string
+		caBundle *api.ElasticsearchCABundleSpec
+		valid    bool
+	}{
+		{desc: "none", valid: true},
+		{desc: "config map", caBundle: &api.ElasticsearchCABundleSpec{ConfigMapName: "private-ca"}, valid: true},
+		{desc: "secret", caBundle: &api.ElasticsearchCABundleSpec{SecretName: "private-ca", Key: "ca.crt"}, valid: true},
+		{desc: "no source", caBundle: &api.ElasticsearchCABundleSpec{Key: "ca.crt"}},
+		{desc: "both sources", caBundle: &api.ElasticsearchCABundleSpec{ConfigMapName: "private-ca", SecretName: "private-ca"}},
+	}
+
+	for _, test := range tests {
+		err := validateCABundle(test.caBundle)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected ca bundle to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected ca bundle to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateDNS(t *testing.T) {
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		valid bool
+	}{
+		{desc: "default", valid: true},
+		{desc: "custom config", spec: api.ElasticsearchNodeSpec{DNSConfig: &v1.PodDNSConfig{Searches: []string{"example.com"}}}, valid: true},
+		{desc: "none with nameservers", spec: api.ElasticsearchNodeSpec{DNSPolicy: v1.DNSNone, DNSConfig: &v1.PodDNSConfig{Nameservers: []string{"10.0.0.2"}}}, valid: true},
+		{desc: "none without config", spec: api.ElasticsearchNodeSpec{DNSPolicy: v1.DNSNone}},
+		{desc: "none without nameservers", spec: api.ElasticsearchNodeSpec{DNSPolicy: v1.DNSNone, DNSConfig: &v1.PodDNSConfig{}}},
+	}
+
+	for _, test := range tests {
+		err := validateDNS(test.spec)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected dns settings to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected dns settings to be rejected", test.desc)
+		}
+	}
+}
+
+func TestValidateClusterName(t *testing.T) {
+	tests := []struct {
+		desc  string
+		name  string
+		valid bool
+	}{
+		{desc: "default", valid: true},
+		{desc: "custom name", name: "es-prod_1.eu", valid: true},
+		{desc: "colon", name: "remote:es"},
+		{desc: "whitespace", name: "es prod"},
+		{desc: "leading dash", name: "-es"},
+		{desc: "too long", name: strings.Repeat("a", 256)},
+	}
+
+	for _, test := range tests {
+		err := validateClusterName(test.name)
+		if test.valid && err != nil {
+			t.Errorf("%s: expected cluster name to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected cluster name to be rejected", test.desc)
+		}
+	}
+}
+
+func TestClusterNameSetting(t *testing.T) {
+	cluster := &api.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch"}}
+	if got := clusterNameSetting(cluster); got != "" {
+		t.Errorf("Exp. the cluster name to default to the resource name but got %q", got)
+	}
+
+	cluster.Spec.ClusterName = "elasticsearch"
+	if got := clusterNameSetting(cluster); got != "" {
+		t.Errorf("Exp. no override for the resource name but got %q", got)
+	}
+
+	cluster.Spec.ClusterName = "es-prod"
+	if got := clusterNameSetting(cluster); got != "es-prod" {
+		t.Errorf("Exp. the cluster name to be overridden with es-prod but got %q", got)
+	}
+}
+
+func TestValidatePorts(t *testing.T) {
+	tests := []struct {
+		desc  string
+		spec  api.ElasticsearchNodeSpec
+		valid bool
+	}{
+		{desc: "defaults", valid: true},
+		{desc: "custom ports", spec: api.ElasticsearchNodeSpec{HTTPPort: 9201, TransportPort: 9301}, valid: true},
+		{desc: "same ports", spec: api.ElasticsearchNodeSpec{HTTPPort: 9300}},
+		{desc: "out of range", spec: api.ElasticsearchNodeSpec{TransportPort: 70000}},
+		{desc: "negative", spec: api.ElasticsearchNodeSpec{HTTPPort: -1}},
+		{desc: "proxy port", spec: api.ElasticsearchNodeSpec{HTTPPort: 60000}},
+	}
+
+	for _, test := range tests {
+		err := validatePorts(getPorts(test.spec))
+		if test.valid && err != nil {
+			t.Errorf("%s: Expected ports to be valid, got %v", test.desc, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: Expected ports to be rejected", test.desc)
+		}
+	}
+}
+
+func TestSelectorForRESTAPI(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch"},
+		Spec: api.ElasticsearchSpec{
+			Nodes: []api.ElasticsearchNode{
+				{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData, api.ElasticsearchRoleMaster}, NodeCount: 3},
+			},
+		},
+	}
+
+	expected := map[string]string{"cluster-name": "elasticsearch", "es-node-client": "true"}
+	if diff := cmp.Diff(selectorForRESTAPI(cluster), expected); diff != "" {
+		t.Errorf("Exp. all client nodes to be selected: %s", diff)
+	}
+
+	cluster.Spec.Nodes = append(cluster.Spec.Nodes, api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient},
+		NodeCount: 2,
+	})
+
+	expected = map[string]string{"cluster-name": "elasticsearch", "es-node-client": "true", "es-node-data": "false", "es-node-master": "false"}
+	if diff := cmp.Diff(selectorForRESTAPI(cluster), expected); diff != "" {
+		t.Errorf("Exp. only coordinating nodes to be selected: %s", diff)
+	}
+}
+
+func TestValidateSpecRequiresMasterAndDataNodes(t *testing.T) {
+	master := []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}
+	data := []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient, api.ElasticsearchRoleData}
+
+	tests := []struct {
+		desc  string
+		nodes []api.ElasticsearchNode
+		err   string
+	}{
+		{
+			desc:  "zero masters",
+			nodes: []api.ElasticsearchNode{{Roles: data, NodeCount: 3}},
+			err:   "no master nodes requested",
+		},
+		{
+			desc:  "master node group scaled to zero",
+			nodes: []api.ElasticsearchNode{{Roles: master, NodeCount: 0}, {Roles: data, NodeCount: 3}},
+			err:   "no master nodes requested",
+		},
+		{
+			desc:  "too many masters",
+			nodes: []api.ElasticsearchNode{{Roles: master, NodeCount: 5}, {Roles: data, NodeCount: 3}},
+			err:   "invalid master nodes count",
+		},
+		{
+			desc:  "zero data nodes",
+			nodes: []api.ElasticsearchNode{{Roles: master, NodeCount: 3}},
+			err:   "no data nodes requested",
+		},
+		{
+			desc:  "single master and data node",
+			nodes: []api.ElasticsearchNode{{Roles: append(data, api.ElasticsearchRoleMaster), NodeCount: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		cluster := &api.Elasticsearch{
+			Spec: api.ElasticsearchSpec{
+				Nodes:            test.nodes,
+				RedundancyPolicy: api.ZeroRedundancy,
+			},
+		}
+
+		err := ValidateSpec(cluster)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: expected the spec to be valid, got %v", test.desc, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected an error with %q, got %v", test.desc, test.err, err)
+		}
+	}
+}
+
+func TestInvalidMasterCountConditionMessage(t *testing.T) {
+	status := &api.ElasticsearchStatus{}
+	updateInvalidMasterCountCondition(0)(status, v1.ConditionTrue)
+	if len(status.Conditions) != 1 || !strings.HasPrefix(status.Conditions[0].Message, "No master nodes requested") {
+		t.Errorf("expected the condition to report the missing master nodes, got %v", status.Conditions)
+	}
+
+	updateInvalidMasterCountCondition(5)(status, v1.ConditionTrue)
+	if !strings.HasPrefix(status.Conditions[0].Message, "Invalid master nodes count") {
+		t.Errorf("expected the condition to report too many master nodes, got %v", status.Conditions)
+	}
+}
+
+func TestCoordinatingNodesRequireDataNodes(t *testing.T) {
+	cluster := &api.Elasticsearch{
+		Spec: api.ElasticsearchSpec{
+			Nodes: []api.ElasticsearchNode{
+				{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleMaster}, NodeCount: 3},
+				{Roles: []api.ElasticsearchNodeRole{api.ElasticsearchRoleClient}, NodeCount: 2},
+			},
+		},
+	}
+
+	if isValidDataCount(cluster) {
+		t.Error("Expected a cluster with coordinating nodes but no data nodes to be invalid")
+	}
+
+	cluster.Spec.Nodes = append(cluster.Spec.Nodes, api.ElasticsearchNode{
+		Roles:     []api.ElasticsearchNodeRole{api.ElasticsearchRoleData},
+		NodeCount: 1,
+	})
+	if !isValidDataCount(cluster) {
+		t.Error("Expected a cluster with coordinating and data nodes to be valid")
+	}
+}
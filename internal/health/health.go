@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// defaultCacheSyncTimeout bounds the wait of a readiness request for the caches,
+// well below the timeout of the readiness probe of the operator deployment
+const defaultCacheSyncTimeout = 500 * time.Millisecond
+
+// CacheSyncer is the part of the manager cache the readiness check waits on
+type CacheSyncer interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// CachesSynced returns a readiness check passing once the informer caches of
+// the manager are synced, so that the operator reconciles and validates with
+// the current state of the cluster. It does not wait for the leader election:
+// a new operator pod would never become ready while the old one holds the lease.
+func CachesSynced(cache CacheSyncer) healthz.Checker {
+	return cachesSynced(cache, defaultCacheSyncTimeout)
+}
+
+func cachesSynced(cache CacheSyncer, timeout time.Duration) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		if !cache.WaitForCacheSync(ctx) {
+			return kverrors.New("informer caches not synced")
+		}
+		return nil
+	}
+}
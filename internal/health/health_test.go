@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// fakeCache is synced once its channel is closed
+type fakeCache struct {
+	synced chan struct{}
+}
+
+func (c *fakeCache) WaitForCacheSync(ctx context.Context) bool {
+	select {
+	case <-c.synced:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func probe(handler http.Handler, path string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestHealthzHandlerIgnoresCacheSync(t *testing.T) {
+	handler := &healthz.Handler{Checks: map[string]healthz.Checker{"healthz": healthz.Ping}}
+
+	// the operator is alive while its caches are still syncing
+	if code := probe(handler, "/"); code != http.StatusOK {
+		t.Errorf("expected the operator to be reported alive, got status %d", code)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	cache := &fakeCache{synced: make(chan struct{})}
+	handler := &healthz.Handler{Checks: map[string]healthz.Checker{"readyz": cachesSynced(cache, 10*time.Millisecond)}}
+
+	// the manager serves the handler below /readyz
+	if code := probe(handler, "/"); code != http.StatusInternalServerError {
+		t.Errorf("expected the operator not to be ready before the caches are synced, got status %d", code)
+	}
+
+	close(cache.synced)
+	if code := probe(handler, "/"); code != http.StatusOK {
+		t.Errorf("expected the operator to be ready once the caches are synced, got status %d", code)
+	}
+	if code := probe(handler, "/readyz"); code != http.StatusOK {
+		t.Errorf("expected the single check to be served, got status %d", code)
+	}
+}
@@ -86,6 +86,33 @@ func MutateLabelsOnly(current, desired *corev1.PersistentVolumeClaim) {
 	current.Labels = desired.Labels
 }
 
+// LabelsAndAnnotationsEqual return only true if the pvcs are equal in labels and the
+// current persistentvolumeclaim has the desired annotations. Annotations added by
+// others, e.g. the volume controllers, are ignored.
+func LabelsAndAnnotationsEqual(current, desired *corev1.PersistentVolumeClaim) bool {
+	if !LabelsEqual(current, desired) {
+		return false
+	}
+	for key, value := range desired.Annotations {
+		if current.Annotations[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MutateLabelsAndAnnotations copies the labels from desired to current persistentvolumeclaim
+// and adds the desired annotations, keeping the annotations added by others.
+func MutateLabelsAndAnnotations(current, desired *corev1.PersistentVolumeClaim) {
+	current.Labels = desired.Labels
+	if len(desired.Annotations) > 0 && current.Annotations == nil {
+		current.Annotations = map[string]string{}
+	}
+	for key, value := range desired.Annotations {
+		current.Annotations[key] = value
+	}
+}
+
 // List returns a list of pods that match the given selector.
 func ListPVC(ctx context.Context, c client.Client, namespace string, selector map[string]string) ([]corev1.PersistentVolumeClaim, error) {
 	list := &corev1.PersistentVolumeClaimList{}
@@ -101,3 +128,21 @@ func ListPVC(ctx context.Context, c client.Client, namespace string, selector ma
 
 	return list.Items, nil
 }
+
+// DeletePVC attempts to delete a k8s persistentvolumeclaim if existing or returns an error.
+func DeletePVC(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	pvc := NewPVC(key.Name, key.Namespace, nil)
+
+	if err := c.Delete(ctx, pvc, &client.DeleteOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return kverrors.Wrap(err, "failed to delete persistentvolumeclaim",
+			"name", key.Name,
+			"namespace", key.Namespace,
+		)
+	}
+
+	return nil
+}
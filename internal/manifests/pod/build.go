@@ -43,12 +43,54 @@ func (b *Builder) WithTolerations(t ...corev1.Toleration) *Builder {
 	return b
 }
 
+// WithInitContainers appends init containers to the podspec
+func (b *Builder) WithInitContainers(c ...corev1.Container) *Builder {
+	b.spec.InitContainers = append(b.spec.InitContainers, c...)
+	return b
+}
+
+// WithImagePullSecrets sets the image pull secrets for the podspec
+func (b *Builder) WithImagePullSecrets(s ...corev1.LocalObjectReference) *Builder {
+	b.spec.ImagePullSecrets = s
+	return b
+}
+
 // WithAffinity sets the affinity rule for the podspec
 func (b *Builder) WithAffinity(a *corev1.Affinity) *Builder {
 	b.spec.Affinity = a
 	return b
 }
 
+// WithTopologySpreadConstraints sets the topology spread constraints for the podspec
+func (b *Builder) WithTopologySpreadConstraints(c ...corev1.TopologySpreadConstraint) *Builder {
+	b.spec.TopologySpreadConstraints = c
+	return b
+}
+
+// WithHostAliases sets the host aliases for the podspec
+func (b *Builder) WithHostAliases(a ...corev1.HostAlias) *Builder {
+	b.spec.HostAliases = a
+	return b
+}
+
+// WithDNSPolicy sets the DNS policy for the podspec
+func (b *Builder) WithDNSPolicy(p corev1.DNSPolicy) *Builder {
+	b.spec.DNSPolicy = p
+	return b
+}
+
+// WithDNSConfig sets the DNS parameters for the podspec
+func (b *Builder) WithDNSConfig(c *corev1.PodDNSConfig) *Builder {
+	b.spec.DNSConfig = c
+	return b
+}
+
+// WithPriorityClassName sets the priority class name of the podspec
+func (b *Builder) WithPriorityClassName(name string) *Builder {
+	b.spec.PriorityClassName = name
+	return b
+}
+
 // WithRestartPolicy sets the restart policy for the podspec
 func (b *Builder) WithRestartPolicy(rp corev1.RestartPolicy) *Builder {
 	b.spec.RestartPolicy = rp
@@ -62,6 +104,12 @@ func (b *Builder) WithTerminationGracePeriodSeconds(p time.Duration) *Builder {
 	return b
 }
 
+// WithReadinessGates appends the readiness gates to the podspec
+func (b *Builder) WithReadinessGates(g ...corev1.PodReadinessGate) *Builder {
+	b.spec.ReadinessGates = append(b.spec.ReadinessGates, g...)
+	return b
+}
+
 // WithSecurityContext sets the security context for the podspec
 func (b *Builder) WithSecurityContext(sc corev1.PodSecurityContext) *Builder {
 	b.spec.SecurityContext = &sc
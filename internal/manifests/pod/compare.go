@@ -9,8 +9,18 @@ import (
 )
 
 // ArePodTemplateSpecEqual compares two corev1.PodTemplateSpec objects
-// and returns true only if pod spec are equal and tolerations are strictly the same
+// and returns true only if pod spec are equal and tolerations are strictly the same.
+// The labels need to be the same, while the annotations of lhs only need to contain
+// the ones of rhs since tools like kubectl annotate pod templates, e.g. on rollout restart.
 func ArePodTemplateSpecEqual(lhs, rhs corev1.PodTemplateSpec) bool {
+	if !comparators.AreStringMapsSame(lhs.Labels, rhs.Labels) {
+		return false
+	}
+
+	if !comparators.ContainsStringMap(lhs.Annotations, rhs.Annotations) {
+		return false
+	}
+
 	return ArePodSpecEqual(lhs.Spec, rhs.Spec, true)
 }
 
@@ -18,9 +28,19 @@ func ArePodTemplateSpecEqual(lhs, rhs corev1.PodTemplateSpec) bool {
 // only if they are equal in any of the following:
 // - Length of containers slice
 // - Node selectors
+// - Affinity
+// - TopologySpreadConstraints
+// - HostAliases
+// - DNSPolicy and DNSConfig
 // - Tolerations, if strict they need to be the same, non-strict for superset check
-// - Containers: Name, Image, VolumeMounts, EnvVar, Args, Ports, ResourceRequirements
-func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
+// - SecurityContext of the pod and containers, only if strict since admission may amend them on pods
+// - ImagePullSecrets, if strict they need to be the same, non-strict for superset check
+// - PriorityClassName, only if strict since admission may set the default priority class on pods
+// - TerminationGracePeriodSeconds
+// - ReadinessGates
+// - InitContainers: Name, Image, Command, Args
+// - Containers: Name, Image, ImagePullPolicy, VolumeMounts, EnvVar, Command, Args, Ports, ResourceRequirements, ReadinessProbe, LivenessProbe, StartupProbe, Lifecycle
+func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strict bool) bool {
 	equal := true
 
 	if len(lhs.Containers) != len(rhs.Containers) {
@@ -32,10 +52,26 @@ func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
 		equal = false
 	}
 
-	// strictTolerations are for when we compare from the deployments or statefulsets
-	// if we are seeing if rolled out pods contain changes we don't want strictTolerations
+	if !reflect.DeepEqual(lhs.Affinity, rhs.Affinity) {
+		equal = false
+	}
+
+	if !reflect.DeepEqual(lhs.TopologySpreadConstraints, rhs.TopologySpreadConstraints) {
+		equal = false
+	}
+
+	if (len(lhs.HostAliases) != 0 || len(rhs.HostAliases) != 0) && !reflect.DeepEqual(lhs.HostAliases, rhs.HostAliases) {
+		equal = false
+	}
+
+	if dnsPolicy(lhs) != dnsPolicy(rhs) || !reflect.DeepEqual(lhs.DNSConfig, rhs.DNSConfig) {
+		equal = false
+	}
+
+	// strict is for when we compare from the deployments or statefulsets
+	// if we are seeing if rolled out pods contain changes we don't want strict
 	//   since k8s may add additional tolerations to pods
-	if strictTolerations {
+	if strict {
 		// check tolerations
 		if !comparators.AreTolerationsSame(lhs.Tolerations, rhs.Tolerations) {
 			equal = false
@@ -47,6 +83,38 @@ func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
 		}
 	}
 
+	// pods may additionally get the image pull secrets of their service account
+	if strict {
+		if !comparators.AreImagePullSecretsSame(lhs.ImagePullSecrets, rhs.ImagePullSecrets) {
+			equal = false
+		}
+	} else {
+		if !comparators.ContainsSameImagePullSecrets(lhs.ImagePullSecrets, rhs.ImagePullSecrets) {
+			equal = false
+		}
+	}
+
+	if strict && !reflect.DeepEqual(lhs.SecurityContext, rhs.SecurityContext) {
+		equal = false
+	}
+
+	// admission may set the default priority class on pods
+	if strict && lhs.PriorityClassName != rhs.PriorityClassName {
+		equal = false
+	}
+
+	if terminationGracePeriodSeconds(lhs) != terminationGracePeriodSeconds(rhs) {
+		equal = false
+	}
+
+	if (len(lhs.ReadinessGates) != 0 || len(rhs.ReadinessGates) != 0) && !reflect.DeepEqual(lhs.ReadinessGates, rhs.ReadinessGates) {
+		equal = false
+	}
+
+	if !areInitContainersEqual(lhs.InitContainers, rhs.InitContainers) {
+		equal = false
+	}
+
 	// check container fields
 	for _, lContainer := range lhs.Containers {
 		found := false
@@ -69,10 +137,18 @@ func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
 				equal = false
 			}
 
+			if lContainer.ImagePullPolicy != rContainer.ImagePullPolicy {
+				equal = false
+			}
+
 			if !comparators.EnvValueEqual(lContainer.Env, rContainer.Env) {
 				equal = false
 			}
 
+			if !reflect.DeepEqual(lContainer.Command, rContainer.Command) {
+				equal = false
+			}
+
 			if !reflect.DeepEqual(lContainer.Args, rContainer.Args) {
 				equal = false
 			}
@@ -84,6 +160,26 @@ func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
 			if !comparators.AreResourceRequementsSame(lContainer.Resources, rContainer.Resources) {
 				equal = false
 			}
+
+			if strict && !reflect.DeepEqual(lContainer.SecurityContext, rContainer.SecurityContext) {
+				equal = false
+			}
+
+			if !comparators.AreProbesSame(lContainer.ReadinessProbe, rContainer.ReadinessProbe) {
+				equal = false
+			}
+
+			if !comparators.AreProbesSame(lContainer.LivenessProbe, rContainer.LivenessProbe) {
+				equal = false
+			}
+
+			if !comparators.AreProbesSame(lContainer.StartupProbe, rContainer.StartupProbe) {
+				equal = false
+			}
+
+			if !reflect.DeepEqual(lContainer.Lifecycle, rContainer.Lifecycle) {
+				equal = false
+			}
 		}
 
 		if !found {
@@ -93,3 +189,36 @@ func ArePodSpecEqual(lhs, rhs corev1.PodSpec, strictTolerations bool) bool {
 
 	return equal
 }
+
+func areInitContainersEqual(lhs, rhs []corev1.Container) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for i := range lhs {
+		if lhs[i].Name != rhs[i].Name || lhs[i].Image != rhs[i].Image {
+			return false
+		}
+
+		if !reflect.DeepEqual(lhs[i].Command, rhs[i].Command) || !reflect.DeepEqual(lhs[i].Args, rhs[i].Args) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func terminationGracePeriodSeconds(spec corev1.PodSpec) int64 {
+	if spec.TerminationGracePeriodSeconds == nil {
+		return corev1.DefaultTerminationGracePeriodSeconds
+	}
+	return *spec.TerminationGracePeriodSeconds
+}
+
+// dnsPolicy returns the DNS policy of the spec with the server default
+func dnsPolicy(spec corev1.PodSpec) corev1.DNSPolicy {
+	if spec.DNSPolicy == "" {
+		return corev1.DNSClusterFirst
+	}
+	return spec.DNSPolicy
+}
@@ -7,6 +7,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestArePodTemplateSpecEqual(t *testing.T) {
@@ -507,6 +509,83 @@ func TestArePodTemplateSpecEqual(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			desc: "readiness probe change",
+			lhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{defaultContainer},
+				},
+			},
+			rhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						diffContainer(func(c *corev1.Container) {
+							c.ReadinessProbe = &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(9300)},
+								},
+							}
+						}),
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			desc: "liveness probe change",
+			lhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{defaultContainer},
+				},
+			},
+			rhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						diffContainer(func(c *corev1.Container) {
+							c.LivenessProbe = &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(9300)},
+								},
+							}
+						}),
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			desc: "image pull policy change",
+			lhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{defaultContainer},
+				},
+			},
+			rhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						diffContainer(func(c *corev1.Container) {
+							c.ImagePullPolicy = corev1.PullAlways
+						}),
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			desc: "image pull secrets change",
+			lhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{defaultContainer},
+				},
+			},
+			rhs: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers:       []corev1.Container{defaultContainer},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				},
+			},
+			want: false,
+		},
 	}
 	for _, test := range tests {
 		test := test
@@ -733,3 +812,110 @@ func TestPodSpecEqual_NonStrictTolerations(t *testing.T) {
 		})
 	}
 }
+
+func TestPodSpecEqual_NonStrictImagePullSecrets(t *testing.T) {
+	desired := corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+	current := corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}, {Name: "elasticsearch-dockercfg"}},
+	}
+
+	if !pod.ArePodSpecEqual(current, desired, false) {
+		t.Error("expected pod with additional service account pull secrets to match non-strict")
+	}
+	if pod.ArePodSpecEqual(current, desired, true) {
+		t.Error("expected pod with additional pull secrets not to match strict")
+	}
+}
+
+func TestPodSpecEqual_SecurityContextStrictOnly(t *testing.T) {
+	desired := corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{},
+	}
+	current := corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			SELinuxOptions: &corev1.SELinuxOptions{Level: "s0:c26,c5"},
+		},
+	}
+
+	if !pod.ArePodSpecEqual(current, desired, false) {
+		t.Error("expected pod with a security context amended by admission to match non-strict")
+	}
+	if pod.ArePodSpecEqual(current, desired, true) {
+		t.Error("expected different security contexts not to match strict")
+	}
+}
+
+func TestPodSpecEqual_PriorityClassNameStrictOnly(t *testing.T) {
+	desired := corev1.PodSpec{}
+	current := corev1.PodSpec{
+		PriorityClassName: "cluster-default",
+	}
+
+	if !pod.ArePodSpecEqual(current, desired, false) {
+		t.Error("expected pod with a default priority class set by admission to match non-strict")
+	}
+	if pod.ArePodSpecEqual(current, desired, true) {
+		t.Error("expected different priority classes not to match strict")
+	}
+}
+
+func TestPodSpecEqual_TerminationGracePeriodSeconds(t *testing.T) {
+	defaultGracePeriod := corev1.DefaultTerminationGracePeriodSeconds
+	customGracePeriod := int64(180)
+
+	if !pod.ArePodSpecEqual(corev1.PodSpec{}, corev1.PodSpec{TerminationGracePeriodSeconds: &defaultGracePeriod}, true) {
+		t.Error("expected an unset grace period to match the server default")
+	}
+	if pod.ArePodSpecEqual(corev1.PodSpec{TerminationGracePeriodSeconds: &defaultGracePeriod}, corev1.PodSpec{TerminationGracePeriodSeconds: &customGracePeriod}, false) {
+		t.Error("expected different grace periods not to match")
+	}
+}
+
+func TestPodSpecEqual_HostAliases(t *testing.T) {
+	aliases := []corev1.HostAlias{{IP: "10.0.0.10", Hostnames: []string{"s3.example.com"}}}
+
+	if !pod.ArePodSpecEqual(corev1.PodSpec{}, corev1.PodSpec{HostAliases: []corev1.HostAlias{}}, true) {
+		t.Error("expected empty host aliases to match unset ones")
+	}
+	if pod.ArePodSpecEqual(corev1.PodSpec{}, corev1.PodSpec{HostAliases: aliases}, false) {
+		t.Error("expected different host aliases not to match")
+	}
+}
+
+func TestPodSpecEqual_DNS(t *testing.T) {
+	if !pod.ArePodSpecEqual(corev1.PodSpec{}, corev1.PodSpec{DNSPolicy: corev1.DNSClusterFirst}, true) {
+		t.Error("expected an unset dns policy to match the server default")
+	}
+	if pod.ArePodSpecEqual(corev1.PodSpec{}, corev1.PodSpec{DNSConfig: &corev1.PodDNSConfig{Searches: []string{"example.com"}}}, false) {
+		t.Error("expected different dns configs not to match")
+	}
+}
+
+func TestArePodTemplateSpecEqual_Metadata(t *testing.T) {
+	desired := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"component": "elasticsearch", "team": "logging"},
+			Annotations: map[string]string{"prometheus.io/scrape": "true"},
+		},
+	}
+
+	current := *desired.DeepCopy()
+	current.Annotations["kubectl.kubernetes.io/restartedAt"] = "2021-01-01T00:00:00Z"
+	if !pod.ArePodTemplateSpecEqual(current, desired) {
+		t.Error("expected additional annotations on the current template to match")
+	}
+
+	current = *desired.DeepCopy()
+	current.Labels["team"] = "infra"
+	if pod.ArePodTemplateSpecEqual(current, desired) {
+		t.Error("expected different labels not to match")
+	}
+
+	current = *desired.DeepCopy()
+	delete(current.Annotations, "prometheus.io/scrape")
+	if pod.ArePodTemplateSpecEqual(current, desired) {
+		t.Error("expected missing annotations not to match")
+	}
+}
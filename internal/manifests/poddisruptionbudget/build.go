@@ -0,0 +1,57 @@
+package poddisruptionbudget
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Builder represents the type to build PodDisruptionBudget objects
+type Builder struct {
+	pdb *policyv1.PodDisruptionBudget
+}
+
+// New returns a new Builder for PodDisruptionBudget objects
+func New(name, namespace string, labels map[string]string) *Builder {
+	return &Builder{pdb: newPodDisruptionBudget(name, namespace, labels)}
+}
+
+func newPodDisruptionBudget(name, namespace string, labels map[string]string) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: policyv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{},
+	}
+}
+
+// Build returns the final PodDisruptionBudget object
+func (b *Builder) Build() *policyv1.PodDisruptionBudget { return b.pdb }
+
+// WithSelector sets the label selector of the pods the budget applies to
+func (b *Builder) WithSelector(s map[string]string) *Builder {
+	b.pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: s}
+	return b
+}
+
+// WithMinAvailable sets the minimum number of available pods and
+// clears the maximum number of unavailable pods
+func (b *Builder) WithMinAvailable(v intstr.IntOrString) *Builder {
+	b.pdb.Spec.MinAvailable = &v
+	b.pdb.Spec.MaxUnavailable = nil
+	return b
+}
+
+// WithMaxUnavailable sets the maximum number of unavailable pods and
+// clears the minimum number of available pods
+func (b *Builder) WithMaxUnavailable(v intstr.IntOrString) *Builder {
+	b.pdb.Spec.MaxUnavailable = &v
+	b.pdb.Spec.MinAvailable = nil
+	return b
+}
@@ -51,6 +51,24 @@ func (b *Builder) WithServicePorts(sp ...corev1.ServicePort) *Builder {
 	return b
 }
 
+// WithClusterIP sets the spec ClusterIP, use corev1.ClusterIPNone for headless services.
+func (b *Builder) WithClusterIP(ip string) *Builder {
+	b.svc.Spec.ClusterIP = ip
+	return b
+}
+
+// WithType sets the spec service type.
+func (b *Builder) WithType(t corev1.ServiceType) *Builder {
+	b.svc.Spec.Type = t
+	return b
+}
+
+// WithExternalTrafficPolicy sets the spec external traffic policy.
+func (b *Builder) WithExternalTrafficPolicy(p corev1.ServiceExternalTrafficPolicyType) *Builder {
+	b.svc.Spec.ExternalTrafficPolicy = p
+	return b
+}
+
 // WithPublishNotReady sets the spec PublishNotReadyAddresses flag.
 func (b *Builder) WithPublishNotReady(val bool) *Builder {
 	b.svc.Spec.PublishNotReadyAddresses = val
@@ -75,6 +75,23 @@ func CreateOrUpdate(ctx context.Context, c client.Client, svc *corev1.Service, e
 	return nil
 }
 
+// Delete attempts to delete a k8s service if existing or returns an error.
+func Delete(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	svc := New(key.Name, key.Namespace, nil).Build()
+
+	if err := c.Delete(ctx, svc, &client.DeleteOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return kverrors.Wrap(err, "failed to delete service",
+			"name", svc.Name,
+			"namespace", svc.Namespace,
+		)
+	}
+
+	return nil
+}
+
 // Equal return only true if the service are equal
 func Equal(current, desired *corev1.Service) bool {
 	return equality.Semantic.DeepEqual(current, desired)
@@ -85,7 +102,37 @@ func Equal(current, desired *corev1.Service) bool {
 func Mutate(current, desired *corev1.Service) {
 	current.Labels = desired.Labels
 	current.Annotations = desired.Annotations
-	current.Spec.Ports = desired.Spec.Ports
+	if desired.Spec.Type != "" {
+		current.Spec.Type = desired.Spec.Type
+	}
+	current.Spec.Ports = keepNodePorts(current, desired)
 	current.Spec.Selector = desired.Spec.Selector
 	current.Spec.PublishNotReadyAddresses = desired.Spec.PublishNotReadyAddresses
+	current.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+}
+
+// keepNodePorts returns the desired ports with the node ports allocated
+// for the current service, so that updates do not reallocate them. Node
+// ports are only kept if the current service is already mutated to a
+// service type allocating node ports.
+func keepNodePorts(current, desired *corev1.Service) []corev1.ServicePort {
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	copy(ports, desired.Spec.Ports)
+
+	if current.Spec.Type != corev1.ServiceTypeNodePort && current.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return ports
+	}
+
+	for i := range ports {
+		if ports[i].NodePort != 0 {
+			continue
+		}
+		for _, cp := range current.Spec.Ports {
+			if cp.Name == ports[i].Name && cp.Port == ports[i].Port {
+				ports[i].NodePort = cp.NodePort
+			}
+		}
+	}
+
+	return ports
 }
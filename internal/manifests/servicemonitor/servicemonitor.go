@@ -75,6 +75,23 @@ func CreateOrUpdate(ctx context.Context, c client.Client, sm *monitoringv1.Servi
 	return nil
 }
 
+// Delete attempts to delete a k8s servicemonitor if existing or returns an error.
+func Delete(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	sm := New(key.Name, key.Namespace, nil).Build()
+
+	if err := c.Delete(ctx, sm, &client.DeleteOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return kverrors.Wrap(err, "failed to delete servicemonitor",
+			"name", sm.Name,
+			"namespace", sm.Namespace,
+		)
+	}
+
+	return nil
+}
+
 // Equal return only true if the service monitors are equal
 func Equal(current, desired *monitoringv1.ServiceMonitor) bool {
 	return equality.Semantic.DeepEqual(current, desired)
@@ -53,3 +53,9 @@ func (b *Builder) WithTemplate(t corev1.PodTemplateSpec) *Builder {
 	b.sts.Spec.Template = t
 	return b
 }
+
+// WithVolumeClaimTemplates sets the statefulset spec volume claim templates
+func (b *Builder) WithVolumeClaimTemplates(t ...corev1.PersistentVolumeClaim) *Builder {
+	b.sts.Spec.VolumeClaimTemplates = t
+	return b
+}
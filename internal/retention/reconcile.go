@@ -0,0 +1,130 @@
+package retention
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apis "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
+	"github.com/openshift/elasticsearch-operator/internal/manifests/pod"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+)
+
+type RetentionRequest struct {
+	cluster  *apis.Elasticsearch
+	esClient esclient.Client
+	ll       logr.Logger
+	now      func() time.Time
+}
+
+// Reconcile deletes the indices exceeding the retention policies of the cluster
+func Reconcile(log logr.Logger, cluster *apis.Elasticsearch, reqClient client.Client) error {
+	if len(cluster.Spec.Retention) == 0 {
+		return nil
+	}
+
+	ll := log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace, "handler", "retention")
+
+	labels := map[string]string{
+		"cluster-name": cluster.Name,
+		"component":    "elasticsearch",
+	}
+	esPods, err := pod.List(context.TODO(), reqClient, cluster.Namespace, labels)
+	if err != nil {
+		return err
+	}
+
+	running := false
+	for _, pod := range esPods {
+		if pod.Status.Phase == corev1.PodRunning {
+			running = true
+			break
+		}
+	}
+	if !running {
+		return nil
+	}
+
+	rr := RetentionRequest{
+		cluster:  cluster,
+		esClient: esclient.NewClient(ll, cluster.Name, cluster.Namespace, reqClient),
+		ll:       ll,
+		now:      time.Now,
+	}
+
+	return rr.applyRetention()
+}
+
+func (rr *RetentionRequest) applyRetention() error {
+	for _, policy := range rr.cluster.Spec.Retention {
+		indices, err := rr.esClient.GetIndicesCreationDate(policy.IndexPattern)
+		if err != nil {
+			return kverrors.Wrap(err, "failed to list indices for retention",
+				"pattern", policy.IndexPattern,
+			)
+		}
+
+		expired, err := expiredIndices(indices, policy, rr.now())
+		if err != nil {
+			return err
+		}
+
+		for _, name := range expired {
+			if err := rr.esClient.DeleteIndex(name); err != nil {
+				return err
+			}
+			rr.ll.Info("Deleted index exceeding retention", "index", name, "pattern", policy.IndexPattern, "maxAgeDays", policy.MaxAgeDays)
+		}
+	}
+
+	return nil
+}
+
+// expiredIndices returns the sorted names of the indices matching the policy
+// pattern which were created more than MaxAgeDays before now. Hidden indices
+// starting with a dot only match patterns starting with a dot.
+func expiredIndices(indices estypes.CatIndicesResponses, policy apis.ElasticsearchRetentionPolicy, now time.Time) ([]string, error) {
+	maxAge := time.Duration(policy.MaxAgeDays) * 24 * time.Hour
+	hidden := strings.HasPrefix(policy.IndexPattern, ".")
+
+	expired := []string{}
+	for _, index := range indices {
+		if strings.HasPrefix(index.Index, ".") && !hidden {
+			continue
+		}
+
+		matched, err := path.Match(policy.IndexPattern, index.Index)
+		if err != nil {
+			return nil, kverrors.Wrap(err, "invalid retention index pattern",
+				"pattern", policy.IndexPattern,
+			)
+		}
+		if !matched {
+			continue
+		}
+
+		millis, err := strconv.ParseInt(index.CreationDate, 10, 64)
+		if err != nil {
+			return nil, kverrors.Wrap(err, "failed to parse index creation date",
+				"index", index.Index,
+				"creation_date", index.CreationDate,
+			)
+		}
+
+		if now.Sub(time.UnixMilli(millis)) > maxAge {
+			expired = append(expired, index.Index)
+		}
+	}
+
+	sort.Strings(expired)
+	return expired, nil
+}
@@ -0,0 +1,132 @@
+package retention
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apis "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+	"github.com/openshift/elasticsearch-operator/test/helpers"
+)
+
+// catIndices is a sample response of _cat/indices?format=json&h=index,creation.date
+const catIndices = `[
+  {"index":"app-000001","creation.date":"1648684800000"},
+  {"index":"app-000002","creation.date":"1649116800000"},
+  {"index":"app-000003","creation.date":"1649462400000"},
+  {"index":"infra-000001","creation.date":"1646092800000"},
+  {"index":".security","creation.date":"1646092800000"}
+]`
+
+func TestExpiredIndices(t *testing.T) {
+	indices := estypes.CatIndicesResponses{}
+	if err := json.Unmarshal([]byte(catIndices), &indices); err != nil {
+		t.Fatalf("failed to decode sample response: %s", err)
+	}
+	now := time.Date(2022, time.April, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc   string
+		policy apis.ElasticsearchRetentionPolicy
+		want   []string
+	}{
+		{
+			desc:   "older than a week",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: "app-*", MaxAgeDays: 7},
+			want:   []string{"app-000001"},
+		},
+		{
+			desc:   "older than a day",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: "app-*", MaxAgeDays: 1},
+			want:   []string{"app-000001", "app-000002"},
+		},
+		{
+			desc:   "exactly the maximum age is kept",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: "app-*", MaxAgeDays: 10},
+			want:   []string{},
+		},
+		{
+			desc:   "other pattern",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: "infra-*", MaxAgeDays: 30},
+			want:   []string{"infra-000001"},
+		},
+		{
+			desc:   "wildcard skips hidden indices",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: "*", MaxAgeDays: 30},
+			want:   []string{"infra-000001"},
+		},
+		{
+			desc:   "hidden pattern",
+			policy: apis.ElasticsearchRetentionPolicy{IndexPattern: ".sec*", MaxAgeDays: 30},
+			want:   []string{".security"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := expiredIndices(indices, test.policy, now)
+		if err != nil {
+			t.Fatalf("%s: failed with error: %s", test.desc, err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: unexpected expired indices (-want +got):\n%s", test.desc, diff)
+		}
+	}
+
+	invalid := estypes.CatIndicesResponses{{Index: "app-000004", CreationDate: "yesterday"}}
+	if _, err := expiredIndices(invalid, apis.ElasticsearchRetentionPolicy{IndexPattern: "app-*", MaxAgeDays: 1}, now); err == nil {
+		t.Error("Exp. an error for an invalid creation date")
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	cluster := &apis.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch", Namespace: "openshift-logging"},
+		Spec: apis.ElasticsearchSpec{
+			Retention: []apis.ElasticsearchRetentionPolicy{{IndexPattern: "app-*", MaxAgeDays: 1}},
+		},
+	}
+
+	listURI := "_cat/indices/app-*?format=json&h=index,creation.date"
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		listURI: {
+			{StatusCode: 200, Body: catIndices},
+			{StatusCode: 200, Body: `[{"index":"app-000003","creation.date":"1649462400000"}]`},
+		},
+		"app-000001": {{StatusCode: 200, Body: `{"acknowledged":true}`}},
+		"app-000002": {{StatusCode: 200, Body: `{"acknowledged":true}`}},
+	})
+	k8sClient := fake.NewFakeClient()
+	rr := RetentionRequest{
+		cluster:  cluster,
+		esClient: helpers.NewFakeElasticsearchClient(cluster.Name, cluster.Namespace, k8sClient, chatter),
+		ll:       logr.Discard(),
+		now:      func() time.Time { return time.Date(2022, time.April, 10, 0, 0, 0, 0, time.UTC) },
+	}
+
+	if err := rr.applyRetention(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	for _, name := range []string{"app-000001", "app-000002"} {
+		req, found := chatter.GetRequest(name)
+		if !found || req.Method != "DELETE" {
+			t.Errorf("Exp. expired index %q to be deleted", name)
+		}
+	}
+	if _, found := chatter.Requests["app-000003"]; found {
+		t.Error("Exp. the recent index app-000003 to be kept")
+	}
+
+	// Nothing left to delete
+	if err := rr.applyRetention(); err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if reqs := chatter.Requests["app-000001"]; len(reqs) != 0 {
+		t.Errorf("Exp. no further deletion but got %v", reqs)
+	}
+}
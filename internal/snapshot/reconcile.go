@@ -0,0 +1,290 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apis "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/elasticsearch/esclient"
+	estypes "github.com/openshift/elasticsearch-operator/internal/types/elasticsearch"
+)
+
+const (
+	// pollInterval is the time to wait before checking an unfinished snapshot again
+	pollInterval = 30 * time.Second
+
+	snapshotInProgress = "IN_PROGRESS"
+	snapshotNameFormat = "2006.01.02-15.04"
+
+	defaultRepositoryClient = "default"
+)
+
+type SnapshotRequest struct {
+	client   client.Client
+	snapshot *apis.ElasticsearchSnapshot
+	esClient esclient.Client
+	ll       logr.Logger
+	now      func() time.Time
+}
+
+// Reconcile registers the snapshot repository and takes the snapshots due.
+// It returns the time to wait for the next snapshot or for the last one to finish.
+func Reconcile(log logr.Logger, snapshot *apis.ElasticsearchSnapshot, reqClient client.Client) (time.Duration, error) {
+	ll := log.WithValues("snapshot", snapshot.Name, "cluster", snapshot.Spec.ClusterName, "namespace", snapshot.Namespace)
+
+	cluster := &apis.Elasticsearch{}
+	key := types.NamespacedName{Name: snapshot.Spec.ClusterName, Namespace: snapshot.Namespace}
+	if err := reqClient.Get(context.TODO(), key, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			ll.Info("Elasticsearch cluster not found, waiting for it to be created")
+			return pollInterval, nil
+		}
+		return 0, kverrors.Wrap(err, "failed to get elasticsearch cluster",
+			"cluster", key.Name,
+			"namespace", key.Namespace,
+		)
+	}
+
+	sr := SnapshotRequest{
+		client:   reqClient,
+		snapshot: snapshot,
+		esClient: esclient.NewClient(ll, cluster.Name, cluster.Namespace, reqClient),
+		ll:       ll,
+		now:      time.Now,
+	}
+
+	requeueAfter, err := sr.reconcileSnapshot()
+	if updateErr := sr.updateStatus(); updateErr != nil {
+		ll.Error(updateErr, "failed to update snapshot status")
+		if err == nil {
+			err = updateErr
+		}
+	}
+	return requeueAfter, err
+}
+
+func (sr *SnapshotRequest) reconcileSnapshot() (time.Duration, error) {
+	spec := sr.snapshot.Spec
+	status := &sr.snapshot.Status
+
+	sched, err := parseSchedule(spec.Schedule)
+	if err != nil {
+		updateCondition(status, apis.SnapshotFailed, corev1.ConditionTrue, "InvalidSchedule", err.Error())
+		return 0, err
+	}
+
+	if err := sr.ensureRepository(); err != nil {
+		updateCondition(status, apis.SnapshotRepositoryFailed, corev1.ConditionTrue, "RegistrationFailed", err.Error())
+		return 0, err
+	}
+	updateCondition(status, apis.SnapshotRepositoryFailed, corev1.ConditionFalse, "", "")
+
+	if err := sr.refreshLastSnapshot(); err != nil {
+		return 0, err
+	}
+
+	now := sr.now().UTC()
+	last := sr.snapshot.CreationTimestamp.Time
+	if status.LastScheduleTime != nil {
+		last = status.LastScheduleTime.Time
+	}
+
+	due := sched.next(last)
+	inProgress := status.LastSnapshot != nil && status.LastSnapshot.State == snapshotInProgress
+	if !spec.Suspend && !due.IsZero() && !now.Before(due) && !inProgress {
+		name := snapshotName(sr.snapshot.Name, now)
+		if err := sr.esClient.CreateSnapshot(spec.Repository.Name, name, newSnapshot(spec)); err != nil {
+			updateCondition(status, apis.SnapshotFailed, corev1.ConditionTrue, "SnapshotFailed", err.Error())
+			return 0, err
+		}
+		sr.ll.Info("Started snapshot", "name", name, "repository", spec.Repository.Name)
+
+		scheduled := metav1.NewTime(now)
+		status.LastScheduleTime = &scheduled
+		status.LastSnapshot = &apis.SnapshotResult{
+			Name:      name,
+			State:     snapshotInProgress,
+			StartTime: &scheduled,
+		}
+		updateCondition(status, apis.SnapshotFailed, corev1.ConditionFalse, "", "")
+		inProgress = true
+		last = now
+	}
+
+	next := sched.next(last)
+	if next.Before(now) {
+		// missed schedules are not caught up, only one snapshot is taken
+		next = sched.next(now)
+	}
+	if next.IsZero() {
+		status.NextScheduleTime = nil
+		return 0, nil
+	}
+	nextTime := metav1.NewTime(next)
+	status.NextScheduleTime = &nextTime
+
+	requeueAfter := next.Sub(now)
+	if inProgress && requeueAfter > pollInterval {
+		requeueAfter = pollInterval
+	}
+	return requeueAfter, nil
+}
+
+// ensureRepository registers the repository unless it is already registered with the same settings
+func (sr *SnapshotRequest) ensureRepository() error {
+	name := sr.snapshot.Spec.Repository.Name
+	desired := newRepository(sr.snapshot.Spec.Repository)
+
+	current, err := sr.esClient.GetSnapshotRepository(name)
+	if err != nil {
+		return err
+	}
+	if current != nil && reflect.DeepEqual(current, desired) {
+		return nil
+	}
+
+	sr.ll.Info("Registering snapshot repository", "repository", name, "type", desired.Type)
+	return sr.esClient.CreateSnapshotRepository(name, desired)
+}
+
+// refreshLastSnapshot records the state of the last snapshot until it finished
+func (sr *SnapshotRequest) refreshLastSnapshot() error {
+	status := &sr.snapshot.Status
+	if status.LastSnapshot == nil || status.LastSnapshot.State != snapshotInProgress {
+		return nil
+	}
+
+	info, err := sr.esClient.GetSnapshot(sr.snapshot.Spec.Repository.Name, status.LastSnapshot.Name)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		status.LastSnapshot.State = "FAILED"
+		status.LastSnapshot.Reason = "snapshot not found in the repository"
+		updateCondition(status, apis.SnapshotFailed, corev1.ConditionTrue, "SnapshotMissing", status.LastSnapshot.Reason)
+		return nil
+	}
+
+	status.LastSnapshot.State = info.State
+	status.LastSnapshot.Reason = info.Reason
+	if info.StartTimeInMillis > 0 {
+		start := metav1.NewTime(time.UnixMilli(info.StartTimeInMillis).UTC())
+		status.LastSnapshot.StartTime = &start
+	}
+	if info.EndTimeInMillis > 0 {
+		end := metav1.NewTime(time.UnixMilli(info.EndTimeInMillis).UTC())
+		status.LastSnapshot.EndTime = &end
+	}
+
+	switch info.State {
+	case "FAILED":
+		sr.ll.Info("Snapshot failed", "name", info.Snapshot, "reason", info.Reason)
+		updateCondition(status, apis.SnapshotFailed, corev1.ConditionTrue, "SnapshotFailed", info.Reason)
+	case "PARTIAL":
+		sr.ll.Info("Snapshot is missing shards", "name", info.Snapshot, "reason", info.Reason)
+		updateCondition(status, apis.SnapshotFailed, corev1.ConditionTrue, "SnapshotPartial", info.Reason)
+	case "SUCCESS":
+		sr.ll.Info("Snapshot finished", "name", info.Snapshot)
+	}
+	return nil
+}
+
+func (sr *SnapshotRequest) updateStatus() error {
+	current := &apis.ElasticsearchSnapshot{}
+	key := types.NamespacedName{Name: sr.snapshot.Name, Namespace: sr.snapshot.Namespace}
+	if err := sr.client.Get(context.TODO(), key, current); err != nil {
+		return kverrors.Wrap(err, "failed to get elasticsearch snapshot",
+			"snapshot", key.Name,
+			"namespace", key.Namespace,
+		)
+	}
+
+	if reflect.DeepEqual(current.Status, sr.snapshot.Status) {
+		return nil
+	}
+
+	current.Status = sr.snapshot.Status
+	if err := sr.client.Status().Update(context.TODO(), current); err != nil {
+		return kverrors.Wrap(err, "failed to update elasticsearch snapshot status",
+			"snapshot", key.Name,
+			"namespace", key.Namespace,
+		)
+	}
+	return nil
+}
+
+// newRepository returns the repository registration payload
+func newRepository(spec apis.SnapshotRepositorySpec) *estypes.SnapshotRepository {
+	client := spec.Client
+	if client == "" {
+		client = defaultRepositoryClient
+	}
+
+	settings := map[string]string{
+		"bucket": spec.Bucket,
+		"client": client,
+	}
+	if basePath := strings.Trim(spec.BasePath, "/"); basePath != "" {
+		settings["base_path"] = basePath
+	}
+
+	return &estypes.SnapshotRepository{
+		Type:     string(spec.Type),
+		Settings: settings,
+	}
+}
+
+// newSnapshot returns the snapshot creation payload
+func newSnapshot(spec apis.ElasticsearchSnapshotSpec) *estypes.Snapshot {
+	return &estypes.Snapshot{
+		Indices:            strings.Join(spec.Indices, ","),
+		IgnoreUnavailable:  true,
+		IncludeGlobalState: false,
+	}
+}
+
+func snapshotName(name string, t time.Time) string {
+	return fmt.Sprintf("%s-%s", name, t.UTC().Format(snapshotNameFormat))
+}
+
+// updateCondition sets a condition of the snapshot status, a false condition is removed
+func updateCondition(status *apis.ElasticsearchSnapshotStatus, conditionType apis.ClusterConditionType, value corev1.ConditionStatus, reason, message string) {
+	for i, condition := range status.Conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		if value == corev1.ConditionFalse {
+			status.Conditions = append(status.Conditions[:i], status.Conditions[i+1:]...)
+			return
+		}
+		if condition.Status != value {
+			status.Conditions[i].LastTransitionTime = metav1.Now()
+		}
+		status.Conditions[i].Status = value
+		status.Conditions[i].Reason = reason
+		status.Conditions[i].Message = message
+		return
+	}
+
+	if value == corev1.ConditionFalse {
+		return
+	}
+	status.Conditions = append(status.Conditions, apis.ClusterCondition{
+		Type:               conditionType,
+		Status:             value,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
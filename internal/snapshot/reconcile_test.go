@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apis "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/utils"
+	"github.com/openshift/elasticsearch-operator/test/helpers"
+)
+
+func TestNewRepository(t *testing.T) {
+	tests := []struct {
+		desc string
+		spec apis.SnapshotRepositorySpec
+		want string
+	}{
+		{
+			desc: "s3 with defaults",
+			spec: apis.SnapshotRepositorySpec{Name: "backups", Type: apis.SnapshotRepositoryS3, Bucket: "es-backups"},
+			want: `{"type":"s3","settings":{"bucket":"es-backups","client":"default"}}`,
+		},
+		{
+			desc: "gcs with base path and client",
+			spec: apis.SnapshotRepositorySpec{Name: "backups", Type: apis.SnapshotRepositoryGCS, Bucket: "es-backups", BasePath: "/logging/prod/", Client: "secondary"},
+			want: `{"type":"gcs","settings":{"base_path":"logging/prod","bucket":"es-backups","client":"secondary"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := utils.ToJSON(newRepository(test.spec))
+		if err != nil {
+			t.Fatalf("%s: failed with error: %s", test.desc, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: exp. payload %s but got %s", test.desc, test.want, got)
+		}
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	spec := apis.ElasticsearchSnapshotSpec{Indices: []string{"app-*", "infra-*"}}
+
+	got, err := utils.ToJSON(newSnapshot(spec))
+	if err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	want := `{"indices":"app-*,infra-*","ignore_unavailable":true,"include_global_state":false}`
+	if got != want {
+		t.Errorf("Exp. payload %s but got %s", want, got)
+	}
+}
+
+func TestReconcileSnapshot(t *testing.T) {
+	created := time.Date(2022, time.March, 30, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2022, time.March, 31, 2, 0, 30, 0, time.UTC)
+
+	cr := &apis.ElasticsearchSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nightly",
+			Namespace:         "openshift-logging",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: apis.ElasticsearchSnapshotSpec{
+			ClusterName: "elasticsearch",
+			Schedule:    "0 2 * * *",
+			Repository:  apis.SnapshotRepositorySpec{Name: "backups", Type: apis.SnapshotRepositoryS3, Bucket: "es-backups"},
+		},
+	}
+
+	chatter := helpers.NewFakeElasticsearchChatter(map[string]helpers.FakeElasticsearchResponses{
+		"_snapshot/backups": {
+			{StatusCode: 404, Body: `{"error":{"type":"repository_missing_exception"},"status":404}`},
+			{StatusCode: 200, Body: `{"acknowledged":true}`},
+		},
+		"_snapshot/backups/nightly-2022.03.31-02.00": {
+			{StatusCode: 200, Body: `{"accepted":true}`},
+			{StatusCode: 200, Body: `{"snapshots":[{"snapshot":"nightly-2022.03.31-02.00","state":"SUCCESS","start_time_in_millis":1648692030000,"end_time_in_millis":1648692090000}]}`},
+		},
+	})
+	k8sClient := fake.NewFakeClient()
+	sr := SnapshotRequest{
+		client:   k8sClient,
+		snapshot: cr,
+		esClient: helpers.NewFakeElasticsearchClient("elasticsearch", "openshift-logging", k8sClient, chatter),
+		ll:       logr.Discard(),
+		now:      func() time.Time { return now },
+	}
+
+	requeueAfter, err := sr.reconcileSnapshot()
+	if err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+
+	register := chatter.Requests["_snapshot/backups"]
+	if len(register) != 2 || register[1].Method != "PUT" {
+		t.Fatalf("Exp. the missing repository to be registered but got %v", register)
+	}
+	if want := `{"type":"s3","settings":{"bucket":"es-backups","client":"default"}}`; register[1].Body != want {
+		t.Errorf("Exp. repository payload %s but got %s", want, register[1].Body)
+	}
+
+	if req, found := chatter.GetRequest("_snapshot/backups/nightly-2022.03.31-02.00"); !found || req.Method != "PUT" {
+		t.Fatalf("Exp. the due snapshot to be started")
+	}
+	last := cr.Status.LastSnapshot
+	if last == nil || last.Name != "nightly-2022.03.31-02.00" || last.State != snapshotInProgress {
+		t.Errorf("Exp. the started snapshot to be recorded but got %v", last)
+	}
+	if requeueAfter != pollInterval {
+		t.Errorf("Exp. to poll the snapshot in progress after %s but got %s", pollInterval, requeueAfter)
+	}
+
+	// The next reconciliation records the result and waits for the next schedule
+	now = now.Add(time.Minute)
+	chatter.Responses["_snapshot/backups"] = helpers.FakeElasticsearchResponses{
+		{StatusCode: 200, Body: `{"backups":{"type":"s3","settings":{"bucket":"es-backups","client":"default"}}}`},
+	}
+	chatter.Requests["_snapshot/backups"] = nil
+
+	requeueAfter, err = sr.reconcileSnapshot()
+	if err != nil {
+		t.Fatalf("failed with error: %s", err)
+	}
+	if register := chatter.Requests["_snapshot/backups"]; len(register) != 1 {
+		t.Errorf("Exp. a registered repository not to be registered again but got %v", register)
+	}
+	if last := cr.Status.LastSnapshot; last.State != "SUCCESS" || last.EndTime == nil {
+		t.Errorf("Exp. the finished snapshot to be recorded but got %v", last)
+	}
+	wantNext := time.Date(2022, time.April, 1, 2, 0, 0, 0, time.UTC)
+	if next := cr.Status.NextScheduleTime; next == nil || !next.Time.Equal(wantNext) {
+		t.Errorf("Exp. the next snapshot at %s but got %v", wantNext, next)
+	}
+	if want := wantNext.Sub(now); requeueAfter != want {
+		t.Errorf("Exp. to requeue after %s but got %s", want, requeueAfter)
+	}
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+)
+
+// schedule is a standard five field cron expression: minute, hour,
+// day of month, month and day of week. Each field holds the bitset
+// of the values it matches.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+	// a field given as '*' matches any day, see dayMatches
+	domAny, dowAny bool
+}
+
+type fieldRange struct {
+	name     string
+	min, max int
+}
+
+var fieldRanges = []fieldRange{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	// 7 is sunday as well
+	{name: "day of week", min: 0, max: 7},
+}
+
+// maxScheduleSearch bounds the search for the next time, e.g. "0 0 30 2 *" never matches
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+func parseSchedule(spec string) (*schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(fieldRanges) {
+		return nil, kverrors.New("schedule must have five fields: minute, hour, day of month, month and day of week",
+			"schedule", spec)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseField(field, fieldRanges[i])
+		if err != nil {
+			return nil, kverrors.Wrap(err, "invalid schedule", "schedule", spec)
+		}
+		bits[i] = b
+	}
+
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseField parses a comma separated list of values, ranges ("a-b")
+// and wildcards, each with an optional step ("*/15", "1-10/2", "5/10")
+func parseField(field string, r fieldRange) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, kverrors.New("invalid step", "field", r.name, "value", part)
+			}
+			rangePart, step = part[:i], s
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = r.min, r.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, kverrors.New("invalid range", "field", r.name, "value", part)
+			}
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, kverrors.New("invalid range", "field", r.name, "value", part)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, kverrors.New("invalid value", "field", r.name, "value", part)
+			}
+			start, end = value, value
+			if step > 1 {
+				end = r.max
+			}
+		}
+
+		if start < r.min || end > r.max || start > end {
+			return 0, kverrors.New("value out of range", "field", r.name, "value", part, "min", r.min, "max", r.max)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time after t matching the schedule in UTC
+// or the zero time if there is none
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted
+// a day matching either of them is scheduled
+func (s *schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	valid := []string{"0 2 * * *", "*/15 * * * *", "0 0 1,15 * 1-5", "30 4 * * 7", "5/10 * * * *"}
+	for _, spec := range valid {
+		if _, err := parseSchedule(spec); err != nil {
+			t.Errorf("Exp. %q to be valid but got: %s", spec, err)
+		}
+	}
+
+	invalid := []string{"", "0 2 * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"}
+	for _, spec := range invalid {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("Exp. %q to be invalid", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2022, time.March, 30, 10, 17, 42, 0, time.UTC) // a wednesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "0 2 * * *", want: time.Date(2022, time.March, 31, 2, 0, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2022, time.March, 30, 10, 30, 0, 0, time.UTC)},
+		{spec: "17 10 * * *", want: time.Date(2022, time.March, 31, 10, 17, 0, 0, time.UTC)},
+		{spec: "0 0 1 * *", want: time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 3 * * 0", want: time.Date(2022, time.April, 3, 3, 0, 0, 0, time.UTC)},
+		{spec: "0 3 * * 7", want: time.Date(2022, time.April, 3, 3, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted
+		{spec: "0 0 15 * 5", want: time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, test := range tests {
+		sched, err := parseSchedule(test.spec)
+		if err != nil {
+			t.Fatalf("%q: failed with error: %s", test.spec, err)
+		}
+		if got := sched.next(from); !got.Equal(test.want) {
+			t.Errorf("%q: exp. next time %s but got %s", test.spec, test.want, got)
+		}
+	}
+}
@@ -165,6 +165,24 @@ type CatIndicesResponse struct {
 	DocsDeleted      string `json:"docs.deleted,omitempty"`
 	StoreSize        string `json:"store.size,omitempty"`
 	PrimaryStoreSize string `json:"pri.store.size,omitempty"`
+	CreationDate     string `json:"creation.date,omitempty"`
+}
+
+type CatNodesResponse struct {
+	Name        string `json:"name,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	NodeRole    string `json:"node.role,omitempty"`
+	Master      string `json:"master,omitempty"`
+	HeapPercent string `json:"heap.percent,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+type CatShardsResponse struct {
+	Index  string `json:"index,omitempty"`
+	Shard  string `json:"shard,omitempty"`
+	PriRep string `json:"prirep,omitempty"`
+	State  string `json:"state,omitempty"`
+	Node   string `json:"node,omitempty"`
 }
 
 type MasterNodeAndNodeStateResponse struct {
@@ -192,3 +210,26 @@ type StatsNode struct {
 	Versions []string       `json:"versions,omitempty"`
 	Count    map[string]int `json:"count,omitempty"`
 }
+
+type SnapshotRepository struct {
+	Type     string            `json:"type"`
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+type Snapshot struct {
+	Indices            string `json:"indices,omitempty"`
+	IgnoreUnavailable  bool   `json:"ignore_unavailable"`
+	IncludeGlobalState bool   `json:"include_global_state"`
+}
+
+type SnapshotsResponse struct {
+	Snapshots []SnapshotInfo `json:"snapshots,omitempty"`
+}
+
+type SnapshotInfo struct {
+	Snapshot          string `json:"snapshot,omitempty"`
+	State             string `json:"state,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	StartTimeInMillis int64  `json:"start_time_in_millis,omitempty"`
+	EndTimeInMillis   int64  `json:"end_time_in_millis,omitempty"`
+}
@@ -8,3 +8,13 @@ import (
 func AreStringMapsSame(lhs, rhs map[string]string) bool {
 	return reflect.DeepEqual(lhs, rhs)
 }
+
+// ContainsStringMap returns true if lhs holds all key/value pairs of rhs
+func ContainsStringMap(lhs, rhs map[string]string) bool {
+	for key, val := range rhs {
+		if lval, ok := lhs[key]; !ok || lval != val {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package comparators
+
+import (
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AreProbesSame compares two probes treating unset fields as the
+// values the API server defaults them to.
+func AreProbesSame(lhs, rhs *v1.Probe) bool {
+	if lhs == nil || rhs == nil {
+		return lhs == nil && rhs == nil
+	}
+
+	return reflect.DeepEqual(withProbeDefaults(lhs), withProbeDefaults(rhs))
+}
+
+func withProbeDefaults(probe *v1.Probe) *v1.Probe {
+	p := probe.DeepCopy()
+
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = 1
+	}
+	if p.PeriodSeconds == 0 {
+		p.PeriodSeconds = 10
+	}
+	if p.SuccessThreshold == 0 {
+		p.SuccessThreshold = 1
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = 3
+	}
+	if p.HTTPGet != nil && p.HTTPGet.Scheme == "" {
+		p.HTTPGet.Scheme = v1.URISchemeHTTP
+	}
+
+	return p
+}
@@ -0,0 +1,55 @@
+package comparators
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestAreProbesSameWithServerDefaults(t *testing.T) {
+	desired := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{Path: "/_cluster/health", Port: intstr.FromInt(9200)},
+		},
+	}
+	current := &v1.Probe{
+		TimeoutSeconds:   1,
+		PeriodSeconds:    10,
+		SuccessThreshold: 1,
+		FailureThreshold: 3,
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{Path: "/_cluster/health", Port: intstr.FromInt(9200), Scheme: v1.URISchemeHTTP},
+		},
+	}
+
+	if !AreProbesSame(current, desired) {
+		t.Errorf("AreProbesSame returned false for probes differing only in server defaults")
+	}
+}
+
+func TestAreProbesSameDifferentPort(t *testing.T) {
+	current := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(9300)},
+		},
+	}
+	desired := &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(9301)},
+		},
+	}
+
+	if AreProbesSame(current, desired) {
+		t.Errorf("AreProbesSame returned true for probes with different ports")
+	}
+}
+
+func TestAreProbesSameNil(t *testing.T) {
+	if !AreProbesSame(nil, nil) {
+		t.Errorf("AreProbesSame returned false for two nil probes")
+	}
+	if AreProbesSame(nil, &v1.Probe{}) {
+		t.Errorf("AreProbesSame returned true for a nil and a non-nil probe")
+	}
+}
@@ -0,0 +1,34 @@
+package comparators
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// AreImagePullSecretsSame compares two lists of image pull secrets for equality
+func AreImagePullSecretsSame(lhs, rhs []v1.LocalObjectReference) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	return ContainsSameImagePullSecrets(lhs, rhs)
+}
+
+// ContainsSameImagePullSecrets checks that the image pull secrets in rhs are all contained within lhs
+// this follows our other patterns of "current, desired"
+func ContainsSameImagePullSecrets(lhs, rhs []v1.LocalObjectReference) bool {
+	for _, rhsSecret := range rhs {
+		found := false
+		for _, lhsSecret := range lhs {
+			if lhsSecret.Name == rhsSecret.Name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,124 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ViaQ/logerr/v2/kverrors"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/elasticsearch"
+)
+
+// +kubebuilder:webhook:path=/mutate-logging-openshift-io-v1-elasticsearch,mutating=true,failurePolicy=fail,sideEffects=None,groups=logging.openshift.io,resources=elasticsearches,verbs=create;update,versions=v1,name=melasticsearch.logging.openshift.io,admissionReviewVersions=v1
+
+// ElasticsearchDefaulter writes the defaults computed during the reconciliation
+// into the Elasticsearch specs
+type ElasticsearchDefaulter struct {
+	Log logr.Logger
+}
+
+func (d *ElasticsearchDefaulter) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&loggingv1.Elasticsearch{}).
+		WithDefaulter(d).
+		Complete()
+}
+
+func (d *ElasticsearchDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	cluster, ok := obj.(*loggingv1.Elasticsearch)
+	if !ok {
+		return kverrors.New("unexpected object type, expected an Elasticsearch")
+	}
+
+	if cluster.Spec.ManagementState == loggingv1.ManagementStateUnmanaged {
+		return nil
+	}
+
+	previous, err := previousCluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.Log.V(1).Info("Setting elasticsearch defaults",
+		"cluster", cluster.Name,
+		"namespace", cluster.Namespace)
+	elasticsearch.SetDefaults(cluster, previous)
+	return nil
+}
+
+// previousCluster returns the Elasticsearch being updated by the admission
+// request, nil on creation
+func previousCluster(ctx context.Context) (*loggingv1.Elasticsearch, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || len(req.OldObject.Raw) == 0 {
+		return nil, nil
+	}
+
+	previous := &loggingv1.Elasticsearch{}
+	if err := json.Unmarshal(req.OldObject.Raw, previous); err != nil {
+		return nil, kverrors.Wrap(err, "failed to decode the previous elasticsearch")
+	}
+	return previous, nil
+}
+
+// +kubebuilder:webhook:path=/validate-logging-openshift-io-v1-elasticsearch,mutating=false,failurePolicy=fail,sideEffects=None,groups=logging.openshift.io,resources=elasticsearches,verbs=create;update,versions=v1,name=velasticsearch.logging.openshift.io,admissionReviewVersions=v1
+
+// ElasticsearchValidator rejects Elasticsearch specs the reconciliation would
+// report as invalid
+type ElasticsearchValidator struct {
+	Log logr.Logger
+}
+
+func (v *ElasticsearchValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&loggingv1.Elasticsearch{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *ElasticsearchValidator) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	return v.validate(obj)
+}
+
+func (v *ElasticsearchValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) error {
+	// Updates leaving the spec as stored, e.g. of the finalizer of the operator,
+	// are never held by a spec accepted before
+	oldCluster, oldOk := oldObj.(*loggingv1.Elasticsearch)
+	newCluster, newOk := newObj.(*loggingv1.Elasticsearch)
+	if oldOk && newOk && equality.Semantic.DeepEqual(oldCluster.Spec, newCluster.Spec) {
+		return nil
+	}
+	return v.validate(newObj)
+}
+
+func (v *ElasticsearchValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func (v *ElasticsearchValidator) validate(obj runtime.Object) error {
+	cluster, ok := obj.(*loggingv1.Elasticsearch)
+	if !ok {
+		return kverrors.New("unexpected object type, expected an Elasticsearch")
+	}
+
+	// The operator leaves unmanaged clusters alone, so does the webhook
+	if cluster.Spec.ManagementState == loggingv1.ManagementStateUnmanaged {
+		return nil
+	}
+
+	if err := elasticsearch.ValidateSpec(cluster); err != nil {
+		v.Log.V(1).Info("Rejecting invalid elasticsearch spec",
+			"cluster", cluster.Name,
+			"namespace", cluster.Namespace,
+			"error", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ViaQ/logerr/v2/log"
+	"github.com/google/go-cmp/cmp"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
+	"github.com/openshift/elasticsearch-operator/internal/elasticsearch"
+)
+
+func newCluster() *loggingv1.Elasticsearch {
+	return &loggingv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "elasticsearch",
+			Namespace: "openshift-logging",
+		},
+		Spec: loggingv1.ElasticsearchSpec{
+			ManagementState:  loggingv1.ManagementStateManaged,
+			RedundancyPolicy: loggingv1.SingleRedundancy,
+			Nodes: []loggingv1.ElasticsearchNode{
+				{
+					Roles:     []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleMaster, loggingv1.ElasticsearchRoleData},
+					NodeCount: 3,
+				},
+			},
+		},
+	}
+}
+
+func TestElasticsearchValidator(t *testing.T) {
+	two := int32(2)
+	three := int32(3)
+	heapSize := resource.MustParse("4Gi")
+
+	tests := []struct {
+		desc   string
+		mutate func(*loggingv1.Elasticsearch)
+		valid  bool
+	}{
+		{
+			desc:   "valid spec",
+			mutate: func(*loggingv1.Elasticsearch) {},
+			valid:  true,
+		},
+		{
+			desc: "too many master nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].NodeCount = 5
+			},
+		},
+		{
+			desc: "zero nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].NodeCount = 0
+			},
+		},
+		{
+			desc: "no data nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].Roles = []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleMaster}
+			},
+		},
+		{
+			desc: "no master nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].Roles = []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleClient, loggingv1.ElasticsearchRoleData}
+			},
+		},
+		{
+			desc: "single master and data node",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.RedundancyPolicy = loggingv1.ZeroRedundancy
+				es.Spec.Nodes[0].NodeCount = 1
+			},
+			valid: true,
+		},
+		{
+			desc: "redundancy with a single data node",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].NodeCount = 1
+			},
+		},
+		{
+			desc: "replicas less than data nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.ReplicasPerIndex = &two
+			},
+			valid: true,
+		},
+		{
+			desc: "replicas as many as data nodes",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.ReplicasPerIndex = &three
+			},
+		},
+		{
+			desc: "memory request above the limit",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].Resources = corev1.ResourceRequirements{
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+				}
+			},
+		},
+		{
+			desc: "heap size above the memory limit",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.Nodes[0].Resources = corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				}
+				es.Spec.Nodes[0].HeapSize = &heapSize
+			},
+		},
+		{
+			desc: "invalid spec of an unmanaged cluster",
+			mutate: func(es *loggingv1.Elasticsearch) {
+				es.Spec.ManagementState = loggingv1.ManagementStateUnmanaged
+				es.Spec.Nodes[0].NodeCount = 0
+			},
+			valid: true,
+		},
+	}
+
+	validator := &ElasticsearchValidator{Log: log.NewLogger("webhooks-testing")}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			cluster := newCluster()
+			test.mutate(cluster)
+
+			createErr := validator.ValidateCreate(context.TODO(), cluster)
+			updateErr := validator.ValidateUpdate(context.TODO(), newCluster(), cluster)
+			if test.valid && (createErr != nil || updateErr != nil) {
+				t.Errorf("expected the spec to be accepted, got create: %v, update: %v", createErr, updateErr)
+			}
+			if !test.valid && (createErr == nil || updateErr == nil) {
+				t.Errorf("expected the spec to be rejected on create and update")
+			}
+		})
+	}
+}
+
+func TestElasticsearchValidatorDelete(t *testing.T) {
+	validator := &ElasticsearchValidator{Log: log.NewLogger("webhooks-testing")}
+
+	cluster := newCluster()
+	cluster.Spec.Nodes[0].NodeCount = 0
+	if err := validator.ValidateDelete(context.TODO(), cluster); err != nil {
+		t.Errorf("expected delete to be allowed, got %v", err)
+	}
+}
+
+func TestElasticsearchValidatorMetadataUpdate(t *testing.T) {
+	validator := &ElasticsearchValidator{Log: log.NewLogger("webhooks-testing")}
+
+	stored := newCluster()
+	stored.Spec.Nodes[0].NodeCount = 0
+	cluster := stored.DeepCopy()
+	cluster.Finalizers = []string{"logging.openshift.io/elasticsearch-cleanup"}
+
+	if err := validator.ValidateUpdate(context.TODO(), stored, cluster); err != nil {
+		t.Errorf("expected an update of the metadata only to be allowed, got %v", err)
+	}
+}
+
+func TestElasticsearchDefaulter(t *testing.T) {
+	defaulter := &ElasticsearchDefaulter{Log: log.NewLogger("webhooks-testing")}
+
+	cluster := newCluster()
+	if err := defaulter.Default(context.TODO(), cluster); err != nil {
+		t.Fatalf("got err: %s", err)
+	}
+
+	want := newCluster()
+	elasticsearch.SetDefaults(want, nil)
+	if diff := cmp.Diff(want, cluster); diff != "" {
+		t.Errorf("unexpected defaults (-want +got):\n%s", diff)
+	}
+	if cluster.Spec.ShardsPerIndex == nil || int(*cluster.Spec.ShardsPerIndex) != elasticsearch.CalculatePrimaryCount(newCluster()) {
+		t.Errorf("expected the primary shards of the reconciliation, got %v", cluster.Spec.ShardsPerIndex)
+	}
+	if cluster.Spec.ReplicasPerIndex == nil || int(*cluster.Spec.ReplicasPerIndex) != elasticsearch.CalculateReplicaCount(newCluster()) {
+		t.Errorf("expected the replica shards of the reconciliation, got %v", cluster.Spec.ReplicasPerIndex)
+	}
+}
+
+func TestElasticsearchDefaulterUpdate(t *testing.T) {
+	defaulter := &ElasticsearchDefaulter{Log: log.NewLogger("webhooks-testing")}
+
+	previous := newCluster()
+	if err := defaulter.Default(context.TODO(), previous); err != nil {
+		t.Fatalf("got err: %s", err)
+	}
+	raw, err := json.Marshal(previous)
+	if err != nil {
+		t.Fatalf("got err: %s", err)
+	}
+	ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			OldObject: runtime.RawExtension{Raw: raw},
+		},
+	})
+
+	// Scaling the data nodes changes the computed shard counts
+	updated := previous.DeepCopy()
+	updated.Spec.Nodes[0].NodeCount = 2
+	updated.Spec.Nodes = append(updated.Spec.Nodes, loggingv1.ElasticsearchNode{
+		Roles:     []loggingv1.ElasticsearchNodeRole{loggingv1.ElasticsearchRoleData},
+		NodeCount: 3,
+	})
+	if err := defaulter.Default(ctx, updated); err != nil {
+		t.Fatalf("got err: %s", err)
+	}
+
+	if got := *updated.Spec.ShardsPerIndex; got != 5 {
+		t.Errorf("expected the primary shards to follow the data nodes, got %d", got)
+	}
+	if updated.Spec.Nodes[1].Resources.Limits.Memory().IsZero() {
+		t.Errorf("expected the resources of the new node to be defaulted")
+	}
+}
+
+func TestElasticsearchDefaulterUnmanaged(t *testing.T) {
+	defaulter := &ElasticsearchDefaulter{Log: log.NewLogger("webhooks-testing")}
+
+	cluster := newCluster()
+	cluster.Spec.ManagementState = loggingv1.ManagementStateUnmanaged
+	if err := defaulter.Default(context.TODO(), cluster); err != nil {
+		t.Fatalf("got err: %s", err)
+	}
+
+	want := newCluster()
+	want.Spec.ManagementState = loggingv1.ManagementStateUnmanaged
+	if diff := cmp.Diff(want, cluster); diff != "" {
+		t.Errorf("expected an unmanaged cluster to be left untouched (-want +got):\n%s", diff)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/pprof"
 	"os"
 	"runtime"
+	"time"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
@@ -26,7 +27,9 @@ import (
 
 	loggingv1 "github.com/openshift/elasticsearch-operator/apis/logging/v1"
 	controllers "github.com/openshift/elasticsearch-operator/controllers/logging"
+	"github.com/openshift/elasticsearch-operator/internal/health"
 	"github.com/openshift/elasticsearch-operator/internal/metrics"
+	"github.com/openshift/elasticsearch-operator/internal/webhooks"
 	"github.com/openshift/elasticsearch-operator/version"
 
 	"github.com/ViaQ/logerr/v2/log"
@@ -53,12 +56,22 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionID string
+	var leaderElectionNamespace string
 	var probeAddr string
+	var healthPollInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe end point binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "d471c3b1.openshift.io",
+		"The name of the lease held by the active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"The namespace of the lease held by the active controller manager. "+
+			"Defaults to the namespace the operator runs in.")
+	flag.DurationVar(&healthPollInterval, "health-poll-interval", 30*time.Second,
+		"How often the health of the Elasticsearch clusters is checked and reported in their status.")
 
 	flag.Parse()
 
@@ -83,14 +96,18 @@ func main() {
 
 	setupLog := logger.WithName("setup")
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		Namespace:              namespace,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "d471c3b1.openshift.io",
-		Logger:                 ll,
+		Scheme:                  scheme,
+		Namespace:               namespace,
+		MetricsBindAddress:      metricsAddr,
+		Port:                    9443,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+		// the operator exits once the manager stops, so the lease can be
+		// released right away for the next replica to take over
+		LeaderElectionReleaseOnCancel: true,
+		Logger:                        ll,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -98,11 +115,21 @@ func main() {
 	}
 
 	if err = (&controllers.ElasticsearchReconciler{
+		Client:             mgr.GetClient(),
+		Log:                logger.WithName("controllers").WithName("Elasticsearch"),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("elasticsearch-operator"),
+		HealthPollInterval: healthPollInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Elasticsearch")
+		os.Exit(1)
+	}
+	if err = (&controllers.ElasticsearchSnapshotReconciler{
 		Client: mgr.GetClient(),
-		Log:    logger.WithName("controllers").WithName("Elasticsearch"),
+		Log:    logger.WithName("controllers").WithName("ElasticsearchSnapshot"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Elasticsearch")
+		setupLog.Error(err, "unable to create controller", "controller", "ElasticsearchSnapshot")
 		os.Exit(1)
 	}
 	if err = (&controllers.KibanaReconciler{
@@ -121,13 +148,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Secret")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&webhooks.ElasticsearchDefaulter{
+			Log: logger.WithName("webhooks").WithName("Elasticsearch"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Elasticsearch")
+			os.Exit(1)
+		}
+		if err = (&webhooks.ElasticsearchValidator{
+			Log: logger.WithName("webhooks").WithName("Elasticsearch"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Elasticsearch")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", health.CachesSynced(mgr.GetCache())); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}